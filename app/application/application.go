@@ -1,17 +1,22 @@
 package application
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	managementuc "usp-management-device-api/business/management_uc"
+	"usp-management-device-api/business/models"
 	"usp-management-device-api/common/logging"
 	httpcontroller "usp-management-device-api/controller/http"
+	kafkacontroller "usp-management-device-api/controller/kafka"
 	"usp-management-device-api/infras/enviroments"
+	kafkapublisher "usp-management-device-api/infras/kafka_publisher"
 	miniostore "usp-management-device-api/infras/minio"
 	uspstore "usp-management-device-api/infras/sql_store"
+	webhookclient "usp-management-device-api/infras/webhook_client"
 	middleware "usp-management-device-api/middlewares"
 
 	"github.com/gin-gonic/gin"
@@ -54,18 +59,145 @@ func StartApplication(buildTime, version string) {
 		globalStore.GetSQLPort(),
 		globalStore.GetAppName())
 
+	uspStore := uspstore.NewStore(uspDB)
+	minioStore := miniostore.NewMinioStore(minioDB)
+
+	// background workers stop on ctx cancel before the db is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	shutdownHooks := []func(){}
+
+	// outbox rows are always written, they are only relayed when enabled;
+	// without the relay the publisher stays nil and no Kafka broker is needed
+	var eventPublisher interface {
+		PublishOutboxEvents(ctx context.Context, events []models.OutboxEvent) error
+	}
+	var outboxPublisher *kafkapublisher.KafkaPublisher
+	if globalStore.GetOutboxRelayEnable() {
+		outboxPublisher = kafkapublisher.NewKafkaPublisher(
+			kafkaConnect(
+				mustKafkaBrokers(globalStore.GetKafkaBrokers(), "OUTBOX_RELAY_ENABLE"),
+				globalStore.GetKafkaUser(),
+				globalStore.GetKafkaPass()),
+			globalStore.GetOutboxTopic())
+		eventPublisher = outboxPublisher
+	}
+
+	mu := managementuc.NewManagementUsecase(
+		uspStore,
+		minioStore,
+		eventPublisher,
+		webhookclient.NewWebhookClient(globalStore.GetWebhookTimeout()),
+		globalStore.GetUSPAutoRegisterDefaultModel(),
+		globalStore.GetDeviceParameterHistoryEnable())
+
+	if globalStore.GetOutboxRelayEnable() {
+		done := make(chan struct{})
+		go func() {
+			mu.RunOutboxRelay(ctx, globalStore.GetOutboxRelayInterval(), globalStore.GetOutboxRelayBatchSize())
+			close(done)
+		}()
+
+		shutdownHooks = append(shutdownHooks, func() {
+			<-done
+			outboxPublisher.Writer.Close()
+		})
+	}
+
+	if globalStore.GetWebhookDispatchEnable() {
+		done := make(chan struct{})
+		go func() {
+			mu.RunWebhookDispatcher(
+				ctx,
+				globalStore.GetWebhookDispatchInterval(),
+				globalStore.GetWebhookDispatchBatchSize(),
+				models.WebhookRetryPolicy{
+					MaxAttempts: globalStore.GetWebhookMaxAttempts(),
+					BaseDelay:   globalStore.GetWebhookRetryBaseDelay(),
+					MaxDelay:    globalStore.GetWebhookRetryMaxDelay(),
+					Timeout:     globalStore.GetWebhookTimeout(),
+					Concurrency: globalStore.GetWebhookDispatchConcurrency(),
+				})
+			close(done)
+		}()
+
+		shutdownHooks = append(shutdownHooks, func() {
+			<-done
+		})
+	}
+
+	if globalStore.GetDeviceParameterHistoryEnable() {
+		done := make(chan struct{})
+		go func() {
+			mu.RunParameterHistoryPruner(
+				ctx,
+				globalStore.GetDeviceParameterHistoryPruneInterval(),
+				globalStore.GetDeviceParameterHistoryRetention())
+			close(done)
+		}()
+
+		shutdownHooks = append(shutdownHooks, func() {
+			<-done
+		})
+	}
+
+	if globalStore.GetImportJobEnable() {
+		done := make(chan struct{})
+		go func() {
+			mu.RunImportJobWorkers(
+				ctx,
+				globalStore.GetImportJobWorkers(),
+				globalStore.GetImportJobInterval(),
+				globalStore.GetImportJobChunkSize())
+			close(done)
+		}()
+
+		shutdownHooks = append(shutdownHooks, func() {
+			<-done
+		})
+	}
+
+	if globalStore.GetUSPEventIngestEnable() {
+		eventReader := readerKafkaSetup(
+			mustKafkaBrokers(globalStore.GetKafkaBrokers(), "USP_EVENT_INGEST_ENABLE"),
+			globalStore.GetUSPEventGroupID(),
+			append(globalStore.GetUSPEventTopics(), globalStore.GetUSPGetRespTopics()...),
+			globalStore.GetKafkaUser(),
+			globalStore.GetKafkaPass())
+		deadLetterWriter := kafkaConnect(
+			globalStore.GetKafkaBrokers(),
+			globalStore.GetKafkaUser(),
+			globalStore.GetKafkaPass())
+
+		consumer := kafkacontroller.NewUSPEventConsumer(
+			mu,
+			eventReader,
+			deadLetterWriter,
+			globalStore.GetUSPEventDeadLetterTopic(),
+			globalStore.GetUSPGetRespTopics())
+		done := make(chan struct{})
+		go func() {
+			consumer.Run(ctx)
+			close(done)
+		}()
+
+		shutdownHooks = append(shutdownHooks, func() {
+			<-done
+			eventReader.Close()
+			deadLetterWriter.Close()
+		})
+	}
+
 	go func() {
 		<-c
+		cancel()
+		for _, hook := range shutdownHooks {
+			hook()
+		}
 		db, _ := uspDB.DB()
 		db.Close()
 		os.Exit(0)
 	}()
 
-	uspStore := uspstore.NewStore(uspDB)
-	minioStore := miniostore.NewMinioStore(minioDB)
-
-	mu := managementuc.NewManagementUsecase(uspStore, minioStore)
-
 	router := gin.Default()
 
 	if globalStore.GetAppDeployEnv() == "prod" {
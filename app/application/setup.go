@@ -56,6 +56,15 @@ func mustConnectionSQL(host, user, pass, database, port, applicationName string)
 	return db
 }
 
+// mustKafkaBrokers stops the start when a Kafka worker is enabled by flag without KAFKA_BROKERS,
+// kafka.NewWriter would panic later with a less helpful message
+func mustKafkaBrokers(brokers []string, flag string) []string {
+	if len(brokers) == 0 {
+		panic(fmt.Sprintf("KAFKA_BROKERS is required when %s is true", flag))
+	}
+	return brokers
+}
+
 func kafkaConnect(hosts []string, user, pass string) *kafka.Writer {
 	// kafka dialer setup
 	mechanism := plain.Mechanism{
@@ -68,7 +77,7 @@ func kafkaConnect(hosts []string, user, pass string) *kafka.Writer {
 
 	kafkaWriter := kafka.NewWriter(kafka.WriterConfig{
 		Brokers:      hosts,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     &kafka.Hash{},
 		BatchSize:    100,
 		BatchTimeout: 50,
 		RequiredAcks: int(kafka.RequireAll),
@@ -77,7 +86,9 @@ func kafkaConnect(hosts []string, user, pass string) *kafka.Writer {
 	},
 	)
 
-	kafkaWriter.Transport = sharedTransport
+	if user != "" && pass != "" {
+		kafkaWriter.Transport = sharedTransport
+	}
 
 	return kafkaWriter
 }
@@ -111,7 +122,7 @@ func redisConnectPassword(host, pass string, database int) *redis.Pool {
 func readerKafkaSetup(
 	bootstrapServers []string,
 	groupID string,
-	topic string,
+	topics []string,
 	username string,
 	password string,
 ) *kafka.Reader {
@@ -119,7 +130,7 @@ func readerKafkaSetup(
 		// Kafka configure
 		Brokers: bootstrapServers,
 		// Topic configure
-		Topic:                  topic,
+		GroupTopics:            topics,
 		GroupID:                groupID,
 		WatchPartitionChanges:  true,
 		PartitionWatchInterval: 5 * time.Second,
@@ -10,8 +10,17 @@ import (
 )
 
 type service struct {
-	store      iUSPStoreRepository
-	minioStore iUSPMinioRepository
+	store          iUSPStoreRepository
+	minioStore     iUSPMinioRepository
+	eventPublisher iEventPublisher
+	webhookSender  iWebhookSender
+
+	// autoRegisterDefaultModel is the model name used when an unknown endpoint
+	// does not report Device.DeviceInfo.ModelName in its Boot! event
+	autoRegisterDefaultModel string
+
+	// parameterHistoryEnable keeps every reported value in device_parameter_value_history
+	parameterHistoryEnable bool
 }
 
 type IManagementUsecase interface {
@@ -21,6 +30,16 @@ type IManagementUsecase interface {
 	IFirmwareUsecase
 	IGroupUsecase
 	IDeviceUsecase
+	IEventUsecase
+	IOutboxUsecase
+	IWebhookUsecase
+	IDriftUsecase
+	IParameterValueUsecase
+	IImportJobUsecase
+	IStatsUsecase
+	ISearchUsecase
+	ISavedViewUsecase
+	IExportUsecase
 }
 
 type IProfileUsecase interface {
@@ -63,19 +82,14 @@ type IProfileUsecase interface {
 		ctx context.Context,
 		file io.Reader,
 		updatedBy string,
-	) error
+		opts models.ImportOptions,
+	) (*models.ImportReport, error)
 
 	UpdateProfileWithParameterId(
 		ctx context.Context,
 		id string,
 		profileParameter *models.ProfileUpdate,
 	) error
-
-	ExportProfilesCSV(
-		ctx context.Context,
-		condition map[string]any,
-		oppts models.QueryOptions,
-	) ([]byte, error)
 }
 
 type IParameterUsecase interface {
@@ -111,13 +125,8 @@ type IParameterUsecase interface {
 		file io.Reader,
 		updatedBy string,
 		batchSize int,
-	) ([]string, error)
-
-	ExportParametersCSV(
-		ctx context.Context,
-		condition map[string]any,
-		oppts models.QueryOptions,
-	) ([]byte, error)
+		opts models.ImportOptions,
+	) (*models.ImportReport, error)
 
 	GetParameters(
 		ctx context.Context,
@@ -131,6 +140,14 @@ type IModelUsecase interface {
 		input *models.Model,
 	) (string, error)
 
+	// ImportModelsFromCSVFile imports a model CSV, the model export is read back as it is.
+	ImportModelsFromCSVFile(
+		ctx context.Context,
+		file io.Reader,
+		updatedBy string,
+		opts models.ImportOptions,
+	) (*models.ImportReport, error)
+
 	UpdateModelWithModelId(
 		ctx context.Context,
 		modelID string,
@@ -156,6 +173,7 @@ type IModelUsecase interface {
 	FindModelWithModelId(
 		ctx context.Context,
 		modelId string,
+		expand ...string,
 	) (*models.Model, error)
 }
 
@@ -167,6 +185,15 @@ type IFirmwareUsecase interface {
 		firmware *models.Firmware,
 	) (string, error)
 
+	// ImportFirmwaresFromCSVFile imports a firmware CSV into a model, the firmware export is read back as it is.
+	ImportFirmwaresFromCSVFile(
+		ctx context.Context,
+		file io.Reader,
+		modelId uuid.UUID,
+		updatedBy string,
+		opts models.ImportOptions,
+	) (*models.ImportReport, error)
+
 	UpdateFirmwareWithId(
 		ctx context.Context,
 		condition map[string]any,
@@ -196,6 +223,7 @@ type IFirmwareUsecase interface {
 	GetFirmwareWithId(
 		ctx context.Context,
 		condition map[string]any,
+		expand ...string,
 	) (*models.Firmware, error)
 
 	GetFirmwares(
@@ -211,6 +239,15 @@ type IGroupUsecase interface {
 		group *models.Group,
 	) (string, error)
 
+	// ImportGroupsFromCSVFile imports a group CSV into a model, the group export is read back as it is.
+	ImportGroupsFromCSVFile(
+		ctx context.Context,
+		file io.Reader,
+		modelId uuid.UUID,
+		updatedBy string,
+		opts models.ImportOptions,
+	) (*models.ImportReport, error)
+
 	UpdateGroupWithId(
 		ctx context.Context,
 		modelId string,
@@ -240,6 +277,7 @@ type IGroupUsecase interface {
 	GetGroupWithId(
 		ctx context.Context,
 		condition map[string]any,
+		expand ...string,
 	) (*models.Group, error)
 
 	TotalGroupsWithModelId(
@@ -287,11 +325,13 @@ type IDeviceUsecase interface {
 		batchSize int,
 		modelID uuid.UUID,
 		groupID uuid.UUID,
-	) ([]string, error)
+		opts models.ImportOptions,
+	) (*models.ImportReport, error)
 
 	GetDeviceWithId(
 		ctx context.Context,
 		condition map[string]any,
+		expand ...string,
 	) (*models.Device, error)
 
 	TotalDevicesWithGroupId(
@@ -299,15 +339,380 @@ type IDeviceUsecase interface {
 		modelId string,
 		groupId string,
 	) (int64, error)
+
+	// LookupDevices resolves devices across models from a serial number, endpoint id or MAC address.
+	LookupDevices(
+		ctx context.Context,
+		lookup models.DeviceLookup,
+	) ([]models.Device, error)
+
+	// CountDevicesByLifecycleState returns the number of devices of a model in every lifecycle state.
+	CountDevicesByLifecycleState(
+		ctx context.Context,
+		modelId string,
+	) ([]models.DeviceStateCount, error)
+
+	// ListDeviceLifecycleTransitions returns the lifecycle history of a device, newest first.
+	ListDeviceLifecycleTransitions(
+		ctx context.Context,
+		modelId string,
+		deviceId string,
+	) ([]models.DeviceLifecycleTransition, error)
+
+	// ReplaceDevice hands the site of a device over to new hardware in one transaction:
+	// group, description and device profile assignments move to the successor and
+	// the device is marked REPLACED with a link to it.
+	ReplaceDevice(
+		ctx context.Context,
+		modelId string,
+		deviceId string,
+		replacement *models.DeviceReplacement,
+	) (*models.DeviceReplacementResult, error)
+
+	// GetDeviceLineage returns every device that served the site of a device, oldest first.
+	GetDeviceLineage(
+		ctx context.Context,
+		modelId string,
+		deviceId string,
+	) ([]models.Device, error)
+
+	// ReassignDeviceModel moves a device to another model and reports the effective changes,
+	// nothing is written for a dry run.
+	ReassignDeviceModel(
+		ctx context.Context,
+		modelId string,
+		deviceId string,
+		reassignment *models.DeviceReassignment,
+	) (*models.DeviceReassignmentPlan, error)
+}
+
+type IEventUsecase interface {
+	// HandleUSPNotify applies a USP Notify message received from the controller.
+	HandleUSPNotify(
+		ctx context.Context,
+		event *models.USPNotifyEvent,
+	) error
+
+	// HandleUSPGetResp stores the values of a USP GetResp received from the controller.
+	HandleUSPGetResp(
+		ctx context.Context,
+		resp *models.USPGetResp,
+	) error
+}
+
+type IOutboxUsecase interface {
+	// RelayOutboxEvents publishes one batch of pending outbox events and returns how many were published.
+	RelayOutboxEvents(
+		ctx context.Context,
+		batchSize int,
+	) (int, error)
+
+	// RunOutboxRelay relays outbox events every interval until ctx is cancelled.
+	RunOutboxRelay(
+		ctx context.Context,
+		interval time.Duration,
+		batchSize int,
+	)
+}
+
+type IWebhookUsecase interface {
+	CreateWebhookSubscription(
+		ctx context.Context,
+		subscription *models.WebhookSubscription,
+	) (string, error)
+
+	UpdateWebhookSubscriptionWithId(
+		ctx context.Context,
+		id string,
+		subscription *models.WebhookSubscriptionUpdate,
+	) error
+
+	DeleteWebhookSubscriptionWithId(
+		ctx context.Context,
+		id string,
+		updatedBy string,
+	) error
+
+	GetWebhookSubscriptionWithId(
+		ctx context.Context,
+		id string,
+	) (*models.WebhookSubscription, error)
+
+	ListWebhookSubscriptions(
+		ctx context.Context,
+		condition map[string]any,
+		opts models.QueryOptions,
+	) ([]models.WebhookSubscription, error)
+
+	ListWebhookDeliveries(
+		ctx context.Context,
+		subscriptionId string,
+		condition map[string]any,
+		opts models.QueryOptions,
+	) ([]models.WebhookDelivery, error)
+
+	// DispatchWebhookDeliveries sends one batch of due deliveries and returns how many were attempted.
+	DispatchWebhookDeliveries(
+		ctx context.Context,
+		batchSize int,
+		policy models.WebhookRetryPolicy,
+	) (int, error)
+
+	// RunWebhookDispatcher dispatches due deliveries every interval until ctx is cancelled.
+	RunWebhookDispatcher(
+		ctx context.Context,
+		interval time.Duration,
+		batchSize int,
+		policy models.WebhookRetryPolicy,
+	)
+}
+
+type IDriftUsecase interface {
+	// AssignProfile applies a profile to the group or device set on assignment.
+	AssignProfile(
+		ctx context.Context,
+		modelId string,
+		assignment *models.ProfileAssignment,
+	) (string, error)
+
+	UnassignProfile(
+		ctx context.Context,
+		modelId string,
+		condition map[string]any,
+	) error
+
+	ListProfileAssignments(
+		ctx context.Context,
+		modelId string,
+		condition map[string]any,
+	) ([]models.ProfileAssignment, error)
+
+	// GetDeviceDriftReport compares the values reported by a device with its effective configuration.
+	GetDeviceDriftReport(
+		ctx context.Context,
+		modelId string,
+		deviceId string,
+	) (*models.DeviceDriftReport, error)
+
+	// GetGroupDriftReport returns one drift report per device of the group, paginated by device.
+	GetGroupDriftReport(
+		ctx context.Context,
+		modelId string,
+		groupId string,
+		driftedOnly bool,
+		opts models.QueryOptions,
+	) ([]models.DeviceDriftReport, error)
+
+	// GetModelDriftSummary aggregates drift per parameter path over every device of a model,
+	// the most drifted parameters first.
+	GetModelDriftSummary(
+		ctx context.Context,
+		modelId string,
+	) ([]models.ParameterDriftSummary, error)
+}
+
+type IParameterValueUsecase interface {
+	// IngestDeviceParameterValues stores the values of a GetResp posted for a device
+	// and returns how many values were stored.
+	IngestDeviceParameterValues(
+		ctx context.Context,
+		modelId string,
+		deviceId string,
+		resp *models.USPGetResp,
+	) (int, error)
+
+	// GetDeviceParameterValues returns the values of a device whose path starts with one of the prefixes,
+	// asOf reads the values from the history as they were at that time.
+	GetDeviceParameterValues(
+		ctx context.Context,
+		modelId string,
+		deviceId string,
+		prefixes []string,
+		asOf *time.Time,
+		opts models.QueryOptions,
+	) ([]models.DeviceParameterValue, error)
+
+	// RunParameterHistoryPruner deletes history older than retention every interval until ctx is cancelled.
+	RunParameterHistoryPruner(
+		ctx context.Context,
+		interval time.Duration,
+		retention time.Duration,
+	)
+}
+
+type IImportJobUsecase interface {
+	// SubmitImportJob stores the file in object storage and queues the job, size is the file size in bytes.
+	SubmitImportJob(
+		ctx context.Context,
+		job *models.ImportJob,
+		file io.Reader,
+		size int64,
+	) (*models.ImportJob, error)
+
+	// GetImportJob returns the job with its first rejected rows.
+	GetImportJob(
+		ctx context.Context,
+		id string,
+	) (*models.ImportJob, error)
+
+	// CancelImportJob stops a queued or running job, the rows already committed are kept.
+	CancelImportJob(
+		ctx context.Context,
+		id string,
+		updatedBy string,
+	) (*models.ImportJob, error)
+
+	// RunImportJobWorkers processes queued jobs with `workers` concurrent workers until ctx is cancelled.
+	RunImportJobWorkers(
+		ctx context.Context,
+		workers int,
+		interval time.Duration,
+		chunkSize int,
+	)
+}
+
+type IStatsUsecase interface {
+	// GetStats counts the rows of a resource matching filter grouped by the groupBy dimensions.
+	GetStats(
+		ctx context.Context,
+		resource string,
+		groupBy []string,
+		filter *models.FilterExpr,
+	) (*models.StatsResult, error)
+}
+
+type ISearchUsecase interface {
+	// Search looks for text in devices, models, groups, firmwares, profiles and parameters,
+	// types restricts the kinds of hit, the best matches first.
+	Search(
+		ctx context.Context,
+		text string,
+		types []string,
+		limit int,
+	) ([]models.SearchHit, error)
+}
+
+// IExportUsecase streams the rows of a list matching condition, filter and order to w,
+// the times are written in the timezone of export.
+type IExportUsecase interface {
+	ExportProfiles(
+		ctx context.Context,
+		w io.Writer,
+		export models.ExportOptions,
+		condition map[string]any,
+		oppts models.QueryOptions,
+	) error
+
+	ExportParameters(
+		ctx context.Context,
+		w io.Writer,
+		export models.ExportOptions,
+		condition map[string]any,
+		oppts models.QueryOptions,
+	) error
+
+	ExportModels(
+		ctx context.Context,
+		w io.Writer,
+		export models.ExportOptions,
+		condition map[string]any,
+		oppts models.QueryOptions,
+	) error
+
+	ExportFirmwares(
+		ctx context.Context,
+		w io.Writer,
+		export models.ExportOptions,
+		condition map[string]any,
+		oppts models.QueryOptions,
+	) error
+
+	ExportGroups(
+		ctx context.Context,
+		w io.Writer,
+		export models.ExportOptions,
+		condition map[string]any,
+		oppts models.QueryOptions,
+	) error
+
+	ExportDevices(
+		ctx context.Context,
+		w io.Writer,
+		export models.ExportOptions,
+		condition map[string]any,
+		oppts models.QueryOptions,
+	) error
+}
+
+// The teams of the saved view methods are the consumer groups of the caller, a view shared with
+// a team is visible to its members.
+type ISavedViewUsecase interface {
+	// CreateSavedView saves a view of its owner, its team must be one of teams.
+	CreateSavedView(
+		ctx context.Context,
+		view *models.SavedView,
+		teams []string,
+	) (string, error)
+
+	// UpdateSavedViewWithId changes a view of user, only the owner can change it.
+	UpdateSavedViewWithId(
+		ctx context.Context,
+		id string,
+		user string,
+		teams []string,
+		view *models.SavedViewUpdate,
+	) error
+
+	DeleteSavedViewWithId(
+		ctx context.Context,
+		id string,
+		user string,
+		teams []string,
+	) error
+
+	// GetSavedViewWithId returns a view owned by user, shared with one of teams or with everyone.
+	GetSavedViewWithId(
+		ctx context.Context,
+		id string,
+		user string,
+		teams []string,
+	) (*models.SavedView, error)
+
+	// ListSavedViews returns the views of a resource owned by user, shared with one of teams or
+	// with everyone.
+	ListSavedViews(
+		ctx context.Context,
+		resource string,
+		user string,
+		teams []string,
+	) ([]models.SavedView, error)
+
+	// LoadSavedView returns a view to run on the list of resource, its filter, order and fields
+	// are checked again as the list may have changed since it was saved.
+	LoadSavedView(
+		ctx context.Context,
+		id string,
+		user string,
+		teams []string,
+		resource string,
+	) (*models.SavedView, error)
 }
 
 func NewManagementUsecase(
 	store iUSPStoreRepository,
 	minioStore iUSPMinioRepository,
+	eventPublisher iEventPublisher,
+	webhookSender iWebhookSender,
+	autoRegisterDefaultModel string,
+	parameterHistoryEnable bool,
 ) IManagementUsecase {
 	return &service{
-		store:      store,
-		minioStore: minioStore,
+		store:                    store,
+		minioStore:               minioStore,
+		eventPublisher:           eventPublisher,
+		webhookSender:            webhookSender,
+		autoRegisterDefaultModel: autoRegisterDefaultModel,
+		parameterHistoryEnable:   parameterHistoryEnable,
 	}
 }
 
@@ -318,6 +723,14 @@ type iUSPMinioRepository interface {
 	MoveFileBetweenBuckets(srcBucket, srcPath, dstBucket, dstPath string) error
 }
 
+type iEventPublisher interface {
+	PublishOutboxEvents(ctx context.Context, events []models.OutboxEvent) error
+}
+
+type iWebhookSender interface {
+	Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
+}
+
 type iUSPStoreRepository interface {
 	// BeginTx starts a transaction and stores it in the context
 	BeginTx(ctx context.Context) (context.Context, error)
@@ -344,6 +757,41 @@ type iUSPStoreRepository interface {
 		moreKeys ...string,
 	) (*models.Group, error)
 
+	// InsertDeviceLifecycleTransition records a lifecycle_state change, it must run in the transaction of the change.
+	InsertDeviceLifecycleTransition(
+		ctx context.Context,
+		transition *models.DeviceLifecycleTransition,
+	) error
+
+	ListDeviceLifecycleTransitions(
+		ctx context.Context,
+		condition map[string]any,
+	) ([]models.DeviceLifecycleTransition, error)
+
+	CountDeviceByLifecycleState(
+		ctx context.Context,
+		modelId string,
+	) ([]models.DeviceStateCount, error)
+
+	// SearchEntities matches the text of every target, deleted rows are left out.
+	SearchEntities(
+		ctx context.Context,
+		query models.SearchQuery,
+	) ([]models.SearchHit, error)
+
+	// AggregateStats counts the rows of the query grouped by its columns, the largest counts first.
+	// total counts every matching row, including the buckets past the limit.
+	AggregateStats(
+		ctx context.Context,
+		query models.StatsQuery,
+	) (buckets []models.StatsBucket, total int64, err error)
+
+	// LookupDevices resolves non-deleted devices by serial number, endpoint id or MAC address.
+	LookupDevices(
+		ctx context.Context,
+		lookup models.DeviceLookup,
+	) ([]models.Device, error)
+
 	// FindModel retrieves a model by condition and optionally preloads related model entity.
 	// If record not found, returns an error indicating the entity does not exist.
 	FindModel(
@@ -452,6 +900,13 @@ type iUSPStoreRepository interface {
 		device *models.DeviceUpdate,
 	) error
 
+	// UpdateDeviceModel moves a device to another model, group_id is written even when nil.
+	UpdateDeviceModel(
+		ctx context.Context,
+		id string,
+		device *models.DeviceModelUpdate,
+	) error
+
 	// UpdateGroup updates an existing group in the database.
 	UpdateGroup(
 		ctx context.Context,
@@ -575,10 +1030,12 @@ type iUSPStoreRepository interface {
 		ctx context.Context,
 	) (int64, error)
 
+	// ListModels lists the rows matching condition and the query options, moreKeys are the relations to preload.
 	ListModels(
 		ctx context.Context,
 		condition map[string]any,
 		oppts models.QueryOptions,
+		moreKeys ...string,
 	) ([]models.Model, error)
 
 	ChangeStatusModelsToDelete(
@@ -591,10 +1048,12 @@ type iUSPStoreRepository interface {
 		ctx context.Context,
 	) (int64, error)
 
+	// ListFirmware lists the rows matching condition and the query options, moreKeys are the relations to preload.
 	ListFirmware(
 		ctx context.Context,
 		condition map[string]any,
 		opts models.QueryOptions,
+		moreKeys ...string,
 	) ([]models.Firmware, error)
 
 	CountFirmwareByStatus(
@@ -608,10 +1067,12 @@ type iUSPStoreRepository interface {
 		updatedBy string,
 	) error
 
+	// ListGroups lists the rows matching condition and the query options, moreKeys are the relations to preload.
 	ListGroups(
 		ctx context.Context,
 		condition map[string]any,
 		opts models.QueryOptions,
+		moreKeys ...string,
 	) ([]models.Group, error)
 
 	ChangeStatusGroupToDelete(
@@ -625,10 +1086,12 @@ type iUSPStoreRepository interface {
 		modelId string,
 	) (int64, error)
 
+	// ListDevices lists the rows matching condition and the query options, moreKeys are the relations to preload.
 	ListDevices(
 		ctx context.Context,
 		condition map[string]any,
 		opts models.QueryOptions,
+		moreKeys ...string,
 	) ([]models.Device, error)
 
 	CountDeviceByStatus(
@@ -657,6 +1120,263 @@ type iUSPStoreRepository interface {
 		profiles []*models.Profile,
 	) error
 
+	// InsertDeviceFirmwareTransfer records a firmware transfer reported by a device.
+	InsertDeviceFirmwareTransfer(
+		ctx context.Context,
+		transfer *models.DeviceFirmwareTransfer,
+	) error
+
+	// InsertOutboxEvent stores a domain event, it must run in the transaction of the change it describes.
+	InsertOutboxEvent(
+		ctx context.Context,
+		event *models.OutboxEvent,
+	) error
+
+	// NextOutboxAggregateVersion returns the version of the next event of an aggregate.
+	NextOutboxAggregateVersion(
+		ctx context.Context,
+		aggregateId uuid.UUID,
+	) (int64, error)
+
+	// ListPendingOutboxEvents returns the oldest pending outbox events by seq, locked for update,
+	// at most one per aggregate: its lowest pending version.
+	ListPendingOutboxEvents(
+		ctx context.Context,
+		limit int,
+	) ([]models.OutboxEvent, error)
+
+	// MarkOutboxEventsPublished marks outbox events as delivered to the broker.
+	MarkOutboxEventsPublished(
+		ctx context.Context,
+		ids []string,
+	) error
+
+	// MarkOutboxEventsFailed records a failed publish attempt, the events stay pending.
+	MarkOutboxEventsFailed(
+		ctx context.Context,
+		ids []string,
+		reason string,
+	) error
+
+	// InsertWebhookSubscription inserts a new webhook subscription into the database.
+	InsertWebhookSubscription(
+		ctx context.Context,
+		subscription *models.WebhookSubscription,
+	) error
+
+	// FindWebhookSubscription retrieves a webhook subscription by condition.
+	// If record not found, returns an error indicating the entity does not exist.
+	FindWebhookSubscription(
+		ctx context.Context,
+		condition map[string]any,
+	) (*models.WebhookSubscription, error)
+
+	ListWebhookSubscriptions(
+		ctx context.Context,
+		condition map[string]any,
+		opts models.QueryOptions,
+	) ([]models.WebhookSubscription, error)
+
+	// ListWebhookSubscriptionsForEvent returns the enabled subscriptions whose filter matches eventType.
+	ListWebhookSubscriptionsForEvent(
+		ctx context.Context,
+		eventType string,
+	) ([]models.WebhookSubscription, error)
+
+	// UpdateWebhookSubscription updates an existing webhook subscription in the database.
+	UpdateWebhookSubscription(
+		ctx context.Context,
+		id string,
+		subscription *models.WebhookSubscriptionUpdate,
+	) error
+
+	ChangeStatusWebhookSubscriptionToDelete(
+		ctx context.Context,
+		id string,
+		updatedBy string,
+	) error
+
+	// StreamProfiles reads the profiles of an export from a cursor, the parameters of every batch
+	// are loaded with one query.
+	StreamProfiles(
+		ctx context.Context,
+		condition map[string]any,
+		oppts models.QueryOptions,
+		fn func([]models.Profile) error,
+	) error
+
+	// StreamParameters reads the parameters of an export from a cursor.
+	StreamParameters(
+		ctx context.Context,
+		condition map[string]any,
+		oppts models.QueryOptions,
+		fn func([]models.Parameter) error,
+	) error
+
+	// StreamModels reads the models of an export from a cursor.
+	StreamModels(
+		ctx context.Context,
+		condition map[string]any,
+		oppts models.QueryOptions,
+		fn func([]models.Model) error,
+	) error
+
+	// StreamFirmwares reads the firmwares of an export from a cursor.
+	StreamFirmwares(
+		ctx context.Context,
+		condition map[string]any,
+		oppts models.QueryOptions,
+		fn func([]models.Firmware) error,
+	) error
+
+	// StreamGroups reads the groups of an export from a cursor.
+	StreamGroups(
+		ctx context.Context,
+		condition map[string]any,
+		oppts models.QueryOptions,
+		fn func([]models.Group) error,
+	) error
+
+	// StreamDevices reads the devices of an export from a cursor.
+	StreamDevices(
+		ctx context.Context,
+		condition map[string]any,
+		oppts models.QueryOptions,
+		fn func([]models.Device) error,
+	) error
+
+	// InsertSavedView inserts a new saved view into the database.
+	InsertSavedView(
+		ctx context.Context,
+		view *models.SavedView,
+	) error
+
+	// FindSavedView retrieves a saved view by condition.
+	// If record not found, returns an error indicating the entity does not exist.
+	FindSavedView(
+		ctx context.Context,
+		condition map[string]any,
+	) (*models.SavedView, error)
+
+	// ListSavedViews returns the views of a resource owned by user, shared with one of teams or
+	// with everyone, by name.
+	ListSavedViews(
+		ctx context.Context,
+		resource string,
+		user string,
+		teams []string,
+	) ([]models.SavedView, error)
+
+	// UpdateSavedView updates an existing saved view in the database.
+	UpdateSavedView(
+		ctx context.Context,
+		id string,
+		view *models.SavedViewUpdate,
+	) error
+
+	ChangeStatusSavedViewToDelete(
+		ctx context.Context,
+		id string,
+		updatedBy string,
+	) error
+
+	// InsertWebhookDelivery queues a webhook delivery, it must run in the transaction of the change it describes.
+	InsertWebhookDelivery(
+		ctx context.Context,
+		delivery *models.WebhookDelivery,
+	) error
+
+	ListWebhookDeliveries(
+		ctx context.Context,
+		condition map[string]any,
+		opts models.QueryOptions,
+	) ([]models.WebhookDelivery, error)
+
+	// ListDueWebhookDeliveries returns pending deliveries whose next attempt is due, locked for update.
+	ListDueWebhookDeliveries(
+		ctx context.Context,
+		limit int,
+	) ([]models.WebhookDelivery, error)
+
+	// PostponeWebhookDeliveries moves next_attempt_at of the deliveries.
+	PostponeWebhookDeliveries(
+		ctx context.Context,
+		ids []string,
+		nextAttemptAt time.Time,
+	) error
+
+	// UpdateWebhookDelivery records the outcome of a delivery attempt while the delivery is
+	// still leased until leasedUntil, it returns false when the lease was lost.
+	UpdateWebhookDelivery(
+		ctx context.Context,
+		id string,
+		leasedUntil time.Time,
+		delivery *models.WebhookDeliveryUpdate,
+	) (bool, error)
+
+	// UpsertDeviceParameterValues stores the last reported value per device and path.
+	UpsertDeviceParameterValues(
+		ctx context.Context,
+		values []models.DeviceParameterValue,
+	) error
+
+	ListDeviceParameterValues(
+		ctx context.Context,
+		condition map[string]any,
+	) ([]models.DeviceParameterValue, error)
+
+	// InsertDeviceParameterHistory appends reported values to the history.
+	InsertDeviceParameterHistory(
+		ctx context.Context,
+		values []models.DeviceParameterValueHistory,
+	) error
+
+	// ListDeviceParameterSnapshot returns the latest values of a device filtered by path prefixes,
+	// or the values as of a time read from the history.
+	ListDeviceParameterSnapshot(
+		ctx context.Context,
+		deviceId string,
+		prefixes []string,
+		asOf *time.Time,
+		opts models.QueryOptions,
+	) ([]models.DeviceParameterValue, error)
+
+	DeleteDeviceParameterHistoryBefore(
+		ctx context.Context,
+		before time.Time,
+	) (int64, error)
+
+	// InsertProfileAssignment assigns a profile to a group or a device.
+	InsertProfileAssignment(
+		ctx context.Context,
+		assignment *models.ProfileAssignment,
+	) error
+
+	// FindProfileAssignment retrieves a profile assignment by condition.
+	// If record not found, returns an error indicating the entity does not exist.
+	FindProfileAssignment(
+		ctx context.Context,
+		condition map[string]any,
+	) (*models.ProfileAssignment, error)
+
+	// ListProfileAssignments returns assignments with their profile, profile parameters and parameters preloaded.
+	ListProfileAssignments(
+		ctx context.Context,
+		condition map[string]any,
+	) ([]models.ProfileAssignment, error)
+
+	DeleteProfileAssignment(
+		ctx context.Context,
+		id string,
+	) error
+
+	// MoveProfileAssignmentsToDevice retargets device profile assignments to another device.
+	MoveProfileAssignmentsToDevice(
+		ctx context.Context,
+		ids []string,
+		deviceId string,
+	) error
+
 	ListTotalParameters(
 		ctx context.Context,
 		condition map[string]any,
@@ -679,4 +1399,67 @@ type iUSPStoreRepository interface {
 		condition map[string]any,
 		modelId string,
 	) ([]models.Group, error)
+
+	// InsertImportJob queues an import job.
+	InsertImportJob(
+		ctx context.Context,
+		job *models.ImportJob,
+	) error
+
+	// FindImportJob retrieves an import job by condition.
+	// If record not found, returns an error indicating the entity does not exist.
+	FindImportJob(
+		ctx context.Context,
+		condition map[string]any,
+	) (*models.ImportJob, error)
+
+	// ListClaimableImportJobs returns queued jobs and running jobs whose lease expired, locked for update.
+	ListClaimableImportJobs(
+		ctx context.Context,
+		limit int,
+	) ([]models.ImportJob, error)
+
+	// UpdateImportJobInStatus updates an import job only while its status is one of statuses,
+	// it reports whether the job was updated.
+	UpdateImportJobInStatus(
+		ctx context.Context,
+		id string,
+		statuses []string,
+		job *models.ImportJobUpdate,
+	) (bool, error)
+
+	// UpdateRunningImportJob updates a RUNNING import job only while it is held by the claim that set attempts,
+	// it reports whether the job was updated.
+	UpdateRunningImportJob(
+		ctx context.Context,
+		id string,
+		attempts int,
+		job *models.ImportJobUpdate,
+	) (bool, error)
+
+	// InsertImportJobErrors stores the rejected rows of an import job.
+	InsertImportJobErrors(
+		ctx context.Context,
+		jobErrors []models.ImportJobError,
+	) error
+
+	// ListImportJobErrors returns the first rejected rows of an import job ordered by line.
+	ListImportJobErrors(
+		ctx context.Context,
+		jobId string,
+		limit int,
+	) ([]models.ImportJobError, error)
+
+	// ListDeviceMacAddresses returns the mac addresses already used by a device, deleted ones included.
+	ListDeviceMacAddresses(
+		ctx context.Context,
+		macAddresses []string,
+	) ([]string, error)
+
+	// ListDeviceSerialNumbers returns the serial numbers already used by a non-deleted device of the manufacturer.
+	ListDeviceSerialNumbers(
+		ctx context.Context,
+		manufacturer string,
+		serialNumbers []string,
+	) ([]string, error)
 }
@@ -3,15 +3,15 @@ package managementuc
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"io"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
 	"usp-management-device-api/common/logging"
-	validate "usp-management-device-api/common/validator"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -74,6 +74,9 @@ func (s *service) CreateFirmware(
 		logging.Errorf("failed to create firmware: %v", err)
 		return "", err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateFirmware, firmware.Id, models.EventActionCreated, firmware.UpdatedBy, firmware); err != nil {
+		return "", err
+	}
 
 	// Commit transaction if success
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -138,6 +141,9 @@ func (s *service) CreateProfileWithNewParameters(
 			return err
 		}
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateProfile, profile.Id, models.EventActionCreated, profile.UpdatedBy, profile); err != nil {
+		return err
+	}
 
 	// If all operations succeed, commit the transaction
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -253,6 +259,9 @@ func (s *service) CreateProfileWithParameterId(
 			return "", err
 		}
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateProfile, profile.Id, models.EventActionCreated, profile.UpdatedBy, profile); err != nil {
+		return "", err
+	}
 
 	// Commit transaction
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -296,6 +305,12 @@ func (s *service) CreateModels(
 			"name",
 		)
 	}
+	// validate endpoint id template, empty means the default template
+	if input.EndpointIdTemplate != "" {
+		if err := models.ValidateEndpointIdTemplate(input.EndpointIdTemplate); err != nil {
+			return "", apperrors.NewInvalidRequestError(err, "invalid endpoint_id_template: "+err.Error(), "endpoint_id_template")
+		}
+	}
 	// Insert model
 	if err := s.store.InsertModel(txCtx, input); err != nil {
 		logging.Errorf("failed to insert model: %v", err)
@@ -368,31 +383,10 @@ func (s *service) CreateGroup(
 		}
 	}
 	//validate download period
-	if group.DownloadPeriod != "" {
-		var downloadPeriodRegex = regexp.MustCompile(`^([01]\d|2[0-3]):([0-5]\d)~([01]\d|2[0-3]):([0-5]\d)$`)
-		if !downloadPeriodRegex.MatchString(group.DownloadPeriod) {
-			return "", apperrors.NewInvalidRequestError(nil, "invalid format, expected format: 'HH:MM~HH:MM'", "download_period")
-		}
-		layout := "15:04"
-		parts := strings.Split(group.DownloadPeriod, "~")
-		if len(parts) != 2 {
-			return "", apperrors.NewInvalidRequestError(nil, "invalid format, expected format: 'start~end'", "download_period")
-		}
-		from, err := time.Parse(layout, parts[0])
-		if err != nil {
-			return "", apperrors.NewInvalidRequestError(err, "invalid start time: "+parts[0], "download_period")
-		}
-		logging.Infof("Parsed start time: %s", from.Format(layout))
-		to, err := time.Parse(layout, parts[1])
-		if err != nil {
-			return "", apperrors.NewInvalidRequestError(err, "invalid end time: "+parts[1], "download_period")
-		}
-		logging.Infof("Parsed end time: %s", to.Format(layout))
-		// if from.Equal(to) {
-		// 	return apperrors.NewInvalidRequestError(nil, "start time and end time cannot be the same", "download_period")
-		// }
-	} else if group.DownloadPeriod == "" {
-		group.DownloadPeriod = "00:00~00:00" // set to default period if not provided
+	if group.DownloadPeriod == "" {
+		group.DownloadPeriod = defaultDownloadPeriod // set to default period if not provided
+	} else if err := checkDownloadPeriod(group.DownloadPeriod); err != nil {
+		return "", err
 	}
 	// Insert group
 	logging.Infof("Inserting group: name=%s, model_id=%s, firmware_id=%v", group.Name, group.ModelId, group.FirmwareId)
@@ -404,6 +398,9 @@ func (s *service) CreateGroup(
 		}
 		return "", err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateGroup, group.Id, models.EventActionCreated, group.UpdatedBy, group); err != nil {
+		return "", err
+	}
 
 	// Commit transaction if success
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -445,6 +442,7 @@ func (s *service) CreateDevice(
 	}
 
 	//Find modelId exists
+	model := &models.Model{}
 	if device.ModelId != nil {
 		existingModel, err := s.store.FindModel(txCtx, map[string]any{
 			models.Model{}.GetIdColumnName(): *device.ModelId,
@@ -453,6 +451,7 @@ func (s *service) CreateDevice(
 			logging.Errorf("model not found with id: %s", *device.ModelId)
 			return "", apperrors.NewInvalidRequestError(err, "model not found with id: "+device.ModelId.String(), "model_id")
 		}
+		model = existingModel
 	}
 
 	//Find groupId exists
@@ -466,15 +465,24 @@ func (s *service) CreateDevice(
 			return "", apperrors.NewInvalidRequestError(err, "group with id: "+(*device.GroupId).String()+" does not belong to model with id: "+(*device.ModelId).String(), "group_id")
 		}
 	}
-	// validate endpoint ID (it should always be set by controller now)
-	if err := validate.ValidateEndpointID(device.EndpointId, device.MacAddress); err != nil {
-		return "", apperrors.NewInvalidRequestError(err, err.Error(), "endpoint_id")
+	// serial number is unique per manufacturer
+	if err := s.checkSerialNumberUnique(txCtx, model.Manufacturer, device.SerialNumber, ""); err != nil {
+		return "", err
+	}
+	// a device enters the lifecycle pre-provisioned, the first Notify activates it
+	device.LifecycleState = models.DeviceStatePreProvisioned
+	// render or check endpoint ID against the model template
+	if err := resolveDeviceEndpointId(model, device); err != nil {
+		return "", err
 	}
 	// Insert device
 	if err := s.store.InsertDevice(txCtx, device); err != nil {
 		logging.Errorf("failed to insert device: %v", err)
 		return "", err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateDevice, device.Id, models.EventActionCreated, device.UpdatedBy, device); err != nil {
+		return "", err
+	}
 
 	// Commit transaction if success
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -494,15 +502,14 @@ func (s *service) CreateDevicesWithBatch(
 	batchSize int,
 	modelID uuid.UUID,
 	groupID uuid.UUID,
-) ([]string, error) {
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
 	txCtx, err := s.store.BeginTx(ctx)
 	if err != nil {
 		logging.Errorf("failed to begin transaction: %v", err)
 		return nil, err
 	}
 
-	var deviceIds []string
-
 	success := false
 	defer func() {
 		if !success {
@@ -512,8 +519,41 @@ func (s *service) CreateDevicesWithBatch(
 			}
 		}
 	}()
+
+	report, err := s.importDeviceRows(txCtx, file, updatedBy, batchSize, modelID, groupID, opts)
+	if err != nil {
+		return report, err
+	}
+	if opts.DryRun {
+		return report.MarkDryRun(), nil
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	success = true
+	logging.Infof("Devices imported: %d created, %d rejected", report.Created, len(report.Rejected))
+	return report, nil
+}
+
+// importDeviceRows imports the rows of a device CSV in txCtx, the caller commits or rolls back.
+func (s *service) importDeviceRows(
+	txCtx context.Context,
+	file io.Reader,
+	updatedBy string,
+	batchSize int,
+	modelID uuid.UUID,
+	groupID uuid.UUID,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	// every inserted device emits an event, the webhook subscriptions are read once
+	txCtx = withWebhookSubscriptions(txCtx)
+	report := models.NewImportReport(models.ImportModeInsert)
 	r := csv.NewReader(file)
 	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
 
 	// Read file header
 	headers, err := r.Read()
@@ -526,108 +566,200 @@ func (s *service) CreateDevicesWithBatch(
 		logging.Errorf("invalid csv header")
 		return nil, apperrors.NewInvalidRequestError(err, "invalid csv header", "headers")
 	}
+	// optional identity columns, matched by header name
+	columns := map[string]int{}
+	for i, header := range headers {
+		columns[strings.TrimSpace(header)] = i
+	}
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	// Check modelId và groupId một lần cho cả file
+	existingModel, err := s.store.FindModel(txCtx, map[string]any{
+		models.Model{}.GetIdColumnName(): modelID,
+	})
+	if err != nil || existingModel == nil {
+		logging.Errorf("model not found with id: %s", modelID)
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+modelID.String(), "model_id")
+	}
+	_, err = s.store.FindGroup(txCtx, map[string]any{
+		models.Group{}.GetIdColumnName():      groupID,
+		models.Group{}.GetModelIdColumnName(): modelID,
+	})
+	if err != nil {
+		logging.Errorf("group not found with id=%v for model_id=%s: %v", groupID, modelID, err)
+		return nil, apperrors.NewInvalidRequestError(err, "group with id: "+groupID.String()+" does not belong to model with id: "+modelID.String(), "group_id")
+	}
+
+	macAddresses := map[string]int{}
+	serialNumbers := map[string]int{}
+	insertBatch := func(batch []*models.Device) error {
+		if err := s.store.InsertDevicesBatch(txCtx, batch); err != nil {
+			logging.Errorf("failed to insert devices: %v", err)
+			return err
+		}
+		// Collect device IDs
+		for _, d := range batch {
+			report.Ids = append(report.Ids, d.Id.String())
+			if err := s.emitDomainEvent(txCtx, models.AggregateDevice, d.Id, models.EventActionCreated, d.UpdatedBy, d); err != nil {
+				return err
+			}
+		}
+		report.Created += len(batch)
+		return nil
+	}
 
+	// rows are checked against the database once per batch instead of once per row
+	type pendingDevice struct {
+		line   int
+		device *models.Device
+	}
 	var batch []*models.Device
+	var pending []pendingDevice
+	flushPending := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		macs := make([]string, 0, len(pending))
+		serials := make([]string, 0, len(pending))
+		for _, p := range pending {
+			macs = append(macs, p.device.MacAddress)
+			if p.device.SerialNumber != "" {
+				serials = append(serials, p.device.SerialNumber)
+			}
+		}
+		foundMacs, err := s.store.ListDeviceMacAddresses(txCtx, macs)
+		if err != nil {
+			logging.Errorf("failed to list devices by mac address: %v", err)
+			return err
+		}
+		foundSerials, err := s.store.ListDeviceSerialNumbers(txCtx, existingModel.Manufacturer, serials)
+		if err != nil {
+			logging.Errorf("failed to list devices by serial number: %v", err)
+			return err
+		}
+		existingMacs, existingSerials := importKeySet(foundMacs), importKeySet(foundSerials)
+
+		for _, p := range pending {
+			device := p.device
+			if _, ok := existingMacs[device.MacAddress]; ok {
+				report.Reject(p.line, device.MacAddress, "device already exists with mac address: "+device.MacAddress)
+				continue
+			}
+			if _, ok := existingSerials[device.SerialNumber]; ok && device.SerialNumber != "" {
+				report.Reject(p.line, device.MacAddress,
+					"device already exists with serial number "+device.SerialNumber+" for manufacturer "+existingModel.Manufacturer)
+				continue
+			}
+			// strict mode only validates once a row is rejected, nothing will be written
+			if !importWritesRows(report, opts) {
+				continue
+			}
+
+			batch = append(batch, device)
+			// Insert batch nếu đủ batchSize
+			if len(batch) >= batchSize {
+				if err := insertBatch(batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
 	for {
 		record, err := r.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			logging.Errorf("failed to read csv record: %v", err)
-			return nil, err
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				logging.Errorf("failed to read csv record: %v", err)
+				return nil, err
+			}
+			report.Total++
+			report.Reject(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
 		}
-		if len(record) < 1 {
-			logging.Errorf("invalid csv record: %v", record)
+		report.Total++
+		if len(record) < 1 || strings.TrimSpace(record[0]) == "" {
+			report.Skip()
 			continue
 		}
+		line, _ := r.FieldPos(0)
 
 		// MAC address normalization
-		macAddress := strings.ToLower(strings.ReplaceAll(record[0], ":", ""))
-		if len(macAddress) != 12 {
-			logging.Warnf("invalid mac address format: %s, skip", macAddress)
+		macAddress := strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(strings.TrimSpace(record[0])))
+		if !isHexMacAddress(macAddress) {
+			report.Reject(line, record[0], "invalid mac address format")
+			continue
+		}
+		if first, ok := macAddresses[macAddress]; ok {
+			report.Reject(line, macAddress, "duplicate mac address in file, first seen on line "+strconv.Itoa(first))
 			continue
 		}
-		// create EndpointId
-		macUpper := strings.ToUpper(macAddress)
-		endpointId := "os::" + macUpper[:6] + "-" + macUpper
+		macAddresses[macAddress] = line
 
 		device := &models.Device{
 			MacAddress: macAddress,
-			EndpointId: endpointId,
 			ModelId:    &modelID,
 			GroupId:    &groupID,
 			Status:     "ENABLE",
 			UpdatedBy:  updatedBy,
-		}
 
-		// Check thiết bị đã tồn tại chưa
-		existingDevice, err := s.store.FindDevice(txCtx, map[string]any{
-			models.Device{}.GetMacAddressColumnName(): device.MacAddress,
-		})
-		if err == nil && existingDevice != nil {
-			logging.Warnf("device already exists with mac address: %s, skip", device.MacAddress)
-			return nil, apperrors.NewInvalidRequestError(err, "device already exists with mac address: "+device.MacAddress, "mac_address")
+			LifecycleState:   models.DeviceStatePreProvisioned,
+			SerialNumber:     column(record, "Serial Number"),
+			ProductClass:     column(record, "Product Class"),
+			HardwareRevision: column(record, "Hardware Revision"),
 		}
 
-		// Check modelId có tồn tại không
-		existingModel, err := s.store.FindModel(txCtx, map[string]any{
-			models.Model{}.GetIdColumnName(): *device.ModelId,
-		})
-		if err != nil || existingModel == nil {
-			logging.Errorf("model not found with id: %s", *device.ModelId)
-			return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+device.ModelId.String(), "model_id")
-		}
-		// Find groupId exists
-		_, err = s.store.FindGroup(txCtx, map[string]any{
-			models.Group{}.GetIdColumnName():      *device.GroupId,
-			models.Group{}.GetModelIdColumnName(): device.ModelId,
-		})
-		if err != nil {
-			logging.Errorf("group not found with id=%v for model_id=%s: %v", *device.GroupId, *device.ModelId, err)
-			return nil, apperrors.NewInvalidRequestError(err, "group with id: "+(*device.GroupId).String()+" does not belong to model with id: "+(*device.ModelId).String(), "group_id")
+		// serial number is unique per manufacturer, also inside the file
+		if device.SerialNumber != "" {
+			if first, ok := serialNumbers[device.SerialNumber]; ok {
+				report.Reject(line, macAddress, "duplicate serial number in file, first seen on line "+strconv.Itoa(first))
+				continue
+			}
+			serialNumbers[device.SerialNumber] = line
 		}
-		// validate endpoint ID
-		if err := validate.ValidateEndpointID(device.EndpointId, device.MacAddress); err != nil {
-			return nil, apperrors.NewInvalidRequestError(err, err.Error(), "endpoint_id")
+		// create EndpointId from the model template
+		if err := resolveDeviceEndpointId(existingModel, device); err != nil {
+			if reason, ok := importRowRejection(err); ok {
+				report.Reject(line, macAddress, reason)
+				continue
+			}
+			return nil, err
 		}
 
-		batch = append(batch, device)
-
-		// Insert batch nếu đủ batchSize
-		if len(batch) >= batchSize {
-			if err := s.store.InsertDevicesBatch(txCtx, batch); err != nil {
-				logging.Errorf("failed to insert devices: %v", err)
+		pending = append(pending, pendingDevice{line: line, device: device})
+		if len(pending) >= batchSize {
+			if err := flushPending(); err != nil {
 				return nil, err
 			}
-			// Collect device IDs
-			for _, d := range batch {
-				deviceIds = append(deviceIds, d.Id.String())
-			}
-			batch = batch[:0]
 		}
 	}
+	if err := flushPending(); err != nil {
+		return nil, err
+	}
+
+	if err := finishImport(report, opts); err != nil {
+		logging.Errorf("device import rejected, %d invalid rows", len(report.Rejected))
+		return report, err
+	}
 
 	// Insert phần còn lại
 	if len(batch) > 0 {
-		if err := s.store.InsertDevicesBatch(txCtx, batch); err != nil {
-			logging.Errorf("failed to insert devices: %v", err)
+		if err := insertBatch(batch); err != nil {
 			return nil, err
 		}
-		// Collect device IDs
-		for _, d := range batch {
-			deviceIds = append(deviceIds, d.Id.String())
-		}
 	}
-
-	if err := s.store.CommitTx(txCtx); err != nil {
-		logging.Errorf("failed to commit transaction: %v", err)
-		return nil, err
-	}
-
-	success = true
-	logging.Infof("Devices created successfully with IDs: %v", deviceIds)
-	return deviceIds, nil
+	return report, nil
 }
 
 // CreateParametersFromCSVStreaming creates parameters from a CSV file in a streaming manner.
@@ -638,7 +770,14 @@ func (s *service) CreateParametersFromCSVFile(
 	file io.Reader,
 	updatedBy string,
 	batchSize int,
-) ([]string, error) {
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	// the catalog import is all-or-nothing
+	opts.Strict = true
+	if opts.Mode == "" {
+		opts.Mode = models.ImportModeInsert
+	}
+
 	txCtx, err := s.store.BeginTx(ctx)
 	if err != nil {
 		logging.Errorf("failed to begin transaction: %v", err)
@@ -654,8 +793,36 @@ func (s *service) CreateParametersFromCSVFile(
 			}
 		}
 	}()
+
+	report, err := s.importParameterRows(txCtx, file, updatedBy, batchSize, opts)
+	if err != nil {
+		return report, err
+	}
+	if opts.DryRun {
+		return report.MarkDryRun(), nil
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	success = true
+	logging.Infof("Parameters imported: %d created, %d updated, %d deleted", report.Created, report.Updated, report.Deleted)
+	return report, nil
+}
+
+// importParameterRows imports the rows of a parameter CSV in txCtx, the caller commits or rolls back.
+func (s *service) importParameterRows(
+	txCtx context.Context,
+	file io.Reader,
+	updatedBy string,
+	batchSize int,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
 	r := csv.NewReader(file)
 	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
 
 	//Read file header
 	headers, err := r.Read()
@@ -664,24 +831,110 @@ func (s *service) CreateParametersFromCSVFile(
 		return nil, apperrors.NewInvalidRequestError(err, "file error", "headers")
 	}
 	logging.Infof("CSV Headers: %v", headers)
-	if len(headers) < 3 || headers[0] != "Path" || headers[1] != "Data Type" || headers[2] != "Description" {
+	if !hasExportColumns(headers, models.ParameterExportSchema) {
 		logging.Errorf("invalid csv header")
 		return nil, apperrors.NewInvalidRequestError(err, "invalid csv header", "headers")
 	}
 
-	var ids []string
+	report := models.NewImportReport(opts.Mode)
+	paths := map[string]int{}
+	insertBatch := func(batch []*models.Parameter) error {
+		if err := s.store.InsertParametersBatch(txCtx, batch); err != nil {
+			logging.Errorf("failed to insert parameters: %v", err)
+			return err
+		}
+		for _, p := range batch {
+			report.Ids = append(report.Ids, p.Id.String())
+		}
+		report.Created += len(batch)
+		return nil
+	}
+
+	// rows are checked against the database once per batch instead of once per row
+	type pendingParameter struct {
+		line  int
+		param *models.Parameter
+	}
 	var batch []*models.Parameter
+	var pending []pendingParameter
+	flushPending := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		pendingPaths := make([]string, 0, len(pending))
+		for _, p := range pending {
+			pendingPaths = append(pendingPaths, p.param.Path)
+		}
+		found, err := s.store.ListTotalParameters(txCtx, map[string]any{
+			models.Parameter{}.GetPathColumnName(): pendingPaths,
+		})
+		if err != nil {
+			logging.Errorf("failed to list parameters by path: %v", err)
+			return err
+		}
+		existing := make(map[string]*models.Parameter, len(found))
+		for i := range found {
+			existing[found[i].Path] = &found[i]
+		}
+
+		for _, p := range pending {
+			param := p.param
+			if existingParam, ok := existing[param.Path]; ok {
+				if !opts.Upserts() {
+					report.Reject(p.line, param.Path, "parameter already exists with path: "+param.Path)
+					continue
+				}
+				if !importWritesRows(report, opts) {
+					continue
+				}
+				update := parameterImportUpdate(existingParam, param)
+				if update == nil {
+					report.Skip()
+					continue
+				}
+				if err := s.store.UpdateParameter(txCtx, existingParam.Id.String(), update); err != nil {
+					logging.Errorf("failed to update parameter %s: %v", param.Path, err)
+					return err
+				}
+				report.Updated++
+				continue
+			}
+			if !importWritesRows(report, opts) {
+				continue
+			}
+
+			batch = append(batch, param)
+			// Insert batch if batch size is reached, default is 100 records
+			if len(batch) >= batchSize {
+				if err := insertBatch(batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
 	for {
 		record, err := r.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			logging.Errorf("failed to read csv record: %v", err)
-			return nil, err
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				logging.Errorf("failed to read csv record: %v", err)
+				return nil, err
+			}
+			report.Total++
+			report.Reject(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
 		}
+		report.Total++
+		line, _ := r.FieldPos(0)
 		if len(record) < 3 {
-			logging.Errorf("invalid csv record: %v", record)
+			report.Skip()
 			continue
 		}
 
@@ -692,58 +945,82 @@ func (s *service) CreateParametersFromCSVFile(
 			Status:      "ENABLE",
 			UpdatedBy:   updatedBy,
 		}
-		batch = append(batch, param)
 		//validate parameters
-		existingParam, err := s.store.FindParameter(txCtx, map[string]any{
-			models.Parameter{}.GetPathColumnName(): param.Path,
-		})
-		if err == nil && existingParam != nil {
-			logging.Warnf("parameter already exists with path: %s, skip", param.Path)
-			return nil, apperrors.NewInvalidRequestError(err, "parameter already exists with path: "+param.Path, "path")
+		if param.Path == "" || param.DataType == "" {
+			report.Reject(line, param.Path, "path and data type are required")
+			continue
 		}
+		if first, ok := paths[param.Path]; ok {
+			report.Reject(line, param.Path, "duplicate path in file, first seen on line "+strconv.Itoa(first))
+			continue
+		}
+		paths[param.Path] = line
 
-		// Insert batch if batch size is reached, default is 100 records
-		if len(batch) >= batchSize {
-			if err := s.store.InsertParametersBatch(txCtx, batch); err != nil {
-				logging.Errorf("failed to insert parameters: %v", err)
+		pending = append(pending, pendingParameter{line: line, param: param})
+		if len(pending) >= batchSize {
+			if err := flushPending(); err != nil {
 				return nil, err
 			}
-			for _, p := range batch {
-				ids = append(ids, p.Id.String())
-			}
-			batch = batch[:0]
 		}
 	}
+	if err := flushPending(); err != nil {
+		return nil, err
+	}
 
+	if err := finishImport(report, opts); err != nil {
+		logging.Errorf("parameter import rejected, %d invalid rows", len(report.Rejected))
+		return report, err
+	}
 	if len(batch) > 0 {
-		if err := s.store.InsertParametersBatch(txCtx, batch); err != nil {
-			logging.Errorf("failed to insert parameters: %v", err)
+		if err := insertBatch(batch); err != nil {
 			return nil, err
 		}
-		for _, p := range batch {
-			ids = append(ids, p.Id.String())
-		}
 	}
-
-	if err := s.store.CommitTx(txCtx); err != nil {
-		logging.Errorf("failed to commit transaction: %v", err)
-		return nil, err
+	// replace soft-deletes the catalog rows missing from the file
+	if opts.Mode == models.ImportModeReplace {
+		if len(paths) == 0 {
+			return nil, apperrors.NewInvalidRequestError(nil, "replace import needs at least one row", "file")
+		}
+		parameters, err := s.store.ListTotalParameters(txCtx, map[string]any{
+			models.Parameter{}.GetStatusColumnName(): []string{"ENABLE", "DISABLE"},
+		})
+		if err != nil {
+			logging.Errorf("failed to list parameters: %v", err)
+			return nil, err
+		}
+		for _, parameter := range parameters {
+			if _, ok := paths[parameter.Path]; ok {
+				continue
+			}
+			if err := s.store.ChangeStatusParameterToDelete(txCtx, parameter.Id.String(), updatedBy); err != nil {
+				logging.Errorf("failed to delete parameter %s: %v", parameter.Path, err)
+				return nil, err
+			}
+			report.Deleted++
+		}
 	}
-
-	success = true
-	logging.Infof("Parameters created successfully with IDs: %v", ids)
-	return ids, nil
+	return report, nil
 }
 
+// profileImportBatchSize is the number of profile rows checked against the database at once.
+const profileImportBatchSize = 100
+
 func (s *service) CreateProfileWithBatch(
 	ctx context.Context,
 	file io.Reader,
 	updatedBy string,
-) error {
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	// the catalog import is all-or-nothing
+	opts.Strict = true
+	if opts.Mode == "" {
+		opts.Mode = models.ImportModeInsert
+	}
+
 	txCtx, err := s.store.BeginTx(ctx)
 	if err != nil {
 		logging.Errorf("failed to begin transaction: %v", err)
-		return err
+		return nil, err
 	}
 
 	success := false
@@ -755,28 +1032,195 @@ func (s *service) CreateProfileWithBatch(
 		}
 	}()
 
+	report, err := s.importProfileRows(txCtx, file, updatedBy, opts)
+	if err != nil {
+		return report, err
+	}
+	if opts.DryRun {
+		return report.MarkDryRun(), nil
+	}
+
+	// Commit transaction
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	success = true
+	logging.Infof("Profiles imported: %d created, %d updated, %d deleted", report.Created, report.Updated, report.Deleted)
+	return report, nil
+}
+
+// importProfileRows imports the rows of a profile CSV in txCtx, the caller commits or rolls back.
+func (s *service) importProfileRows(
+	txCtx context.Context,
+	file io.Reader,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	// every written profile emits an event, the webhook subscriptions are read once
+	txCtx = withWebhookSubscriptions(txCtx)
+
 	r := csv.NewReader(file)
 	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
 
 	// Read file header
 	headers, err := r.Read()
 	if err != nil {
 		logging.Errorf("failed to read csv header: %v", err)
-		return apperrors.NewInvalidRequestError(err, "file error", "headers")
+		return nil, apperrors.NewInvalidRequestError(err, "file error", "headers")
 	}
 
-	expectedHeaders := []string{
-		"Name", "Msg Type", "Tags", "Max Depth", "Allow Partial",
-		"First Level Only", "Return Commands", "Return Events",
-		"Return Params", "Return Unique Key Sets", "Send Resp", "Parameters IDs",
+	// the export schema header, its read-only columns are ignored, or the legacy header
+	legacy := slices.Equal(headers, models.LegacyProfileImportColumns)
+	if !legacy && !hasExportColumns(headers, models.ProfileExportSchema) {
+		return nil, apperrors.NewInvalidRequestError(nil, "invalid csv header", "headers")
 	}
-	if len(headers) != len(expectedHeaders) {
-		return apperrors.NewInvalidRequestError(nil, "invalid csv header", "headers")
+
+	report := models.NewImportReport(opts.Mode)
+	names := map[string]int{}
+
+	// rows are checked against the database once per batch instead of once per row
+	type pendingProfile struct {
+		line    int
+		profile *models.Profile
+		members []profileImportMember
 	}
-	for i, h := range expectedHeaders {
-		if headers[i] != h {
-			return apperrors.NewInvalidRequestError(nil, "invalid csv header", "headers")
+	var pending []pendingProfile
+	flushPending := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		pendingNames := make([]string, 0, len(pending))
+		pendingParamIDs := []string{}
+		pendingParamPaths := []string{}
+		for _, p := range pending {
+			pendingNames = append(pendingNames, p.profile.Name)
+			for _, member := range p.members {
+				if member.id != "" {
+					pendingParamIDs = append(pendingParamIDs, member.id)
+				} else {
+					pendingParamPaths = append(pendingParamPaths, member.path)
+				}
+			}
+		}
+		foundProfiles, err := s.store.ListProfiles(txCtx, map[string]any{
+			models.Profile{}.GetProfileNameColumnName(): pendingNames,
+		}, models.QueryOptions{})
+		if err != nil {
+			logging.Errorf("failed to list profiles by name: %v", err)
+			return err
+		}
+		existingProfiles := make(map[string]*models.Profile, len(foundProfiles))
+		for i := range foundProfiles {
+			existingProfiles[foundProfiles[i].Name] = &foundProfiles[i]
+		}
+		existingParams := map[string]*models.Parameter{}
+		if len(pendingParamIDs) > 0 {
+			foundParams, err := s.store.ListTotalParameters(txCtx, map[string]any{
+				models.Parameter{}.GetIdColumnName(): pendingParamIDs,
+			})
+			if err != nil {
+				logging.Errorf("failed to list parameters by id: %v", err)
+				return err
+			}
+			for i := range foundParams {
+				existingParams[foundParams[i].Id.String()] = &foundParams[i]
+			}
 		}
+		paramsByPath := map[string]*models.Parameter{}
+		if len(pendingParamPaths) > 0 {
+			foundParams, err := s.store.ListTotalParameters(txCtx, map[string]any{
+				models.Parameter{}.GetPathColumnName(): pendingParamPaths,
+			})
+			if err != nil {
+				logging.Errorf("failed to list parameters by path: %v", err)
+				return err
+			}
+			for i := range foundParams {
+				paramsByPath[foundParams[i].Path] = &foundParams[i]
+			}
+		}
+
+		for _, p := range pending {
+			profile := p.profile
+			existingProfile := existingProfiles[profile.Name]
+			if existingProfile != nil && !opts.Upserts() {
+				report.Reject(p.line, profile.Name, "profile already exists with name: "+profile.Name)
+				continue
+			}
+			reason := ""
+			parameters := make([]importedProfileParameter, 0, len(p.members))
+			for _, member := range p.members {
+				var existingParam *models.Parameter
+				if member.id != "" {
+					if existingParam = existingParams[member.id]; existingParam == nil {
+						reason = "parameter not found with id: " + member.id
+						break
+					}
+				} else if existingParam = paramsByPath[member.path]; existingParam == nil {
+					reason = "parameter not found with path: " + member.path
+					break
+				}
+				parameters = append(parameters, importedProfileParameter{
+					parameter:    existingParam,
+					defaultValue: member.defaultValue,
+					required:     member.required,
+					keepValues:   legacy,
+				})
+			}
+			if reason != "" {
+				report.Reject(p.line, profile.Name, reason)
+				continue
+			}
+			if !importWritesRows(report, opts) {
+				continue
+			}
+			if existingProfile != nil {
+				// the legacy header has no description column
+				if legacy {
+					profile.Description = existingProfile.Description
+				}
+				updated, err := s.updateImportedProfile(txCtx, existingProfile, profile, parameters)
+				if err != nil {
+					return err
+				}
+				if updated {
+					report.Updated++
+				} else {
+					report.Skip()
+				}
+				continue
+			}
+
+			logging.Infof("Creating profile: %s", profile.Name)
+			// Insert profile
+			if err := s.store.InsertProfile(txCtx, profile); err != nil {
+				logging.Errorf("failed to insert profile: %v", err)
+				return err
+			}
+			for _, parameter := range parameters {
+				pp := &models.ProfileParameter{
+					ProfileId:    profile.Id,
+					ParameterId:  parameter.parameter.Id,
+					DefaultValue: parameter.defaultValue,
+					Required:     parameter.required,
+					UpdatedBy:    updatedBy,
+				}
+				if err := s.store.InsertProfileParameter(txCtx, pp); err != nil {
+					logging.Errorf("failed to insert profile parameter: %v", err)
+					return err
+				}
+			}
+			if err := s.emitDomainEvent(txCtx, models.AggregateProfile, profile.Id, models.EventActionCreated, updatedBy, profile); err != nil {
+				return err
+			}
+			report.Created++
+			report.Ids = append(report.Ids, profile.Id.String())
+		}
+		pending = pending[:0]
+		return nil
 	}
 
 	// Read each profile from CSV
@@ -786,109 +1230,162 @@ func (s *service) CreateProfileWithBatch(
 			break
 		}
 		if err != nil {
-			logging.Errorf("failed to read csv record: %v", err)
-			return err
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				logging.Errorf("failed to read csv record: %v", err)
+				return nil, err
+			}
+			report.Total++
+			report.Reject(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
+		}
+		report.Total++
+		line, _ := r.FieldPos(0)
+		if len(record) != len(headers) {
+			report.Reject(line, "", "expected "+strconv.Itoa(len(headers))+" columns, got "+strconv.Itoa(len(record)))
+			continue
 		}
 
-		// Parse values
-		profileName := strings.TrimSpace(record[0])
-
-		// Convert message type string -> int
-		msgType, err := strconv.Atoi(strings.TrimSpace(record[1]))
-		if err != nil {
-			logging.Errorf("invalid message type: %v", record[1])
-			return apperrors.NewInvalidRequestError(err, "invalid msg_type", "msg_type")
+		profile, members, reason := parseProfileRecord(record, legacy, updatedBy)
+		if reason != "" {
+			report.Reject(line, profile.Name, reason)
+			continue
 		}
+		if first, ok := names[profile.Name]; ok {
+			report.Reject(line, profile.Name, "duplicate profile name in file, first seen on line "+strconv.Itoa(first))
+			continue
+		}
+		names[profile.Name] = line
 
-		// Tags: split by ; → pq.StringArray
-		tags := pq.StringArray{}
-		if record[2] != "" {
-			for _, tag := range strings.Split(record[2], ";") {
-				tags = append(tags, strings.TrimSpace(tag))
+		pending = append(pending, pendingProfile{line: line, profile: profile, members: members})
+		if len(pending) >= profileImportBatchSize {
+			if err := flushPending(); err != nil {
+				return nil, err
 			}
 		}
+	}
+	if err := flushPending(); err != nil {
+		return nil, err
+	}
 
-		// MaxDepth
-		maxDepth, err := strconv.Atoi(strings.TrimSpace(record[3]))
+	if err := finishImport(report, opts); err != nil {
+		logging.Errorf("profile import rejected, %d invalid rows", len(report.Rejected))
+		return report, err
+	}
+	// replace soft-deletes the catalog rows missing from the file
+	if opts.Mode == models.ImportModeReplace {
+		if len(names) == 0 {
+			return nil, apperrors.NewInvalidRequestError(nil, "replace import needs at least one row", "file")
+		}
+		profiles, err := s.store.ListProfiles(txCtx, map[string]any{
+			models.Profile{}.GetStatusColumnName(): []string{"ENABLE", "DISABLE"},
+		}, models.QueryOptions{})
 		if err != nil {
-			logging.Errorf("invalid max depth: %v", record[3])
-			return apperrors.NewInvalidRequestError(err, "invalid max_depth", "max_depth")
-		}
-
-		// Parse bool helper
-		parseBool := func(s string) bool {
-			return strings.ToLower(strings.TrimSpace(s)) == "true"
-		}
-
-		profile := &models.Profile{
-			Name:                profileName,
-			MsgType:             msgType,
-			Tags:                tags,
-			MaxDepth:            maxDepth,
-			AllowPartial:        parseBool(record[4]),
-			FirstLevelOnly:      parseBool(record[5]),
-			ReturnCommands:      parseBool(record[6]),
-			ReturnEvents:        parseBool(record[7]),
-			ReturnParams:        parseBool(record[8]),
-			ReturnUniqueKeySets: parseBool(record[9]),
-			SendResp:            parseBool(record[10]),
-			Status:              "ENABLE",
-			UpdatedBy:           updatedBy,
-		}
-
-		// Check profile exists
-		existingProfile, err := s.store.FindProfile(txCtx, map[string]any{
-			models.Profile{}.GetProfileNameColumnName(): profile.Name,
-		})
-		if err == nil && existingProfile != nil {
-			logging.Errorf("profile already exists: %s", profile.Name)
-			return apperrors.NewInvalidRequestError(err, "profile already exists with name: "+profile.Name, "name")
-		}
-		logging.Infof("Creating profile: %s", profile.Name)
-		// Insert profile
-		if err := s.store.InsertProfile(txCtx, profile); err != nil {
-			logging.Errorf("failed to insert profile: %v", err)
-			return err
+			logging.Errorf("failed to list profiles: %v", err)
+			return nil, err
 		}
-
-		// Parse parameter IDs (comma-separated)
-		paramIDs := strings.Split(record[11], ",")
-		for _, paramId := range paramIDs {
-			paramId = strings.TrimSpace(paramId)
-			if paramId == "" {
+		for _, existing := range profiles {
+			if _, ok := names[existing.Name]; ok {
 				continue
 			}
-
-			existingParam, err := s.store.FindParameter(txCtx, map[string]any{
-				models.Parameter{}.GetIdColumnName(): paramId,
-			})
-			if err != nil {
-				logging.Errorf("failed to find parameter: %v", err)
-				return err
+			if err := s.store.ChangeStatusProfileToDelete(txCtx, existing.Id.String(), updatedBy); err != nil {
+				logging.Errorf("failed to delete profile %s: %v", existing.Name, err)
+				return nil, err
 			}
-			logging.Infof("Found parameter for ID: %s", paramId)
+			if err := s.emitDomainEvent(txCtx, models.AggregateProfile, existing.Id, models.EventActionDeleted, updatedBy, deletedEventData(existing.Id)); err != nil {
+				return nil, err
+			}
+			report.Deleted++
+		}
+	}
+	return report, nil
+}
+
+// parseProfileRecord reads a row of the profile CSV, reason is set when a value is invalid.
+// A legacy row lists its parameters by id, a schema row by path with their values.
+func parseProfileRecord(record []string, legacy bool, updatedBy string) (*models.Profile, []profileImportMember, string) {
+	// Parse values
+	profile := &models.Profile{
+		Name:      strings.TrimSpace(record[0]),
+		Status:    "ENABLE",
+		UpdatedBy: updatedBy,
+	}
+	if profile.Name == "" {
+		return profile, nil, "name is required"
+	}
+
+	// Convert message type string -> int
+	msgType, err := strconv.Atoi(strings.TrimSpace(record[1]))
+	if err != nil {
+		return profile, nil, "invalid msg_type: " + record[1]
+	}
+	profile.MsgType = msgType
+
+	// Tags: split by ; → pq.StringArray
+	tags := pq.StringArray{}
+	if record[2] != "" {
+		for _, tag := range strings.Split(record[2], ";") {
+			tags = append(tags, strings.TrimSpace(tag))
+		}
+	}
+	profile.Tags = tags
 
-			pp := &models.ProfileParameter{
-				ProfileId:    profile.Id,
-				ParameterId:  existingParam.Id,
-				DefaultValue: "",
-				Required:     true,
-				UpdatedBy:    updatedBy,
+	// MaxDepth
+	maxDepth, err := strconv.Atoi(strings.TrimSpace(record[3]))
+	if err != nil {
+		return profile, nil, "invalid max_depth: " + record[3]
+	}
+	profile.MaxDepth = maxDepth
+
+	// Parse bool helper
+	parseBool := func(s string) bool {
+		return strings.ToLower(strings.TrimSpace(s)) == "true"
+	}
+	profile.AllowPartial = parseBool(record[4])
+	profile.FirstLevelOnly = parseBool(record[5])
+	profile.ReturnCommands = parseBool(record[6])
+	profile.ReturnEvents = parseBool(record[7])
+	profile.ReturnParams = parseBool(record[8])
+	profile.ReturnUniqueKeySets = parseBool(record[9])
+	profile.SendResp = parseBool(record[10])
+
+	members := []profileImportMember{}
+	if !legacy {
+		profile.Description = strings.TrimSpace(record[11])
+		parsed, err := models.ParseProfileParameterMembers(record[12])
+		if err != nil {
+			return profile, nil, "invalid parameters: " + err.Error()
+		}
+		paths := map[string]bool{}
+		for _, member := range parsed {
+			path := strings.TrimSpace(member.Path)
+			if path == "" {
+				return profile, nil, "parameter path is required"
 			}
-			if err := s.store.InsertProfileParameter(txCtx, pp); err != nil {
-				logging.Errorf("failed to insert profile parameter: %v", err)
-				return err
+			if paths[path] {
+				return profile, nil, "duplicate parameter path: " + path
 			}
+			paths[path] = true
+			members = append(members, profileImportMember{
+				path:         path,
+				defaultValue: member.DefaultValue,
+				required:     member.Required,
+			})
 		}
+		return profile, members, ""
 	}
 
-	// Commit transaction
-	if err := s.store.CommitTx(txCtx); err != nil {
-		logging.Errorf("failed to commit transaction: %v", err)
-		return err
+	// Parse parameter IDs (comma-separated)
+	for _, paramId := range strings.Split(record[11], ",") {
+		paramId = strings.TrimSpace(paramId)
+		if paramId == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(paramId)
+		if err != nil {
+			return profile, nil, "invalid parameter id: " + paramId
+		}
+		members = append(members, profileImportMember{id: parsed.String(), required: true})
 	}
-
-	success = true
-	logging.Infof("Profiles created successfully")
-	return nil
+	return profile, members, ""
 }
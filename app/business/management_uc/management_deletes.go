@@ -94,6 +94,9 @@ func (s *service) DeleteProfileWithProfileId(
 		logging.Errorf("failed to change status of profile id=%s: %v", id, err)
 		return err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateProfile, profile.Id, models.EventActionDeleted, updatedBy, deletedEventData(profile.Id)); err != nil {
+		return err
+	}
 
 	// Commit transaction
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -214,6 +217,9 @@ func (s *service) DeleteFirmwareWithId(
 		logging.Errorf("failed to change status of firmware id=%s: %v", id, err)
 		return err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateFirmware, firmware.Id, models.EventActionDeleted, updatedBy, deletedEventData(firmware.Id)); err != nil {
+		return err
+	}
 
 	//Move firmware file from firmware bucket to trash bucket in MinIO
 	firmwarePath := model.Name + "/" + firmware.Name
@@ -280,6 +286,9 @@ func (s *service) DeleteGroupWithId(
 		logging.Errorf("failed to change status of group id=%s: %v", id, err)
 		return err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateGroup, group.Id, models.EventActionDeleted, updatedBy, deletedEventData(group.Id)); err != nil {
+		return err
+	}
 
 	// Commit transaction
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -338,6 +347,9 @@ func (s *service) DeleteDeviceWithId(
 		logging.Errorf("failed to change status of device id=%s: %v", id, err)
 		return err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateDevice, device.Id, models.EventActionDeleted, updatedBy, deletedEventData(device.Id)); err != nil {
+		return err
+	}
 
 	// Commit transaction
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -0,0 +1,56 @@
+package managementuc
+
+import (
+	"context"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+)
+
+func (s *service) LookupDevices(
+	ctx context.Context,
+	lookup models.DeviceLookup,
+) ([]models.Device, error) {
+	lookup.MacAddress = strings.ToLower(strings.ReplaceAll(lookup.MacAddress, ":", ""))
+	if lookup.IsEmpty() {
+		return nil, apperrors.NewInvalidRequestError(nil,
+			"one of serial_number, endpoint_id or mac_address is required", "serial_number")
+	}
+
+	devices, err := s.store.LookupDevices(ctx, lookup)
+	if err != nil {
+		logging.Errorf("failed to lookup devices %+v: %v", lookup, err)
+		return nil, err
+	}
+	return devices, nil
+}
+
+// checkSerialNumberUnique rejects a serial number already used by another device
+// of a model with the same manufacturer, excludeId is the device being updated.
+func (s *service) checkSerialNumberUnique(
+	ctx context.Context,
+	manufacturer string,
+	serialNumber string,
+	excludeId string,
+) error {
+	if serialNumber == "" {
+		return nil
+	}
+
+	devices, err := s.store.LookupDevices(ctx, models.DeviceLookup{
+		SerialNumber: serialNumber,
+		Manufacturer: manufacturer,
+	})
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.Id.String() != excludeId {
+			logging.Errorf("device already exists with serial number %s for manufacturer %s", serialNumber, manufacturer)
+			return apperrors.NewInvalidRequestError(nil,
+				"device already exists with serial number "+serialNumber+" for manufacturer "+manufacturer, "serial_number")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package managementuc
+
+import (
+	"context"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+)
+
+// transitionDeviceLifecycle moves a device to state `to` if the lifecycle allows it and records
+// the transition with its reason and actor. txCtx must hold the transaction of the device update,
+// the caller writes lifecycle_state on the device row.
+func (s *service) transitionDeviceLifecycle(
+	txCtx context.Context,
+	device *models.Device,
+	to string,
+	reason string,
+	actor string,
+) error {
+	from := device.LifecycleState
+	if from == "" {
+		from = models.DeviceStatePreProvisioned
+	}
+	if !models.IsDeviceLifecycleState(to) {
+		return apperrors.NewInvalidRequestError(nil,
+			"invalid lifecycle_state "+to+", allowed: "+strings.Join(models.DeviceLifecycleStates, ", "), "lifecycle_state")
+	}
+	if !models.CanTransitionDevice(from, to) {
+		allowed := strings.Join(models.NextDeviceStates(from), ", ")
+		if allowed == "" {
+			allowed = "none, " + from + " is terminal"
+		}
+		return apperrors.NewInvalidRequestError(nil,
+			"lifecycle transition "+from+" -> "+to+" is not allowed, allowed: "+allowed, "lifecycle_state")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return apperrors.NewInvalidRequestError(nil, "lifecycle_reason is required to change lifecycle_state", "lifecycle_reason")
+	}
+
+	transition := &models.DeviceLifecycleTransition{
+		DeviceId:  device.Id,
+		FromState: from,
+		ToState:   to,
+		Reason:    reason,
+		Actor:     actor,
+	}
+	if err := s.store.InsertDeviceLifecycleTransition(txCtx, transition); err != nil {
+		logging.Errorf("failed to record lifecycle transition of device %s: %v", device.Id, err)
+		return err
+	}
+	return s.emitDomainEvent(txCtx, models.AggregateDevice, device.Id, models.EventActionLifecycleChanged, actor, map[string]any{
+		"id":         device.Id,
+		"model_id":   device.ModelId,
+		"from_state": from,
+		"to_state":   to,
+		"reason":     reason,
+	})
+}
+
+func (s *service) CountDevicesByLifecycleState(
+	ctx context.Context,
+	modelId string,
+) ([]models.DeviceStateCount, error) {
+	if _, err := s.store.FindModel(ctx, map[string]any{
+		models.Model{}.GetIdColumnName(): modelId,
+	}); err != nil {
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+modelId, "find_model_error")
+	}
+
+	counts, err := s.store.CountDeviceByLifecycleState(ctx, modelId)
+	if err != nil {
+		logging.Errorf("failed to count devices by lifecycle state of model %s: %v", modelId, err)
+		return nil, err
+	}
+
+	// every state is reported, in lifecycle order
+	byState := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		byState[count.LifecycleState] = count.Count
+	}
+	result := make([]models.DeviceStateCount, 0, len(models.DeviceLifecycleStates))
+	for _, state := range models.DeviceLifecycleStates {
+		result = append(result, models.DeviceStateCount{LifecycleState: state, Count: byState[state]})
+	}
+	return result, nil
+}
+
+func (s *service) ListDeviceLifecycleTransitions(
+	ctx context.Context,
+	modelId string,
+	deviceId string,
+) ([]models.DeviceLifecycleTransition, error) {
+	if _, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetIdColumnName():      deviceId,
+		models.Device{}.GetModelIdColumnName(): modelId,
+	}); err != nil {
+		logging.Errorf("device not found with id=%s: %v", deviceId, err)
+		return nil, err
+	}
+
+	transitions, err := s.store.ListDeviceLifecycleTransitions(ctx, map[string]any{
+		models.DeviceLifecycleTransition{}.GetDeviceIdColumnName(): deviceId,
+	})
+	if err != nil {
+		logging.Errorf("failed to list lifecycle transitions of device %s: %v", deviceId, err)
+		return nil, err
+	}
+	return transitions, nil
+}
@@ -0,0 +1,285 @@
+package managementuc
+
+import (
+	"context"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+
+	"github.com/google/uuid"
+)
+
+func (s *service) ReassignDeviceModel(
+	ctx context.Context,
+	modelId string,
+	deviceId string,
+	reassignment *models.DeviceReassignment,
+) (*models.DeviceReassignmentPlan, error) {
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	device, err := s.store.FindDevice(txCtx, map[string]any{
+		models.Device{}.GetIdColumnName():      deviceId,
+		models.Device{}.GetModelIdColumnName(): modelId,
+		models.Device{}.GetStatusColumnName():  []string{"ENABLE", "DISABLE"},
+	}, "Model")
+	if err != nil {
+		logging.Errorf("device not found with id=%s: %v", deviceId, err)
+		return nil, apperrors.NewInvalidRequestError(err, "device not found with id="+deviceId, "id")
+	}
+	if models.IsTerminalDeviceState(device.LifecycleState) {
+		return nil, apperrors.NewInvalidRequestError(nil,
+			"device "+deviceId+" is "+device.LifecycleState+" and cannot be reassigned", "lifecycle_state")
+	}
+	if reassignment.TargetModelId.String() == modelId {
+		return nil, apperrors.NewInvalidRequestError(nil, "device already belongs to model "+modelId, "target_model_id")
+	}
+	target, err := s.store.FindModel(txCtx, map[string]any{
+		models.Model{}.GetIdColumnName():     reassignment.TargetModelId.String(),
+		models.Model{}.GetStatusColumnName(): "ENABLE",
+	})
+	if err != nil {
+		logging.Errorf("target model not found with id=%s: %v", reassignment.TargetModelId, err)
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id="+reassignment.TargetModelId.String(), "target_model_id")
+	}
+
+	var currentGroup, targetGroup *models.Group
+	if device.GroupId != nil {
+		currentGroup, err = s.store.FindGroup(txCtx, map[string]any{
+			models.Group{}.GetIdColumnName(): device.GroupId.String(),
+		}, "Firmware")
+		if err != nil {
+			logging.Errorf("group not found with id=%s: %v", device.GroupId, err)
+			return nil, err
+		}
+	}
+	if reassignment.TargetGroupId != nil {
+		targetGroup, err = s.store.FindGroup(txCtx, map[string]any{
+			models.Group{}.GetIdColumnName():      reassignment.TargetGroupId.String(),
+			models.Group{}.GetModelIdColumnName(): target.Id.String(),
+			models.Group{}.GetStatusColumnName():  []string{"ENABLE", "DISABLE"},
+		}, "Firmware")
+		if err != nil {
+			logging.Errorf("group_id=%s does not belong to model %s: %v", reassignment.TargetGroupId, target.Id, err)
+			return nil, apperrors.NewInvalidRequestError(err,
+				"group id="+reassignment.TargetGroupId.String()+" does not belong to model id="+target.Id.String(), "target_group_id")
+		}
+	}
+
+	plan := &models.DeviceReassignmentPlan{
+		DeviceId: device.Id,
+		Changes: []models.FieldChange{
+			{Field: "model_id", From: device.ModelId, To: target.Id},
+		},
+		Warnings: []string{},
+	}
+	if groupIdOf(currentGroup) != groupIdOf(targetGroup) {
+		plan.Changes = append(plan.Changes, models.FieldChange{Field: "group_id", From: device.GroupId, To: reassignment.TargetGroupId})
+	}
+	if targetGroup == nil {
+		plan.Warnings = append(plan.Warnings, "no target group, the device is left without group")
+	}
+
+	// the endpoint id is kept if it still matches the target template, otherwise it is rendered again
+	reassigned := *device
+	reassigned.ModelId = target.Id
+	if err := resolveDeviceEndpointId(target, &reassigned); err != nil {
+		reassigned.EndpointId = ""
+		if err := resolveDeviceEndpointId(target, &reassigned); err != nil {
+			return nil, err
+		}
+	}
+	if reassigned.EndpointId != device.EndpointId {
+		plan.Changes = append(plan.Changes, models.FieldChange{Field: "endpoint_id", From: device.EndpointId, To: reassigned.EndpointId})
+		plan.Warnings = append(plan.Warnings, "endpoint_id changes, the agent must be provisioned with "+reassigned.EndpointId)
+	}
+
+	// serial number is unique per manufacturer
+	if device.Model != nil && device.Model.Manufacturer != target.Manufacturer {
+		if err := s.checkSerialNumberUnique(txCtx, target.Manufacturer, device.SerialNumber, deviceId); err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, models.FieldChange{Field: "manufacturer", From: device.Model.Manufacturer, To: target.Manufacturer})
+	}
+
+	planFirmwareChange(plan, device, currentGroup, targetGroup)
+	if err := s.planProfileChanges(txCtx, plan, device, currentGroup, targetGroup); err != nil {
+		return nil, err
+	}
+
+	if reassignment.DryRun {
+		return plan, nil
+	}
+
+	now := time.Now()
+	if err := s.store.UpdateDeviceModel(txCtx, deviceId, &models.DeviceModelUpdate{
+		ModelId:    target.Id,
+		GroupId:    reassignment.TargetGroupId,
+		EndpointId: reassigned.EndpointId,
+		UpdatedBy:  reassignment.UpdatedBy,
+		UpdatedAt:  &now,
+	}); err != nil {
+		logging.Errorf("failed to reassign device %s to model %s: %v", deviceId, target.Id, err)
+		return nil, err
+	}
+	plan.Applied = true
+	if err := s.emitDomainEvent(txCtx, models.AggregateDevice, device.Id, models.EventActionModelChanged, reassignment.UpdatedBy, plan); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	success = true
+	logging.Infof("Device %s reassigned from model %s to %s", deviceId, modelId, target.Id)
+	return plan, nil
+}
+
+// planFirmwareChange compares the firmware of the current and target groups with the reported software version.
+func planFirmwareChange(
+	plan *models.DeviceReassignmentPlan,
+	device *models.Device,
+	currentGroup *models.Group,
+	targetGroup *models.Group,
+) {
+	var from, to *models.Firmware
+	if currentGroup != nil {
+		from = currentGroup.Firmware
+	}
+	if targetGroup != nil {
+		to = targetGroup.Firmware
+	}
+	if firmwareNameOf(from) != firmwareNameOf(to) {
+		plan.Changes = append(plan.Changes, models.FieldChange{Field: "firmware", From: firmwareNameOf(from), To: firmwareNameOf(to)})
+	}
+	if to == nil {
+		return
+	}
+	if to.Status != "ENABLE" {
+		plan.Warnings = append(plan.Warnings, "firmware "+to.Name+" of the target group is "+to.Status)
+	}
+	if device.SoftwareVersion != to.Name {
+		plan.FirmwareUpgradeRequired = true
+		plan.Warnings = append(plan.Warnings,
+			"device reports software version "+device.SoftwareVersion+", target group expects firmware "+to.Name)
+	}
+}
+
+// planProfileChanges lists the profiles that stop or start applying to the device and
+// the parameters whose expected value changes.
+func (s *service) planProfileChanges(
+	ctx context.Context,
+	plan *models.DeviceReassignmentPlan,
+	device *models.Device,
+	currentGroup *models.Group,
+	targetGroup *models.Group,
+) error {
+	currentAssignments, err := s.groupProfileAssignments(ctx, currentGroup)
+	if err != nil {
+		return err
+	}
+	targetAssignments, err := s.groupProfileAssignments(ctx, targetGroup)
+	if err != nil {
+		return err
+	}
+	deviceAssignments, err := s.store.ListProfileAssignments(ctx, map[string]any{
+		models.ProfileAssignment{}.GetDeviceIdColumnName(): device.Id.String(),
+	})
+	if err != nil {
+		logging.Errorf("failed to list profile assignments of device %s: %v", device.Id, err)
+		return err
+	}
+
+	inTarget := make(map[string]bool, len(targetAssignments))
+	for _, assignment := range targetAssignments {
+		inTarget[assignment.ProfileId.String()] = true
+	}
+	inCurrent := make(map[string]bool, len(currentAssignments))
+	plan.Profiles = make([]models.ProfileAssignmentChange, 0)
+	for _, assignment := range currentAssignments {
+		inCurrent[assignment.ProfileId.String()] = true
+		change := models.ProfileChangeRemoved
+		if inTarget[assignment.ProfileId.String()] {
+			change = models.ProfileChangeKept
+		}
+		plan.Profiles = append(plan.Profiles, profileAssignmentChange(assignment, change))
+	}
+	for _, assignment := range targetAssignments {
+		if !inCurrent[assignment.ProfileId.String()] {
+			plan.Profiles = append(plan.Profiles, profileAssignmentChange(assignment, models.ProfileChangeAdded))
+		}
+	}
+	// device assignments follow the device, profiles are not bound to a model
+	for _, assignment := range deviceAssignments {
+		plan.Profiles = append(plan.Profiles, profileAssignmentChange(assignment, models.ProfileChangeKept))
+		if assignment.Profile != nil && assignment.Profile.Status != "ENABLE" {
+			plan.Warnings = append(plan.Warnings,
+				"device profile "+assignment.Profile.Name+" is "+assignment.Profile.Status+" and does not apply")
+		}
+	}
+
+	plan.Parameters = models.DiffEffectiveConfiguration(
+		models.EffectiveConfiguration(currentAssignments, deviceAssignments),
+		models.EffectiveConfiguration(targetAssignments, deviceAssignments),
+	)
+	return nil
+}
+
+func (s *service) groupProfileAssignments(
+	ctx context.Context,
+	group *models.Group,
+) ([]models.ProfileAssignment, error) {
+	if group == nil {
+		return nil, nil
+	}
+	assignments, err := s.store.ListProfileAssignments(ctx, map[string]any{
+		models.ProfileAssignment{}.GetGroupIdColumnName(): group.Id.String(),
+	})
+	if err != nil {
+		logging.Errorf("failed to list profile assignments of group %s: %v", group.Id, err)
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func profileAssignmentChange(assignment models.ProfileAssignment, change string) models.ProfileAssignmentChange {
+	result := models.ProfileAssignmentChange{
+		ProfileId: assignment.ProfileId,
+		Scope:     assignment.Scope(),
+		Change:    change,
+	}
+	if assignment.Profile != nil {
+		result.ProfileName = assignment.Profile.Name
+	}
+	return result
+}
+
+func groupIdOf(group *models.Group) uuid.UUID {
+	if group == nil || group.Id == nil {
+		return uuid.Nil
+	}
+	return *group.Id
+}
+
+func firmwareNameOf(firmware *models.Firmware) string {
+	if firmware == nil {
+		return ""
+	}
+	return firmware.Name
+}
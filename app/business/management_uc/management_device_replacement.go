@@ -0,0 +1,314 @@
+package managementuc
+
+import (
+	"context"
+	"strings"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+)
+
+// maxDeviceLineageLength bounds the walk over replaced_by_id links.
+const maxDeviceLineageLength = 100
+
+func (s *service) ReplaceDevice(
+	ctx context.Context,
+	modelId string,
+	deviceId string,
+	replacement *models.DeviceReplacement,
+) (*models.DeviceReplacementResult, error) {
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	model, err := s.store.FindModel(txCtx, map[string]any{
+		models.Model{}.GetIdColumnName(): modelId,
+	})
+	if err != nil {
+		logging.Errorf("model not found with id=%s: %v", modelId, err)
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id="+modelId, "model_id")
+	}
+	predecessor, err := s.store.FindDevice(txCtx, map[string]any{
+		models.Device{}.GetIdColumnName():      deviceId,
+		models.Device{}.GetModelIdColumnName(): modelId,
+	})
+	if err != nil || predecessor.Status == "DELETE" {
+		logging.Errorf("device not found with id=%s: %v", deviceId, err)
+		return nil, apperrors.NewInvalidRequestError(err, "device not found with id="+deviceId, "id")
+	}
+	if models.IsTerminalDeviceState(predecessor.LifecycleState) {
+		return nil, apperrors.NewInvalidRequestError(nil,
+			"device "+deviceId+" is "+predecessor.LifecycleState+" and cannot be replaced", "lifecycle_state")
+	}
+
+	successor, err := s.resolveReplacementDevice(txCtx, model, predecessor, replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	// the successor takes over the site: group, description and labels
+	successorUpdate := models.NewDeviceUpdate(predecessor.GroupId, nil, &replacement.UpdatedBy, nil)
+	if predecessor.Description != "" {
+		successorUpdate.Description = &predecessor.Description
+	}
+	if len(predecessor.Labels) > 0 {
+		successorUpdate.Labels = predecessor.Labels
+	}
+	if err := s.store.UpdateDevice(txCtx, successor.Id.String(), successorUpdate); err != nil {
+		logging.Errorf("failed to transfer site of device %s to %s: %v", predecessor.Id, successor.Id, err)
+		return nil, err
+	}
+	successor.GroupId = predecessor.GroupId
+	successor.Description = predecessor.Description
+	if len(predecessor.Labels) > 0 {
+		successor.Labels = predecessor.Labels
+	}
+
+	transferred, err := s.transferDeviceProfileAssignments(txCtx, predecessor, successor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transitionDeviceLifecycle(txCtx, predecessor, models.DeviceStateReplaced, replacement.Reason, replacement.UpdatedBy); err != nil {
+		return nil, err
+	}
+	replaced := models.DeviceStateReplaced
+	replacedAt := time.Now()
+	predecessorUpdate := models.NewDeviceUpdate(nil, nil, &replacement.UpdatedBy, nil)
+	predecessorUpdate.LifecycleState = &replaced
+	predecessorUpdate.ReplacedById = successor.Id
+	predecessorUpdate.ReplacedAt = &replacedAt
+	if err := s.store.UpdateDevice(txCtx, predecessor.Id.String(), predecessorUpdate); err != nil {
+		logging.Errorf("failed to mark device %s replaced: %v", predecessor.Id, err)
+		return nil, err
+	}
+	predecessor.LifecycleState = replaced
+	predecessor.ReplacedById = successor.Id
+	predecessor.ReplacedAt = &replacedAt
+
+	if err := s.emitDomainEvent(txCtx, models.AggregateDevice, predecessor.Id, models.EventActionReplaced, replacement.UpdatedBy, map[string]any{
+		"id":                      predecessor.Id,
+		"model_id":                predecessor.ModelId,
+		"group_id":                predecessor.GroupId,
+		"mac_address":             predecessor.MacAddress,
+		"labels":                  predecessor.Labels,
+		"replaced_by_id":          successor.Id,
+		"replaced_by_mac_address": successor.MacAddress,
+		"transferred_profile_ids": transferred,
+		"reason":                  strings.TrimSpace(replacement.Reason),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	success = true
+	logging.Infof("Device %s replaced by %s", predecessor.Id, successor.Id)
+	return &models.DeviceReplacementResult{
+		Predecessor:           predecessor,
+		Successor:             successor,
+		TransferredProfileIds: transferred,
+	}, nil
+}
+
+// resolveReplacementDevice returns the device registered with the replacement MAC address
+// if it can still take over a site, or registers a new one.
+func (s *service) resolveReplacementDevice(
+	txCtx context.Context,
+	model *models.Model,
+	predecessor *models.Device,
+	replacement *models.DeviceReplacement,
+) (*models.Device, error) {
+	existing, err := s.store.FindDevice(txCtx, map[string]any{
+		models.Device{}.GetMacAddressColumnName(): replacement.MacAddress,
+	})
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); !ok || !appErr.IsErrorKey(apperrors.ErrEntityNotExist) {
+			return nil, err
+		}
+		return s.registerReplacementDevice(txCtx, model, replacement)
+	}
+
+	switch {
+	case existing.Id.String() == predecessor.Id.String():
+		return nil, apperrors.NewInvalidRequestError(nil, "a device cannot replace itself", "mac_address")
+	case existing.Status == "DELETE" || existing.ModelId == nil || existing.ModelId.String() != model.Id.String():
+		return nil, apperrors.NewInvalidRequestError(nil,
+			"device with mac address "+replacement.MacAddress+" exists outside model "+model.Name, "mac_address")
+	case existing.LifecycleState != models.DeviceStatePreProvisioned && existing.LifecycleState != models.DeviceStateActivated:
+		return nil, apperrors.NewInvalidRequestError(nil,
+			"device with mac address "+replacement.MacAddress+" is "+existing.LifecycleState+" and cannot take over a site", "mac_address")
+	}
+	if _, err := s.store.FindDevice(txCtx, map[string]any{
+		models.Device{}.GetReplacedByIdColumnName(): existing.Id,
+	}); err == nil {
+		return nil, apperrors.NewInvalidRequestError(nil,
+			"device with mac address "+replacement.MacAddress+" already replaced another device", "mac_address")
+	}
+
+	// identifiers sent with the request complete the registered device
+	update := models.NewDeviceUpdate(nil, nil, &replacement.UpdatedBy, nil)
+	changed := false
+	if replacement.SerialNumber != "" && replacement.SerialNumber != existing.SerialNumber {
+		if err := s.checkSerialNumberUnique(txCtx, model.Manufacturer, replacement.SerialNumber, existing.Id.String()); err != nil {
+			return nil, err
+		}
+		update.SerialNumber = &replacement.SerialNumber
+		existing.SerialNumber = replacement.SerialNumber
+		changed = true
+	}
+	if replacement.ProductClass != "" && replacement.ProductClass != existing.ProductClass {
+		update.ProductClass = &replacement.ProductClass
+		existing.ProductClass = replacement.ProductClass
+		changed = true
+	}
+	if replacement.HardwareRevision != "" && replacement.HardwareRevision != existing.HardwareRevision {
+		update.HardwareRevision = &replacement.HardwareRevision
+		existing.HardwareRevision = replacement.HardwareRevision
+		changed = true
+	}
+	if changed {
+		if err := s.store.UpdateDevice(txCtx, existing.Id.String(), update); err != nil {
+			logging.Errorf("failed to update replacement device %s: %v", existing.Id, err)
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+func (s *service) registerReplacementDevice(
+	txCtx context.Context,
+	model *models.Model,
+	replacement *models.DeviceReplacement,
+) (*models.Device, error) {
+	if err := s.checkSerialNumberUnique(txCtx, model.Manufacturer, replacement.SerialNumber, ""); err != nil {
+		return nil, err
+	}
+	device := &models.Device{
+		MacAddress: replacement.MacAddress,
+		ModelId:    model.Id,
+		Status:     "ENABLE",
+		UpdatedBy:  replacement.UpdatedBy,
+
+		LifecycleState:   models.DeviceStatePreProvisioned,
+		SerialNumber:     replacement.SerialNumber,
+		ProductClass:     replacement.ProductClass,
+		HardwareRevision: replacement.HardwareRevision,
+	}
+	if err := resolveDeviceEndpointId(model, device); err != nil {
+		return nil, err
+	}
+	if err := s.store.InsertDevice(txCtx, device); err != nil {
+		logging.Errorf("failed to insert replacement device: %v", err)
+		return nil, err
+	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateDevice, device.Id, models.EventActionCreated, replacement.UpdatedBy, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// transferDeviceProfileAssignments moves the device profile assignments of predecessor to successor,
+// an assignment of a profile the successor already has is dropped. It returns the profile ids now on successor.
+func (s *service) transferDeviceProfileAssignments(
+	txCtx context.Context,
+	predecessor *models.Device,
+	successor *models.Device,
+) ([]string, error) {
+	assignments, err := s.store.ListProfileAssignments(txCtx, map[string]any{
+		models.ProfileAssignment{}.GetDeviceIdColumnName(): predecessor.Id,
+	})
+	if err != nil {
+		logging.Errorf("failed to list profile assignments of device %s: %v", predecessor.Id, err)
+		return nil, err
+	}
+	existing, err := s.store.ListProfileAssignments(txCtx, map[string]any{
+		models.ProfileAssignment{}.GetDeviceIdColumnName(): successor.Id,
+	})
+	if err != nil {
+		logging.Errorf("failed to list profile assignments of device %s: %v", successor.Id, err)
+		return nil, err
+	}
+	assigned := make(map[string]bool, len(existing))
+	for _, assignment := range existing {
+		assigned[assignment.ProfileId.String()] = true
+	}
+
+	moveIds := []string{}
+	transferred := []string{}
+	for _, assignment := range assignments {
+		if assigned[assignment.ProfileId.String()] {
+			if err := s.store.DeleteProfileAssignment(txCtx, assignment.Id.String()); err != nil {
+				logging.Errorf("failed to drop duplicate profile assignment %s: %v", assignment.Id, err)
+				return nil, err
+			}
+			continue
+		}
+		moveIds = append(moveIds, assignment.Id.String())
+		transferred = append(transferred, assignment.ProfileId.String())
+	}
+	if err := s.store.MoveProfileAssignmentsToDevice(txCtx, moveIds, successor.Id.String()); err != nil {
+		logging.Errorf("failed to move profile assignments to device %s: %v", successor.Id, err)
+		return nil, err
+	}
+	return transferred, nil
+}
+
+func (s *service) GetDeviceLineage(
+	ctx context.Context,
+	modelId string,
+	deviceId string,
+) ([]models.Device, error) {
+	device, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetIdColumnName():      deviceId,
+		models.Device{}.GetModelIdColumnName(): modelId,
+	})
+	if err != nil {
+		logging.Errorf("device not found with id=%s: %v", deviceId, err)
+		return nil, err
+	}
+
+	// walk back to the first device of the site, then forward to the current one
+	lineage := []models.Device{*device}
+	for current := device; len(lineage) < maxDeviceLineageLength; {
+		predecessor, err := s.store.FindDevice(ctx, map[string]any{
+			models.Device{}.GetReplacedByIdColumnName(): current.Id,
+		})
+		if err != nil {
+			if appErr, ok := err.(*apperrors.AppError); ok && appErr.IsErrorKey(apperrors.ErrEntityNotExist) {
+				break
+			}
+			return nil, err
+		}
+		lineage = append([]models.Device{*predecessor}, lineage...)
+		current = predecessor
+	}
+	for current := device; current.ReplacedById != nil && len(lineage) < maxDeviceLineageLength; {
+		successor, err := s.store.FindDevice(ctx, map[string]any{
+			models.Device{}.GetIdColumnName(): current.ReplacedById,
+		})
+		if err != nil {
+			logging.Errorf("successor %s of device %s not found: %v", current.ReplacedById, current.Id, err)
+			return nil, err
+		}
+		lineage = append(lineage, *successor)
+		current = successor
+	}
+	return lineage, nil
+}
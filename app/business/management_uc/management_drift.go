@@ -0,0 +1,393 @@
+package managementuc
+
+import (
+	"context"
+	"sort"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+)
+
+// driftScanBatchSize is the number of devices loaded at once when a whole model is scanned.
+const driftScanBatchSize = 500
+
+func (s *service) AssignProfile(
+	ctx context.Context,
+	modelId string,
+	assignment *models.ProfileAssignment,
+) (string, error) {
+	if (assignment.GroupId == nil) == (assignment.DeviceId == nil) {
+		return "", apperrors.NewInvalidRequestError(nil, "exactly one of group_id or device_id must be set", "target")
+	}
+
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return "", err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+	// Find profile with profileId
+	profile, err := s.store.FindProfile(txCtx, map[string]any{
+		models.Profile{}.GetIdColumnName():     assignment.ProfileId.String(),
+		models.Profile{}.GetStatusColumnName(): []string{"ENABLE", "DISABLE"},
+	})
+	if err != nil || profile == nil {
+		logging.Errorf("profile not found with id=%s: %v", assignment.ProfileId, err)
+		return "", apperrors.NewInvalidRequestError(err, "profile not found with id="+assignment.ProfileId.String(), "profile_id")
+	}
+	// Find target belongs to model
+	condition := map[string]any{
+		models.ProfileAssignment{}.GetProfileIdColumnName(): assignment.ProfileId.String(),
+	}
+	if assignment.GroupId != nil {
+		if _, err := s.store.FindGroup(txCtx, map[string]any{
+			models.Group{}.GetIdColumnName():      assignment.GroupId.String(),
+			models.Group{}.GetModelIdColumnName(): modelId,
+			models.Group{}.GetStatusColumnName():  []string{"ENABLE", "DISABLE"},
+		}); err != nil {
+			logging.Errorf("group_id=%s does not belong to model: %v", assignment.GroupId, err)
+			return "", apperrors.NewInvalidRequestError(err, "group id="+assignment.GroupId.String()+" does not belong to model", "group_id")
+		}
+		condition[models.ProfileAssignment{}.GetGroupIdColumnName()] = assignment.GroupId.String()
+	} else {
+		if _, err := s.store.FindDevice(txCtx, map[string]any{
+			models.Device{}.GetIdColumnName():      assignment.DeviceId.String(),
+			models.Device{}.GetModelIdColumnName(): modelId,
+			"status":                               []string{"ENABLE", "DISABLE"},
+		}); err != nil {
+			logging.Errorf("device_id=%s does not belong to model: %v", assignment.DeviceId, err)
+			return "", apperrors.NewInvalidRequestError(err, "device id="+assignment.DeviceId.String()+" does not belong to model", "device_id")
+		}
+		condition[models.ProfileAssignment{}.GetDeviceIdColumnName()] = assignment.DeviceId.String()
+	}
+	// Find assignment exists
+	existingAssignment, err := s.store.FindProfileAssignment(txCtx, condition)
+	if err == nil && existingAssignment != nil {
+		logging.Errorf("profile %s is already assigned", assignment.ProfileId)
+		return "", apperrors.NewInvalidRequestError(nil, "profile is already assigned: "+profile.Name, "profile_id")
+	}
+
+	if err := s.store.InsertProfileAssignment(txCtx, assignment); err != nil {
+		logging.Errorf("failed to insert profile assignment: %v", err)
+		return "", err
+	}
+
+	// Commit transaction if success
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return "", err
+	}
+
+	success = true
+	logging.Infof("Profile %s assigned with ID: %s", assignment.ProfileId, assignment.Id)
+	return assignment.Id.String(), nil
+}
+
+func (s *service) UnassignProfile(
+	ctx context.Context,
+	modelId string,
+	condition map[string]any,
+) error {
+	assignments, err := s.ListProfileAssignments(ctx, modelId, condition)
+	if err != nil {
+		return err
+	}
+	if len(assignments) == 0 {
+		return apperrors.NewErrEntityNotExist(models.ProfileAssignment{}.GetEntityName())
+	}
+
+	for _, assignment := range assignments {
+		if err := s.store.DeleteProfileAssignment(ctx, assignment.Id.String()); err != nil {
+			logging.Errorf("failed to delete profile assignment id=%s: %v", assignment.Id, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) ListProfileAssignments(
+	ctx context.Context,
+	modelId string,
+	condition map[string]any,
+) ([]models.ProfileAssignment, error) {
+	// Validate target belongs to model
+	if groupId, ok := condition[models.ProfileAssignment{}.GetGroupIdColumnName()]; ok {
+		if _, err := s.store.FindGroup(ctx, map[string]any{
+			models.Group{}.GetIdColumnName():      groupId,
+			models.Group{}.GetModelIdColumnName(): modelId,
+		}); err != nil {
+			return nil, apperrors.NewInvalidRequestError(err, "group not found in model", "group_id")
+		}
+	}
+	if deviceId, ok := condition[models.ProfileAssignment{}.GetDeviceIdColumnName()]; ok {
+		if _, err := s.store.FindDevice(ctx, map[string]any{
+			models.Device{}.GetIdColumnName():      deviceId,
+			models.Device{}.GetModelIdColumnName(): modelId,
+		}); err != nil {
+			return nil, apperrors.NewInvalidRequestError(err, "device not found in model", "device_id")
+		}
+	}
+	return s.store.ListProfileAssignments(ctx, condition)
+}
+
+func (s *service) GetDeviceDriftReport(
+	ctx context.Context,
+	modelId string,
+	deviceId string,
+) (*models.DeviceDriftReport, error) {
+	device, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetIdColumnName():      deviceId,
+		models.Device{}.GetModelIdColumnName(): modelId,
+		"status":                               []string{"ENABLE", "DISABLE"},
+	})
+	if err != nil {
+		logging.Errorf("device not found with id=%s: %v", deviceId, err)
+		return nil, err
+	}
+
+	reports, err := s.buildDriftReports(ctx, []models.Device{*device})
+	if err != nil {
+		return nil, err
+	}
+	return &reports[0], nil
+}
+
+func (s *service) GetGroupDriftReport(
+	ctx context.Context,
+	modelId string,
+	groupId string,
+	driftedOnly bool,
+	opts models.QueryOptions,
+) ([]models.DeviceDriftReport, error) {
+	if _, err := s.store.FindGroup(ctx, map[string]any{
+		models.Group{}.GetIdColumnName():      groupId,
+		models.Group{}.GetModelIdColumnName(): modelId,
+		models.Group{}.GetStatusColumnName():  []string{"ENABLE", "DISABLE"},
+	}); err != nil {
+		logging.Errorf("group not found with id=%s: %v", groupId, err)
+		return nil, apperrors.NewInvalidRequestError(err, "group not found with id="+groupId, "group_id")
+	}
+
+	condition := map[string]any{
+		models.Device{}.GetModelIdColumnName(): modelId,
+		models.Device{}.GetGroupIdColumnName(): groupId,
+		"status":                               []string{"ENABLE", "DISABLE"},
+	}
+	if !driftedOnly {
+		devices, err := s.store.ListDevices(ctx, condition, models.QueryOptions{
+			Limit:     opts.Limit,
+			Offset:    opts.Offset,
+			OrderExpr: []models.OrderExpr{{Field: "id", Direction: "ASC"}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.buildDriftReports(ctx, devices)
+	}
+
+	// drift is computed from the assignments and reported values, not in SQL: the devices are
+	// scanned until the page of drifted ones is full, limit and offset count drifted devices
+	skip := opts.Offset
+	drifted := make([]models.DeviceDriftReport, 0, opts.Limit)
+	err := s.scanDriftDevices(ctx, condition, func(reports []models.DeviceDriftReport) bool {
+		for _, report := range reports {
+			if report.InSync() {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			drifted = append(drifted, report)
+			if opts.Limit > 0 && len(drifted) == opts.Limit {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifted, nil
+}
+
+func (s *service) GetModelDriftSummary(
+	ctx context.Context,
+	modelId string,
+) ([]models.ParameterDriftSummary, error) {
+	if _, err := s.store.FindModel(ctx, map[string]any{
+		models.Model{}.GetIdColumnName(): modelId,
+	}); err != nil {
+		logging.Errorf("model not found with id=%s: %v", modelId, err)
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id="+modelId, "model_id")
+	}
+
+	summaries := make(map[string]*models.ParameterDriftSummary)
+	err := s.scanDriftDevices(ctx, map[string]any{
+		models.Device{}.GetModelIdColumnName(): modelId,
+		"status":                               []string{"ENABLE", "DISABLE"},
+	}, func(reports []models.DeviceDriftReport) bool {
+		for _, report := range reports {
+			for _, parameter := range report.Parameters {
+				summary, ok := summaries[parameter.Path]
+				if !ok {
+					summary = &models.ParameterDriftSummary{Path: parameter.Path}
+					summaries[parameter.Path] = summary
+				}
+				summary.Devices++
+				switch parameter.Status {
+				case models.DriftStatusDrifted:
+					summary.Drifted++
+				case models.DriftStatusMissing:
+					summary.Missing++
+				}
+				if parameter.ObservedAt != nil && (summary.LastObservedAt == nil || parameter.ObservedAt.After(*summary.LastObservedAt)) {
+					summary.LastObservedAt = parameter.ObservedAt
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.ParameterDriftSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Drifted != result[j].Drifted {
+			return result[i].Drifted > result[j].Drifted
+		}
+		if result[i].Missing != result[j].Missing {
+			return result[i].Missing > result[j].Missing
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+// scanDriftDevices builds the drift reports of the devices matching condition by batches of
+// driftScanBatchSize, paged by id after the last device read so a batch costs the same at any
+// depth. It stops when fn returns false.
+func (s *service) scanDriftDevices(
+	ctx context.Context,
+	condition map[string]any,
+	fn func(reports []models.DeviceDriftReport) bool,
+) error {
+	keyset := &models.Keyset{
+		Columns: []models.KeysetColumn{{Column: models.USPDeviceTableName + ".id"}},
+	}
+	for {
+		devices, err := s.store.ListDevices(ctx, condition, models.QueryOptions{
+			Limit:  driftScanBatchSize,
+			Keyset: keyset,
+		})
+		if err != nil {
+			return err
+		}
+		reports, err := s.buildDriftReports(ctx, devices)
+		if err != nil {
+			return err
+		}
+		if !fn(reports) || len(devices) < driftScanBatchSize {
+			return nil
+		}
+		keyset.Values = []any{devices[len(devices)-1].Id.String()}
+	}
+}
+
+// buildDriftReports loads the profile assignments and reported values of devices
+// and builds one drift report per device, in the order of devices.
+func (s *service) buildDriftReports(
+	ctx context.Context,
+	devices []models.Device,
+) ([]models.DeviceDriftReport, error) {
+	if len(devices) == 0 {
+		return []models.DeviceDriftReport{}, nil
+	}
+
+	deviceIds := make([]string, 0, len(devices))
+	groupIds := make([]string, 0)
+	seenGroups := make(map[string]bool)
+	for _, device := range devices {
+		deviceIds = append(deviceIds, device.Id.String())
+		if device.GroupId != nil && !seenGroups[device.GroupId.String()] {
+			seenGroups[device.GroupId.String()] = true
+			groupIds = append(groupIds, device.GroupId.String())
+		}
+	}
+
+	groupAssignments := make(map[string][]models.ProfileAssignment)
+	if len(groupIds) > 0 {
+		assignments, err := s.store.ListProfileAssignments(ctx, map[string]any{
+			models.ProfileAssignment{}.GetGroupIdColumnName(): groupIds,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, assignment := range assignments {
+			groupAssignments[assignment.GroupId.String()] = append(groupAssignments[assignment.GroupId.String()], assignment)
+		}
+	}
+
+	deviceAssignments := make(map[string][]models.ProfileAssignment)
+	assignments, err := s.store.ListProfileAssignments(ctx, map[string]any{
+		models.ProfileAssignment{}.GetDeviceIdColumnName(): deviceIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, assignment := range assignments {
+		deviceAssignments[assignment.DeviceId.String()] = append(deviceAssignments[assignment.DeviceId.String()], assignment)
+	}
+
+	expected := make(map[string]map[string]models.ExpectedParameter, len(devices))
+	paths := make([]string, 0)
+	seenPaths := make(map[string]bool)
+	for _, device := range devices {
+		var assignedToGroup []models.ProfileAssignment
+		if device.GroupId != nil {
+			assignedToGroup = groupAssignments[device.GroupId.String()]
+		}
+		config := models.EffectiveConfiguration(assignedToGroup, deviceAssignments[device.Id.String()])
+		expected[device.Id.String()] = config
+		for path := range config {
+			if !seenPaths[path] {
+				seenPaths[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	reported := make(map[string][]models.DeviceParameterValue)
+	if len(paths) > 0 {
+		values, err := s.store.ListDeviceParameterValues(ctx, map[string]any{
+			models.DeviceParameterValue{}.GetDeviceIdColumnName(): deviceIds,
+			models.DeviceParameterValue{}.GetPathColumnName():     paths,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			reported[value.DeviceId.String()] = append(reported[value.DeviceId.String()], value)
+		}
+	}
+
+	reports := make([]models.DeviceDriftReport, 0, len(devices))
+	for _, device := range devices {
+		id := device.Id.String()
+		reports = append(reports, models.NewDeviceDriftReport(device, expected[id], reported[id]))
+	}
+	return reports, nil
+}
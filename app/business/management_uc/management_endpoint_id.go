@@ -0,0 +1,49 @@
+package managementuc
+
+import (
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	validate "usp-management-device-api/common/validator"
+)
+
+// resolveDeviceEndpointId renders the endpoint id of a device from the template of its model,
+// an endpoint id given by the caller must match the template and the device MAC address.
+func resolveDeviceEndpointId(
+	model *models.Model,
+	device *models.Device,
+) error {
+	template := model.GetEndpointIdTemplate()
+	values := device.EndpointIdValues()
+
+	if device.EndpointId == "" {
+		endpointId, err := models.RenderEndpointId(template, values)
+		if err != nil {
+			return apperrors.NewInvalidRequestError(err, err.Error(), "endpoint_id")
+		}
+		device.EndpointId = endpointId
+	} else {
+		parsed, oui, ok := models.ParseEndpointId(template, device.EndpointId)
+		if !ok {
+			return apperrors.NewInvalidRequestError(nil,
+				"endpoint_id "+device.EndpointId+" does not match the model template "+template, "endpoint_id")
+		}
+		if parsed.MacAddress != "" && !strings.EqualFold(parsed.MacAddress, device.MacAddress) {
+			return apperrors.NewInvalidRequestError(nil,
+				"endpoint_id "+device.EndpointId+" does not match mac address "+device.MacAddress, "endpoint_id")
+		}
+		if parsed.SerialNumber != "" && values.SerialNumber != "" && parsed.SerialNumber != values.SerialNumber {
+			return apperrors.NewInvalidRequestError(nil,
+				"endpoint_id "+device.EndpointId+" does not match serial number "+values.SerialNumber, "endpoint_id")
+		}
+		if oui != "" && oui != values.OUI() {
+			return apperrors.NewInvalidRequestError(nil,
+				"endpoint_id "+device.EndpointId+" does not match the OUI of mac address "+device.MacAddress, "endpoint_id")
+		}
+	}
+
+	if err := validate.ValidateEndpointID(device.EndpointId); err != nil {
+		return apperrors.NewInvalidRequestError(err, err.Error(), "endpoint_id")
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package managementuc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+	validate "usp-management-device-api/common/validator"
+
+	"github.com/google/uuid"
+)
+
+// eventIngestUser is written to updated_by for rows changed by the event ingest worker
+const eventIngestUser = "usp-event-ingest"
+
+func (s *service) HandleUSPNotify(
+	ctx context.Context,
+	event *models.USPNotifyEvent,
+) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	device, err := s.findOrRegisterDevice(txCtx, event)
+	if err != nil {
+		return err
+	}
+
+	observedAt := event.ObservedAt()
+	deviceUpdate := models.NewDeviceUpdate(nil, nil, nil, nil)
+	deviceUpdate.LastSeenAt = &observedAt
+	// reported values feed configuration drift detection
+	var reported map[string]string
+
+	switch event.Type() {
+	case models.USPEventTypeBoot:
+		deviceUpdate.LastBootAt = &observedAt
+		parameterMap, _ := event.BootParameterMap()
+		reported = parameterMap
+		if version, ok := parameterMap[models.USPParamSoftwareVersion]; ok && version != "" {
+			deviceUpdate.SoftwareVersion = &version
+		}
+		if revision, ok := parameterMap[models.USPParamHardwareVersion]; ok && revision != "" && revision != device.HardwareRevision {
+			deviceUpdate.HardwareRevision = &revision
+		}
+		if event.FirmwareUpdated() {
+			logging.Infof("endpoint %s booted with updated firmware, cause=%s",
+				event.EndpointId, event.Event.Params[models.USPBootCause])
+		}
+	case models.USPEventTypeValueChange:
+		reported = map[string]string{event.ValueChange.ParamPath: event.ValueChange.ParamValue}
+		if event.ValueChange.ParamPath == models.USPParamSoftwareVersion {
+			version := event.ValueChange.ParamValue
+			deviceUpdate.SoftwareVersion = &version
+		}
+	case models.USPEventTypeObjectCreation:
+		logging.Infof("endpoint %s created object %s", event.EndpointId, event.ObjCreation.ObjPath)
+	case models.USPEventTypeOperationComplete:
+		if isFirmwareTransferCommand(event.OperComplete) {
+			if err := s.recordFirmwareTransfer(txCtx, device, event.OperComplete, observedAt); err != nil {
+				return err
+			}
+		}
+	default:
+		logging.Debugf("endpoint %s sent event %s, only last_seen_at is updated", event.EndpointId, event.Type())
+	}
+
+	// the first message from a pre-provisioned device activates it
+	if device.LifecycleState == models.DeviceStatePreProvisioned {
+		activated := models.DeviceStateActivated
+		if err := s.transitionDeviceLifecycle(txCtx, device, activated, "first USP Notify received: "+event.Type(), eventIngestUser); err != nil {
+			return err
+		}
+		deviceUpdate.LifecycleState = &activated
+	}
+
+	if err := s.store.UpdateDevice(txCtx, device.Id.String(), deviceUpdate); err != nil {
+		logging.Errorf("failed to update device state for endpoint %s: %v", event.EndpointId, err)
+		return err
+	}
+	if err := s.storeReportedValues(txCtx, reportedParameterValues(device, reported, observedAt)); err != nil {
+		logging.Errorf("failed to store reported values for endpoint %s: %v", event.EndpointId, err)
+		return err
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	success = true
+	return nil
+}
+
+// findOrRegisterDevice returns the device for the event endpoint,
+// an unknown endpoint is registered against the model it reports on Boot!
+// or against the configured default model.
+func (s *service) findOrRegisterDevice(
+	ctx context.Context,
+	event *models.USPNotifyEvent,
+) (*models.Device, error) {
+	device, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetEndpointIdColumnName(): event.EndpointId,
+	})
+	if err == nil {
+		return device, nil
+	}
+	if appErr, ok := err.(*apperrors.AppError); !ok || !appErr.IsErrorKey(apperrors.ErrEntityNotExist) {
+		return nil, err
+	}
+
+	if err := validate.ValidateEndpointID(event.EndpointId); err != nil {
+		return nil, apperrors.NewErrInvalidEventPayload(err.Error())
+	}
+
+	model, err := s.resolveEventModel(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	// the MAC address is read back from the endpoint id through the model template
+	template := model.GetEndpointIdTemplate()
+	values, _, ok := models.ParseEndpointId(template, event.EndpointId)
+	if !ok {
+		return nil, apperrors.NewErrInvalidEventPayload(
+			"endpoint_id " + event.EndpointId + " does not match the template " + template + " of model " + model.Name)
+	}
+	if values.MacAddress == "" {
+		return nil, apperrors.NewErrInvalidEventPayload(
+			"template " + template + " of model " + model.Name + " has no {mac}, endpoint " + event.EndpointId + " cannot be auto-registered")
+	}
+	hardwareRevision := ""
+	if event.Type() == models.USPEventTypeBoot {
+		parameterMap, _ := event.BootParameterMap()
+		hardwareRevision = parameterMap[models.USPParamHardwareVersion]
+		for placeholder, reported := range map[string][2]string{
+			models.EndpointIdPlaceholderSerial:       {values.SerialNumber, parameterMap[models.USPParamSerialNumber]},
+			models.EndpointIdPlaceholderProductClass: {values.ProductClass, parameterMap[models.USPParamProductClass]},
+		} {
+			if reported[0] != "" && reported[1] != "" && reported[0] != reported[1] {
+				return nil, apperrors.NewErrInvalidEventPayload(
+					"endpoint_id " + event.EndpointId + " " + placeholder + " does not match the reported value " + reported[1])
+			}
+		}
+		if values.SerialNumber == "" {
+			values.SerialNumber = parameterMap[models.USPParamSerialNumber]
+		}
+		if values.ProductClass == "" {
+			values.ProductClass = parameterMap[models.USPParamProductClass]
+		}
+	}
+	macAddress := values.MacAddress
+
+	existingDevice, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetMacAddressColumnName(): macAddress,
+	})
+	// the MAC belongs to a deleted device or to one whose endpoint id was rendered again, the
+	// event goes to the dead-letter topic to be replayed once the device is fixed
+	if err == nil && existingDevice != nil {
+		logging.Errorf("endpoint %s maps to mac address %s of device %s with endpoint %s",
+			event.EndpointId, macAddress, existingDevice.Id, existingDevice.EndpointId)
+		return nil, apperrors.NewErrInvalidEventPayload(
+			"mac address " + macAddress + " of endpoint_id " + event.EndpointId + " belongs to device " +
+				existingDevice.Id.String() + " with endpoint_id " + existingDevice.EndpointId + " (status " + existingDevice.Status + ")")
+	}
+	if err := s.checkSerialNumberUnique(ctx, model.Manufacturer, values.SerialNumber, ""); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.HTTPCode() < http.StatusInternalServerError {
+			return nil, apperrors.NewErrInvalidEventPayload("endpoint_id " + event.EndpointId + ": " + appErr.ErrorMessage())
+		}
+		return nil, err
+	}
+
+	device = &models.Device{
+		MacAddress:  macAddress,
+		EndpointId:  event.EndpointId,
+		ModelId:     model.Id,
+		Status:      "ENABLE",
+		UpdatedBy:   eventIngestUser,
+		Description: "auto-registered from " + event.Type(),
+
+		LifecycleState:   models.DeviceStatePreProvisioned,
+		SerialNumber:     values.SerialNumber,
+		ProductClass:     values.ProductClass,
+		HardwareRevision: hardwareRevision,
+	}
+	if err := s.store.InsertDevice(ctx, device); err != nil {
+		logging.Errorf("failed to auto-register endpoint %s: %v", event.EndpointId, err)
+		return nil, err
+	}
+	if err := s.emitDomainEvent(ctx, models.AggregateDevice, device.Id, models.EventActionCreated, eventIngestUser, device); err != nil {
+		return nil, err
+	}
+
+	logging.Infof("Device auto-registered with ID: %s for endpoint %s", device.Id, event.EndpointId)
+	return device, nil
+}
+
+// resolveEventModel maps Device.DeviceInfo.ModelName from the Boot! ParameterMap to a model,
+// falling back to the configured default model name.
+func (s *service) resolveEventModel(
+	ctx context.Context,
+	event *models.USPNotifyEvent,
+) (*models.Model, error) {
+	names := []string{}
+	if event.Type() == models.USPEventTypeBoot {
+		parameterMap, _ := event.BootParameterMap()
+		if name := parameterMap[models.USPParamModelName]; name != "" {
+			names = append(names, name)
+		}
+	}
+	if s.autoRegisterDefaultModel != "" {
+		names = append(names, s.autoRegisterDefaultModel)
+	}
+
+	for _, name := range names {
+		model, err := s.store.FindModel(ctx, map[string]any{
+			models.Model{}.GetNameColumnName():   name,
+			models.Model{}.GetStatusColumnName(): "ENABLE",
+		})
+		if err == nil && model != nil {
+			return model, nil
+		}
+		if appErr, ok := err.(*apperrors.AppError); !ok || !appErr.IsErrorKey(apperrors.ErrEntityNotExist) {
+			return nil, err
+		}
+	}
+
+	logging.Errorf("cannot resolve model for endpoint %s, tried %v", event.EndpointId, names)
+	return nil, apperrors.NewInvalidRequestError(nil, "cannot resolve model for endpoint: "+event.EndpointId, "model_id")
+}
+
+func (s *service) recordFirmwareTransfer(
+	ctx context.Context,
+	device *models.Device,
+	operation *models.USPOperationComplete,
+	completedAt time.Time,
+) error {
+	transfer := &models.DeviceFirmwareTransfer{
+		DeviceId:    device.Id,
+		ObjPath:     operation.ObjPath,
+		CommandName: operation.CommandName,
+		CommandKey:  operation.CommandKey,
+		Status:      models.FirmwareTransferStatusSuccess,
+		CompletedAt: &completedAt,
+	}
+	if operation.CmdFailure != nil {
+		transfer.Status = models.FirmwareTransferStatusFailed
+		transfer.ErrCode = operation.CmdFailure.ErrCode
+		transfer.ErrMsg = operation.CmdFailure.ErrMsg
+	}
+
+	// command_key carries the firmware id or name the Download() was issued for
+	if operation.CommandKey != "" {
+		condition := map[string]any{
+			models.Firmware{}.GetModelIdColumnName(): device.ModelId,
+		}
+		if firmwareId, err := uuid.Parse(operation.CommandKey); err == nil {
+			condition[models.Firmware{}.GetIdColumnName()] = firmwareId
+		} else {
+			condition[models.Firmware{}.GetNameColumnName()] = operation.CommandKey
+		}
+		firmware, err := s.store.FindFirmware(ctx, condition)
+		if err == nil && firmware != nil {
+			transfer.FirmwareId = firmware.Id
+		} else {
+			logging.Warnf("no firmware matches command_key %s for device %s", operation.CommandKey, device.Id)
+		}
+	}
+
+	if err := s.store.InsertDeviceFirmwareTransfer(ctx, transfer); err != nil {
+		logging.Errorf("failed to record firmware transfer for device %s: %v", device.Id, err)
+		return err
+	}
+	return nil
+}
+
+func isFirmwareTransferCommand(operation *models.USPOperationComplete) bool {
+	if !strings.HasPrefix(operation.ObjPath, models.USPFirmwareImagePrefix) {
+		return false
+	}
+	return operation.CommandName == models.USPFirmwareDownloadCmd ||
+		operation.CommandName == models.USPFirmwareActivateCmd
+}
@@ -1,439 +1,426 @@
 package managementuc
 
 import (
-	"bytes"
 	"context"
-	"encoding/csv"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"time"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
-	utils "usp-management-device-api/common/utils"
+	exportwriter "usp-management-device-api/common/export_writer"
+
+	"github.com/google/uuid"
 )
 
-func (s *service) ExportParametersCSV(
+func (s *service) ExportParameters(
 	ctx context.Context,
+	w io.Writer,
+	export models.ExportOptions,
 	condition map[string]any,
 	oppts models.QueryOptions,
-) ([]byte, error) {
-	if len(oppts.OrderExpr) == 0 {
-		oppts.OrderExpr = []models.OrderExpr{
-			{
-				Field:     "updated_at",
-				Direction: "DESC",
-			},
-		}
+) error {
+	format, loc, err := resolveExportOptions(export)
+	if err != nil {
+		return err
 	}
-
-	validColumns := map[string]bool{
-		"path":        true,
-		"data_type":   true,
-		"description": true,
-		"created_at":  true,
-		"updated_at":  true,
-		"updated_by":  true,
-		"status":      true,
-	}
-	for _, expr := range oppts.OrderExpr {
-		if _, ok := validColumns[strings.ToLower(expr.Field)]; !ok {
-			return nil, apperrors.NewInvalidRequestError(
-				nil,
-				fmt.Sprintf("invalid order field: %s", expr.Field),
-				"invalid order field",
-			)
-		}
+	qb := NewParameterQueryBuilder()
+	if err := prepareExportQuery(qb.QueryBuilder, condition, oppts); err != nil {
+		return err
+	}
+	condition, oppts, err = qb.BuildParameter()
+	if err != nil {
+		return err
 	}
 
-	hasStatusFilter := false
-	for _, expr := range oppts.FilterExpr {
-		if strings.EqualFold(expr.Filter, "status") {
-			hasStatusFilter = true
-			if strings.EqualFold(expr.Op, "eq") &&
-				strings.EqualFold(fmt.Sprint(expr.Value), "DELETE") {
-				return nil, apperrors.NewInvalidRequestError(nil, "cannot export models with DELETE status", "invalid status filter")
-			}
-		}
+	return exportTable(w, format, "parameters", models.ParameterExportSchema.Header(),
+		func(fn func([]models.Parameter) error) error {
+			return s.store.StreamParameters(ctx, condition, oppts, fn)
+		},
+		parameterExportRow(loc),
+	)
+}
+
+func (s *service) ExportProfiles(
+	ctx context.Context,
+	w io.Writer,
+	export models.ExportOptions,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) error {
+	format, loc, err := resolveExportOptions(export)
+	if err != nil {
+		return err
 	}
-	if !hasStatusFilter {
-		condition["status"] = []string{"ENABLE", "DISABLE"}
+	qb := NewProfileQueryBuilder()
+	if err := prepareExportQuery(qb.QueryBuilder, condition, oppts); err != nil {
+		return err
 	}
-
-	parameters, err := s.store.ListParameters(ctx, condition, oppts)
+	condition, oppts, err = qb.BuildProfile()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
+	return exportTable(w, format, "profiles", models.ProfileExportSchema.Header(),
+		func(fn func([]models.Profile) error) error {
+			return s.store.StreamProfiles(ctx, condition, oppts, fn)
+		},
+		profileExportRow(loc),
+	)
+}
 
-	// header
-	headers := []string{"path", "data_type", "description", "created_at", "updated_at", "updated_by", "status"}
-	if err := writer.Write(headers); err != nil {
-		return nil, err
+func (s *service) ExportModels(
+	ctx context.Context,
+	w io.Writer,
+	export models.ExportOptions,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) error {
+	format, loc, err := resolveExportOptions(export)
+	if err != nil {
+		return err
 	}
-
-	// rows
-	for _, p := range parameters {
-		row := []string{
-			p.Path,
-			p.DataType,
-			p.Description,
-			utils.FormatTimeGMT7(p.CreatedAt, time.RFC3339),
-			utils.FormatTimeGMT7(p.UpdatedAt, time.RFC3339),
-			p.UpdatedBy,
-			p.Status,
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, err
-		}
+	qb := NewModelQueryBuilder()
+	if err := prepareExportQuery(qb.QueryBuilder, condition, oppts); err != nil {
+		return err
 	}
-
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, err
+	condition, oppts, err = qb.BuildModel()
+	if err != nil {
+		return err
 	}
 
-	return buf.Bytes(), nil
+	return exportTable(w, format, "models", models.ModelExportSchema.Header(),
+		func(fn func([]models.Model) error) error {
+			return s.store.StreamModels(ctx, condition, oppts, fn)
+		},
+		modelExportRow(loc),
+	)
 }
 
-func (s *service) ExportProfilesCSV(
+func (s *service) ExportFirmwares(
 	ctx context.Context,
+	w io.Writer,
+	export models.ExportOptions,
 	condition map[string]any,
 	oppts models.QueryOptions,
-) ([]byte, error) {
-	if len(oppts.OrderExpr) == 0 {
-		oppts.OrderExpr = []models.OrderExpr{
-			{
-				Field:     "updated_at",
-				Direction: "DESC",
-			},
-		}
+) error {
+	format, loc, err := resolveExportOptions(export)
+	if err != nil {
+		return err
 	}
-
-	validColumns := map[string]bool{
-		"name":                   true,
-		"msg_type":               true,
-		"return_commands":        true,
-		"return_events":          true,
-		"return_params":          true,
-		"return_unique_key_sets": true,
-		"allow_partial":          true,
-		"send_resp":              true,
-		"first_level_only":       true,
-		"max_depth":              true,
-		"tags":                   true,
-		"created_at":             true,
-		"updated_at":             true,
-		"status":                 true,
-		"updated_by":             true,
-		"description":            true,
-	}
-	for _, expr := range oppts.OrderExpr {
-		if _, ok := validColumns[strings.ToLower(expr.Field)]; !ok {
-			return nil, apperrors.NewInvalidRequestError(
-				nil,
-				fmt.Sprintf("invalid order field: %s", expr.Field),
-				"invalid order field",
-			)
-		}
+	if err := s.checkExportModel(ctx, condition); err != nil {
+		return err
+	}
+	qb := NewFirmwareQueryBuilder()
+	if err := prepareExportQuery(qb.QueryBuilder, condition, oppts); err != nil {
+		return err
+	}
+	condition, oppts, err = qb.BuildFirmware()
+	if err != nil {
+		return err
 	}
 
-	hasStatusFilter := false
-	for _, expr := range oppts.FilterExpr {
-		if strings.EqualFold(expr.Filter, "status") {
-			hasStatusFilter = true
-			if strings.EqualFold(expr.Op, "eq") &&
-				strings.EqualFold(fmt.Sprint(expr.Value), "DELETE") {
-				return nil, apperrors.NewInvalidRequestError(nil, "cannot export models with DELETE status", "invalid status filter")
-			}
-		}
+	return exportTable(w, format, "firmwares", models.FirmwareExportSchema.Header(),
+		func(fn func([]models.Firmware) error) error {
+			return s.store.StreamFirmwares(ctx, condition, oppts, fn)
+		},
+		firmwareExportRow(loc),
+	)
+}
+
+func (s *service) ExportGroups(
+	ctx context.Context,
+	w io.Writer,
+	export models.ExportOptions,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) error {
+	format, loc, err := resolveExportOptions(export)
+	if err != nil {
+		return err
+	}
+	if err := s.checkExportModel(ctx, condition); err != nil {
+		return err
 	}
-	if !hasStatusFilter {
-		condition["status"] = []string{"ENABLE", "DISABLE"}
+	qb := NewGroupQueryBuilder()
+	if err := prepareExportQuery(qb.QueryBuilder, condition, oppts); err != nil {
+		return err
+	}
+	condition, oppts, err = qb.BuildGroup()
+	if err != nil {
+		return err
 	}
 
-	profiles, err := s.store.ListProfiles(ctx, condition, oppts)
+	return exportTable(w, format, "groups", models.GroupExportSchema.Header(),
+		func(fn func([]models.Group) error) error {
+			return s.store.StreamGroups(ctx, condition, oppts, fn)
+		},
+		groupExportRow(loc),
+	)
+}
+
+func (s *service) ExportDevices(
+	ctx context.Context,
+	w io.Writer,
+	export models.ExportOptions,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) error {
+	format, loc, err := resolveExportOptions(export)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err := s.checkExportModel(ctx, condition); err != nil {
+		return err
+	}
+	qb := NewDeviceQueryBuilder()
+	if err := prepareExportQuery(qb.QueryBuilder, condition, oppts); err != nil {
+		return err
+	}
+	condition, oppts, err = qb.BuildDevice()
+	if err != nil {
+		return err
 	}
 
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
+	return exportTable(w, format, "devices", models.DeviceExportSchema.Header(),
+		func(fn func([]models.Device) error) error {
+			return s.store.StreamDevices(ctx, condition, oppts, fn)
+		},
+		deviceExportRow(loc),
+	)
+}
 
-	// header
-	headers := []string{"name",
-		"msg_type",
-		"return_commands",
-		"return_events",
-		"return_params",
-		"return_unique_key_sets",
-		"allow_partial", "send_resp",
-		"first_level_only",
-		"max_depth",
-		"tags",
-		"created_at",
-		"updated_at",
-		"updated_by",
-		"status",
-		"description",
-		"parameter_paths",
-	}
-	if err := writer.Write(headers); err != nil {
-		return nil, err
+// parameterExportRow returns the cells of a parameter in the order of ParameterExportSchema
+func parameterExportRow(loc *time.Location) func(*models.Parameter) []any {
+	return func(p *models.Parameter) []any {
+		return []any{
+			p.Path,
+			p.DataType,
+			p.Description,
+			exportTime(p.CreatedAt, loc),
+			exportTime(p.UpdatedAt, loc),
+			p.UpdatedBy,
+			p.Status,
+		}
 	}
+}
 
-	// rows
-	for _, p := range profiles {
-		var paramPaths []string
+// profileExportRow returns the cells of a profile in the order of ProfileExportSchema
+func profileExportRow(loc *time.Location) func(*models.Profile) []any {
+	return func(p *models.Profile) []any {
+		members := models.ProfileParameterMembers{}
 		for _, pp := range p.ProfileParameters {
 			if pp.Parameter != nil {
-				paramPaths = append(paramPaths, pp.Parameter.Path)
+				members = append(members, models.ProfileParameterMember{
+					Path:         pp.Parameter.Path,
+					DefaultValue: pp.DefaultValue,
+					Required:     pp.Required,
+				})
 			}
 		}
-		tagsStr := strings.Join(p.Tags, ";")
-		paramPathsStr := strings.Join(paramPaths, ";")
-		row := []string{
+		// sorted so exporting an imported file gives the same cell
+		slices.SortFunc(members, func(a, b models.ProfileParameterMember) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+		return []any{
 			p.Name,
-			fmt.Sprintf("%v", p.MsgType),
-			fmt.Sprintf("%v", p.ReturnCommands),
-			fmt.Sprintf("%v", p.ReturnEvents),
-			fmt.Sprintf("%v", p.ReturnParams),
-			fmt.Sprintf("%v", p.ReturnUniqueKeySets),
-			fmt.Sprintf("%v", p.AllowPartial),
-			fmt.Sprintf("%v", p.SendResp),
-			fmt.Sprintf("%v", p.FirstLevelOnly),
-			fmt.Sprintf("%d", p.MaxDepth),
-			tagsStr,
-			utils.FormatTimeGMT7(p.CreatedAt, time.RFC3339),
-			utils.FormatTimeGMT7(p.UpdatedAt, time.RFC3339),
+			p.MsgType,
+			[]string(p.Tags),
+			p.MaxDepth,
+			p.AllowPartial,
+			p.FirstLevelOnly,
+			p.ReturnCommands,
+			p.ReturnEvents,
+			p.ReturnParams,
+			p.ReturnUniqueKeySets,
+			p.SendResp,
+			p.Description,
+			members,
+			exportTime(p.CreatedAt, loc),
+			exportTime(p.UpdatedAt, loc),
 			p.UpdatedBy,
 			p.Status,
-			p.Description,
-			paramPathsStr,
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, err
 		}
 	}
-
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
 }
 
-func (s *service) ExportDevicesCSV(
-	ctx context.Context,
-	condition map[string]any,
-	oppts models.QueryOptions,
-) ([]byte, error) {
-	if len(oppts.OrderExpr) == 0 {
-		oppts.OrderExpr = []models.OrderExpr{
-			{
-				Field:     "updated_at",
-				Direction: "DESC",
-			},
+// modelExportRow returns the cells of a model in the order of ModelExportSchema
+func modelExportRow(loc *time.Location) func(*models.Model) []any {
+	return func(m *models.Model) []any {
+		return []any{
+			m.Name,
+			m.VendorName,
+			m.Manufacturer,
+			m.Description,
+			m.EndpointIdTemplate,
+			exportTime(m.CreatedAt, loc),
+			exportTime(m.UpdatedAt, loc),
+			m.UpdatedBy,
+			m.Status,
 		}
 	}
+}
 
-	validColumns := map[string]bool{
-		"mac_address": true,
-		"endpoint_id": true,
-		"model_id":    true,
-		"group_id":    true,
-		"created_at":  true,
-		"updated_at":  true,
-		"updated_by":  true,
-		"status":      true,
-		"description": true,
-	}
-	for _, expr := range oppts.OrderExpr {
-		if _, ok := validColumns[strings.ToLower(expr.Field)]; !ok {
-			return nil, apperrors.NewInvalidRequestError(
-				nil,
-				fmt.Sprintf("invalid order field: %s", expr.Field),
-				"invalid order field",
-			)
+// firmwareExportRow returns the cells of a firmware in the order of FirmwareExportSchema
+func firmwareExportRow(loc *time.Location) func(*models.Firmware) []any {
+	return func(f *models.Firmware) []any {
+		return []any{
+			f.Name,
+			f.FilePath,
+			f.Description,
+			exportTime(f.CreatedAt, loc),
+			exportTime(f.UpdatedAt, loc),
+			f.UpdatedBy,
+			f.Status,
 		}
 	}
+}
 
-	hasStatusFilter := false
-	for _, expr := range oppts.FilterExpr {
-		if strings.EqualFold(expr.Filter, "status") {
-			hasStatusFilter = true
-			if strings.EqualFold(expr.Op, "eq") &&
-				strings.EqualFold(fmt.Sprint(expr.Value), "DELETE") {
-				return nil, apperrors.NewInvalidRequestError(nil, "cannot export models with DELETE status", "invalid status filter")
-			}
+// groupExportRow returns the cells of a group in the order of GroupExportSchema
+func groupExportRow(loc *time.Location) func(*models.Group) []any {
+	return func(g *models.Group) []any {
+		return []any{
+			g.Name,
+			g.Description,
+			exportUUID(g.FirmwareId),
+			g.DownloadPeriod,
+			exportTime(g.CreatedAt, loc),
+			exportTime(g.UpdatedAt, loc),
+			g.UpdatedBy,
+			g.Status,
 		}
 	}
-	if !hasStatusFilter {
-		condition["status"] = []string{"ENABLE", "DISABLE"}
-	}
-
-	devices, err := s.store.ListDevices(ctx, condition, oppts)
-	if err != nil {
-		return nil, err
-	}
-
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
-
-	// header
-	headers := []string{
-		"mac_address",
-		"endpoint_id",
-		"model_id",
-		"group_id",
-		"created_at",
-		"updated_at",
-		"updated_by",
-		"status",
-		"description",
-	}
-	if err := writer.Write(headers); err != nil {
-		return nil, err
-	}
-
-	// rows
-	for _, d := range devices {
-		var modelId, groupId string
-		if d.ModelId != nil {
-			modelId = d.ModelId.String()
-		}
-		if d.GroupId != nil {
-			groupId = d.GroupId.String()
-		}
+}
 
-		row := []string{
+// deviceExportRow returns the cells of a device in the order of DeviceExportSchema
+func deviceExportRow(loc *time.Location) func(*models.Device) []any {
+	return func(d *models.Device) []any {
+		return []any{
 			d.MacAddress,
+			d.SerialNumber,
+			d.ProductClass,
+			d.HardwareRevision,
 			d.EndpointId,
-			modelId,
-			groupId,
-			utils.FormatTimeGMT7(d.CreatedAt, time.RFC3339),
-			utils.FormatTimeGMT7(d.UpdatedAt, time.RFC3339),
+			exportUUID(d.ModelId),
+			exportUUID(d.GroupId),
+			d.SoftwareVersion,
+			d.LifecycleState,
+			d.Description,
+			exportTime(d.CreatedAt, loc),
+			exportTime(d.UpdatedAt, loc),
 			d.UpdatedBy,
 			d.Status,
-			d.Description,
 		}
-		if err := writer.Write(row); err != nil {
-			return nil, err
-		}
-	}
-
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, err
 	}
-
-	return buf.Bytes(), nil
 }
 
-func (s *service) ExportFirmwaresCSV(
-	ctx context.Context,
-	condition map[string]any,
-	modelId string,
-	oppts models.QueryOptions,
-) ([]byte, error) {
-	if len(oppts.OrderExpr) == 0 {
-		oppts.OrderExpr = []models.OrderExpr{
-			{
-				Field:     "updated_at",
-				Direction: "DESC",
-			},
-		}
+// resolveExportOptions checks the format and timezone of an export
+func resolveExportOptions(export models.ExportOptions) (string, *time.Location, error) {
+	format := strings.ToLower(strings.TrimSpace(export.Format))
+	if format == "" {
+		format = exportwriter.FormatCSV
+	}
+	if !exportwriter.IsFormat(format) {
+		return "", nil, apperrors.NewInvalidRequestError(
+			nil,
+			fmt.Sprintf("invalid export format: %s, allowed formats: %s", export.Format, strings.Join(exportwriter.Formats, ", ")),
+			"invalid_format",
+		)
 	}
 
-	firmwares, err := s.store.ListTotalFirmwares(ctx, condition, modelId)
+	timezone := strings.TrimSpace(export.Timezone)
+	if timezone == "" {
+		timezone = models.DefaultExportTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
 	if err != nil {
-		return nil, err
+		return "", nil, apperrors.NewInvalidRequestError(err, "invalid timezone: "+export.Timezone, "invalid_timezone")
 	}
+	return format, loc, nil
+}
 
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
-
-	// header
-	headers := []string{"name", "file_path", "description", "created_at", "updated_at", "updated_by", "status"}
-	if err := writer.Write(headers); err != nil {
-		return nil, err
+// prepareExportQuery sets the conditions, filter and orders of an export, exports are not paginated
+func prepareExportQuery(qb *QueryBuilder, condition map[string]any, oppts models.QueryOptions) error {
+	for key, value := range condition {
+		qb.AddCondition(key, value)
 	}
-
-	// rows
-	for _, f := range firmwares {
-		row := []string{
-			f.Name,
-			f.FilePath,
-			f.Description,
-			utils.FormatTimeGMT7(f.CreatedAt, time.RFC3339),
-			utils.FormatTimeGMT7(f.UpdatedAt, time.RFC3339),
-			f.UpdatedBy,
-			f.Status,
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, err
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return err
+	}
+	for _, order := range oppts.OrderExpr {
+		if err := qb.AddOrder(order.Field, order.Direction); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, err
+// checkExportModel rejects the export of a model that does not exist
+func (s *service) checkExportModel(ctx context.Context, condition map[string]any) error {
+	modelId := condition["model_id"]
+	if modelId == nil {
+		return nil
 	}
-
-	return buf.Bytes(), nil
+	if _, err := s.store.FindModel(ctx, map[string]any{"id": modelId}); err != nil {
+		return apperrors.NewInvalidRequestError(err, "model not found with id: "+fmt.Sprint(modelId), "find_model_error")
+	}
+	return nil
 }
 
-func (s *service) ExportGroupsCSV(
-	ctx context.Context,
-	condition map[string]any,
-	modelId string,
-	oppts models.QueryOptions,
-) ([]byte, error) {
-	// Always order by updated_at DESC
-	if len(oppts.OrderExpr) == 0 {
-		oppts.OrderExpr = []models.OrderExpr{
-			{
-				Field:     "updated_at",
-				Direction: "DESC",
-			},
+// exportTable writes the rows read by stream to w. The writer is opened with the first batch,
+// so an error raised before any row is read leaves w untouched and can still be answered.
+func exportTable[T any](
+	w io.Writer,
+	format string,
+	sheet string,
+	columns []string,
+	stream func(fn func([]T) error) error,
+	row func(*T) []any,
+) error {
+	var writer exportwriter.Writer
+	open := func() error {
+		if writer != nil {
+			return nil
+		}
+		var err error
+		if writer, err = exportwriter.New(format, w, sheet); err != nil {
+			return err
 		}
+		return writer.WriteHeader(columns)
 	}
 
-	groups, err := s.store.ListTotalGroups(ctx, condition, modelId)
+	err := stream(func(batch []T) error {
+		if err := open(); err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := writer.WriteRow(row(&batch[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
-
-	// header
-	headers := []string{"name", "description", "created_at", "updated_at", "updated_by", "status"}
-	if err := writer.Write(headers); err != nil {
-		return nil, err
+	// an export without rows still has its header
+	if err := open(); err != nil {
+		return err
 	}
+	return writer.Close()
+}
 
-	// rows
-	for _, g := range groups {
-		row := []string{
-			g.Name,
-			g.Description,
-			utils.FormatTimeGMT7(g.CreatedAt, time.RFC3339),
-			utils.FormatTimeGMT7(g.UpdatedAt, time.RFC3339),
-			g.UpdatedBy,
-			g.Status,
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, err
-		}
+func exportTime(t *time.Time, loc *time.Location) any {
+	if t == nil {
+		return nil
 	}
+	return t.In(loc).Format(time.RFC3339)
+}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, err
+func exportUUID(id *uuid.UUID) any {
+	if id == nil {
+		return nil
 	}
-
-	return buf.Bytes(), nil
+	return id.String()
 }
@@ -0,0 +1,310 @@
+package managementuc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"usp-management-device-api/business/models"
+	exportwriter "usp-management-device-api/common/export_writer"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// exportCSV writes rows as the export of the resource does and reads the file back as the
+// CSV imports do
+func exportCSV[T any](t *testing.T, columns []string, rows []T, row func(*T) []any) [][]string {
+	t.Helper()
+	var file bytes.Buffer
+	err := exportTable(&file, exportwriter.FormatCSV, "sheet", columns,
+		func(fn func([]T) error) error {
+			if len(rows) == 0 {
+				return nil
+			}
+			return fn(rows)
+		},
+		row,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestExportTableCSV(t *testing.T) {
+	columns := models.ParameterExportSchema.Header()
+	created := time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rows []models.Parameter
+		want [][]string
+	}{
+		{
+			name: "no rows keeps the header",
+			want: [][]string{columns},
+		},
+		{
+			name: "cells in schema order, times in the export timezone",
+			rows: []models.Parameter{
+				{Path: "Device.WiFi.SSID.1.SSID", DataType: "string", Description: "ssid", CreatedAt: &created, UpdatedBy: "admin", Status: "ENABLE"},
+			},
+			want: [][]string{
+				columns,
+				{"Device.WiFi.SSID.1.SSID", "string", "ssid", "2026-03-01T09:30:00+07:00", "", "admin", "ENABLE"},
+			},
+		},
+		{
+			name: "separators, quotes and new lines are quoted",
+			rows: []models.Parameter{
+				{Path: "Device.A", DataType: "string", Description: `a, "b"` + "\nc"},
+			},
+			want: [][]string{
+				columns,
+				{"Device.A", "string", `a, "b"` + "\nc", "", "", "", ""},
+			},
+		},
+	}
+
+	loc, err := time.LoadLocation(models.DefaultExportTimezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := exportCSV(t, columns, tt.rows, parameterExportRow(loc))
+			if !slices.EqualFunc(got, tt.want, slices.Equal[[]string]) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasExportColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		schema  models.ExportSchema
+		want    bool
+	}{
+		{"export header", models.ParameterExportSchema.Header(), models.ParameterExportSchema, true},
+		{"importable columns only", []string{"Path", "Data Type", "Description"}, models.ParameterExportSchema, true},
+		{"extra columns after", []string{"Path", "Data Type", "Description", "Note"}, models.ParameterExportSchema, true},
+		{"missing column", []string{"Path", "Data Type"}, models.ParameterExportSchema, false},
+		{"other order", []string{"Data Type", "Path", "Description"}, models.ParameterExportSchema, false},
+		{"empty", nil, models.ParameterExportSchema, false},
+		{"legacy profile header", models.LegacyProfileImportColumns, models.ProfileExportSchema, false},
+		{"profile export header", models.ProfileExportSchema.Header(), models.ProfileExportSchema, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasExportColumns(tt.headers, tt.schema); got != tt.want {
+				t.Errorf("hasExportColumns(%q) = %v, want %v", tt.headers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileParameterMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		members models.ProfileParameterMembers
+		text    string
+	}{
+		{"nil", nil, "[]"},
+		{"empty", models.ProfileParameterMembers{}, "[]"},
+		{
+			name: "values with separators",
+			members: models.ProfileParameterMembers{
+				{Path: "Device.A", DefaultValue: `x, "y"; z`, Required: true},
+				{Path: "Device.B"},
+			},
+			text: `[{"path":"Device.A","default_value":"x, \"y\"; z","required":true},{"path":"Device.B","default_value":"","required":false}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := tt.members.String()
+			if text != tt.text {
+				t.Errorf("String() = %s, want %s", text, tt.text)
+			}
+			parsed, err := models.ParseProfileParameterMembers(text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed) != len(tt.members) || (len(parsed) > 0 && !slices.Equal(parsed, tt.members)) {
+				t.Errorf("parsed %v, want %v", parsed, tt.members)
+			}
+		})
+	}
+
+	for _, text := range []string{"", "  "} {
+		if parsed, err := models.ParseProfileParameterMembers(text); err != nil || len(parsed) != 0 {
+			t.Errorf("ParseProfileParameterMembers(%q) = %v, %v, want no member", text, parsed, err)
+		}
+	}
+	if _, err := models.ParseProfileParameterMembers("Device.A"); err == nil {
+		t.Error("ParseProfileParameterMembers of a path list, want an error")
+	}
+}
+
+// TestProfileExportRoundTrip imports a profile export with CreateProfileWithBatch into a
+// database whose parameters have other ids: the parameters are found again by path
+func TestProfileExportRoundTrip(t *testing.T) {
+	source := func(path string) *models.Parameter {
+		return &models.Parameter{Id: newRowId(), Path: path}
+	}
+	exported := []models.Profile{
+		{Name: "empty", MsgType: 1, Tags: pq.StringArray{}},
+		{
+			Name: "wifi", MsgType: 3, Tags: pq.StringArray{"wifi", "daily"}, MaxDepth: 2,
+			AllowPartial: true, FirstLevelOnly: true, ReturnCommands: true, ReturnEvents: true,
+			ReturnParams: true, ReturnUniqueKeySets: true, SendResp: true,
+			Description: "ssid, password",
+			ProfileParameters: []*models.ProfileParameter{
+				{Parameter: source("Device.WiFi.SSID.1.SSID"), DefaultValue: `home "5G"`, Required: true},
+				{Parameter: source("Device.WiFi.AccessPoint.1.Security.KeyPassphrase")},
+			},
+		},
+	}
+	records := exportCSV(t, models.ProfileExportSchema.Header(), exported, profileExportRow(time.UTC))
+
+	store := &memoryImportStore{parameters: []*models.Parameter{
+		{Id: newRowId(), Path: "Device.WiFi.AccessPoint.1.Security.KeyPassphrase", Status: "ENABLE"},
+		{Id: newRowId(), Path: "Device.WiFi.SSID.1.SSID", Status: "ENABLE"},
+	}}
+	s := &service{store: store}
+	report, err := s.CreateProfileWithBatch(context.Background(), csvFile(records), "importer",
+		models.ImportOptions{Mode: models.ImportModeInsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != len(exported) {
+		t.Fatalf("created %d profiles, want %d", report.Created, len(exported))
+	}
+	reexported := exportCSV(t, models.ProfileExportSchema.Header(), store.exportedProfiles(), profileExportRow(time.UTC))
+	assertImportableColumns(t, models.ProfileExportSchema, records, reexported)
+
+	// the profile is changed after the import, upserting the export restores its columns,
+	// its parameters and their default value and required flag
+	wifi := store.profiles[1]
+	wifi.MaxDepth, wifi.SendResp, wifi.Tags = 5, false, pq.StringArray{"weekly"}
+	for _, pp := range store.profileParameters {
+		pp.DefaultValue, pp.Required = "guest", false
+	}
+	store.profileParameters = store.profileParameters[:1]
+	report, err = s.CreateProfileWithBatch(context.Background(), csvFile(records), "importer",
+		models.ImportOptions{Mode: models.ImportModeUpsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Updated != 1 || report.Skipped != 1 {
+		t.Errorf("upsert updated %d, skipped %d profiles, want 1 and 1", report.Updated, report.Skipped)
+	}
+	reexported = exportCSV(t, models.ProfileExportSchema.Header(), store.exportedProfiles(), profileExportRow(time.UTC))
+	assertImportableColumns(t, models.ProfileExportSchema, records, reexported)
+
+	// upserted again, nothing changes
+	report, err = s.CreateProfileWithBatch(context.Background(), csvFile(reexported), "importer",
+		models.ImportOptions{Mode: models.ImportModeUpsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Updated != 0 || report.Skipped != len(exported) {
+		t.Errorf("upsert of the export updated %d, skipped %d, want only skipped rows", report.Updated, report.Skipped)
+	}
+}
+
+func TestParameterExportRoundTrip(t *testing.T) {
+	exported := []models.Parameter{
+		{Path: "Device.DeviceInfo.SoftwareVersion", DataType: "string", Description: "firmware, as reported"},
+		{Path: "Device.Time.Enable", DataType: "boolean"},
+	}
+	records := exportCSV(t, models.ParameterExportSchema.Header(), exported, parameterExportRow(time.UTC))
+
+	store := &memoryImportStore{}
+	s := &service{store: store}
+	report, err := s.CreateParametersFromCSVFile(context.Background(), csvFile(records), "importer", 100,
+		models.ImportOptions{Mode: models.ImportModeInsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != len(exported) {
+		t.Fatalf("created %d parameters, want %d", report.Created, len(exported))
+	}
+	reexported := exportCSV(t, models.ParameterExportSchema.Header(), derefRows(store.parameters), parameterExportRow(time.UTC))
+	assertImportableColumns(t, models.ParameterExportSchema, records, reexported)
+
+	store.parameters[0].DataType = "int"
+	report, err = s.CreateParametersFromCSVFile(context.Background(), csvFile(records), "importer", 100,
+		models.ImportOptions{Mode: models.ImportModeUpsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Updated != 1 || report.Skipped != 1 {
+		t.Errorf("upsert updated %d, skipped %d parameters, want 1 and 1", report.Updated, report.Skipped)
+	}
+	reexported = exportCSV(t, models.ParameterExportSchema.Header(), derefRows(store.parameters), parameterExportRow(time.UTC))
+	assertImportableColumns(t, models.ParameterExportSchema, records, reexported)
+}
+
+// TestDeviceExportRoundTrip imports a device export into one group, Model ID and Group ID are read-only
+func TestDeviceExportRoundTrip(t *testing.T) {
+	otherGroup := uuid.New()
+	exported := []models.Device{
+		{MacAddress: "4485da000001", SerialNumber: "SN1", ProductClass: "XGS-PON", HardwareRevision: "1.0", GroupId: &otherGroup},
+		{MacAddress: "4485da000002", LifecycleState: models.DeviceStateInService},
+	}
+	records := exportCSV(t, models.DeviceExportSchema.Header(), exported, deviceExportRow(time.UTC))
+
+	modelID, groupID := newRowId(), newRowId()
+	store := &memoryImportStore{
+		models: []*models.Model{{Id: modelID, Name: "HGW-1", Manufacturer: "acme"}},
+		groups: []*models.Group{{Id: groupID, ModelId: modelID, Name: "default"}},
+	}
+	s := &service{store: store}
+	report, err := s.importDeviceRows(context.Background(), csvFile(records), "importer", 100, *modelID, *groupID,
+		models.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != len(exported) {
+		t.Fatalf("created %d devices, want %d", report.Created, len(exported))
+	}
+	reexported := exportCSV(t, models.DeviceExportSchema.Header(), derefRows(store.devices), deviceExportRow(time.UTC))
+	assertImportableColumns(t, models.DeviceExportSchema, records, reexported)
+	for _, d := range store.devices {
+		if *d.ModelId != *modelID || *d.GroupId != *groupID {
+			t.Errorf("device %s imported into model %s group %s, want the ids of the request", d.MacAddress, d.ModelId, d.GroupId)
+		}
+		if d.LifecycleState != models.DeviceStatePreProvisioned {
+			t.Errorf("device %s imported as %s, want %s", d.MacAddress, d.LifecycleState, models.DeviceStatePreProvisioned)
+		}
+	}
+
+	// importing the export again rejects every device, the MAC addresses exist
+	report, err = s.importDeviceRows(context.Background(), csvFile(reexported), "importer", 100, *modelID, *groupID,
+		models.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 0 || len(report.Rejected) != len(exported) {
+		t.Errorf("second import created %d, rejected %d devices, want every device rejected", report.Created, len(report.Rejected))
+	}
+}
+
+func csvFile(records [][]string) *strings.Reader {
+	var file strings.Builder
+	w := csv.NewWriter(&file)
+	if err := w.WriteAll(records); err != nil {
+		panic(err)
+	}
+	return strings.NewReader(file.String())
+}
@@ -0,0 +1,613 @@
+package managementuc
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+
+	"github.com/google/uuid"
+)
+
+// defaultDownloadPeriod is the download period of a group created without one.
+const defaultDownloadPeriod = "00:00~00:00"
+
+var downloadPeriodRegex = regexp.MustCompile(`^([01]\d|2[0-3]):([0-5]\d)~([01]\d|2[0-3]):([0-5]\d)$`)
+
+// checkDownloadPeriod returns an invalid request error unless period is 'HH:MM~HH:MM'.
+func checkDownloadPeriod(period string) error {
+	if !downloadPeriodRegex.MatchString(period) {
+		return apperrors.NewInvalidRequestError(nil, "invalid format, expected format: 'HH:MM~HH:MM'", "download_period")
+	}
+	layout := "15:04"
+	parts := strings.Split(period, "~")
+	if _, err := time.Parse(layout, parts[0]); err != nil {
+		return apperrors.NewInvalidRequestError(err, "invalid start time: "+parts[0], "download_period")
+	}
+	if _, err := time.Parse(layout, parts[1]); err != nil {
+		return apperrors.NewInvalidRequestError(err, "invalid end time: "+parts[1], "download_period")
+	}
+	return nil
+}
+
+// isEntityNotExist reports whether err is the not found error of a Find of the store.
+func isEntityNotExist(err error) bool {
+	appErr, ok := err.(*apperrors.AppError)
+	return ok && appErr.IsErrorKey(apperrors.ErrEntityNotExist)
+}
+
+// checkUpsertImportMode rejects the replace mode, the rows missing from a model, firmware or
+// group file are kept: deleting them would delete their devices.
+func checkUpsertImportMode(opts models.ImportOptions) error {
+	if opts.Mode == models.ImportModeReplace {
+		return apperrors.NewInvalidRequestError(nil, "mode must be one of insert, upsert", "mode")
+	}
+	return nil
+}
+
+// readImportRecords reads a CSV whose header starts with the columns of schema and calls row
+// with the line of each record. Rows missing a column are skipped, unreadable rows rejected.
+func readImportRecords(
+	file io.Reader,
+	schema models.ExportSchema,
+	report *models.ImportReport,
+	row func(line int, record []string) error,
+) error {
+	r := csv.NewReader(file)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	headers, err := r.Read()
+	if err != nil {
+		logging.Errorf("failed to read csv header: %v", err)
+		return apperrors.NewInvalidRequestError(err, "file error", "headers")
+	}
+	if !hasExportColumns(headers, schema) {
+		logging.Errorf("invalid csv header: %v", headers)
+		return apperrors.NewInvalidRequestError(nil, "invalid csv header", "headers")
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				logging.Errorf("failed to read csv record: %v", err)
+				return err
+			}
+			report.Total++
+			report.Reject(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
+		}
+		report.Total++
+		line, _ := r.FieldPos(0)
+		if len(record) < len(schema.Columns) {
+			report.Skip()
+			continue
+		}
+		if err := row(line, record); err != nil {
+			return err
+		}
+	}
+}
+
+// importInTx runs importRows in a transaction, it is rolled back when the import fails or is a dry run.
+func (s *service) importInTx(
+	ctx context.Context,
+	opts models.ImportOptions,
+	importRows func(txCtx context.Context) (*models.ImportReport, error),
+) (*models.ImportReport, error) {
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	report, err := importRows(txCtx)
+	if err != nil {
+		return report, err
+	}
+	if opts.DryRun {
+		return report.MarkDryRun(), nil
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+	success = true
+	return report, nil
+}
+
+func (s *service) ImportModelsFromCSVFile(
+	ctx context.Context,
+	file io.Reader,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	// the catalog import is all-or-nothing
+	opts.Strict = true
+	if opts.Mode == "" {
+		opts.Mode = models.ImportModeInsert
+	}
+	if err := checkUpsertImportMode(opts); err != nil {
+		return nil, err
+	}
+	report, err := s.importInTx(ctx, opts, func(txCtx context.Context) (*models.ImportReport, error) {
+		return s.importModelRows(txCtx, file, updatedBy, opts)
+	})
+	if err == nil && !report.DryRun {
+		logging.Infof("Models imported: %d created, %d updated", report.Created, report.Updated)
+	}
+	return report, err
+}
+
+// importModelRows imports the rows of a model CSV in txCtx, a model is found by name.
+func (s *service) importModelRows(
+	txCtx context.Context,
+	file io.Reader,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	report := models.NewImportReport(opts.Mode)
+	names := map[string]int{}
+	err := readImportRecords(file, models.ModelExportSchema, report, func(line int, record []string) error {
+		model := &models.Model{
+			Name:               strings.TrimSpace(record[0]),
+			VendorName:         strings.TrimSpace(record[1]),
+			Manufacturer:       strings.TrimSpace(record[2]),
+			Description:        strings.TrimSpace(record[3]),
+			EndpointIdTemplate: strings.TrimSpace(record[4]),
+			Status:             "ENABLE",
+			UpdatedBy:          updatedBy,
+		}
+		if reason := checkImportedModel(model); reason != "" {
+			report.Reject(line, model.Name, reason)
+			return nil
+		}
+		if first, ok := names[model.Name]; ok {
+			report.Reject(line, model.Name, "duplicate name in file, first seen on line "+strconv.Itoa(first))
+			return nil
+		}
+		names[model.Name] = line
+
+		existing, err := s.store.FindModel(txCtx, map[string]any{
+			models.Model{}.GetNameColumnName(): model.Name,
+		})
+		if err != nil && !isEntityNotExist(err) {
+			logging.Errorf("failed to find model %s: %v", model.Name, err)
+			return err
+		}
+		if existing != nil {
+			if !opts.Upserts() {
+				report.Reject(line, model.Name, "model already exists with name: "+model.Name)
+				return nil
+			}
+			if !importWritesRows(report, opts) {
+				return nil
+			}
+			update := modelImportUpdate(existing, model)
+			if update == nil {
+				report.Skip()
+				return nil
+			}
+			if err := s.store.UpdateModel(txCtx, existing.Id.String(), update); err != nil {
+				logging.Errorf("failed to update model %s: %v", model.Name, err)
+				return err
+			}
+			report.Updated++
+			return nil
+		}
+		if !importWritesRows(report, opts) {
+			return nil
+		}
+		if err := s.store.InsertModel(txCtx, model); err != nil {
+			logging.Errorf("failed to insert model %s: %v", model.Name, err)
+			return err
+		}
+		report.Ids = append(report.Ids, model.Id.String())
+		report.Created++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := finishImport(report, opts); err != nil {
+		logging.Errorf("model import rejected, %d invalid rows", len(report.Rejected))
+		return report, err
+	}
+	return report, nil
+}
+
+// checkImportedModel returns why a model row cannot be imported, empty when it can.
+func checkImportedModel(model *models.Model) string {
+	switch {
+	case model.Name == "" || model.VendorName == "" || model.Manufacturer == "":
+		return "name, vendor name and manufacturer are required"
+	case len(model.Name) > 255 || len(model.VendorName) > 255 || len(model.Manufacturer) > 255 || len(model.Description) > 255:
+		return "name, vendor name, manufacturer and description are at most 255 characters"
+	}
+	if model.EndpointIdTemplate != "" {
+		if err := models.ValidateEndpointIdTemplate(model.EndpointIdTemplate); err != nil {
+			return "invalid endpoint_id_template: " + err.Error()
+		}
+	}
+	return ""
+}
+
+// modelImportUpdate returns the update that makes existing match an imported row,
+// nil when they are equal. A soft-deleted model is enabled again.
+func modelImportUpdate(existing *models.Model, imported *models.Model) *models.ModelUpdate {
+	if existing.Status != "DELETE" &&
+		existing.VendorName == imported.VendorName &&
+		existing.Manufacturer == imported.Manufacturer &&
+		existing.Description == imported.Description &&
+		existing.EndpointIdTemplate == imported.EndpointIdTemplate {
+		return nil
+	}
+	var status *string
+	if existing.Status == "DELETE" {
+		status = &imported.Status
+	}
+	update := models.NewModelUpdate(nil, &imported.VendorName, &imported.Manufacturer, status,
+		&imported.Description, &imported.UpdatedBy, nil)
+	update.EndpointIdTemplate = &imported.EndpointIdTemplate
+	return update
+}
+
+func (s *service) ImportFirmwaresFromCSVFile(
+	ctx context.Context,
+	file io.Reader,
+	modelId uuid.UUID,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	// the catalog import is all-or-nothing
+	opts.Strict = true
+	if opts.Mode == "" {
+		opts.Mode = models.ImportModeInsert
+	}
+	if err := checkUpsertImportMode(opts); err != nil {
+		return nil, err
+	}
+	report, err := s.importInTx(ctx, opts, func(txCtx context.Context) (*models.ImportReport, error) {
+		return s.importFirmwareRows(txCtx, file, modelId, updatedBy, opts)
+	})
+	if err == nil && !report.DryRun {
+		logging.Infof("Firmwares of model %s imported: %d created, %d updated", modelId, report.Created, report.Updated)
+	}
+	return report, err
+}
+
+// importFirmwareRows imports the rows of a firmware CSV into a model in txCtx. A firmware is
+// found by name, File Path is kept as it is: the files are not uploaded again.
+func (s *service) importFirmwareRows(
+	txCtx context.Context,
+	file io.Reader,
+	modelId uuid.UUID,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	if _, err := s.store.FindModel(txCtx, map[string]any{
+		models.Model{}.GetIdColumnName(): modelId,
+	}); err != nil {
+		logging.Errorf("failed to find model: %v", err)
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+modelId.String(), "model_id")
+	}
+
+	report := models.NewImportReport(opts.Mode)
+	names := map[string]int{}
+	err := readImportRecords(file, models.FirmwareExportSchema, report, func(line int, record []string) error {
+		firmware := &models.Firmware{
+			ModelId:     &modelId,
+			Name:        strings.TrimSpace(record[0]),
+			FilePath:    strings.TrimSpace(record[1]),
+			Description: strings.TrimSpace(record[2]),
+			Status:      "ENABLE",
+			UpdatedBy:   updatedBy,
+		}
+		switch {
+		case firmware.Name == "" || firmware.FilePath == "":
+			report.Reject(line, firmware.Name, "name and file path are required")
+			return nil
+		case len(firmware.Name) > 255 || len(firmware.FilePath) > 255 || len(firmware.Description) > 255:
+			report.Reject(line, firmware.Name, "name, file path and description are at most 255 characters")
+			return nil
+		}
+		if first, ok := names[firmware.Name]; ok {
+			report.Reject(line, firmware.Name, "duplicate name in file, first seen on line "+strconv.Itoa(first))
+			return nil
+		}
+		names[firmware.Name] = line
+
+		// firmware names are unique across models
+		existing, err := s.store.FindFirmware(txCtx, map[string]any{
+			models.Firmware{}.GetNameColumnName(): firmware.Name,
+		})
+		if err != nil && !isEntityNotExist(err) {
+			logging.Errorf("failed to find firmware %s: %v", firmware.Name, err)
+			return err
+		}
+		if existing != nil {
+			if !opts.Upserts() {
+				report.Reject(line, firmware.Name, "firmware already exists with name: "+firmware.Name)
+				return nil
+			}
+			if existing.ModelId == nil || *existing.ModelId != modelId {
+				report.Reject(line, firmware.Name, "firmware "+firmware.Name+" belongs to another model")
+				return nil
+			}
+			if !importWritesRows(report, opts) {
+				return nil
+			}
+			update := firmwareImportUpdate(existing, firmware)
+			if update == nil {
+				report.Skip()
+				return nil
+			}
+			if err := s.store.UpdateFirmware(txCtx, existing.Id.String(), update); err != nil {
+				logging.Errorf("failed to update firmware %s: %v", firmware.Name, err)
+				return err
+			}
+			if err := s.emitDomainEvent(txCtx, models.AggregateFirmware, existing.Id, models.EventActionUpdated, updatedBy, update); err != nil {
+				return err
+			}
+			report.Updated++
+			return nil
+		}
+		if !importWritesRows(report, opts) {
+			return nil
+		}
+		if err := s.store.InsertFirmware(txCtx, firmware); err != nil {
+			logging.Errorf("failed to insert firmware %s: %v", firmware.Name, err)
+			return err
+		}
+		if err := s.emitDomainEvent(txCtx, models.AggregateFirmware, firmware.Id, models.EventActionCreated, updatedBy, firmware); err != nil {
+			return err
+		}
+		report.Ids = append(report.Ids, firmware.Id.String())
+		report.Created++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := finishImport(report, opts); err != nil {
+		logging.Errorf("firmware import rejected, %d invalid rows", len(report.Rejected))
+		return report, err
+	}
+	return report, nil
+}
+
+// firmwareImportUpdate returns the update that makes existing match an imported row,
+// nil when they are equal. A soft-deleted firmware is enabled again.
+func firmwareImportUpdate(existing *models.Firmware, imported *models.Firmware) *models.FirmwareUpdate {
+	if existing.Status != "DELETE" &&
+		existing.FilePath == imported.FilePath &&
+		existing.Description == imported.Description {
+		return nil
+	}
+	var status *string
+	if existing.Status == "DELETE" {
+		status = &imported.Status
+	}
+	return models.NewFirmwareUpdate(&imported.FilePath, status, &imported.Description, &imported.UpdatedBy)
+}
+
+func (s *service) ImportGroupsFromCSVFile(
+	ctx context.Context,
+	file io.Reader,
+	modelId uuid.UUID,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	// the catalog import is all-or-nothing
+	opts.Strict = true
+	if opts.Mode == "" {
+		opts.Mode = models.ImportModeInsert
+	}
+	if err := checkUpsertImportMode(opts); err != nil {
+		return nil, err
+	}
+	report, err := s.importInTx(ctx, opts, func(txCtx context.Context) (*models.ImportReport, error) {
+		return s.importGroupRows(txCtx, file, modelId, updatedBy, opts)
+	})
+	if err == nil && !report.DryRun {
+		logging.Infof("Groups of model %s imported: %d created, %d updated", modelId, report.Created, report.Updated)
+	}
+	return report, err
+}
+
+// importGroupRows imports the rows of a group CSV into a model in txCtx. A group is found by
+// name in the model, Firmware ID must be a firmware of the model; an empty one leaves the
+// firmware of an existing group as it is.
+func (s *service) importGroupRows(
+	txCtx context.Context,
+	file io.Reader,
+	modelId uuid.UUID,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	if _, err := s.store.FindModel(txCtx, map[string]any{
+		models.Model{}.GetIdColumnName(): modelId,
+	}); err != nil {
+		logging.Errorf("failed to find model: %v", err)
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+modelId.String(), "model_id")
+	}
+
+	report := models.NewImportReport(opts.Mode)
+	names := map[string]int{}
+	// firmwares of the model already checked, by id
+	firmwares := map[uuid.UUID]bool{}
+	err := readImportRecords(file, models.GroupExportSchema, report, func(line int, record []string) error {
+		group := &models.Group{
+			ModelId:        &modelId,
+			Name:           strings.TrimSpace(record[0]),
+			Description:    strings.TrimSpace(record[1]),
+			DownloadPeriod: strings.TrimSpace(record[3]),
+			Status:         "ENABLE",
+			UpdatedBy:      updatedBy,
+		}
+		switch {
+		case group.Name == "":
+			report.Reject(line, group.Name, "name is required")
+			return nil
+		case len(group.Name) > 255 || len(group.Description) > 255:
+			report.Reject(line, group.Name, "name and description are at most 255 characters")
+			return nil
+		}
+		if group.DownloadPeriod == "" {
+			group.DownloadPeriod = defaultDownloadPeriod
+		}
+		if err := checkDownloadPeriod(group.DownloadPeriod); err != nil {
+			reason, _ := importRowRejection(err)
+			report.Reject(line, group.Name, reason)
+			return nil
+		}
+		if firmwareId := strings.TrimSpace(record[2]); firmwareId != "" {
+			id, err := uuid.Parse(firmwareId)
+			if err != nil {
+				report.Reject(line, group.Name, "invalid firmware id: "+firmwareId)
+				return nil
+			}
+			found, checked := firmwares[id]
+			if !checked {
+				_, err := s.store.FindFirmware(txCtx, map[string]any{
+					models.Firmware{}.GetIdColumnName():      id,
+					models.Firmware{}.GetModelIdColumnName(): modelId,
+				})
+				if err != nil && !isEntityNotExist(err) {
+					logging.Errorf("failed to find firmware %s: %v", id, err)
+					return err
+				}
+				found = err == nil
+				firmwares[id] = found
+			}
+			if !found {
+				report.Reject(line, group.Name, "firmware id="+firmwareId+" does not belong to model")
+				return nil
+			}
+			group.FirmwareId = &id
+		}
+		if first, ok := names[group.Name]; ok {
+			report.Reject(line, group.Name, "duplicate name in file, first seen on line "+strconv.Itoa(first))
+			return nil
+		}
+		names[group.Name] = line
+
+		existing, err := s.store.FindGroup(txCtx, map[string]any{
+			models.Group{}.GetNameColumnName():    group.Name,
+			models.Group{}.GetModelIdColumnName(): modelId,
+		})
+		if err != nil && !isEntityNotExist(err) {
+			logging.Errorf("failed to find group %s: %v", group.Name, err)
+			return err
+		}
+		if existing != nil {
+			if !opts.Upserts() {
+				report.Reject(line, group.Name, "group already exists with name: "+group.Name+" for model id: "+modelId.String())
+				return nil
+			}
+			if !importWritesRows(report, opts) {
+				return nil
+			}
+			updated, err := s.updateImportedGroup(txCtx, existing, group)
+			if err != nil {
+				return err
+			}
+			if !updated {
+				report.Skip()
+				return nil
+			}
+			report.Updated++
+			return nil
+		}
+		if !importWritesRows(report, opts) {
+			return nil
+		}
+		if err := s.store.InsertGroup(txCtx, group); err != nil {
+			logging.Errorf("failed to insert group %s: %v", group.Name, err)
+			return err
+		}
+		if err := s.emitDomainEvent(txCtx, models.AggregateGroup, group.Id, models.EventActionCreated, updatedBy, group); err != nil {
+			return err
+		}
+		report.Ids = append(report.Ids, group.Id.String())
+		report.Created++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := finishImport(report, opts); err != nil {
+		logging.Errorf("group import rejected, %d invalid rows", len(report.Rejected))
+		return report, err
+	}
+	return report, nil
+}
+
+// updateImportedGroup makes existing match an imported row and emits its events as an update
+// of the group does, updated is false when nothing changed.
+func (s *service) updateImportedGroup(txCtx context.Context, existing *models.Group, imported *models.Group) (bool, error) {
+	update := groupImportUpdate(existing, imported)
+	if update == nil {
+		return false, nil
+	}
+	if err := s.store.UpdateGroup(txCtx, existing.Id.String(), update); err != nil {
+		logging.Errorf("failed to update group %s: %v", existing.Name, err)
+		return false, err
+	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateGroup, existing.Id, models.EventActionUpdated, imported.UpdatedBy, update); err != nil {
+		return false, err
+	}
+	if update.FirmwareId != nil && (existing.FirmwareId == nil || *existing.FirmwareId != *update.FirmwareId) {
+		if err := s.emitDomainEvent(txCtx, models.AggregateGroup, existing.Id, models.EventActionFirmwareChanged, imported.UpdatedBy, map[string]any{
+			"id":                   existing.Id,
+			"model_id":             existing.ModelId,
+			"previous_firmware_id": existing.FirmwareId,
+			"firmware_id":          update.FirmwareId,
+		}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// groupImportUpdate returns the update that makes existing match an imported row, nil when
+// they are equal. A row without firmware keeps the firmware of the group, a soft-deleted
+// group is enabled again.
+func groupImportUpdate(existing *models.Group, imported *models.Group) *models.GroupUpdate {
+	sameFirmware := imported.FirmwareId == nil ||
+		(existing.FirmwareId != nil && *existing.FirmwareId == *imported.FirmwareId)
+	if existing.Status != "DELETE" &&
+		sameFirmware &&
+		existing.Description == imported.Description &&
+		existing.DownloadPeriod == imported.DownloadPeriod {
+		return nil
+	}
+	var status *string
+	if existing.Status == "DELETE" {
+		status = &imported.Status
+	}
+	return models.NewGroupUpdate(imported.FirmwareId, nil, status, &imported.Description, &imported.UpdatedBy, &imported.DownloadPeriod)
+}
@@ -0,0 +1,167 @@
+package managementuc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"usp-management-device-api/business/models"
+
+	"github.com/google/uuid"
+)
+
+// derefRows copies the rows of the store as the export reads them from the database
+func derefRows[T any](rows []*T) []T {
+	values := make([]T, 0, len(rows))
+	for _, r := range rows {
+		values = append(values, *r)
+	}
+	return values
+}
+
+// assertImportableColumns compares the importable columns of two exports byte for byte,
+// the read-only audit columns are written by the database
+func assertImportableColumns(t *testing.T, schema models.ExportSchema, exported [][]string, reexported [][]string) {
+	t.Helper()
+	if len(reexported) != len(exported) {
+		t.Fatalf("export again has %d records, want %d", len(reexported), len(exported))
+	}
+	for i := range exported {
+		want, got := exported[i][:len(schema.Columns)], reexported[i][:len(schema.Columns)]
+		if !slices.Equal(got, want) {
+			t.Errorf("record %d exported again as %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestModelExportRoundTrip(t *testing.T) {
+	created := time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)
+	exported := []models.Model{
+		{Name: "HGW-1", VendorName: "acme", Manufacturer: "ACME", Description: "gateway, dual band",
+			EndpointIdTemplate: models.DefaultEndpointIdTemplate, CreatedAt: &created, UpdatedBy: "admin", Status: "ENABLE"},
+		{Name: "ONT \"X\"", VendorName: "acme", Manufacturer: "ACME", EndpointIdTemplate: "os::{oui}-{serial}"},
+	}
+	records := exportCSV(t, models.ModelExportSchema.Header(), exported, modelExportRow(time.UTC))
+
+	store := &memoryImportStore{}
+	s := &service{store: store}
+	report, err := s.importModelRows(context.Background(), csvFile(records), "importer",
+		models.ImportOptions{Mode: models.ImportModeInsert, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != len(exported) {
+		t.Fatalf("created %d models, want %d", report.Created, len(exported))
+	}
+	reexported := exportCSV(t, models.ModelExportSchema.Header(), derefRows(store.models), modelExportRow(time.UTC))
+	assertImportableColumns(t, models.ModelExportSchema, records, reexported)
+
+	// the same file upserted again changes nothing
+	report, err = s.importModelRows(context.Background(), csvFile(reexported), "importer",
+		models.ImportOptions{Mode: models.ImportModeUpsert, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 0 || report.Updated != 0 || report.Skipped != len(exported) {
+		t.Errorf("upsert of the export created %d, updated %d, skipped %d, want only skipped rows",
+			report.Created, report.Updated, report.Skipped)
+	}
+}
+
+// TestFirmwareExportRoundTrip imports the firmwares of a model into another database, names
+// are unique across models so the same file cannot be upserted into a second model
+func TestFirmwareExportRoundTrip(t *testing.T) {
+	exported := []models.Firmware{
+		{Name: "hgw-1.2.0", FilePath: "firmwares/hgw-1.2.0.bin", Description: "fixes wifi, dhcp"},
+		{Name: "hgw-1.3.0", FilePath: "firmwares/hgw-1.3.0.bin"},
+	}
+	records := exportCSV(t, models.FirmwareExportSchema.Header(), exported, firmwareExportRow(time.UTC))
+
+	modelId := newRowId()
+	store := &memoryImportStore{models: []*models.Model{{Id: modelId, Name: "HGW-2"}}}
+	s := &service{store: store}
+	report, err := s.importFirmwareRows(context.Background(), csvFile(records), *modelId, "importer",
+		models.ImportOptions{Mode: models.ImportModeInsert, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != len(exported) {
+		t.Fatalf("created %d firmwares, want %d", report.Created, len(exported))
+	}
+	for _, fw := range store.firmwares {
+		if *fw.ModelId != *modelId {
+			t.Errorf("firmware %s imported into model %s, want %s", fw.Name, fw.ModelId, modelId)
+		}
+	}
+	reexported := exportCSV(t, models.FirmwareExportSchema.Header(), derefRows(store.firmwares), firmwareExportRow(time.UTC))
+	assertImportableColumns(t, models.FirmwareExportSchema, records, reexported)
+
+	report, err = s.importFirmwareRows(context.Background(), csvFile(reexported), uuid.New(), "importer",
+		models.ImportOptions{Mode: models.ImportModeUpsert, Strict: true})
+	if err == nil {
+		t.Fatalf("import into an unknown model reported %+v, want an error", report)
+	}
+
+	otherModel := newRowId()
+	store.models = append(store.models, &models.Model{Id: otherModel, Name: "HGW-3"})
+	report, err = s.importFirmwareRows(context.Background(), csvFile(reexported), *otherModel, "importer",
+		models.ImportOptions{Mode: models.ImportModeUpsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rejected) != len(exported) {
+		t.Errorf("upsert of the firmwares of another model rejected %d rows, want %d", len(report.Rejected), len(exported))
+	}
+}
+
+// TestGroupExportRoundTrip imports the groups of a model back into it, Firmware ID is a
+// firmware of the model
+func TestGroupExportRoundTrip(t *testing.T) {
+	modelId := newRowId()
+	firmware := &models.Firmware{Id: newRowId(), ModelId: modelId, Name: "hgw-1.2.0"}
+	exported := []models.Group{
+		{ModelId: modelId, Name: "pilot", Description: "night, weekdays", FirmwareId: firmware.Id, DownloadPeriod: "01:00~05:30"},
+		{ModelId: modelId, Name: "default", DownloadPeriod: defaultDownloadPeriod},
+	}
+	records := exportCSV(t, models.GroupExportSchema.Header(), exported, groupExportRow(time.UTC))
+
+	store := &memoryImportStore{
+		models:    []*models.Model{{Id: modelId, Name: "HGW-1"}},
+		firmwares: []*models.Firmware{firmware},
+	}
+	s := &service{store: store}
+	report, err := s.importGroupRows(context.Background(), csvFile(records), *modelId, "importer",
+		models.ImportOptions{Mode: models.ImportModeInsert, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != len(exported) {
+		t.Fatalf("created %d groups, want %d", report.Created, len(exported))
+	}
+	reexported := exportCSV(t, models.GroupExportSchema.Header(), derefRows(store.groups), groupExportRow(time.UTC))
+	assertImportableColumns(t, models.GroupExportSchema, records, reexported)
+
+	// an upsert without Firmware ID keeps the firmware of the group
+	update := [][]string{models.GroupExportSchema.Columns, {"pilot", "day", "", "09:00~17:00"}}
+	report, err = s.importGroupRows(context.Background(), csvFile(update), *modelId, "importer",
+		models.ImportOptions{Mode: models.ImportModeUpsert, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pilot := store.groups[0]
+	if report.Updated != 1 || pilot.Description != "day" || pilot.DownloadPeriod != "09:00~17:00" ||
+		pilot.FirmwareId == nil || *pilot.FirmwareId != *firmware.Id {
+		t.Errorf("upserted group %+v, report %+v", *pilot, *report)
+	}
+
+	// a firmware of another model is rejected
+	other := [][]string{models.GroupExportSchema.Columns, {"canary", "", uuid.NewString(), ""}}
+	report, err = s.importGroupRows(context.Background(), csvFile(other), *modelId, "importer",
+		models.ImportOptions{Mode: models.ImportModeInsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rejected) != 1 || report.Created != 0 {
+		t.Errorf("group with the firmware of another model reported %+v, want it rejected", *report)
+	}
+}
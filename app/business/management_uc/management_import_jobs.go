@@ -0,0 +1,523 @@
+package managementuc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	importJobBucket = "imports"
+	// importJobLease is extended at every checkpoint, a chunk must commit within it
+	importJobLease = 5 * time.Minute
+	// maxImportJobAttempts bounds the claims of a job that keeps crashing its worker
+	maxImportJobAttempts = 3
+	// maxImportJobErrors bounds the rejected rows kept per job, the rejected count covers all of them
+	maxImportJobErrors = 1000
+	importJobBatchSize = 100
+)
+
+// errImportJobReleased stops a worker whose job was cancelled or claimed again after its lease expired.
+var errImportJobReleased = errors.New("import job was cancelled or claimed by another worker")
+
+func (s *service) SubmitImportJob(
+	ctx context.Context,
+	job *models.ImportJob,
+	file io.Reader,
+	size int64,
+) (*models.ImportJob, error) {
+	if job.Mode == "" {
+		job.Mode = models.ImportModeInsert
+	}
+	switch job.Kind {
+	case models.ImportJobKindDevice:
+		if job.Mode != models.ImportModeInsert {
+			return nil, apperrors.NewInvalidRequestError(nil, "device import only supports mode insert", "mode")
+		}
+		if job.ModelId == nil || job.GroupId == nil {
+			return nil, apperrors.NewInvalidRequestError(nil, "model_id and group_id are required", "group_id")
+		}
+		if _, err := s.store.FindModel(ctx, map[string]any{
+			models.Model{}.GetIdColumnName(): job.ModelId,
+		}); err != nil {
+			logging.Errorf("model not found with id: %s", job.ModelId)
+			return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+job.ModelId.String(), "model_id")
+		}
+		if _, err := s.store.FindGroup(ctx, map[string]any{
+			models.Group{}.GetIdColumnName():      job.GroupId,
+			models.Group{}.GetModelIdColumnName(): job.ModelId,
+		}); err != nil {
+			logging.Errorf("group not found with id=%v for model_id=%s: %v", job.GroupId, job.ModelId, err)
+			return nil, apperrors.NewInvalidRequestError(err, "group with id: "+job.GroupId.String()+" does not belong to model with id: "+job.ModelId.String(), "group_id")
+		}
+	case models.ImportJobKindParameter:
+		// replace needs the whole file in one transaction, a job commits chunk by chunk
+		if job.Mode != models.ImportModeInsert && job.Mode != models.ImportModeUpsert {
+			return nil, apperrors.NewInvalidRequestError(nil, "import jobs only support mode insert or upsert", "mode")
+		}
+	default:
+		return nil, apperrors.NewInvalidRequestError(nil, "unknown import kind: "+job.Kind, "kind")
+	}
+
+	id := uuid.New()
+	job.Id = &id
+	job.Status = models.ImportJobStatusQueued
+	job.ObjectName = strings.ToLower(job.Kind) + "/" + id.String() + ".csv"
+	if err := s.minioStore.UploadFile(importJobBucket, job.ObjectName, file, size); err != nil {
+		logging.Errorf("failed to upload import file to MinIO: %v", err)
+		return nil, err
+	}
+	if err := s.store.InsertImportJob(ctx, job); err != nil {
+		logging.Errorf("failed to insert import job %s, object %s is orphaned: %v", id, job.ObjectName, err)
+		return nil, err
+	}
+
+	logging.Infof("Import job %s queued, kind=%s file=%s", id, job.Kind, job.FileName)
+	job.Errors = []models.ImportJobError{}
+	return job, nil
+}
+
+func (s *service) GetImportJob(
+	ctx context.Context,
+	id string,
+) (*models.ImportJob, error) {
+	job, err := s.store.FindImportJob(ctx, map[string]any{
+		models.ImportJob{}.GetIdColumnName(): id,
+	})
+	if err != nil {
+		logging.Errorf("import job not found with id=%s: %v", id, err)
+		return nil, err
+	}
+	job.Errors, err = s.store.ListImportJobErrors(ctx, id, maxImportJobErrors)
+	if err != nil {
+		logging.Errorf("failed to list errors of import job %s: %v", id, err)
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *service) CancelImportJob(
+	ctx context.Context,
+	id string,
+	updatedBy string,
+) (*models.ImportJob, error) {
+	now := time.Now()
+	cancelled := models.ImportJobStatusCancelled
+	ok, err := s.store.UpdateImportJobInStatus(ctx, id, []string{models.ImportJobStatusQueued, models.ImportJobStatusRunning}, &models.ImportJobUpdate{
+		Status:     &cancelled,
+		UpdatedBy:  &updatedBy,
+		FinishedAt: &now,
+		UpdatedAt:  &now,
+	})
+	if err != nil {
+		logging.Errorf("failed to cancel import job %s: %v", id, err)
+		return nil, err
+	}
+	if !ok {
+		job, err := s.store.FindImportJob(ctx, map[string]any{
+			models.ImportJob{}.GetIdColumnName(): id,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, apperrors.NewInvalidRequestError(nil, "import job "+id+" is "+job.Status+" and cannot be cancelled", "status")
+	}
+
+	// a running worker notices the cancel at its next checkpoint and rolls back its chunk
+	logging.Infof("Import job %s cancelled by %s", id, updatedBy)
+	return s.GetImportJob(ctx, id)
+}
+
+func (s *service) RunImportJobWorkers(
+	ctx context.Context,
+	workers int,
+	interval time.Duration,
+	chunkSize int,
+) {
+	logging.Infof("Import job workers started, workers=%d interval=%s chunk_size=%d", workers, interval, chunkSize)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, workers)
+	for {
+		select {
+		case <-ctx.Done():
+			// running jobs stop at their next chunk and are queued again
+			for range workers {
+				slots <- struct{}{}
+			}
+			logging.Infof("Import job workers stopped")
+			return
+		case <-ticker.C:
+		}
+
+		free := workers - len(slots)
+		if free == 0 {
+			continue
+		}
+		jobs, err := s.claimImportJobs(ctx, free)
+		if err != nil {
+			continue
+		}
+		for _, job := range jobs {
+			slots <- struct{}{}
+			utils.SubmitBackgroundJob(func() {
+				defer func() { <-slots }()
+				s.runImportJob(ctx, &job, chunkSize)
+			})
+		}
+	}
+}
+
+// claimImportJobs leases claimable jobs to this worker pool, attempts identifies the claim.
+func (s *service) claimImportJobs(
+	ctx context.Context,
+	limit int,
+) ([]models.ImportJob, error) {
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	jobs, err := s.store.ListClaimableImportJobs(txCtx, limit)
+	if err != nil {
+		logging.Errorf("failed to list claimable import jobs: %v", err)
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	leaseUntil := now.Add(importJobLease)
+	running := models.ImportJobStatusRunning
+	for i := range jobs {
+		jobs[i].Attempts++
+		jobs[i].Status = running
+		jobs[i].LeaseUntil = &leaseUntil
+		update := &models.ImportJobUpdate{
+			Status:     &running,
+			Attempts:   &jobs[i].Attempts,
+			LeaseUntil: &leaseUntil,
+			UpdatedAt:  &now,
+		}
+		if jobs[i].StartedAt == nil {
+			jobs[i].StartedAt = &now
+			update.StartedAt = &now
+		}
+		if _, err := s.store.UpdateImportJobInStatus(txCtx, jobs[i].Id.String(), []string{models.ImportJobStatusQueued, running}, update); err != nil {
+			logging.Errorf("failed to claim import job %s: %v", jobs[i].Id, err)
+			return nil, err
+		}
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	success = true
+	return jobs, nil
+}
+
+func (s *service) runImportJob(ctx context.Context, job *models.ImportJob, chunkSize int) {
+	if job.Attempts > maxImportJobAttempts {
+		s.finishImportJob(job, models.ImportJobStatusFailed, "gave up after "+strconv.Itoa(maxImportJobAttempts)+" attempts")
+		return
+	}
+	logging.Infof("Import job %s started, attempt %d, resuming after row %d", job.Id, job.Attempts, job.ProcessedRows)
+
+	path, err := s.downloadImportJobFile(job)
+	if err != nil {
+		s.finishImportJob(job, models.ImportJobStatusFailed, "failed to read the import file")
+		return
+	}
+	defer os.Remove(path)
+
+	err = s.processImportJob(ctx, job, path, chunkSize)
+	switch {
+	case errors.Is(err, errImportJobReleased):
+		logging.Infof("Import job %s released after row %d", job.Id, job.ProcessedRows)
+	case ctx.Err() != nil:
+		s.requeueImportJob(job)
+	case err != nil:
+		reason := err.Error()
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			reason = appErr.ErrorMessage()
+		}
+		s.finishImportJob(job, models.ImportJobStatusFailed, reason)
+	default:
+		s.finishImportJob(job, models.ImportJobStatusSucceeded, "")
+	}
+}
+
+func (s *service) downloadImportJobFile(job *models.ImportJob) (string, error) {
+	tmp, err := os.CreateTemp("", "import-job-*.csv")
+	if err != nil {
+		logging.Errorf("failed to create temp file for import job %s: %v", job.Id, err)
+		return "", err
+	}
+	path := tmp.Name()
+	tmp.Close()
+	if err := s.minioStore.DownloadFile(importJobBucket, job.ObjectName, path); err != nil {
+		logging.Errorf("failed to download import file %s: %v", job.ObjectName, err)
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// processImportJob imports the file chunk by chunk, every chunk commits with its checkpoint
+// so a job claimed again resumes after the last committed row.
+func (s *service) processImportJob(ctx context.Context, job *models.ImportJob, path string, chunkSize int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if job.TotalRows == 0 {
+		if job.TotalRows, err = countCSVRows(file); err != nil {
+			return err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	r := csv.NewReader(file)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return apperrors.NewInvalidRequestError(err, "file error", "headers")
+	}
+	// skip the rows committed by a previous attempt
+	for skipped := 0; skipped < job.ProcessedRows; skipped++ {
+		if _, err := r.Read(); err == io.EOF {
+			break
+		} else if err != nil && !isCSVParseError(err) {
+			return err
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk, done, err := readImportJobChunk(r, header, chunkSize)
+		if err != nil {
+			return err
+		}
+		if chunk.rows > 0 {
+			if err := s.commitImportJobChunk(ctx, job, chunk); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// importJobChunk is a slice of the job file rewritten as a CSV with the header,
+// lines maps the line of a row in data to its line in the file.
+type importJobChunk struct {
+	data   bytes.Buffer
+	lines  map[int]int
+	rows   int
+	errors []models.ImportJobError
+}
+
+// readImportJobChunk reads up to size rows, done is true once the file is exhausted.
+func readImportJobChunk(r *csv.Reader, header []string, size int) (*importJobChunk, bool, error) {
+	chunk := &importJobChunk{lines: map[int]int{}}
+	w := csv.NewWriter(&chunk.data)
+	if err := w.Write(header); err != nil {
+		return nil, false, err
+	}
+	w.Flush()
+	line := 2
+	for chunk.rows < size {
+		record, err := r.Read()
+		if err == io.EOF {
+			return chunk, true, nil
+		}
+		chunk.rows++
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, false, err
+			}
+			chunk.errors = append(chunk.errors, models.ImportJobError{Line: parseErr.StartLine, Reason: parseErr.Err.Error()})
+			continue
+		}
+		fileLine, _ := r.FieldPos(0)
+		chunk.lines[line] = fileLine
+		written := chunk.data.Len()
+		if err := w.Write(record); err != nil {
+			return nil, false, err
+		}
+		w.Flush()
+		line += bytes.Count(chunk.data.Bytes()[written:], []byte("\n"))
+	}
+	return chunk, false, w.Error()
+}
+
+// commitImportJobChunk imports a chunk and records its errors and the checkpoint in one transaction.
+func (s *service) commitImportJobChunk(ctx context.Context, job *models.ImportJob, chunk *importJobChunk) error {
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	report, err := s.importJobRows(txCtx, job, &chunk.data)
+	if err != nil {
+		return err
+	}
+
+	jobErrors := chunk.errors
+	for _, rejected := range report.Rejected {
+		jobErrors = append(jobErrors, models.ImportJobError{Line: chunk.lines[rejected.Line], Key: rejected.Key, Reason: rejected.Reason})
+	}
+	next := *job
+	next.ProcessedRows += chunk.rows
+	next.Created += report.Created
+	next.Updated += report.Updated
+	next.Skipped += report.Skipped
+	next.Rejected += len(jobErrors)
+	jobErrors = jobErrors[:max(0, min(len(jobErrors), maxImportJobErrors-job.Rejected))]
+	for i := range jobErrors {
+		jobErrors[i].JobId = job.Id
+	}
+	if err := s.store.InsertImportJobErrors(txCtx, jobErrors); err != nil {
+		logging.Errorf("failed to insert errors of import job %s: %v", job.Id, err)
+		return err
+	}
+
+	ok, err := s.store.UpdateRunningImportJob(txCtx, job.Id.String(), job.Attempts, models.NewImportJobCheckpoint(&next, time.Now().Add(importJobLease)))
+	if err != nil {
+		logging.Errorf("failed to checkpoint import job %s: %v", job.Id, err)
+		return err
+	}
+	if !ok {
+		return errImportJobReleased
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	success = true
+	*job = next
+	return nil
+}
+
+func (s *service) importJobRows(txCtx context.Context, job *models.ImportJob, file io.Reader) (*models.ImportReport, error) {
+	// rows are committed chunk by chunk, an invalid row is reported and the others are written
+	opts := models.ImportOptions{Mode: job.Mode}
+	switch job.Kind {
+	case models.ImportJobKindDevice:
+		return s.importDeviceRows(txCtx, file, job.UpdatedBy, importJobBatchSize, *job.ModelId, *job.GroupId, opts)
+	case models.ImportJobKindParameter:
+		return s.importParameterRows(txCtx, file, job.UpdatedBy, importJobBatchSize, opts)
+	}
+	return nil, apperrors.NewInvalidRequestError(nil, "unknown import kind: "+job.Kind, "kind")
+}
+
+// finishImportJob records the outcome of a job, it runs after ctx may be cancelled.
+func (s *service) finishImportJob(job *models.ImportJob, status string, lastError string) {
+	now := time.Now()
+	update := models.NewImportJobCheckpoint(job, now)
+	update.Status = &status
+	update.FinishedAt = &now
+	if lastError != "" {
+		update.LastError = &lastError
+	}
+	if _, err := s.store.UpdateRunningImportJob(context.Background(), job.Id.String(), job.Attempts, update); err != nil {
+		logging.Errorf("failed to finish import job %s: %v", job.Id, err)
+		return
+	}
+	logging.Infof("Import job %s %s: %d rows, %d created, %d updated, %d rejected",
+		job.Id, status, job.ProcessedRows, job.Created, job.Updated, job.Rejected)
+}
+
+// requeueImportJob hands a job back on shutdown, it resumes from its checkpoint.
+func (s *service) requeueImportJob(job *models.ImportJob) {
+	queued := models.ImportJobStatusQueued
+	// a shutdown does not count as a failed attempt
+	attempts := job.Attempts - 1
+	update := models.NewImportJobCheckpoint(job, time.Now())
+	update.Status = &queued
+	update.Attempts = &attempts
+	if _, err := s.store.UpdateRunningImportJob(context.Background(), job.Id.String(), job.Attempts, update); err != nil {
+		logging.Errorf("failed to requeue import job %s: %v", job.Id, err)
+		return
+	}
+	logging.Infof("Import job %s queued again after row %d", job.Id, job.ProcessedRows)
+}
+
+// countCSVRows counts the rows after the header, rows the CSV reader rejects included.
+func countCSVRows(file io.Reader) (int, error) {
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	if _, err := r.Read(); err != nil {
+		if err == io.EOF {
+			return 0, nil
+		}
+		if !isCSVParseError(err) {
+			return 0, err
+		}
+	}
+	rows := 0
+	for {
+		_, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil && !isCSVParseError(err) {
+			return 0, err
+		}
+		rows++
+	}
+}
+
+func isCSVParseError(err error) bool {
+	var parseErr *csv.ParseError
+	return errors.As(err, &parseErr)
+}
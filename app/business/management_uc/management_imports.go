@@ -0,0 +1,227 @@
+package managementuc
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+
+	"github.com/lib/pq"
+)
+
+// importWritesRows reports whether valid rows are still written,
+// a strict import stops writing at the first rejected row unless it is a dry run.
+func importWritesRows(report *models.ImportReport, opts models.ImportOptions) bool {
+	return opts.DryRun || !opts.Strict || len(report.Rejected) == 0
+}
+
+// finishImport fails a strict import that has rejected rows, a dry run reports them instead.
+func finishImport(report *models.ImportReport, opts models.ImportOptions) error {
+	// rows checked against the database per batch are rejected after the rows read later
+	sort.SliceStable(report.Rejected, func(i, j int) bool {
+		return report.Rejected[i].Line < report.Rejected[j].Line
+	})
+	if opts.DryRun || !opts.Strict || len(report.Rejected) == 0 {
+		return nil
+	}
+	report.Created = 0
+	report.Updated = 0
+	report.Deleted = 0
+	report.Ids = []string{}
+	return apperrors.NewInvalidRequestError(nil,
+		"import rejected, "+strconv.Itoa(len(report.Rejected))+" invalid rows", "file")
+}
+
+// importKeySet indexes the keys an import batch found in the database.
+func importKeySet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set
+}
+
+// importRowRejection returns the message of a validation error so the row can be reported,
+// ok is false for errors that must abort the import.
+func importRowRejection(err error) (string, bool) {
+	if appErr, ok := err.(*apperrors.AppError); ok && appErr.IsErrorKey(apperrors.ErrInvalidRequest) {
+		return appErr.ErrorMessage(), true
+	}
+	return "", false
+}
+
+// isHexMacAddress reports whether mac is 12 lowercase hexadecimal characters.
+func isHexMacAddress(mac string) bool {
+	if len(mac) != 12 {
+		return false
+	}
+	for _, char := range mac {
+		if !((char >= '0' && char <= '9') || (char >= 'a' && char <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+// parameterImportUpdate returns the update that makes existing match an imported row,
+// nil when they are equal. A soft-deleted parameter is enabled again.
+func parameterImportUpdate(existing *models.Parameter, imported *models.Parameter) *models.ParameterUpdate {
+	if existing.DataType == imported.DataType && existing.Description == imported.Description && existing.Status != "DELETE" {
+		return nil
+	}
+	var status *string
+	if existing.Status == "DELETE" {
+		status = &imported.Status
+	}
+	return models.NewParameterUpdate(nil, &imported.DataType, &imported.Description, status, &imported.UpdatedBy)
+}
+
+// hasExportColumns reports whether a CSV header starts with the columns of schema,
+// the read-only columns of an export may follow them
+func hasExportColumns(headers []string, schema models.ExportSchema) bool {
+	return len(headers) >= len(schema.Columns) && slices.Equal(headers[:len(schema.Columns)], schema.Columns)
+}
+
+// profileImportMember is a parameter of a profile CSV row, by id in the legacy header
+// and by path in the export schema
+type profileImportMember struct {
+	id           string
+	path         string
+	defaultValue string
+	required     bool
+}
+
+// importedProfileParameter is a parameter found for a profile row, keepValues leaves the
+// default value and required flag of an existing link as they are
+type importedProfileParameter struct {
+	parameter    *models.Parameter
+	defaultValue string
+	required     bool
+	keepValues   bool
+}
+
+// updateImportedProfile makes existing match an imported row and its parameters,
+// updated is false when nothing changed. A soft-deleted profile is enabled again.
+func (s *service) updateImportedProfile(
+	txCtx context.Context,
+	existing *models.Profile,
+	imported *models.Profile,
+	parameters []importedProfileParameter,
+) (bool, error) {
+	update := profileImportUpdate(existing, imported)
+	if update != nil {
+		if err := s.store.UpdateProfile(txCtx, existing.Id.String(), update); err != nil {
+			logging.Errorf("failed to update profile %s: %v", existing.Name, err)
+			return false, err
+		}
+	}
+
+	profileParameters, err := s.store.ListProfileParameter(txCtx, map[string]any{
+		models.ProfileParameter{}.GetProfileIdColumnName(): existing.Id,
+	})
+	if err != nil {
+		logging.Errorf("failed to list profile parameters: %v", err)
+		return false, err
+	}
+	wanted := make(map[string]importedProfileParameter, len(parameters))
+	for _, parameter := range parameters {
+		wanted[parameter.parameter.Id.String()] = parameter
+	}
+	current := make(map[string]bool, len(profileParameters))
+	parametersChanged := false
+	for _, pp := range profileParameters {
+		current[pp.ParameterId.String()] = true
+		parameter, ok := wanted[pp.ParameterId.String()]
+		if ok {
+			if parameter.keepValues || (pp.DefaultValue == parameter.defaultValue && pp.Required == parameter.required) {
+				continue
+			}
+			ppUpdate := models.NewProfileParameterUpdate(&parameter.defaultValue, &parameter.required)
+			ppUpdate.UpdatedBy = &imported.UpdatedBy
+			if err := s.store.UpdateProfileParameter(txCtx, pp.Id.String(), ppUpdate); err != nil {
+				logging.Errorf("failed to update profile parameter: %v", err)
+				return false, err
+			}
+			parametersChanged = true
+			continue
+		}
+		if err := s.store.DeleteProfileParameter(txCtx, pp.Id.String()); err != nil {
+			logging.Errorf("failed to delete profile parameter: %v", err)
+			return false, err
+		}
+		parametersChanged = true
+	}
+	for _, parameter := range parameters {
+		if current[parameter.parameter.Id.String()] {
+			continue
+		}
+		pp := &models.ProfileParameter{
+			ProfileId:    existing.Id,
+			ParameterId:  parameter.parameter.Id,
+			DefaultValue: parameter.defaultValue,
+			Required:     parameter.required,
+			UpdatedBy:    imported.UpdatedBy,
+		}
+		if err := s.store.InsertProfileParameter(txCtx, pp); err != nil {
+			logging.Errorf("failed to insert profile parameter: %v", err)
+			return false, err
+		}
+		parametersChanged = true
+	}
+
+	if update == nil && !parametersChanged {
+		return false, nil
+	}
+	if update == nil {
+		update = models.NewProfileUpdate()
+		update.UpdatedBy = &imported.UpdatedBy
+	}
+	update.Parameters = make([]models.ParameterRef, 0, len(parameters))
+	for _, parameter := range parameters {
+		update.Parameters = append(update.Parameters, models.ParameterRef{Id: parameter.parameter.Id.String()})
+	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateProfile, existing.Id, models.EventActionUpdated, imported.UpdatedBy, update); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// profileImportUpdate returns the update that makes the columns of existing match an imported row,
+// nil when they are equal.
+func profileImportUpdate(existing *models.Profile, imported *models.Profile) *models.ProfileUpdate {
+	if existing.Status != "DELETE" &&
+		existing.MsgType == imported.MsgType &&
+		existing.MaxDepth == imported.MaxDepth &&
+		slices.Equal(existing.Tags, imported.Tags) &&
+		existing.AllowPartial == imported.AllowPartial &&
+		existing.FirstLevelOnly == imported.FirstLevelOnly &&
+		existing.ReturnCommands == imported.ReturnCommands &&
+		existing.ReturnEvents == imported.ReturnEvents &&
+		existing.ReturnParams == imported.ReturnParams &&
+		existing.ReturnUniqueKeySets == imported.ReturnUniqueKeySets &&
+		existing.SendResp == imported.SendResp &&
+		existing.Description == imported.Description {
+		return nil
+	}
+	update := models.NewProfileUpdate()
+	update.MsgType = &imported.MsgType
+	update.MaxDepth = &imported.MaxDepth
+	// a non-nil empty array clears the tags
+	update.Tags = append(pq.StringArray{}, imported.Tags...)
+	update.AllowPartial = &imported.AllowPartial
+	update.FirstLevelOnly = &imported.FirstLevelOnly
+	update.ReturnCommands = &imported.ReturnCommands
+	update.ReturnEvents = &imported.ReturnEvents
+	update.ReturnParams = &imported.ReturnParams
+	update.ReturnUniqueKeySets = &imported.ReturnUniqueKeySets
+	update.SendResp = &imported.SendResp
+	update.Description = &imported.Description
+	update.UpdatedBy = &imported.UpdatedBy
+	if existing.Status == "DELETE" {
+		update.Status = &imported.Status
+	}
+	return update
+}
@@ -0,0 +1,157 @@
+//go:build integration
+
+package managementuc
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"usp-management-device-api/business/models"
+	uspstore "usp-management-device-api/infras/sql_store"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// The benchmarks of this file run the imports and the per-row imports they replaced against
+// the CockroachDB or PostgreSQL database of USP_BENCH_SQL_DSN, which must have the schema of
+// the service:
+//
+//	USP_BENCH_SQL_DSN="host=localhost port=26257 user=root dbname=usp_system_db sslmode=disable" \
+//		go test -tags integration -run '^$' -bench DB ./business/management_uc/
+//
+// Every import runs in a transaction that is rolled back, the database is left as it was.
+
+func benchDBStore(b *testing.B) iUSPStoreRepository {
+	dsn := os.Getenv("USP_BENCH_SQL_DSN")
+	if dsn == "" {
+		b.Skip("USP_BENCH_SQL_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		PrepareStmt:            true,
+		TranslateError:         true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return uspstore.NewStore(db)
+}
+
+// benchPrefix returns 6 hex digits, unique per run, used as the OUI of the imported devices
+// and in the names of the imported rows
+func benchPrefix() string {
+	return strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:6])
+}
+
+// benchDBImport runs importRows in a transaction rolled back after it, seed writes the rows
+// the import needs in the same transaction and is not timed
+func benchDBImport(
+	b *testing.B,
+	store iUSPStoreRepository,
+	seed func(txCtx context.Context, prefix string) error,
+	importRows func(txCtx context.Context, prefix string) (*models.ImportReport, error),
+	rows int,
+) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		txCtx, err := store.BeginTx(context.Background())
+		if err != nil {
+			b.Fatal(err)
+		}
+		prefix := benchPrefix()
+		if err := seed(txCtx, prefix); err != nil {
+			store.RollbackTx(txCtx)
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		report, err := importRows(txCtx, prefix)
+		b.StopTimer()
+		store.RollbackTx(txCtx)
+		if err != nil || report.Created != rows {
+			b.Fatalf("import reported %+v, %v", report, err)
+		}
+		b.StartTimer()
+	}
+	b.ReportMetric(float64(rows*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func BenchmarkImportDeviceRowsDB(b *testing.B) {
+	const rows, batchSize = 1000, 100
+	store := benchDBStore(b)
+	s := newImportService(store)
+	var modelId, groupId uuid.UUID
+	seed := func(txCtx context.Context, prefix string) error {
+		model := &models.Model{Name: "bench-" + prefix, VendorName: "bench", Manufacturer: "bench-" + prefix, Status: "ENABLE"}
+		if err := store.InsertModel(txCtx, model); err != nil {
+			return err
+		}
+		group := &models.Group{ModelId: model.Id, Name: "bench", Status: "ENABLE", DownloadPeriod: defaultDownloadPeriod}
+		if err := store.InsertGroup(txCtx, group); err != nil {
+			return err
+		}
+		modelId, groupId = *model.Id, *group.Id
+		return nil
+	}
+
+	b.Run("prefetch_per_batch", func(b *testing.B) {
+		benchDBImport(b, store, seed, func(txCtx context.Context, prefix string) (*models.ImportReport, error) {
+			return s.importDeviceRows(txCtx, strings.NewReader(deviceImportCSV(prefix, rows)),
+				"bench", batchSize, modelId, groupId, models.ImportOptions{})
+		}, rows)
+	})
+	b.Run("find_per_row", func(b *testing.B) {
+		benchDBImport(b, store, seed, func(txCtx context.Context, prefix string) (*models.ImportReport, error) {
+			return s.perRowImportDeviceRows(txCtx, strings.NewReader(deviceImportCSV(prefix, rows)),
+				"bench", batchSize, modelId, groupId, models.ImportOptions{})
+		}, rows)
+	})
+}
+
+func BenchmarkImportParameterRowsDB(b *testing.B) {
+	const rows, batchSize = 1000, 100
+	store := benchDBStore(b)
+	s := newImportService(store)
+	opts := models.ImportOptions{Mode: models.ImportModeInsert, Strict: true}
+	noSeed := func(txCtx context.Context, prefix string) error { return nil }
+
+	b.Run("prefetch_per_batch", func(b *testing.B) {
+		benchDBImport(b, store, noSeed, func(txCtx context.Context, prefix string) (*models.ImportReport, error) {
+			return s.importParameterRows(txCtx, strings.NewReader(parameterImportCSV("Bench"+prefix, rows)), "bench", batchSize, opts)
+		}, rows)
+	})
+	b.Run("find_per_row", func(b *testing.B) {
+		benchDBImport(b, store, noSeed, func(txCtx context.Context, prefix string) (*models.ImportReport, error) {
+			return s.perRowImportParameterRows(txCtx, strings.NewReader(parameterImportCSV("Bench"+prefix, rows)), "bench", batchSize, opts)
+		}, rows)
+	})
+}
+
+func BenchmarkImportProfileRowsDB(b *testing.B) {
+	const rows, members, parameters = 200, 5, 500
+	store := benchDBStore(b)
+	s := newImportService(store)
+	opts := models.ImportOptions{Mode: models.ImportModeInsert, Strict: true}
+	seed := func(txCtx context.Context, prefix string) error {
+		batch := make([]*models.Parameter, 0, parameters)
+		for i := 0; i < parameters; i++ {
+			batch = append(batch, &models.Parameter{
+				Path: benchParameterPath("Bench"+prefix, i), DataType: "string", Status: "ENABLE", UpdatedBy: "bench",
+			})
+		}
+		return store.InsertParametersBatch(txCtx, batch)
+	}
+
+	b.Run("prefetch_per_batch", func(b *testing.B) {
+		benchDBImport(b, store, seed, func(txCtx context.Context, prefix string) (*models.ImportReport, error) {
+			return s.importProfileRows(txCtx, strings.NewReader(profileImportCSV("Bench"+prefix, rows, members, parameters)), "bench", opts)
+		}, rows)
+	})
+	b.Run("find_per_row", func(b *testing.B) {
+		benchDBImport(b, store, seed, func(txCtx context.Context, prefix string) (*models.ImportReport, error) {
+			return s.perRowImportProfileRows(txCtx, strings.NewReader(profileImportCSV("Bench"+prefix, rows, members, parameters)), "bench", opts)
+		}, rows)
+	})
+}
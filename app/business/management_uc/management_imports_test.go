@@ -0,0 +1,1210 @@
+package managementuc
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+
+	"github.com/google/uuid"
+)
+
+// importRoundTrip is the simulated latency of one query of the fake store, close to a
+// round trip to a CockroachDB node in the same region
+const importRoundTrip = 200 * time.Microsecond
+
+// memoryImportStore keeps the rows the imports write so that they can be read back and
+// exported again. A Find matches the columns of its condition; every query is counted and
+// costs latency, reads and writes alike. The store methods the imports do not call are left
+// nil and panic when called.
+type memoryImportStore struct {
+	iUSPStoreRepository
+	latency time.Duration
+	queries map[string]int
+
+	models            []*models.Model
+	firmwares         []*models.Firmware
+	groups            []*models.Group
+	parameters        []*models.Parameter
+	profiles          []*models.Profile
+	profileParameters []*models.ProfileParameter
+	devices           []*models.Device
+	events            []*models.OutboxEvent
+}
+
+// query counts a query of the store and waits for its round trip
+func (f *memoryImportStore) query(method string) {
+	if f.queries == nil {
+		f.queries = map[string]int{}
+	}
+	f.queries[method]++
+	time.Sleep(f.latency)
+}
+
+func (f *memoryImportStore) totalQueries() int {
+	total := 0
+	for _, n := range f.queries {
+		total += n
+	}
+	return total
+}
+
+// conditionMatches reports whether the columns of a row have the values of condition, a
+// []string matches any of its values as the IN of the store does. Ids are compared as
+// strings whether they are passed as uuid.UUID, *uuid.UUID or string
+func conditionMatches(condition map[string]any, columns map[string]any) bool {
+	for column, want := range condition {
+		got, ok := columns[column]
+		if !ok {
+			return false
+		}
+		if values, in := want.([]string); in {
+			if !slices.Contains(values, conditionValue(got)) {
+				return false
+			}
+			continue
+		}
+		if conditionValue(got) != conditionValue(want) {
+			return false
+		}
+	}
+	return true
+}
+
+func conditionValue(v any) string {
+	switch v := v.(type) {
+	case *uuid.UUID:
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// findRows returns the rows whose columns match condition
+func findRows[T any](rows []*T, condition map[string]any, columns func(*T) map[string]any) []*T {
+	var found []*T
+	for _, row := range rows {
+		if conditionMatches(condition, columns(row)) {
+			found = append(found, row)
+		}
+	}
+	return found
+}
+
+func findRow[T any](rows []*T, condition map[string]any, columns func(*T) map[string]any) *T {
+	if found := findRows(rows, condition, columns); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+func modelColumns(m *models.Model) map[string]any {
+	return map[string]any{"id": m.Id, "name": m.Name}
+}
+
+func firmwareColumns(fw *models.Firmware) map[string]any {
+	return map[string]any{"id": fw.Id, "name": fw.Name, "model_id": fw.ModelId}
+}
+
+func groupColumns(g *models.Group) map[string]any {
+	return map[string]any{"id": g.Id, "name": g.Name, "model_id": g.ModelId}
+}
+
+func parameterColumns(p *models.Parameter) map[string]any {
+	return map[string]any{"id": p.Id, "path": p.Path, "status": p.Status}
+}
+
+func profileColumns(p *models.Profile) map[string]any {
+	return map[string]any{"id": p.Id, "name": p.Name, "status": p.Status}
+}
+
+func profileParameterColumns(pp *models.ProfileParameter) map[string]any {
+	return map[string]any{"id": pp.Id, "profile_id": pp.ProfileId}
+}
+
+func deviceColumns(d *models.Device) map[string]any {
+	return map[string]any{"id": d.Id, "mac_address": d.MacAddress, "serial_number": d.SerialNumber}
+}
+
+func newRowId() *uuid.UUID {
+	id := uuid.New()
+	return &id
+}
+
+func setIfNotNil[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+func (f *memoryImportStore) BeginTx(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (f *memoryImportStore) CommitTx(ctx context.Context) error {
+	return nil
+}
+
+func (f *memoryImportStore) RollbackTx(ctx context.Context) error {
+	return nil
+}
+
+func (f *memoryImportStore) FindModel(ctx context.Context, condition map[string]interface{}, moreKeys ...string) (*models.Model, error) {
+	f.query("FindModel")
+	if m := findRow(f.models, condition, modelColumns); m != nil {
+		return m, nil
+	}
+	return nil, apperrors.NewErrEntityNotExist(models.USPModedlEntityName)
+}
+
+func (f *memoryImportStore) InsertModel(ctx context.Context, model *models.Model) error {
+	f.query("InsertModel")
+	model.Id = newRowId()
+	f.models = append(f.models, model)
+	return nil
+}
+
+func (f *memoryImportStore) UpdateModel(ctx context.Context, id string, update *models.ModelUpdate) error {
+	f.query("UpdateModel")
+	m := findRow(f.models, map[string]any{"id": id}, modelColumns)
+	if m == nil {
+		return apperrors.NewErrEntityNotExist(models.USPModedlEntityName)
+	}
+	setIfNotNil(&m.VendorName, update.VendorName)
+	setIfNotNil(&m.Manufacturer, update.Manufacturer)
+	setIfNotNil(&m.Status, update.Status)
+	setIfNotNil(&m.Description, update.Description)
+	setIfNotNil(&m.UpdatedBy, update.UpdatedBy)
+	setIfNotNil(&m.EndpointIdTemplate, update.EndpointIdTemplate)
+	return nil
+}
+
+func (f *memoryImportStore) FindFirmware(ctx context.Context, condition map[string]interface{}, moreKeys ...string) (*models.Firmware, error) {
+	f.query("FindFirmware")
+	if fw := findRow(f.firmwares, condition, firmwareColumns); fw != nil {
+		return fw, nil
+	}
+	return nil, apperrors.NewErrEntityNotExist(models.USPFirmwareEntityName)
+}
+
+func (f *memoryImportStore) InsertFirmware(ctx context.Context, firmware *models.Firmware) error {
+	f.query("InsertFirmware")
+	firmware.Id = newRowId()
+	f.firmwares = append(f.firmwares, firmware)
+	return nil
+}
+
+func (f *memoryImportStore) UpdateFirmware(ctx context.Context, id string, update *models.FirmwareUpdate) error {
+	f.query("UpdateFirmware")
+	fw := findRow(f.firmwares, map[string]any{"id": id}, firmwareColumns)
+	if fw == nil {
+		return apperrors.NewErrEntityNotExist(models.USPFirmwareEntityName)
+	}
+	setIfNotNil(&fw.FilePath, update.FilePath)
+	setIfNotNil(&fw.Status, update.Status)
+	setIfNotNil(&fw.Description, update.Description)
+	setIfNotNil(&fw.UpdatedBy, update.UpdatedBy)
+	return nil
+}
+
+func (f *memoryImportStore) FindGroup(ctx context.Context, condition map[string]interface{}, moreKeys ...string) (*models.Group, error) {
+	f.query("FindGroup")
+	if g := findRow(f.groups, condition, groupColumns); g != nil {
+		return g, nil
+	}
+	return nil, apperrors.NewErrEntityNotExist(models.USPGroupEntityName)
+}
+
+func (f *memoryImportStore) InsertGroup(ctx context.Context, group *models.Group) error {
+	f.query("InsertGroup")
+	group.Id = newRowId()
+	f.groups = append(f.groups, group)
+	return nil
+}
+
+func (f *memoryImportStore) UpdateGroup(ctx context.Context, id string, update *models.GroupUpdate) error {
+	f.query("UpdateGroup")
+	g := findRow(f.groups, map[string]any{"id": id}, groupColumns)
+	if g == nil {
+		return apperrors.NewErrEntityNotExist(models.USPGroupEntityName)
+	}
+	if update.FirmwareId != nil {
+		g.FirmwareId = update.FirmwareId
+	}
+	setIfNotNil(&g.Status, update.Status)
+	setIfNotNil(&g.Description, update.Description)
+	setIfNotNil(&g.UpdatedBy, update.UpdatedBy)
+	setIfNotNil(&g.DownloadPeriod, update.DownloadPeriod)
+	return nil
+}
+
+func (f *memoryImportStore) FindParameter(ctx context.Context, condition map[string]interface{}, moreKeys ...string) (*models.Parameter, error) {
+	f.query("FindParameter")
+	if p := findRow(f.parameters, condition, parameterColumns); p != nil {
+		return p, nil
+	}
+	return nil, apperrors.NewErrEntityNotExist(models.USPParameterEntityName)
+}
+
+func (f *memoryImportStore) ListTotalParameters(ctx context.Context, condition map[string]any) ([]models.Parameter, error) {
+	f.query("ListTotalParameters")
+	return derefRows(findRows(f.parameters, condition, parameterColumns)), nil
+}
+
+func (f *memoryImportStore) InsertParametersBatch(ctx context.Context, parameters []*models.Parameter) error {
+	f.query("InsertParametersBatch")
+	for _, p := range parameters {
+		p.Id = newRowId()
+		f.parameters = append(f.parameters, p)
+	}
+	return nil
+}
+
+func (f *memoryImportStore) UpdateParameter(ctx context.Context, id string, update *models.ParameterUpdate) error {
+	f.query("UpdateParameter")
+	p := findRow(f.parameters, map[string]any{"id": id}, parameterColumns)
+	if p == nil {
+		return apperrors.NewErrEntityNotExist(models.USPParameterEntityName)
+	}
+	setIfNotNil(&p.DataType, update.DataType)
+	setIfNotNil(&p.Description, update.Description)
+	setIfNotNil(&p.Status, update.Status)
+	setIfNotNil(&p.UpdatedBy, update.UpdatedBy)
+	return nil
+}
+
+func (f *memoryImportStore) FindProfile(ctx context.Context, condition map[string]interface{}, moreKeys ...string) (*models.Profile, error) {
+	f.query("FindProfile")
+	if p := findRow(f.profiles, condition, profileColumns); p != nil {
+		profile := *p
+		return &profile, nil
+	}
+	return nil, apperrors.NewErrEntityNotExist(models.USPProfileEntityName)
+}
+
+func (f *memoryImportStore) ListProfiles(ctx context.Context, condition map[string]any, opts models.QueryOptions) ([]models.Profile, error) {
+	f.query("ListProfiles")
+	return derefRows(findRows(f.profiles, condition, profileColumns)), nil
+}
+
+func (f *memoryImportStore) InsertProfile(ctx context.Context, profile *models.Profile) error {
+	f.query("InsertProfile")
+	profile.Id = newRowId()
+	f.profiles = append(f.profiles, profile)
+	return nil
+}
+
+func (f *memoryImportStore) UpdateProfile(ctx context.Context, id string, update *models.ProfileUpdate) error {
+	f.query("UpdateProfile")
+	p := findRow(f.profiles, map[string]any{"id": id}, profileColumns)
+	if p == nil {
+		return apperrors.NewErrEntityNotExist(models.USPProfileEntityName)
+	}
+	setIfNotNil(&p.MsgType, update.MsgType)
+	setIfNotNil(&p.MaxDepth, update.MaxDepth)
+	if update.Tags != nil {
+		p.Tags = update.Tags
+	}
+	setIfNotNil(&p.AllowPartial, update.AllowPartial)
+	setIfNotNil(&p.FirstLevelOnly, update.FirstLevelOnly)
+	setIfNotNil(&p.ReturnCommands, update.ReturnCommands)
+	setIfNotNil(&p.ReturnEvents, update.ReturnEvents)
+	setIfNotNil(&p.ReturnParams, update.ReturnParams)
+	setIfNotNil(&p.ReturnUniqueKeySets, update.ReturnUniqueKeySets)
+	setIfNotNil(&p.SendResp, update.SendResp)
+	setIfNotNil(&p.Description, update.Description)
+	setIfNotNil(&p.Status, update.Status)
+	setIfNotNil(&p.UpdatedBy, update.UpdatedBy)
+	return nil
+}
+
+func (f *memoryImportStore) ListProfileParameter(ctx context.Context, condition map[string]any) ([]models.ProfileParameter, error) {
+	f.query("ListProfileParameter")
+	return derefRows(findRows(f.profileParameters, condition, profileParameterColumns)), nil
+}
+
+func (f *memoryImportStore) InsertProfileParameter(ctx context.Context, profileParameter *models.ProfileParameter) error {
+	f.query("InsertProfileParameter")
+	profileParameter.Id = newRowId()
+	f.profileParameters = append(f.profileParameters, profileParameter)
+	return nil
+}
+
+func (f *memoryImportStore) UpdateProfileParameter(ctx context.Context, id string, update *models.ProfileParameterUpdate) error {
+	f.query("UpdateProfileParameter")
+	pp := findRow(f.profileParameters, map[string]any{"id": id}, profileParameterColumns)
+	if pp == nil {
+		return apperrors.NewErrEntityNotExist(models.USPProfileParameterEntityName)
+	}
+	setIfNotNil(&pp.DefaultValue, update.DefaultValue)
+	setIfNotNil(&pp.Required, update.Required)
+	setIfNotNil(&pp.UpdatedBy, update.UpdatedBy)
+	return nil
+}
+
+func (f *memoryImportStore) DeleteProfileParameter(ctx context.Context, id string) error {
+	f.query("DeleteProfileParameter")
+	f.profileParameters = slices.DeleteFunc(f.profileParameters, func(pp *models.ProfileParameter) bool {
+		return pp.Id.String() == id
+	})
+	return nil
+}
+
+// exportedProfiles returns the profiles with their parameters as the export preloads them
+func (f *memoryImportStore) exportedProfiles() []models.Profile {
+	profiles := make([]models.Profile, 0, len(f.profiles))
+	for _, p := range f.profiles {
+		profile := *p
+		profile.ProfileParameters = nil
+		for _, pp := range findRows(f.profileParameters, map[string]any{"profile_id": p.Id}, profileParameterColumns) {
+			link := *pp
+			link.Parameter = findRow(f.parameters, map[string]any{"id": pp.ParameterId}, parameterColumns)
+			profile.ProfileParameters = append(profile.ProfileParameters, &link)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+func (f *memoryImportStore) FindDevice(ctx context.Context, condition map[string]any, moreKeys ...string) (*models.Device, error) {
+	f.query("FindDevice")
+	if d := findRow(f.devices, condition, deviceColumns); d != nil {
+		return d, nil
+	}
+	return nil, apperrors.NewErrEntityNotExist(models.USPDeviceEntityName)
+}
+
+// LookupDevices finds devices by serial number, the store holds the devices of one manufacturer
+func (f *memoryImportStore) LookupDevices(ctx context.Context, lookup models.DeviceLookup) ([]models.Device, error) {
+	f.query("LookupDevices")
+	return derefRows(findRows(f.devices, map[string]any{"serial_number": lookup.SerialNumber}, deviceColumns)), nil
+}
+
+func (f *memoryImportStore) ListDeviceMacAddresses(ctx context.Context, macAddresses []string) ([]string, error) {
+	f.query("ListDeviceMacAddresses")
+	var found []string
+	for _, d := range findRows(f.devices, map[string]any{"mac_address": macAddresses}, deviceColumns) {
+		found = append(found, d.MacAddress)
+	}
+	return found, nil
+}
+
+func (f *memoryImportStore) ListDeviceSerialNumbers(ctx context.Context, manufacturer string, serialNumbers []string) ([]string, error) {
+	f.query("ListDeviceSerialNumbers")
+	var found []string
+	for _, d := range findRows(f.devices, map[string]any{"serial_number": serialNumbers}, deviceColumns) {
+		found = append(found, d.SerialNumber)
+	}
+	return found, nil
+}
+
+func (f *memoryImportStore) InsertDevicesBatch(ctx context.Context, devices []*models.Device) error {
+	f.query("InsertDevicesBatch")
+	for _, d := range devices {
+		d.Id = newRowId()
+		f.devices = append(f.devices, d)
+	}
+	return nil
+}
+
+func (f *memoryImportStore) NextOutboxAggregateVersion(ctx context.Context, aggregateId uuid.UUID) (int64, error) {
+	f.query("NextOutboxAggregateVersion")
+	version := int64(1)
+	for _, e := range f.events {
+		if e.AggregateId != nil && *e.AggregateId == aggregateId {
+			version++
+		}
+	}
+	return version, nil
+}
+
+func (f *memoryImportStore) InsertOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	f.query("InsertOutboxEvent")
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *memoryImportStore) ListWebhookSubscriptionsForEvent(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	f.query("ListWebhookSubscriptionsForEvent")
+	return nil, nil
+}
+
+// newDeviceImportStore returns a store holding a model and its group, the devices are imported into them
+func newDeviceImportStore(latency time.Duration) (*memoryImportStore, uuid.UUID, uuid.UUID) {
+	modelId, groupId := newRowId(), newRowId()
+	store := &memoryImportStore{
+		latency: latency,
+		models:  []*models.Model{{Id: modelId, Name: "HGW-1", Manufacturer: "acme"}},
+		groups:  []*models.Group{{Id: groupId, ModelId: modelId, Name: "default"}},
+	}
+	return store, *modelId, *groupId
+}
+
+// newProfileImportStore returns a store holding the parameters of profileImportCSV
+func newProfileImportStore(latency time.Duration, prefix string, parameters int) *memoryImportStore {
+	store := &memoryImportStore{latency: latency}
+	for i := 0; i < parameters; i++ {
+		store.parameters = append(store.parameters, &models.Parameter{
+			Id: newRowId(), Path: benchParameterPath(prefix, i), DataType: "string", Status: "ENABLE",
+		})
+	}
+	return store
+}
+
+// deviceImportCSV returns rows devices whose MAC addresses start with oui
+func deviceImportCSV(oui string, rows int) string {
+	var b strings.Builder
+	b.WriteString("MAC Address,Serial Number\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, "%s%06X,SN%s%06d\n", oui, i, oui, i)
+	}
+	return b.String()
+}
+
+func benchParameterPath(prefix string, i int) string {
+	return fmt.Sprintf("Device.%s.%d.Value", prefix, i)
+}
+
+func parameterImportCSV(prefix string, rows int) string {
+	var b strings.Builder
+	b.WriteString("Path,Data Type,Description\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, "%s,string,row %d\n", benchParameterPath(prefix, i), i)
+	}
+	return b.String()
+}
+
+// profileImportCSV returns rows profiles in the export schema, each with members parameters
+// of newProfileImportStore found by path
+func profileImportCSV(prefix string, rows int, members int, parameters int) string {
+	records := [][]string{models.ProfileExportSchema.Columns}
+	for i := 0; i < rows; i++ {
+		var cell strings.Builder
+		cell.WriteString("[")
+		for j := 0; j < members; j++ {
+			if j > 0 {
+				cell.WriteString(",")
+			}
+			fmt.Fprintf(&cell, `{"path":%q,"default_value":"%d","required":true}`,
+				benchParameterPath(prefix, (i*members+j)%parameters), j)
+		}
+		cell.WriteString("]")
+		records = append(records, []string{
+			fmt.Sprintf("%s-%d", prefix, i), "1", "bench", "0",
+			"false", "false", "false", "false", "true", "false", "true", "row " + strconv.Itoa(i), cell.String(),
+		})
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.WriteAll(records); err != nil {
+		panic(err)
+	}
+	return b.String()
+}
+
+// perRowImportDeviceRows is importDeviceRows as it was before the existing keys were
+// prefetched per batch: every row makes a FindDevice by mac address and a LookupDevices by
+// serial number, the devices are still inserted per batch with one event each.
+func (s *service) perRowImportDeviceRows(
+	txCtx context.Context,
+	file io.Reader,
+	updatedBy string,
+	batchSize int,
+	modelID uuid.UUID,
+	groupID uuid.UUID,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	txCtx = withWebhookSubscriptions(txCtx)
+	report := models.NewImportReport(models.ImportModeInsert)
+	r := csv.NewReader(file)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	headers, err := r.Read()
+	if err != nil {
+		return nil, apperrors.NewInvalidRequestError(err, "file error", "headers")
+	}
+	if len(headers) < 1 || headers[0] != "MAC Address" {
+		return nil, apperrors.NewInvalidRequestError(err, "invalid csv header", "headers")
+	}
+	columns := map[string]int{}
+	for i, header := range headers {
+		columns[strings.TrimSpace(header)] = i
+	}
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	existingModel, err := s.store.FindModel(txCtx, map[string]any{
+		models.Model{}.GetIdColumnName(): modelID,
+	})
+	if err != nil || existingModel == nil {
+		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+modelID.String(), "model_id")
+	}
+	_, err = s.store.FindGroup(txCtx, map[string]any{
+		models.Group{}.GetIdColumnName():      groupID,
+		models.Group{}.GetModelIdColumnName(): modelID,
+	})
+	if err != nil {
+		return nil, apperrors.NewInvalidRequestError(err, "group with id: "+groupID.String()+" does not belong to model with id: "+modelID.String(), "group_id")
+	}
+
+	macAddresses := map[string]int{}
+	serialNumbers := map[string]int{}
+	insertBatch := func(batch []*models.Device) error {
+		if err := s.store.InsertDevicesBatch(txCtx, batch); err != nil {
+			return err
+		}
+		for _, d := range batch {
+			report.Ids = append(report.Ids, d.Id.String())
+			if err := s.emitDomainEvent(txCtx, models.AggregateDevice, d.Id, models.EventActionCreated, d.UpdatedBy, d); err != nil {
+				return err
+			}
+		}
+		report.Created += len(batch)
+		return nil
+	}
+
+	var batch []*models.Device
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			report.Total++
+			report.Reject(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
+		}
+		report.Total++
+		if len(record) < 1 || strings.TrimSpace(record[0]) == "" {
+			report.Skip()
+			continue
+		}
+		line, _ := r.FieldPos(0)
+
+		macAddress := strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(strings.TrimSpace(record[0])))
+		if !isHexMacAddress(macAddress) {
+			report.Reject(line, record[0], "invalid mac address format")
+			continue
+		}
+		if first, ok := macAddresses[macAddress]; ok {
+			report.Reject(line, macAddress, "duplicate mac address in file, first seen on line "+strconv.Itoa(first))
+			continue
+		}
+		macAddresses[macAddress] = line
+
+		device := &models.Device{
+			MacAddress: macAddress,
+			ModelId:    &modelID,
+			GroupId:    &groupID,
+			Status:     "ENABLE",
+			UpdatedBy:  updatedBy,
+
+			LifecycleState:   models.DeviceStatePreProvisioned,
+			SerialNumber:     column(record, "Serial Number"),
+			ProductClass:     column(record, "Product Class"),
+			HardwareRevision: column(record, "Hardware Revision"),
+		}
+
+		existingDevice, err := s.store.FindDevice(txCtx, map[string]any{
+			models.Device{}.GetMacAddressColumnName(): device.MacAddress,
+		})
+		if err == nil && existingDevice != nil {
+			report.Reject(line, macAddress, "device already exists with mac address: "+device.MacAddress)
+			continue
+		}
+		if device.SerialNumber != "" {
+			if first, ok := serialNumbers[device.SerialNumber]; ok {
+				report.Reject(line, macAddress, "duplicate serial number in file, first seen on line "+strconv.Itoa(first))
+				continue
+			}
+			serialNumbers[device.SerialNumber] = line
+		}
+		if err := s.checkSerialNumberUnique(txCtx, existingModel.Manufacturer, device.SerialNumber, ""); err != nil {
+			if reason, ok := importRowRejection(err); ok {
+				report.Reject(line, macAddress, reason)
+				continue
+			}
+			return nil, err
+		}
+		if err := resolveDeviceEndpointId(existingModel, device); err != nil {
+			if reason, ok := importRowRejection(err); ok {
+				report.Reject(line, macAddress, reason)
+				continue
+			}
+			return nil, err
+		}
+		if !importWritesRows(report, opts) {
+			continue
+		}
+
+		batch = append(batch, device)
+		if len(batch) >= batchSize {
+			if err := insertBatch(batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := finishImport(report, opts); err != nil {
+		return report, err
+	}
+	if len(batch) > 0 {
+		if err := insertBatch(batch); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// perRowImportParameterRows is importParameterRows as it was before the existing paths were
+// prefetched per batch: every row makes a FindParameter by path.
+func (s *service) perRowImportParameterRows(
+	txCtx context.Context,
+	file io.Reader,
+	updatedBy string,
+	batchSize int,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	r := csv.NewReader(file)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	headers, err := r.Read()
+	if err != nil {
+		return nil, apperrors.NewInvalidRequestError(err, "file error", "headers")
+	}
+	if !hasExportColumns(headers, models.ParameterExportSchema) {
+		return nil, apperrors.NewInvalidRequestError(err, "invalid csv header", "headers")
+	}
+
+	report := models.NewImportReport(opts.Mode)
+	paths := map[string]int{}
+	insertBatch := func(batch []*models.Parameter) error {
+		if err := s.store.InsertParametersBatch(txCtx, batch); err != nil {
+			return err
+		}
+		for _, p := range batch {
+			report.Ids = append(report.Ids, p.Id.String())
+		}
+		report.Created += len(batch)
+		return nil
+	}
+
+	var batch []*models.Parameter
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			report.Total++
+			report.Reject(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
+		}
+		report.Total++
+		line, _ := r.FieldPos(0)
+		if len(record) < 3 {
+			report.Skip()
+			continue
+		}
+
+		param := &models.Parameter{
+			Path:        strings.TrimSpace(record[0]),
+			DataType:    strings.TrimSpace(record[1]),
+			Description: strings.TrimSpace(record[2]),
+			Status:      "ENABLE",
+			UpdatedBy:   updatedBy,
+		}
+		if param.Path == "" || param.DataType == "" {
+			report.Reject(line, param.Path, "path and data type are required")
+			continue
+		}
+		if first, ok := paths[param.Path]; ok {
+			report.Reject(line, param.Path, "duplicate path in file, first seen on line "+strconv.Itoa(first))
+			continue
+		}
+		paths[param.Path] = line
+		existingParam, err := s.store.FindParameter(txCtx, map[string]any{
+			models.Parameter{}.GetPathColumnName(): param.Path,
+		})
+		if err == nil && existingParam != nil {
+			if !opts.Upserts() {
+				report.Reject(line, param.Path, "parameter already exists with path: "+param.Path)
+				continue
+			}
+			if !importWritesRows(report, opts) {
+				continue
+			}
+			update := parameterImportUpdate(existingParam, param)
+			if update == nil {
+				report.Skip()
+				continue
+			}
+			if err := s.store.UpdateParameter(txCtx, existingParam.Id.String(), update); err != nil {
+				return nil, err
+			}
+			report.Updated++
+			continue
+		}
+		if !importWritesRows(report, opts) {
+			continue
+		}
+
+		batch = append(batch, param)
+		if len(batch) >= batchSize {
+			if err := insertBatch(batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := finishImport(report, opts); err != nil {
+		return report, err
+	}
+	if len(batch) > 0 {
+		if err := insertBatch(batch); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// perRowImportProfileRows is importProfileRows as it was before the existing names and
+// parameters were prefetched per batch: every row makes a FindProfile by name and a
+// FindParameter for each of its parameters. The replace mode is left out.
+func (s *service) perRowImportProfileRows(
+	txCtx context.Context,
+	file io.Reader,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error) {
+	txCtx = withWebhookSubscriptions(txCtx)
+
+	r := csv.NewReader(file)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	headers, err := r.Read()
+	if err != nil {
+		return nil, apperrors.NewInvalidRequestError(err, "file error", "headers")
+	}
+	legacy := slices.Equal(headers, models.LegacyProfileImportColumns)
+	if !legacy && !hasExportColumns(headers, models.ProfileExportSchema) {
+		return nil, apperrors.NewInvalidRequestError(nil, "invalid csv header", "headers")
+	}
+
+	report := models.NewImportReport(opts.Mode)
+	names := map[string]int{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			report.Total++
+			report.Reject(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
+		}
+		report.Total++
+		line, _ := r.FieldPos(0)
+		if len(record) != len(headers) {
+			report.Reject(line, "", "expected "+strconv.Itoa(len(headers))+" columns, got "+strconv.Itoa(len(record)))
+			continue
+		}
+
+		profile, members, reason := parseProfileRecord(record, legacy, updatedBy)
+		if reason != "" {
+			report.Reject(line, profile.Name, reason)
+			continue
+		}
+		if first, ok := names[profile.Name]; ok {
+			report.Reject(line, profile.Name, "duplicate profile name in file, first seen on line "+strconv.Itoa(first))
+			continue
+		}
+		names[profile.Name] = line
+
+		existingProfile, err := s.store.FindProfile(txCtx, map[string]any{
+			models.Profile{}.GetProfileNameColumnName(): profile.Name,
+		})
+		if err == nil && existingProfile != nil && !opts.Upserts() {
+			report.Reject(line, profile.Name, "profile already exists with name: "+profile.Name)
+			continue
+		}
+		parameters := make([]importedProfileParameter, 0, len(members))
+		for _, member := range members {
+			condition := map[string]any{models.Parameter{}.GetPathColumnName(): member.path}
+			if member.id != "" {
+				condition = map[string]any{models.Parameter{}.GetIdColumnName(): member.id}
+			}
+			existingParam, err := s.store.FindParameter(txCtx, condition)
+			if err != nil {
+				if !isEntityNotExist(err) {
+					return nil, err
+				}
+				if member.id != "" {
+					reason = "parameter not found with id: " + member.id
+				} else {
+					reason = "parameter not found with path: " + member.path
+				}
+				break
+			}
+			parameters = append(parameters, importedProfileParameter{
+				parameter:    existingParam,
+				defaultValue: member.defaultValue,
+				required:     member.required,
+				keepValues:   legacy,
+			})
+		}
+		if reason != "" {
+			report.Reject(line, profile.Name, reason)
+			continue
+		}
+		if !importWritesRows(report, opts) {
+			continue
+		}
+		if existingProfile != nil {
+			if legacy {
+				profile.Description = existingProfile.Description
+			}
+			updated, err := s.updateImportedProfile(txCtx, existingProfile, profile, parameters)
+			if err != nil {
+				return nil, err
+			}
+			if updated {
+				report.Updated++
+			} else {
+				report.Skip()
+			}
+			continue
+		}
+
+		if err := s.store.InsertProfile(txCtx, profile); err != nil {
+			return nil, err
+		}
+		for _, parameter := range parameters {
+			pp := &models.ProfileParameter{
+				ProfileId:    profile.Id,
+				ParameterId:  parameter.parameter.Id,
+				DefaultValue: parameter.defaultValue,
+				Required:     parameter.required,
+				UpdatedBy:    updatedBy,
+			}
+			if err := s.store.InsertProfileParameter(txCtx, pp); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.emitDomainEvent(txCtx, models.AggregateProfile, profile.Id, models.EventActionCreated, updatedBy, profile); err != nil {
+			return nil, err
+		}
+		report.Created++
+		report.Ids = append(report.Ids, profile.Id.String())
+	}
+
+	if err := finishImport(report, opts); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// assertSameImportReport compares the reports of the per-row and the per-batch import, the
+// ids of the created rows differ
+func assertSameImportReport(t *testing.T, perRow *models.ImportReport, perBatch *models.ImportReport) {
+	t.Helper()
+	if perRow.Total != perBatch.Total || perRow.Created != perBatch.Created || perRow.Updated != perBatch.Updated ||
+		perRow.Skipped != perBatch.Skipped || len(perRow.Ids) != len(perBatch.Ids) ||
+		!slices.Equal(perRow.Rejected, perBatch.Rejected) {
+		t.Errorf("per-row import reported %+v, per-batch import %+v", *perRow, *perBatch)
+	}
+}
+
+func TestImportDeviceRowsPrefetchesPerBatch(t *testing.T) {
+	const rows, batchSize = 1000, 100
+	store, modelId, groupId := newDeviceImportStore(0)
+
+	report, err := newImportService(store).importDeviceRows(context.Background(), strings.NewReader(deviceImportCSV("4485DA", rows)),
+		"bench", batchSize, modelId, groupId, models.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != rows {
+		t.Fatalf("created %d devices, want %d", report.Created, rows)
+	}
+	batches := rows / batchSize
+	want := map[string]int{
+		"FindModel":                        1,
+		"FindGroup":                        1,
+		"ListDeviceMacAddresses":           batches,
+		"ListDeviceSerialNumbers":          batches,
+		"InsertDevicesBatch":               batches,
+		"InsertOutboxEvent":                rows,
+		"ListWebhookSubscriptionsForEvent": 1,
+	}
+	if !mapsEqual(store.queries, want) {
+		t.Errorf("queries %v, want %v", store.queries, want)
+	}
+}
+
+func TestImportParameterRowsPrefetchesPerBatch(t *testing.T) {
+	const rows, batchSize = 1000, 100
+	store := &memoryImportStore{}
+
+	report, err := newImportService(store).importParameterRows(context.Background(), strings.NewReader(parameterImportCSV("Bench", rows)),
+		"bench", batchSize, models.ImportOptions{Mode: models.ImportModeInsert, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != rows {
+		t.Fatalf("created %d parameters, want %d", report.Created, rows)
+	}
+	want := map[string]int{
+		"ListTotalParameters":   rows / batchSize,
+		"InsertParametersBatch": rows / batchSize,
+	}
+	if !mapsEqual(store.queries, want) {
+		t.Errorf("queries %v, want %v", store.queries, want)
+	}
+}
+
+func TestImportProfileRowsPrefetchesPerBatch(t *testing.T) {
+	const rows, members, parameters = 250, 4, 100
+	store := newProfileImportStore(0, "Bench", parameters)
+
+	report, err := newImportService(store).importProfileRows(context.Background(),
+		strings.NewReader(profileImportCSV("Bench", rows, members, parameters)), "bench",
+		models.ImportOptions{Mode: models.ImportModeInsert, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != rows {
+		t.Fatalf("created %d profiles, want %d", report.Created, rows)
+	}
+	batches := (rows + profileImportBatchSize - 1) / profileImportBatchSize
+	want := map[string]int{
+		"ListProfiles":                     batches,
+		"ListTotalParameters":              batches,
+		"InsertProfile":                    rows,
+		"InsertProfileParameter":           rows * members,
+		"InsertOutboxEvent":                rows,
+		"ListWebhookSubscriptionsForEvent": 1,
+	}
+	if !mapsEqual(store.queries, want) {
+		t.Errorf("queries %v, want %v", store.queries, want)
+	}
+}
+
+// TestPerRowImportsMatch checks that the per-row imports the benchmarks compare with report
+// the same rows as the imports, on files with rows already in the store and rows rejected
+func TestPerRowImportsMatch(t *testing.T) {
+	ctx := context.Background()
+	// the second half of the file and a serial number are in the store, a serial number is
+	// repeated in the file and a MAC is invalid
+	devices := deviceImportCSV("4485DA", 20) + "4485DB000001,SN4485DA000003\nnot-a-mac,\n"
+	seed := func(store *memoryImportStore) {
+		for i := 10; i < 20; i++ {
+			store.devices = append(store.devices, &models.Device{Id: newRowId(), MacAddress: fmt.Sprintf("4485da%06x", i)})
+		}
+		store.devices = append(store.devices, &models.Device{Id: newRowId(), MacAddress: "4485dc000001", SerialNumber: "SN4485DA000005"})
+	}
+	perRowStore, modelId, groupId := newDeviceImportStore(0)
+	seed(perRowStore)
+	perRow, err := newImportService(perRowStore).perRowImportDeviceRows(ctx, strings.NewReader(devices), "bench", 4, modelId, groupId, models.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	perBatchStore, modelId, groupId := newDeviceImportStore(0)
+	seed(perBatchStore)
+	perBatch, err := newImportService(perBatchStore).importDeviceRows(ctx, strings.NewReader(devices), "bench", 4, modelId, groupId, models.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameImportReport(t, perRow, perBatch)
+	if len(perBatch.Rejected) != 13 {
+		t.Errorf("device import rejected %v, want the 10 devices in the store, 2 serial numbers and a MAC", perBatch.Rejected)
+	}
+
+	parameters := parameterImportCSV("Bench", 20)
+	upsert := models.ImportOptions{Mode: models.ImportModeUpsert, Strict: true}
+	perRowStore = newProfileImportStore(0, "Bench", 10)
+	perRow, err = newImportService(perRowStore).perRowImportParameterRows(ctx, strings.NewReader(parameters), "bench", 4, upsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	perBatchStore = newProfileImportStore(0, "Bench", 10)
+	perBatch, err = newImportService(perBatchStore).importParameterRows(ctx, strings.NewReader(parameters), "bench", 4, upsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameImportReport(t, perRow, perBatch)
+	if perBatch.Created != 10 || perBatch.Updated != 10 {
+		t.Errorf("parameter import created %d, updated %d, want 10 and 10", perBatch.Created, perBatch.Updated)
+	}
+
+	// the profiles of the second file exist after the first one, some of their parameters do not
+	first, second := profileImportCSV("Bench", 10, 3, 20), profileImportCSV("Bench", 20, 2, 25)
+	for _, opts := range []models.ImportOptions{{Mode: models.ImportModeInsert}, upsert} {
+		perRowStore, perBatchStore = newProfileImportStore(0, "Bench", 20), newProfileImportStore(0, "Bench", 20)
+		for _, file := range []string{first, second} {
+			perRow, err = newImportService(perRowStore).perRowImportProfileRows(ctx, strings.NewReader(file), "bench", opts)
+			if err != nil && perRow == nil {
+				t.Fatal(err)
+			}
+			perBatch, err = newImportService(perBatchStore).importProfileRows(ctx, strings.NewReader(file), "bench", opts)
+			if err != nil && perBatch == nil {
+				t.Fatal(err)
+			}
+			assertSameImportReport(t, perRow, perBatch)
+		}
+		if len(perBatch.Rejected) == 0 {
+			t.Errorf("%s of the second profile file rejected no row", opts.Mode)
+		}
+	}
+}
+
+func newImportService(store iUSPStoreRepository) *service {
+	return &service{store: store}
+}
+
+func mapsEqual(got map[string]int, want map[string]int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if got[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// BenchmarkImportDeviceRows compares the device import with the per-row import it replaced on
+// a store holding the model and group, every query costs importRoundTrip: the inserts and the
+// event of every device are made by both.
+func BenchmarkImportDeviceRows(b *testing.B) {
+	const rows, batchSize = 1000, 100
+	file := deviceImportCSV("4485DA", rows)
+	imports := map[string]func(*service, uuid.UUID, uuid.UUID) (*models.ImportReport, error){
+		"prefetch_per_batch": func(s *service, modelId uuid.UUID, groupId uuid.UUID) (*models.ImportReport, error) {
+			return s.importDeviceRows(context.Background(), strings.NewReader(file), "bench", batchSize, modelId, groupId, models.ImportOptions{})
+		},
+		"find_per_row": func(s *service, modelId uuid.UUID, groupId uuid.UUID) (*models.ImportReport, error) {
+			return s.perRowImportDeviceRows(context.Background(), strings.NewReader(file), "bench", batchSize, modelId, groupId, models.ImportOptions{})
+		},
+	}
+	for _, name := range []string{"prefetch_per_batch", "find_per_row"} {
+		b.Run(name, func(b *testing.B) {
+			var queries int
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				store, modelId, groupId := newDeviceImportStore(importRoundTrip)
+				b.StartTimer()
+				report, err := imports[name](newImportService(store), modelId, groupId)
+				if err != nil || report.Created != rows {
+					b.Fatalf("import reported %+v, %v", report, err)
+				}
+				queries = store.totalQueries()
+			}
+			reportImportMetrics(b, rows, queries)
+		})
+	}
+}
+
+// BenchmarkImportParameterRows compares the parameter import with the per-row import it
+// replaced, half of the file is in the store and upserted.
+func BenchmarkImportParameterRows(b *testing.B) {
+	const rows, batchSize = 1000, 100
+	file := parameterImportCSV("Bench", rows)
+	opts := models.ImportOptions{Mode: models.ImportModeUpsert, Strict: true}
+	imports := map[string]func(*service) (*models.ImportReport, error){
+		"prefetch_per_batch": func(s *service) (*models.ImportReport, error) {
+			return s.importParameterRows(context.Background(), strings.NewReader(file), "bench", batchSize, opts)
+		},
+		"find_per_row": func(s *service) (*models.ImportReport, error) {
+			return s.perRowImportParameterRows(context.Background(), strings.NewReader(file), "bench", batchSize, opts)
+		},
+	}
+	for _, name := range []string{"prefetch_per_batch", "find_per_row"} {
+		b.Run(name, func(b *testing.B) {
+			var queries int
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				store := newProfileImportStore(importRoundTrip, "Bench", rows/2)
+				b.StartTimer()
+				report, err := imports[name](newImportService(store))
+				if err != nil || report.Created != rows/2 {
+					b.Fatalf("import reported %+v, %v", report, err)
+				}
+				queries = store.totalQueries()
+			}
+			reportImportMetrics(b, rows, queries)
+		})
+	}
+}
+
+// BenchmarkImportProfileRows compares the profile import with the per-row import it replaced,
+// every profile has 5 parameters found by path.
+func BenchmarkImportProfileRows(b *testing.B) {
+	const rows, members, parameters = 200, 5, 500
+	file := profileImportCSV("Bench", rows, members, parameters)
+	opts := models.ImportOptions{Mode: models.ImportModeInsert, Strict: true}
+	imports := map[string]func(*service) (*models.ImportReport, error){
+		"prefetch_per_batch": func(s *service) (*models.ImportReport, error) {
+			return s.importProfileRows(context.Background(), strings.NewReader(file), "bench", opts)
+		},
+		"find_per_row": func(s *service) (*models.ImportReport, error) {
+			return s.perRowImportProfileRows(context.Background(), strings.NewReader(file), "bench", opts)
+		},
+	}
+	for _, name := range []string{"prefetch_per_batch", "find_per_row"} {
+		b.Run(name, func(b *testing.B) {
+			var queries int
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				store := newProfileImportStore(importRoundTrip, "Bench", parameters)
+				b.StartTimer()
+				report, err := imports[name](newImportService(store))
+				if err != nil || report.Created != rows {
+					b.Fatalf("import reported %+v, %v", report, err)
+				}
+				queries = store.totalQueries()
+			}
+			reportImportMetrics(b, rows, queries)
+		})
+	}
+}
+
+func reportImportMetrics(b *testing.B, rows int, queries int) {
+	if queries > 0 {
+		b.ReportMetric(float64(queries)/float64(rows), "queries/row")
+	}
+	b.ReportMetric(float64(rows*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
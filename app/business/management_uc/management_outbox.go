@@ -0,0 +1,164 @@
+package managementuc
+
+import (
+	"context"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+
+	"github.com/google/uuid"
+)
+
+// emitDomainEvent writes a domain event into the outbox, txCtx must hold the transaction
+// of the change so the event is stored if and only if the change is committed.
+func (s *service) emitDomainEvent(
+	txCtx context.Context,
+	aggregateType string,
+	aggregateId *uuid.UUID,
+	action string,
+	actor string,
+	data any,
+) error {
+	if aggregateId == nil {
+		return apperrors.NewInternalError(nil, "domain event without aggregate id: "+aggregateType+"."+action)
+	}
+
+	// a created aggregate has no event yet, the unique index rejects a second version 1
+	aggregateVersion := int64(1)
+	if action != models.EventActionCreated {
+		var err error
+		aggregateVersion, err = s.store.NextOutboxAggregateVersion(txCtx, *aggregateId)
+		if err != nil {
+			logging.Errorf("failed to read the event version of %s: %v", aggregateId, err)
+			return err
+		}
+	}
+
+	event, err := models.NewOutboxEvent(
+		aggregateType,
+		*aggregateId,
+		aggregateVersion,
+		models.DomainEventType(aggregateType, action),
+		actor,
+		data,
+	)
+	if err != nil {
+		logging.Errorf("failed to build %s.%s event: %v", aggregateType, action, err)
+		return apperrors.NewInternalError(err, "failed to build domain event")
+	}
+
+	if err := s.store.InsertOutboxEvent(txCtx, event); err != nil {
+		logging.Errorf("failed to insert outbox event %s for %s: %v", event.EventType, aggregateId, err)
+		return err
+	}
+	return s.enqueueWebhookDeliveries(txCtx, event)
+}
+
+// deletedEventData is the data of every `<aggregate>.deleted` event.
+func deletedEventData(id *uuid.UUID) map[string]any {
+	return map[string]any{
+		"id":     id,
+		"status": "DELETE",
+	}
+}
+
+func (s *service) RelayOutboxEvents(
+	ctx context.Context,
+	batchSize int,
+) (int, error) {
+	if s.eventPublisher == nil {
+		return 0, apperrors.NewInternalError(nil, "event publisher is not configured")
+	}
+
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return 0, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	events, err := s.store.ListPendingOutboxEvents(txCtx, batchSize)
+	if err != nil {
+		logging.Errorf("failed to list pending outbox events: %v", err)
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.Id.String())
+	}
+
+	// The batch holds the lowest pending version of each aggregate, the next version is read
+	// once it is published, so the events of an aggregate are published in version order.
+	// The whole batch is retried on failure. A crash after publish re-sends the batch (at-least-once).
+	publishErr := s.eventPublisher.PublishOutboxEvents(ctx, events)
+	if publishErr != nil {
+		logging.Errorf("failed to publish %d outbox events: %v", len(events), publishErr)
+		if err := s.store.MarkOutboxEventsFailed(txCtx, ids, publishErr.Error()); err != nil {
+			logging.Errorf("failed to record outbox publish failure: %v", err)
+			return 0, err
+		}
+	} else if err := s.store.MarkOutboxEventsPublished(txCtx, ids); err != nil {
+		logging.Errorf("failed to mark outbox events published: %v", err)
+		return 0, err
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return 0, err
+	}
+
+	success = true
+	if publishErr != nil {
+		return 0, publishErr
+	}
+	return len(events), nil
+}
+
+func (s *service) RunOutboxRelay(
+	ctx context.Context,
+	interval time.Duration,
+	batchSize int,
+) {
+	logging.Infof("Outbox relay started, interval=%s batch_size=%d", interval, batchSize)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Infof("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+
+		// drain the backlog before waiting for the next tick, a batch holds one event per
+		// aggregate so a short batch does not mean the backlog is empty
+		for ctx.Err() == nil {
+			published, err := s.RelayOutboxEvents(ctx, batchSize)
+			if err != nil || published == 0 {
+				break
+			}
+		}
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
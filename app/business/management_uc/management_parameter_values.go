@@ -0,0 +1,203 @@
+package managementuc
+
+import (
+	"context"
+	"sort"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+)
+
+func (s *service) HandleUSPGetResp(
+	ctx context.Context,
+	resp *models.USPGetResp,
+) error {
+	if err := resp.Validate(true); err != nil {
+		return err
+	}
+
+	// a GetResp answers a Get sent by the controller, the endpoint is never auto-registered here
+	device, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetEndpointIdColumnName(): resp.EndpointId,
+	})
+	if err != nil {
+		logging.Errorf("device not found for endpoint %s: %v", resp.EndpointId, err)
+		return err
+	}
+
+	return s.ingestGetResp(ctx, device, resp)
+}
+
+func (s *service) IngestDeviceParameterValues(
+	ctx context.Context,
+	modelId string,
+	deviceId string,
+	resp *models.USPGetResp,
+) (int, error) {
+	if err := resp.Validate(false); err != nil {
+		return 0, err
+	}
+
+	device, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetIdColumnName():      deviceId,
+		models.Device{}.GetModelIdColumnName(): modelId,
+		"status":                               []string{"ENABLE", "DISABLE"},
+	})
+	if err != nil {
+		logging.Errorf("device not found with id=%s: %v", deviceId, err)
+		return 0, err
+	}
+	if resp.EndpointId != "" && resp.EndpointId != device.EndpointId {
+		return 0, apperrors.NewInvalidRequestError(nil, "endpoint_id does not match the device", "endpoint_id")
+	}
+
+	if err := s.ingestGetResp(ctx, device, resp); err != nil {
+		return 0, err
+	}
+	return len(resp.ParameterValues()), nil
+}
+
+func (s *service) ingestGetResp(
+	ctx context.Context,
+	device *models.Device,
+	resp *models.USPGetResp,
+) error {
+	values := reportedParameterValues(device, resp.ParameterValues(), resp.ObservedAt())
+	if len(values) == 0 {
+		return nil
+	}
+
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	if err := s.storeReportedValues(txCtx, values); err != nil {
+		logging.Errorf("failed to store GetResp values for device %s: %v", device.Id, err)
+		return err
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	success = true
+	return nil
+}
+
+// storeReportedValues updates the latest values and, when enabled, appends them to the history.
+func (s *service) storeReportedValues(
+	txCtx context.Context,
+	values []models.DeviceParameterValue,
+) error {
+	if err := s.store.UpsertDeviceParameterValues(txCtx, values); err != nil {
+		return err
+	}
+	if !s.parameterHistoryEnable || len(values) == 0 {
+		return nil
+	}
+
+	history := make([]models.DeviceParameterValueHistory, 0, len(values))
+	for _, value := range values {
+		history = append(history, models.DeviceParameterValueHistory{
+			DeviceId:   value.DeviceId,
+			Path:       value.Path,
+			ObservedAt: value.ObservedAt,
+			Value:      value.Value,
+			CreatedAt:  value.UpdatedAt,
+		})
+	}
+	return s.store.InsertDeviceParameterHistory(txCtx, history)
+}
+
+func (s *service) GetDeviceParameterValues(
+	ctx context.Context,
+	modelId string,
+	deviceId string,
+	prefixes []string,
+	asOf *time.Time,
+	opts models.QueryOptions,
+) ([]models.DeviceParameterValue, error) {
+	if asOf != nil && !s.parameterHistoryEnable {
+		return nil, apperrors.NewInvalidRequestError(nil, "parameter history is disabled, as_of is not supported", "as_of")
+	}
+
+	if _, err := s.store.FindDevice(ctx, map[string]any{
+		models.Device{}.GetIdColumnName():      deviceId,
+		models.Device{}.GetModelIdColumnName(): modelId,
+		"status":                               []string{"ENABLE", "DISABLE"},
+	}); err != nil {
+		logging.Errorf("device not found with id=%s: %v", deviceId, err)
+		return nil, err
+	}
+
+	values, err := s.store.ListDeviceParameterSnapshot(ctx, deviceId, prefixes, asOf, opts)
+	if err != nil {
+		logging.Errorf("failed to list parameter values of device %s: %v", deviceId, err)
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s *service) RunParameterHistoryPruner(
+	ctx context.Context,
+	interval time.Duration,
+	retention time.Duration,
+) {
+	logging.Infof("Parameter history pruner started, interval=%s retention=%s", interval, retention)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Infof("Parameter history pruner stopped")
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := s.store.DeleteDeviceParameterHistoryBefore(ctx, time.Now().Add(-retention))
+		if err != nil {
+			logging.Errorf("failed to prune parameter history: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			logging.Infof("pruned %d parameter history rows", deleted)
+		}
+	}
+}
+
+// reportedParameterValues converts reported parameters into rows for device_parameter_values,
+// sorted by path so concurrent upserts of a device lock rows in the same order.
+func reportedParameterValues(
+	device *models.Device,
+	parameters map[string]string,
+	observedAt time.Time,
+) []models.DeviceParameterValue {
+	now := time.Now()
+	values := make([]models.DeviceParameterValue, 0, len(parameters))
+	for path, value := range parameters {
+		values = append(values, models.DeviceParameterValue{
+			DeviceId:   device.Id,
+			Path:       path,
+			Value:      value,
+			ObservedAt: &observedAt,
+			UpdatedAt:  &now,
+		})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Path < values[j].Path })
+	return values
+}
@@ -9,17 +9,19 @@ import (
 
 type QueryBuilder struct {
 	conditions map[string]any
-	filters    []models.FilterExpr
+	filter     *models.FilterExpr
 	orders     []models.OrderExpr
 	limit      int
 	offset     int
+	cursor     *string
+	keyset     *models.Keyset
+	keyFields  []models.QueryField
 }
 
 // NewQueryBuilder create query builder
 func NewQueryBuilder() *QueryBuilder {
 	return &QueryBuilder{
 		conditions: make(map[string]any),
-		filters:    []models.FilterExpr{},
 		orders:     []models.OrderExpr{},
 	}
 }
@@ -30,26 +32,57 @@ func (qb *QueryBuilder) AddCondition(key string, value any) *QueryBuilder {
 	return qb
 }
 
-// AddFilter append filter expression with validation
-func (qb *QueryBuilder) AddFilter(field, op, value, join string) error {
-	// Validate operator
-	validOps := []string{"eq", "ne", "lt", "gt", "lte", "gte", "like"}
-	if !contains(validOps, strings.ToLower(op)) {
-		return apperrors.NewInvalidRequestError(nil, fmt.Sprintf("invalid operator: %s", op), "invalid_operator")
+// SetFilter set the filter expression tree with validation of every operator and join
+func (qb *QueryBuilder) SetFilter(filter *models.FilterExpr) error {
+	if filter == nil {
+		qb.filter = nil
+		return nil
+	}
+	if err := validateFilterExpr(filter); err != nil {
+		return err
+	}
+	qb.filter = filter
+	return nil
+}
+
+func validateFilterExpr(filter *models.FilterExpr) error {
+	if !filter.IsGroup() {
+		// Validate operator
+		if !contains(models.FilterOperators, filter.Op) {
+			return apperrors.NewInvalidRequestError(nil, fmt.Sprintf("invalid operator: %s", filter.Op), "invalid_operator")
+		}
+		// Validate values of the list operators
+		switch strings.ToLower(filter.Op) {
+		case "in", "nin":
+			if len(filter.Values) == 0 || len(filter.Values) > models.MaxFilterValues {
+				return apperrors.NewInvalidRequestError(nil,
+					fmt.Sprintf("%s needs 1 to %d values: %s", filter.Op, models.MaxFilterValues, filter.Filter), "invalid_operator")
+			}
+		case "between":
+			if len(filter.Values) != 2 {
+				return apperrors.NewInvalidRequestError(nil,
+					fmt.Sprintf("between needs 2 values: %s", filter.Filter), "invalid_operator")
+			}
+		}
+		return nil
 	}
 
 	// Validate join
 	validJoins := []string{"and", "or"}
-	if !contains(validJoins, strings.ToLower(join)) {
-		return apperrors.NewInvalidRequestError(nil, fmt.Sprintf("invalid join: %s", join), "invalid_join")
+	if !contains(validJoins, strings.ToLower(filter.Join)) {
+		return apperrors.NewInvalidRequestError(nil, fmt.Sprintf("invalid join: %s", filter.Join), "invalid_join")
+	}
+	if len(filter.Children) == 0 {
+		return apperrors.NewInvalidRequestError(nil, "filter group has no children", "invalid_join")
+	}
+	for _, child := range filter.Children {
+		if child == nil {
+			return apperrors.NewInvalidRequestError(nil, "filter group has an empty child", "invalid_join")
+		}
+		if err := validateFilterExpr(child); err != nil {
+			return err
+		}
 	}
-
-	qb.filters = append(qb.filters, models.FilterExpr{
-		Filter: field,
-		Op:     op,
-		Value:  value,
-		Join:   strings.ToUpper(join),
-	})
 	return nil
 }
 
@@ -82,28 +115,138 @@ func (qb *QueryBuilder) SetPagination(limit, offset int) error {
 	return nil
 }
 
-// ValidateFields
-func (qb *QueryBuilder) ValidateFields(validColumns map[string]bool) error {
-	// Validate filter fields
-	for _, filter := range qb.filters {
-		if _, ok := validColumns[strings.ToLower(filter.Filter)]; !ok {
+// SetCursor switch to keyset pagination, an empty cursor reads the first page
+func (qb *QueryBuilder) SetCursor(cursor *string) error {
+	if cursor == nil {
+		return nil
+	}
+	if qb.offset > 0 {
+		return apperrors.NewInvalidRequestError(nil, "offset cannot be used with cursor", "invalid_offset")
+	}
+	qb.cursor = cursor
+	return nil
+}
+
+// resolveKeyset builds the keyset of the cursor from the resolved orders, id is appended
+// as the last sort key so that every row has a distinct position
+func (qb *QueryBuilder) resolveKeyset(fields models.FieldRegistry) error {
+	if qb.cursor == nil {
+		return nil
+	}
+
+	orders := qb.orders
+	hasID := false
+	for _, order := range orders {
+		if strings.EqualFold(order.Field, "id") {
+			hasID = true
+		}
+	}
+	if !hasID {
+		orders = append(orders[:len(orders):len(orders)], models.OrderExpr{Field: "id", Direction: "ASC"})
+	}
+
+	keyset := &models.Keyset{}
+	qb.keyFields = make([]models.QueryField, 0, len(orders))
+	for _, order := range orders {
+		field, _ := fields.Lookup(order.Field)
+		keyset.Columns = append(keyset.Columns, models.KeysetColumn{
+			Column: field.KeysetExpression(),
+			Desc:   strings.EqualFold(order.Direction, "desc"),
+		})
+		qb.keyFields = append(qb.keyFields, field)
+	}
+	qb.keyset = keyset
+
+	if *qb.cursor == "" {
+		return nil
+	}
+
+	cursor, err := models.DecodeCursor(*qb.cursor)
+	if err != nil {
+		return apperrors.NewInvalidRequestError(err, "invalid cursor", "invalid_cursor")
+	}
+	if cursor.Order != models.OrderSignature(qb.orders) || len(cursor.Values) != len(qb.keyFields) {
+		return apperrors.NewInvalidRequestError(nil, "cursor does not match the orderBy of the request", "invalid_cursor")
+	}
+	keyset.Backward = cursor.Backward
+	keyset.Values = make([]any, len(cursor.Values))
+	for i, value := range cursor.Values {
+		if value == nil {
+			if !qb.keyFields[i].Nullable {
+				return apperrors.NewInvalidRequestError(nil, "invalid cursor", "invalid_cursor")
+			}
+			if qb.keyFields[i].KeysetExpression() == qb.keyFields[i].Column {
+				continue
+			}
+			// the sort expression replaces NULL with the zero value of the type
+			zero := map[string]string{models.FieldTypeInt: "0", models.FieldTypeBool: "false"}[qb.keyFields[i].Type]
+			value = &zero
+		}
+		arg, err := qb.keyFields[i].Coerce(*value)
+		if err != nil {
+			return apperrors.NewInvalidRequestError(err, "invalid cursor", "invalid_cursor")
+		}
+		keyset.Values[i] = arg
+	}
+	return nil
+}
+
+// ResolveFields maps every filter and order field to its column through the registry
+// and coerces the filter values to the column type, unknown fields are rejected
+func (qb *QueryBuilder) ResolveFields(fields models.FieldRegistry) error {
+	// Resolve filter fields
+	for _, filter := range qb.filter.Conditions() {
+		field, ok := fields.Lookup(filter.Filter)
+		if !ok {
 			return apperrors.NewInvalidRequestError(
 				nil,
 				fmt.Sprintf("invalid filter field: %s", filter.Filter),
 				"invalid_filter_field",
 			)
 		}
+		if !field.SupportsOperator(filter.Op) {
+			return apperrors.NewInvalidRequestError(
+				nil,
+				fmt.Sprintf("operator %s does not apply to %s field %s", filter.Op, field.Type, filter.Filter),
+				"invalid_operator",
+			)
+		}
+
+		var values []string
+		switch strings.ToLower(filter.Op) {
+		case "isnull", "notnull":
+		case "in", "nin", "between":
+			values = filter.Values
+		default:
+			values = []string{filter.Value}
+		}
+		args := make([]any, 0, len(values))
+		for _, value := range values {
+			arg, err := field.Coerce(value)
+			if err != nil {
+				return apperrors.NewInvalidRequestError(
+					err,
+					fmt.Sprintf("invalid value for filter field %s: %v", filter.Filter, err),
+					"invalid_filter_value",
+				)
+			}
+			args = append(args, arg)
+		}
+		filter.Column = field.Column
+		filter.Args = args
 	}
 
-	// Validate order fields
-	for _, order := range qb.orders {
-		if _, ok := validColumns[strings.ToLower(order.Field)]; !ok {
+	// Resolve order fields
+	for i, order := range qb.orders {
+		field, ok := fields.Lookup(order.Field)
+		if !ok || field.Type == models.FieldTypeStringArray {
 			return apperrors.NewInvalidRequestError(
 				nil,
 				fmt.Sprintf("invalid order field: %s", order.Field),
 				"invalid_order_field",
 			)
 		}
+		qb.orders[i].Column = field.Column
 	}
 
 	return nil
@@ -112,10 +255,10 @@ func (qb *QueryBuilder) ValidateFields(validColumns map[string]bool) error {
 // ApplyStatusFilter default
 func (qb *QueryBuilder) ApplyStatusFilter() error {
 	hasStatusFilter := false
-	for _, expr := range qb.filters {
+	for _, expr := range qb.filter.Conditions() {
 		if strings.EqualFold(expr.Filter, "status") {
 			hasStatusFilter = true
-			if strings.EqualFold(expr.Op, "eq") && strings.EqualFold(expr.Value, "DELETE") {
+			if expr.SelectsValue("DELETE") {
 				return apperrors.NewInvalidRequestError(
 					nil,
 					"cannot list items with DELETE status",
@@ -145,21 +288,23 @@ func (qb *QueryBuilder) SetDefaultOrder() {
 }
 
 // Build QueryOptions and conditions
+// in keyset mode one more row than the limit is read to know whether a next page exists
 func (qb *QueryBuilder) Build() (map[string]any, models.QueryOptions) {
-	return qb.conditions, models.QueryOptions{
+	opts := models.QueryOptions{
 		Limit:      qb.limit,
 		Offset:     qb.offset,
-		FilterExpr: qb.filters,
+		FilterExpr: qb.filter,
 		OrderExpr:  qb.orders,
 	}
-}
-
-func (qb *QueryBuilder) BuildSafeQuery(validColumns map[string]bool) (map[string]any, models.QueryOptions, error) {
-	// Validate fields
-	if err := qb.ValidateFields(validColumns); err != nil {
-		return nil, models.QueryOptions{}, err
+	if qb.keyset != nil {
+		opts.Limit = qb.limit + 1
+		opts.Offset = 0
+		opts.Keyset = qb.keyset
 	}
+	return qb.conditions, opts
+}
 
+func (qb *QueryBuilder) BuildSafeQuery(fields models.FieldRegistry) (map[string]any, models.QueryOptions, error) {
 	// Apply status filter
 	if err := qb.ApplyStatusFilter(); err != nil {
 		return nil, models.QueryOptions{}, err
@@ -168,11 +313,28 @@ func (qb *QueryBuilder) BuildSafeQuery(validColumns map[string]bool) (map[string
 	// Set default order
 	qb.SetDefaultOrder()
 
+	// Resolve fields through the registry
+	if err := qb.ResolveFields(fields); err != nil {
+		return nil, models.QueryOptions{}, err
+	}
+	if err := qb.resolveKeyset(fields); err != nil {
+		return nil, models.QueryOptions{}, err
+	}
+
 	// Build result
 	conditions, opts := qb.Build()
 	return conditions, opts, nil
 }
 
+// resolveExpand maps the relations asked by expand to their preload keys
+func resolveExpand(registry models.ExpandRegistry, names []string) ([]string, error) {
+	preloads, err := registry.Preloads(names)
+	if err != nil {
+		return nil, apperrors.NewInvalidRequestError(err, err.Error(), "invalid_expand")
+	}
+	return preloads, nil
+}
+
 // Helper functions
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -186,166 +348,216 @@ func contains(slice []string, item string) bool {
 /**/
 type ProfileQueryBuilder struct {
 	*QueryBuilder
-	validColumns map[string]bool
+	fields models.FieldRegistry
 }
 
 // NewProfileQueryBuilder create query builder for Profile
 func NewProfileQueryBuilder() *ProfileQueryBuilder {
 	return &ProfileQueryBuilder{
 		QueryBuilder: NewQueryBuilder(),
-		validColumns: map[string]bool{
-			"id":                     true,
-			"profile_name":           true,
-			"msg_type":               true,
-			"return_commands":        true,
-			"return_events":          true,
-			"return_params":          true,
-			"return_unique_key_sets": true,
-			"allow_partial":          true,
-			"send_resp":              true,
-			"first_level_only":       true,
-			"max_depth":              true,
-			"tags":                   true,
-			"created_at":             true,
-			"updated_at":             true,
-			"updated_by":             true,
-			"status":                 true,
-		},
+		fields:       models.ProfileFields,
 	}
 }
 func (pqb *ProfileQueryBuilder) BuildProfile() (map[string]any, models.QueryOptions, error) {
-	return pqb.BuildSafeQuery(pqb.validColumns)
+	return pqb.BuildSafeQuery(pqb.fields)
 }
 
 /**/
 type ParameterQueryBuilder struct {
 	*QueryBuilder
-	validColumns map[string]bool
+	fields models.FieldRegistry
 }
 
 func NewParameterQueryBuilder() *ParameterQueryBuilder {
 	return &ParameterQueryBuilder{
 		QueryBuilder: NewQueryBuilder(),
-		validColumns: map[string]bool{
-			"id":          true,
-			"path":        true,
-			"data_type":   true,
-			"description": true,
-			"created_at":  true,
-			"updated_at":  true,
-			"updated_by":  true,
-			"status":      true,
-		},
+		fields:       models.ParameterFields,
 	}
 }
 func (pqb *ParameterQueryBuilder) BuildParameter() (map[string]any, models.QueryOptions, error) {
-	return pqb.BuildSafeQuery(pqb.validColumns)
+	return pqb.BuildSafeQuery(pqb.fields)
 }
 
 /**/
 type ModelQueryBuilder struct {
 	*QueryBuilder
-	validColumns map[string]bool
+	fields models.FieldRegistry
 }
 
 // NewModelQueryBuilder create query builder for Model
 func NewModelQueryBuilder() *ModelQueryBuilder {
 	return &ModelQueryBuilder{
 		QueryBuilder: NewQueryBuilder(),
-		validColumns: map[string]bool{
-			"id":           true,
-			"name":         true,
-			"vendor_name":  true,
-			"manufacturer": true,
-			"status":       true,
-			"description":  true,
-			"created_at":   true,
-			"updated_at":   true,
-			"updated_by":   true,
-		},
+		fields:       models.ModelFields,
 	}
 }
 func (mqb *ModelQueryBuilder) BuildModel() (map[string]any, models.QueryOptions, error) {
-	return mqb.BuildSafeQuery(mqb.validColumns)
+	return mqb.BuildSafeQuery(mqb.fields)
 }
 
 /**/
 type FirmwareQueryBuilder struct {
 	*QueryBuilder
-	validColumns map[string]bool
+	fields models.FieldRegistry
 }
 
 func NewFirmwareQueryBuilder() *FirmwareQueryBuilder {
 	return &FirmwareQueryBuilder{
 		QueryBuilder: NewQueryBuilder(),
-		validColumns: map[string]bool{
-			"id":          true,
-			"model_id":    true,
-			"name":        true,
-			"file_path":   true,
-			"status":      true,
-			"description": true,
-			"created_at":  true,
-			"updated_at":  true,
-			"updated_by":  true,
-		},
+		fields:       models.FirmwareFields,
 	}
 }
 func (fqb *FirmwareQueryBuilder) BuildFirmware() (map[string]any, models.QueryOptions, error) {
-	return fqb.BuildSafeQuery(fqb.validColumns)
+	return fqb.BuildSafeQuery(fqb.fields)
 }
 
 /**/
 type GroupQueryBuilder struct {
 	*QueryBuilder
-	validColumns map[string]bool
+	fields models.FieldRegistry
 }
 
 // NewGroupQueryBuilder create query builder for Group
 func NewGroupQueryBuilder() *GroupQueryBuilder {
 	return &GroupQueryBuilder{
 		QueryBuilder: NewQueryBuilder(),
-		validColumns: map[string]bool{
-			"id":          true,
-			"model_id":    true,
-			"firmware_id": true,
-			"name":        true,
-			"description": true,
-			"status":      true,
-			"created_at":  true,
-			"updated_at":  true,
-			"updated_by":  true,
-		},
+		fields:       models.GroupFields,
 	}
 }
 func (gqb *GroupQueryBuilder) BuildGroup() (map[string]any, models.QueryOptions, error) {
-	return gqb.BuildSafeQuery(gqb.validColumns)
+	return gqb.BuildSafeQuery(gqb.fields)
 }
 
 /**/
 type DeviceQueryBuilder struct {
 	*QueryBuilder
-	validColumns map[string]bool
+	fields models.FieldRegistry
 }
 
 // NewDeviceQueryBuilder create query builder for Device
 func NewDeviceQueryBuilder() *DeviceQueryBuilder {
 	return &DeviceQueryBuilder{
 		QueryBuilder: NewQueryBuilder(),
-		validColumns: map[string]bool{
-			"id":          true,
-			"mac_address": true,
-			"endpoint_id": true,
-			"model_id":    true,
-			"group_id":    true,
-			"created_at":  true,
-			"updated_at":  true,
-			"updated_by":  true,
-			"status":      true,
-			"description": true,
-		},
+		fields:       models.DeviceFields,
 	}
 }
 func (dqb *DeviceQueryBuilder) BuildDevice() (map[string]any, models.QueryOptions, error) {
-	return dqb.BuildSafeQuery(dqb.validColumns)
+	dqb.ApplyLifecycleFilter()
+	return dqb.BuildSafeQuery(dqb.fields)
+}
+
+// ApplyLifecycleFilter excludes devices in a terminal lifecycle state unless
+// the caller filters or conditions on lifecycle_state itself.
+func (dqb *DeviceQueryBuilder) ApplyLifecycleFilter() {
+	if _, ok := dqb.conditions["lifecycle_state"]; ok {
+		return
+	}
+	for _, expr := range dqb.filter.Conditions() {
+		if strings.EqualFold(expr.Filter, "lifecycle_state") {
+			return
+		}
+	}
+	dqb.conditions["lifecycle_state"] = models.NonTerminalDeviceStates()
+}
+
+/**/
+type WebhookSubscriptionQueryBuilder struct {
+	*QueryBuilder
+	fields models.FieldRegistry
+}
+
+// NewWebhookSubscriptionQueryBuilder create query builder for WebhookSubscription
+func NewWebhookSubscriptionQueryBuilder() *WebhookSubscriptionQueryBuilder {
+	return &WebhookSubscriptionQueryBuilder{
+		QueryBuilder: NewQueryBuilder(),
+		fields:       models.WebhookSubscriptionFields,
+	}
+}
+func (wqb *WebhookSubscriptionQueryBuilder) BuildWebhookSubscription() (map[string]any, models.QueryOptions, error) {
+	return wqb.BuildSafeQuery(wqb.fields)
+}
+
+/**/
+type WebhookDeliveryQueryBuilder struct {
+	*QueryBuilder
+	fields models.FieldRegistry
+}
+
+// NewWebhookDeliveryQueryBuilder create query builder for WebhookDelivery
+func NewWebhookDeliveryQueryBuilder() *WebhookDeliveryQueryBuilder {
+	return &WebhookDeliveryQueryBuilder{
+		QueryBuilder: NewQueryBuilder(),
+		fields:       models.WebhookDeliveryFields,
+	}
+}
+
+// BuildWebhookDelivery deliveries have no ENABLE/DISABLE status, the default status filter is skipped
+func (wqb *WebhookDeliveryQueryBuilder) BuildWebhookDelivery() (map[string]any, models.QueryOptions, error) {
+	wqb.SetDefaultOrder()
+	if err := wqb.ResolveFields(wqb.fields); err != nil {
+		return nil, models.QueryOptions{}, err
+	}
+	if err := wqb.resolveKeyset(wqb.fields); err != nil {
+		return nil, models.QueryOptions{}, err
+	}
+	conditions, opts := wqb.Build()
+	return conditions, opts, nil
+}
+
+// finishKeysetPage trims the extra row read by keyset pagination, restores the order of a
+// backward page and fills the cursors of the neighbour pages
+func finishKeysetPage[T any](qb *QueryBuilder, rows []T, page *models.PageInfo) []T {
+	if qb.keyset == nil {
+		return rows
+	}
+
+	more := len(rows) > qb.limit
+	if more {
+		rows = rows[:qb.limit]
+	}
+	if qb.keyset.Backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if page == nil || len(rows) == 0 {
+		return rows
+	}
+
+	started := len(qb.keyset.Values) > 0
+	hasNext, hasPrev := more, started
+	if qb.keyset.Backward {
+		hasNext, hasPrev = started, more
+	}
+	if hasNext {
+		page.NextCursor = qb.encodeCursor(rows[len(rows)-1], false)
+	}
+	if hasPrev {
+		page.PrevCursor = qb.encodeCursor(rows[0], true)
+	}
+	return rows
+}
+
+// keysetColumns returns the columns a cursor is encoded from, they must be read with the rows
+func (qb *QueryBuilder) keysetColumns() []string {
+	columns := make([]string, 0, len(qb.keyFields))
+	for _, field := range qb.keyFields {
+		columns = append(columns, field.Column)
+	}
+	return columns
+}
+
+// encodeCursor returns the cursor of the page next to row
+func (qb *QueryBuilder) encodeCursor(row any, backward bool) string {
+	cursor := models.Cursor{
+		Order:    models.OrderSignature(qb.orders),
+		Values:   make([]*string, len(qb.keyFields)),
+		Backward: backward,
+	}
+	for i, field := range qb.keyFields {
+		column := field.Column[strings.LastIndex(field.Column, ".")+1:]
+		value, _ := models.ColumnValue(row, column)
+		cursor.Values[i] = models.FormatCursorValue(value)
+	}
+	return models.EncodeCursor(cursor)
 }
@@ -19,17 +19,18 @@ func (s *service) ListTotalProfiles(
 	if err := qb.SetPagination(oppts.Limit, oppts.Offset); err != nil {
 		return nil, err
 	}
+	if err := qb.SetCursor(oppts.Cursor); err != nil {
+		return nil, err
+	}
 
 	// Add base conditions
 	for key, value := range condition {
 		qb.AddCondition(key, value)
 	}
 
-	// Add filters with validation
-	for _, filter := range oppts.FilterExpr {
-		if err := qb.AddFilter(filter.Filter, filter.Op, filter.Value, filter.Join); err != nil {
-			return nil, err
-		}
+	// Set filter tree with validation
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return nil, err
 	}
 
 	// Add orders with validation
@@ -44,11 +45,13 @@ func (s *service) ListTotalProfiles(
 	if err != nil {
 		return nil, err
 	}
+	finalOpts.Select = models.ProfileSelects.Columns(models.USPProfileTableName, oppts.Fields, nil, qb.keysetColumns()...)
 
 	profiles, err := s.store.ListProfiles(ctx, finalCondition, finalOpts)
 	if err != nil {
 		return nil, err
 	}
+	profiles = finishKeysetPage(qb.QueryBuilder, profiles, oppts.Page)
 	profileNames := append([]models.Profile(nil), profiles...)
 	return profileNames, nil
 }
@@ -65,17 +68,18 @@ func (s *service) ListTotalParameters(
 	if err := qb.SetPagination(oppts.Limit, oppts.Offset); err != nil {
 		return nil, err
 	}
+	if err := qb.SetCursor(oppts.Cursor); err != nil {
+		return nil, err
+	}
 
 	// Add base conditions
 	for key, value := range condition {
 		qb.AddCondition(key, value)
 	}
 
-	// Add filters with validation
-	for _, filter := range oppts.FilterExpr {
-		if err := qb.AddFilter(filter.Filter, filter.Op, filter.Value, filter.Join); err != nil {
-			return nil, err
-		}
+	// Set filter tree with validation
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return nil, err
 	}
 
 	// Add orders with validation
@@ -90,6 +94,7 @@ func (s *service) ListTotalParameters(
 	if err != nil {
 		return nil, err
 	}
+	finalOpts.Select = models.ParameterSelects.Columns(models.USPParameterTableName, oppts.Fields, nil, qb.keysetColumns()...)
 
 	parameters, err := s.store.ListParameters(
 		ctx,
@@ -99,6 +104,7 @@ func (s *service) ListTotalParameters(
 	if err != nil {
 		return nil, err
 	}
+	parameters = finishKeysetPage(qb.QueryBuilder, parameters, oppts.Page)
 	parameterNames := append([]models.Parameter(nil), parameters...)
 	return parameterNames, nil
 }
@@ -115,17 +121,18 @@ func (s *service) ListTotalModels(
 	if err := qb.SetPagination(opts.Limit, opts.Offset); err != nil {
 		return nil, err
 	}
+	if err := qb.SetCursor(opts.Cursor); err != nil {
+		return nil, err
+	}
 
 	// Add base conditions
 	for key, value := range condition {
 		qb.AddCondition(key, value)
 	}
 
-	// Add filters with validation
-	for _, filter := range opts.FilterExpr {
-		if err := qb.AddFilter(filter.Filter, filter.Op, filter.Value, filter.Join); err != nil {
-			return nil, err
-		}
+	// Set filter tree with validation
+	if err := qb.SetFilter(opts.FilterExpr); err != nil {
+		return nil, err
 	}
 
 	// Add orders with validation
@@ -140,12 +147,18 @@ func (s *service) ListTotalModels(
 	if err != nil {
 		return nil, err
 	}
+	preloads, err := resolveExpand(models.ModelExpands, opts.Expand)
+	if err != nil {
+		return nil, err
+	}
+	finalOpts.Select = models.ModelSelects.Columns(models.USPModelTableName, opts.Fields, opts.Expand, qb.keysetColumns()...)
 
-	listmodels, err := s.store.ListModels(ctx, finalCondition, finalOpts)
+	listmodels, err := s.store.ListModels(ctx, finalCondition, finalOpts, preloads...)
 	if err != nil {
 		return nil, err
 	}
 
+	listmodels = finishKeysetPage(qb.QueryBuilder, listmodels, opts.Page)
 	modelNames := append([]models.Model(nil), listmodels...)
 	return modelNames, nil
 }
@@ -172,17 +185,18 @@ func (s *service) ListFirmwares(
 	if err := qb.SetPagination(oppts.Limit, oppts.Offset); err != nil {
 		return nil, err
 	}
+	if err := qb.SetCursor(oppts.Cursor); err != nil {
+		return nil, err
+	}
 
 	// Add base conditions
 	for key, value := range condition {
 		qb.AddCondition(key, value)
 	}
 
-	// Add filters with validation
-	for _, filter := range oppts.FilterExpr {
-		if err := qb.AddFilter(filter.Filter, filter.Op, filter.Value, filter.Join); err != nil {
-			return nil, err
-		}
+	// Set filter tree with validation
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return nil, err
 	}
 
 	// Add orders with validation
@@ -197,11 +211,17 @@ func (s *service) ListFirmwares(
 	if err != nil {
 		return nil, err
 	}
+	preloads, err := resolveExpand(models.FirmwareExpands, oppts.Expand)
+	if err != nil {
+		return nil, err
+	}
+	finalOpts.Select = models.FirmwareSelects.Columns(models.USPFirmwareTableName, oppts.Fields, oppts.Expand, qb.keysetColumns()...)
 
-	firmwareList, err := s.store.ListFirmware(ctx, finalCondition, finalOpts)
+	firmwareList, err := s.store.ListFirmware(ctx, finalCondition, finalOpts, preloads...)
 	if err != nil {
 		return nil, err
 	}
+	firmwareList = finishKeysetPage(qb.QueryBuilder, firmwareList, oppts.Page)
 	firmwareNames := append([]models.Firmware(nil), firmwareList...)
 
 	return firmwareNames, nil
@@ -228,6 +248,9 @@ func (s *service) ListTotalGroups(
 	if err := qb.SetPagination(oppts.Limit, oppts.Offset); err != nil {
 		return nil, err
 	}
+	if err := qb.SetCursor(oppts.Cursor); err != nil {
+		return nil, err
+	}
 
 	// Add base conditions
 	for key, value := range condition {
@@ -236,11 +259,9 @@ func (s *service) ListTotalGroups(
 	// Add modelId condition
 	qb.AddCondition("model_id", modelId)
 
-	// Add filters with validation
-	for _, filter := range oppts.FilterExpr {
-		if err := qb.AddFilter(filter.Filter, filter.Op, filter.Value, filter.Join); err != nil {
-			return nil, err
-		}
+	// Set filter tree with validation
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return nil, err
 	}
 
 	// Add orders with validation
@@ -255,10 +276,16 @@ func (s *service) ListTotalGroups(
 	if err != nil {
 		return nil, err
 	}
-	groups, err := s.store.ListGroups(ctx, finalCondition, finalOpts)
+	preloads, err := resolveExpand(models.GroupExpands, oppts.Expand)
+	if err != nil {
+		return nil, err
+	}
+	finalOpts.Select = models.GroupSelects.Columns(models.USPGroupTableName, oppts.Fields, oppts.Expand, qb.keysetColumns()...)
+	groups, err := s.store.ListGroups(ctx, finalCondition, finalOpts, preloads...)
 	if err != nil {
 		return nil, err
 	}
+	groups = finishKeysetPage(qb.QueryBuilder, groups, oppts.Page)
 	groupNames := append([]models.Group(nil), groups...)
 	return groupNames, nil
 }
@@ -285,17 +312,18 @@ func (s *service) ListTotalDevices(
 	if err := qb.SetPagination(oppts.Limit, oppts.Offset); err != nil {
 		return nil, err
 	}
+	if err := qb.SetCursor(oppts.Cursor); err != nil {
+		return nil, err
+	}
 
 	// Add base conditions
 	for key, value := range condition {
 		qb.AddCondition(key, value)
 	}
 
-	// Add filters with validation
-	for _, filter := range oppts.FilterExpr {
-		if err := qb.AddFilter(filter.Filter, filter.Op, filter.Value, filter.Join); err != nil {
-			return nil, err
-		}
+	// Set filter tree with validation
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return nil, err
 	}
 
 	// Add orders with validation
@@ -310,11 +338,17 @@ func (s *service) ListTotalDevices(
 	if err != nil {
 		return nil, err
 	}
+	preloads, err := resolveExpand(models.DeviceExpands, oppts.Expand)
+	if err != nil {
+		return nil, err
+	}
+	finalOpts.Select = models.DeviceSelects.Columns(models.USPDeviceTableName, oppts.Fields, oppts.Expand, qb.keysetColumns()...)
 
-	devices, err := s.store.ListDevices(ctx, finalCondition, finalOpts)
+	devices, err := s.store.ListDevices(ctx, finalCondition, finalOpts, preloads...)
 	if err != nil {
 		return nil, err
 	}
+	devices = finishKeysetPage(qb.QueryBuilder, devices, oppts.Page)
 	deviceNames := append([]models.Device(nil), devices...)
 	return deviceNames, nil
 }
@@ -322,11 +356,16 @@ func (s *service) ListTotalDevices(
 func (s *service) FindModelWithModelId(
 	ctx context.Context,
 	modelId string,
+	expand ...string,
 ) (*models.Model, error) {
+	preloads, err := resolveExpand(models.ModelExpands, expand)
+	if err != nil {
+		return nil, err
+	}
 	condition := map[string]any{
 		"id": modelId,
 	}
-	model, err := s.store.FindModel(ctx, condition)
+	model, err := s.store.FindModel(ctx, condition, preloads...)
 	if err != nil {
 		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+modelId, "find_model_error")
 	}
@@ -336,12 +375,18 @@ func (s *service) FindModelWithModelId(
 func (s *service) GetFirmwareWithId(
 	ctx context.Context,
 	condition map[string]any,
+	expand ...string,
 ) (*models.Firmware, error) {
 	firmwareID := condition["id"]
 	modelID := condition["model_id"]
 
+	preloads, err := resolveExpand(models.FirmwareExpands, expand)
+	if err != nil {
+		return nil, err
+	}
+
 	// check model exists
-	_, err := s.store.FindModel(ctx, map[string]any{"id": modelID})
+	_, err = s.store.FindModel(ctx, map[string]any{"id": modelID})
 	if err != nil {
 		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+fmt.Sprint(modelID), "db_error")
 	}
@@ -350,7 +395,7 @@ func (s *service) GetFirmwareWithId(
 	firmware, err := s.store.FindFirmware(ctx, map[string]any{
 		"id":       firmwareID,
 		"model_id": modelID,
-	})
+	}, preloads...)
 	if err != nil {
 		return nil, apperrors.NewInvalidRequestError(err, "firmware not found with id: "+fmt.Sprint(firmwareID), "db_error")
 	}
@@ -361,12 +406,18 @@ func (s *service) GetFirmwareWithId(
 func (s *service) GetGroupWithId(
 	ctx context.Context,
 	condition map[string]any,
+	expand ...string,
 ) (*models.Group, error) {
 	groupID := condition["id"]
 	modelID := condition["model_id"]
 
+	preloads, err := resolveExpand(models.GroupExpands, expand)
+	if err != nil {
+		return nil, err
+	}
+
 	// check model exists
-	_, err := s.store.FindModel(ctx, map[string]any{"id": modelID})
+	_, err = s.store.FindModel(ctx, map[string]any{"id": modelID})
 	if err != nil {
 		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+fmt.Sprint(modelID), "db_error")
 	}
@@ -375,7 +426,7 @@ func (s *service) GetGroupWithId(
 	group, err := s.store.FindGroup(ctx, map[string]any{
 		"id":       groupID,
 		"model_id": modelID,
-	}, "Firmware")
+	}, preloads...)
 	if err != nil {
 		return nil, apperrors.NewInvalidRequestError(err, "group not found with id: "+fmt.Sprint(groupID), "db_error")
 	}
@@ -386,12 +437,18 @@ func (s *service) GetGroupWithId(
 func (s *service) GetDeviceWithId(
 	ctx context.Context,
 	condition map[string]any,
+	expand ...string,
 ) (*models.Device, error) {
 	deviceID := condition["id"]
 	modelID := condition["model_id"]
 
+	preloads, err := resolveExpand(models.DeviceExpands, expand)
+	if err != nil {
+		return nil, err
+	}
+
 	// check model exists
-	_, err := s.store.FindModel(ctx, map[string]any{"id": modelID})
+	_, err = s.store.FindModel(ctx, map[string]any{"id": modelID})
 	if err != nil {
 		return nil, apperrors.NewInvalidRequestError(err, "model not found with id: "+fmt.Sprint(modelID), "db_error")
 	}
@@ -400,7 +457,7 @@ func (s *service) GetDeviceWithId(
 	device, err := s.store.FindDevice(ctx, map[string]any{
 		"id":       deviceID,
 		"model_id": modelID,
-	}, "Group", "Model")
+	}, preloads...)
 	if err != nil {
 		return nil, apperrors.NewInvalidRequestError(err, "device not found with id: "+fmt.Sprint(deviceID), "db_error")
 	}
@@ -0,0 +1,307 @@
+package managementuc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+)
+
+func (s *service) CreateSavedView(
+	ctx context.Context,
+	view *models.SavedView,
+	teams []string,
+) (string, error) {
+	if err := validateSavedView(view); err != nil {
+		return "", err
+	}
+	if err := checkViewTeam(view.Team, teams); err != nil {
+		return "", err
+	}
+
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return "", err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+	// Names are unique per owner and resource
+	existingView, err := s.store.FindSavedView(txCtx, map[string]any{
+		models.SavedView{}.GetOwnerColumnName():    view.Owner,
+		models.SavedView{}.GetResourceColumnName(): view.Resource,
+		models.SavedView{}.GetNameColumnName():     view.Name,
+		models.SavedView{}.GetStatusColumnName():   "ENABLE",
+	})
+	if err == nil && existingView != nil {
+		logging.Errorf("view already exists with name: %s", view.Name)
+		return "", apperrors.NewInvalidRequestError(err, "view already exists with name: "+view.Name, "name")
+	}
+	// Insert view
+	if err := s.store.InsertSavedView(txCtx, view); err != nil {
+		logging.Errorf("failed to insert view: %v", err)
+		return "", err
+	}
+
+	// Commit transaction if success
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return "", err
+	}
+
+	success = true
+	logging.Infof("View created successfully with ID: %s", view.Id)
+	return view.Id.String(), nil
+}
+
+func (s *service) UpdateSavedViewWithId(
+	ctx context.Context,
+	id string,
+	user string,
+	teams []string,
+	view *models.SavedViewUpdate,
+) error {
+	if view.Team != nil {
+		if err := checkViewTeam(*view.Team, teams); err != nil {
+			return err
+		}
+	}
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+	existingView, err := s.findOwnedSavedView(txCtx, id, user, teams)
+	if err != nil {
+		return err
+	}
+
+	// Validate the view as it will be stored
+	merged := *existingView
+	if view.Name != nil {
+		merged.Name = *view.Name
+	}
+	if view.Filter != nil {
+		merged.Filter = *view.Filter
+	}
+	if view.OrderBy != nil {
+		merged.OrderBy = *view.OrderBy
+	}
+	if view.PageSize != nil {
+		merged.PageSize = *view.PageSize
+	}
+	if view.Fields != nil {
+		merged.Fields = view.Fields
+	}
+	if err := validateSavedView(&merged); err != nil {
+		return err
+	}
+	//Find view name exists
+	if view.Name != nil && *view.Name != existingView.Name {
+		existingName, err := s.store.FindSavedView(txCtx, map[string]any{
+			models.SavedView{}.GetOwnerColumnName():    existingView.Owner,
+			models.SavedView{}.GetResourceColumnName(): existingView.Resource,
+			models.SavedView{}.GetNameColumnName():     *view.Name,
+			models.SavedView{}.GetStatusColumnName():   "ENABLE",
+		})
+		if err == nil && existingName != nil && existingName.Id.String() != id {
+			logging.Errorf("view already exists with name: %s", *view.Name)
+			return apperrors.NewInvalidRequestError(err, "view already exists with name: "+*view.Name, "name")
+		}
+	}
+	// Update view
+	if err := s.store.UpdateSavedView(txCtx, id, view); err != nil {
+		logging.Errorf("failed to update view: %v", err)
+		return err
+	}
+
+	// Commit transaction if success
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	success = true
+	logging.Infof("View updated successfully with ID: %s", id)
+	return nil
+}
+
+func (s *service) DeleteSavedViewWithId(
+	ctx context.Context,
+	id string,
+	user string,
+	teams []string,
+) error {
+	view, err := s.findOwnedSavedView(ctx, id, user, teams)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.ChangeStatusSavedViewToDelete(ctx, view.Id.String(), user); err != nil {
+		logging.Errorf("failed to change status of view id=%s: %v", id, err)
+		return err
+	}
+
+	logging.Infof("View deleted successfully with ID: %s", id)
+	return nil
+}
+
+func (s *service) GetSavedViewWithId(
+	ctx context.Context,
+	id string,
+	user string,
+	teams []string,
+) (*models.SavedView, error) {
+	view, err := s.store.FindSavedView(ctx, map[string]any{
+		models.SavedView{}.GetIdColumnName():     id,
+		models.SavedView{}.GetStatusColumnName(): "ENABLE",
+	})
+	if err != nil {
+		return nil, err
+	}
+	// a private view of another user is reported as missing
+	if !view.VisibleTo(user, teams) {
+		return nil, apperrors.NewErrEntityNotExist(view.GetEntityName())
+	}
+	return view, nil
+}
+
+func (s *service) ListSavedViews(
+	ctx context.Context,
+	resource string,
+	user string,
+	teams []string,
+) ([]models.SavedView, error) {
+	if _, ok := models.SavedViewResources[resource]; !ok {
+		return nil, apperrors.NewInvalidRequestError(nil, "invalid view resource: "+resource, "invalid_resource")
+	}
+	return s.store.ListSavedViews(ctx, resource, user, teams)
+}
+
+func (s *service) LoadSavedView(
+	ctx context.Context,
+	id string,
+	user string,
+	teams []string,
+	resource string,
+) (*models.SavedView, error) {
+	view, err := s.GetSavedViewWithId(ctx, id, user, teams)
+	if err != nil {
+		return nil, err
+	}
+	if view.Resource != resource {
+		return nil, apperrors.NewInvalidRequestError(
+			nil,
+			fmt.Sprintf("view %s applies to %s, not %s", view.Name, view.Resource, resource),
+			"invalid_view",
+		)
+	}
+	// a field used by the view may have been removed from the list or its response since it was saved
+	if err := validateSavedView(view); err != nil {
+		msg := err.Error()
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			msg = appErr.ErrorMessage()
+		}
+		return nil, apperrors.NewInvalidRequestError(
+			err,
+			fmt.Sprintf("view %s is no longer valid, %s", view.Name, msg),
+			"invalid_view",
+		)
+	}
+	return view, nil
+}
+
+// findOwnedSavedView returns a live view that user can change
+func (s *service) findOwnedSavedView(ctx context.Context, id string, user string, teams []string) (*models.SavedView, error) {
+	view, err := s.GetSavedViewWithId(ctx, id, user, teams)
+	if err != nil {
+		logging.Errorf("view not found with id=%s: %v", id, err)
+		return nil, err
+	}
+	if view.Owner != user {
+		logging.Errorf("user %s cannot change view id=%s of %s", user, id, view.Owner)
+		return nil, apperrors.NewErrUnauthorized()
+	}
+	return view, nil
+}
+
+// checkViewTeam checks that a view is shared with one of the teams of its owner, no team keeps it
+// private or shared with everyone
+func checkViewTeam(team string, teams []string) error {
+	if team != "" && !slices.Contains(teams, team) {
+		return apperrors.NewInvalidRequestError(nil, "view can only be shared with a team of its owner: "+team, "invalid_team")
+	}
+	return nil
+}
+
+// validateSavedView checks the filter and order of a view against the fields of its list and its
+// fields against the attributes of the list response, as a list request with the same filter,
+// orderBy and fields would
+func validateSavedView(view *models.SavedView) error {
+	fields, ok := models.SavedViewResources[view.Resource]
+	if !ok {
+		return apperrors.NewInvalidRequestError(nil, "invalid view resource: "+view.Resource, "invalid_resource")
+	}
+	if view.PageSize < 0 || view.PageSize > models.MaxSavedViewPageSize {
+		return apperrors.NewInvalidRequestError(
+			nil,
+			fmt.Sprintf("page_size must be between 1 and %d", models.MaxSavedViewPageSize),
+			"invalid_page_size",
+		)
+	}
+
+	filterExpr, err := utils.ParseFilterExpr(view.Filter)
+	if err != nil {
+		return apperrors.NewInvalidRequestError(err, "invalid filter expression: "+err.Error(), "invalid_filter")
+	}
+	orderExpr, err := utils.ParseOrderExpr(view.OrderBy)
+	if err != nil {
+		return apperrors.NewInvalidRequestError(err, "invalid order expression: "+err.Error(), "invalid_order")
+	}
+
+	qb := NewQueryBuilder()
+	if err := qb.SetFilter(filterExpr); err != nil {
+		return err
+	}
+	for _, order := range orderExpr {
+		if err := qb.AddOrder(order.Field, order.Direction); err != nil {
+			return err
+		}
+	}
+	if _, _, err = qb.BuildSafeQuery(fields); err != nil {
+		return err
+	}
+
+	if len(view.Fields) == 0 {
+		return nil
+	}
+	selects, ok := models.SavedViewSelects[view.Resource]
+	if !ok {
+		return apperrors.NewInvalidRequestError(nil, "fields cannot be saved for "+view.Resource, "invalid_fields")
+	}
+	if err := selects.CheckAttributes(view.Fields); err != nil {
+		return apperrors.NewInvalidRequestError(err, err.Error(), "invalid_fields")
+	}
+	return nil
+}
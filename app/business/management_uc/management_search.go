@@ -0,0 +1,83 @@
+package managementuc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+)
+
+// Search looks for text in devices, models, groups, firmwares, profiles and parameters, types
+// restricts the kinds of hit. A resource matched on several columns is returned once, on its best match.
+func (s *service) Search(
+	ctx context.Context,
+	text string,
+	types []string,
+	limit int,
+) ([]models.SearchHit, error) {
+	text = strings.TrimSpace(text)
+	if length := utf8.RuneCountInString(text); length < models.MinSearchLength || length > models.MaxSearchLength {
+		return nil, apperrors.NewInvalidRequestError(
+			nil,
+			fmt.Sprintf("q must have between %d and %d characters", models.MinSearchLength, models.MaxSearchLength),
+			"invalid_search",
+		)
+	}
+
+	if limit == 0 {
+		limit = models.DefaultSearchLimit
+	}
+	if limit < 0 || limit > models.MaxSearchLimit {
+		return nil, apperrors.NewInvalidRequestError(
+			nil,
+			fmt.Sprintf("limit must be between 1 and %d", models.MaxSearchLimit),
+			"invalid_limit",
+		)
+	}
+
+	for _, t := range types {
+		if !contains(models.SearchTypes, t) {
+			return nil, apperrors.NewInvalidRequestError(nil, "invalid search type: "+t, "invalid_type")
+		}
+	}
+
+	query := models.SearchQuery{Limit: limit}
+	for _, target := range models.SearchTargets {
+		if len(types) > 0 && !contains(types, target.Type) {
+			continue
+		}
+		target.Term = text
+		if target.Column == "mac_address" {
+			// MAC addresses are stored as 12 hex digits without separators
+			target.Term = strings.NewReplacer(":", "", "-", "", ".", "").Replace(text)
+			if target.Term == "" {
+				continue
+			}
+		}
+		query.Targets = append(query.Targets, target)
+	}
+
+	hits, err := s.store.SearchEntities(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// hits come best first, only the first hit of a resource is kept
+	seen := make(map[string]bool, len(hits))
+	results := make([]models.SearchHit, 0, limit)
+	for _, hit := range hits {
+		key := hit.Type + "/" + hit.Id
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		results = append(results, hit)
+		if len(results) == limit {
+			break
+		}
+	}
+
+	return results, nil
+}
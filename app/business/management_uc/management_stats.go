@@ -0,0 +1,95 @@
+package managementuc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+)
+
+// GetStats counts the rows of a resource matching filter grouped by the groupBy dimensions.
+// Deleted rows are left out unless the filter asks for a status, as in the lists.
+func (s *service) GetStats(
+	ctx context.Context,
+	resource string,
+	groupBy []string,
+	filter *models.FilterExpr,
+) (*models.StatsResult, error) {
+	stats, ok := models.StatsResources[strings.ToLower(resource)]
+	if !ok {
+		return nil, apperrors.NewInvalidRequestError(nil, "invalid stats resource: "+resource, "invalid_resource")
+	}
+
+	if len(groupBy) == 0 || len(groupBy) > models.MaxStatsDimensions {
+		return nil, apperrors.NewInvalidRequestError(
+			nil,
+			fmt.Sprintf("groupBy must have between 1 and %d dimensions", models.MaxStatsDimensions),
+			"invalid_group_by",
+		)
+	}
+
+	query := models.StatsQuery{
+		Table:     stats.Table,
+		Condition: make(map[string]any),
+		Limit:     models.MaxStatsBuckets + 1,
+	}
+	names := make([]string, 0, len(groupBy))
+	dimensions := make([]models.StatsDimension, 0, len(groupBy))
+	for _, raw := range groupBy {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		dimension, ok := stats.Dimensions[name]
+		if !ok || contains(names, name) {
+			return nil, apperrors.NewInvalidRequestError(nil, "invalid groupBy dimension: "+raw, "invalid_group_by")
+		}
+		names = append(names, name)
+		dimensions = append(dimensions, dimension)
+		query.Columns = append(query.Columns, dimension.Column)
+		if dimension.Join != "" && !contains(query.Joins, dimension.Join) {
+			query.Joins = append(query.Joins, dimension.Join)
+		}
+	}
+
+	qb := NewQueryBuilder()
+	if err := qb.SetFilter(filter); err != nil {
+		return nil, err
+	}
+	condition, opts, err := qb.BuildSafeQuery(stats.Fields)
+	if err != nil {
+		return nil, err
+	}
+	// the default conditions name columns of the resource, a join may bring the same names
+	for key, value := range condition {
+		query.Condition[stats.Table+"."+key] = value
+	}
+	query.FilterExpr = opts.FilterExpr
+
+	buckets, total, err := s.store.AggregateStats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.StatsResult{Dimensions: names, Total: total}
+	if len(buckets) > models.MaxStatsBuckets {
+		buckets = buckets[:models.MaxStatsBuckets]
+		result.Truncated = true
+	}
+	for i := range buckets {
+		// the store reads the values as text, numbers and booleans are given back their type
+		for j, value := range buckets[i].Values {
+			text, ok := value.(string)
+			if !ok {
+				continue
+			}
+			switch dimensions[j].Type {
+			case models.FieldTypeInt, models.FieldTypeBool:
+				if typed, err := dimensions[j].Coerce(text); err == nil {
+					buckets[i].Values[j] = typed
+				}
+			}
+		}
+	}
+	result.Buckets = buckets
+
+	return result, nil
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"io"
-	"regexp"
-	"strings"
 	"time"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
@@ -77,6 +75,7 @@ func (s *service) UpsertProfileWithParameters(
 		},
 	)
 
+	profileAction := models.EventActionUpdated
 	if err == nil {
 		// Profile exists - update it
 		profile.Id = existingProfile.Id
@@ -104,6 +103,7 @@ func (s *service) UpsertProfileWithParameters(
 				logging.Errorf("failed to insert profile: %v", err)
 				return err
 			}
+			profileAction = models.EventActionCreated
 		} else {
 			logging.Errorf("failed to find profile: %v", err)
 			return err
@@ -143,6 +143,9 @@ func (s *service) UpsertProfileWithParameters(
 			return err
 		}
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateProfile, profile.Id, profileAction, profile.UpdatedBy, profile); err != nil {
+		return err
+	}
 
 	if err := s.store.CommitTx(txCtx); err != nil {
 		logging.Errorf("failed to commit transaction: %v", err)
@@ -254,6 +257,12 @@ func (s *service) UpdateModelWithModelId(
 			return apperrors.NewInvalidRequestError(nil, "image size exceeds 150KB", "image")
 		}
 	}
+	// validate endpoint id template, existing devices keep their endpoint id
+	if model.EndpointIdTemplate != nil && *model.EndpointIdTemplate != "" {
+		if err := models.ValidateEndpointIdTemplate(*model.EndpointIdTemplate); err != nil {
+			return apperrors.NewInvalidRequestError(err, "invalid endpoint_id_template: "+err.Error(), "endpoint_id_template")
+		}
+	}
 	//Find Model name exists
 	if model.Name != nil && *model.Name != "" {
 		existingModelName, err := s.store.FindModel(txCtx, map[string]any{
@@ -376,6 +385,10 @@ func (s *service) UpdateFirmwareWithId(
 		}
 	}
 
+	if err := s.emitDomainEvent(txCtx, models.AggregateFirmware, existingFirmware.Id, models.EventActionUpdated, derefString(firmware.UpdatedBy), firmware); err != nil {
+		return err
+	}
+
 	// Commit transaction if success
 	if err := s.store.CommitTx(txCtx); err != nil {
 		logging.Errorf("failed to commit transaction: %v", err)
@@ -452,35 +465,29 @@ func (s *service) UpdateGroupWithId(
 
 	//validate download period
 	if group.DownloadPeriod != nil {
-		var downloadPeriodRegex = regexp.MustCompile(`^([01]\d|2[0-3]):([0-5]\d)~([01]\d|2[0-3]):([0-5]\d)$`)
-		downloadPeriod := *group.DownloadPeriod
-		if !downloadPeriodRegex.MatchString(downloadPeriod) {
-			return apperrors.NewInvalidRequestError(nil, "invalid format, expected format: 'HH:MM~HH:MM'", "download_period")
-		}
-		layout := "15:04"
-		parts := strings.Split(downloadPeriod, "~")
-		if len(parts) != 2 {
-			return apperrors.NewInvalidRequestError(nil, "invalid format, expected format: 'start~end'", "download_period")
-		}
-		from, err := time.Parse(layout, parts[0])
-		if err != nil {
-			return apperrors.NewInvalidRequestError(err, "invalid start time: "+parts[0], "download_period")
-		}
-		logging.Infof("Parsed start time: %s", from.Format(layout))
-		to, err := time.Parse(layout, parts[1])
-		if err != nil {
-			return apperrors.NewInvalidRequestError(err, "invalid end time: "+parts[1], "download_period")
+		if err := checkDownloadPeriod(*group.DownloadPeriod); err != nil {
+			return err
 		}
-		logging.Infof("Parsed end time: %s", to.Format(layout))
-		// if from.Equal(to) {
-		// 	return apperrors.NewInvalidRequestError(nil, "start time and end time cannot be the same", "download_period")
-		// }
 	}
 	// Update group
 	if err := s.store.UpdateGroup(txCtx, id, group); err != nil {
 		logging.Errorf("failed to update group: %v", err)
 		return err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateGroup, existingGroup.Id, models.EventActionUpdated, derefString(group.UpdatedBy), group); err != nil {
+		return err
+	}
+	// Devices of the group will be upgraded, notify separately from the generic update
+	if group.FirmwareId != nil && (existingGroup.FirmwareId == nil || *existingGroup.FirmwareId != *group.FirmwareId) {
+		if err := s.emitDomainEvent(txCtx, models.AggregateGroup, existingGroup.Id, models.EventActionFirmwareChanged, derefString(group.UpdatedBy), map[string]any{
+			"id":                   existingGroup.Id,
+			"model_id":             existingGroup.ModelId,
+			"previous_firmware_id": existingGroup.FirmwareId,
+			"firmware_id":          group.FirmwareId,
+		}); err != nil {
+			return err
+		}
+	}
 
 	// Commit transaction if success
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -515,7 +522,7 @@ func (s *service) UpdateDeviceWithId(
 		}
 	}()
 	// Find model with modelId
-	_, err = s.store.FindModel(txCtx, map[string]any{
+	model, err := s.store.FindModel(txCtx, map[string]any{
 		models.Model{}.GetIdColumnName(): modelId,
 	})
 	if err != nil {
@@ -545,11 +552,30 @@ func (s *service) UpdateDeviceWithId(
 			return apperrors.NewInvalidRequestError(err, "group not found with id="+(*device.GroupId).String()+" for model_id="+modelId, "group_id")
 		}
 	}
+	// lifecycle_state only moves along an allowed transition
+	if device.LifecycleState != nil {
+		if *device.LifecycleState == existingDevice.LifecycleState {
+			device.LifecycleState = nil
+		} else if *device.LifecycleState == models.DeviceStateReplaced {
+			return apperrors.NewInvalidRequestError(nil, "a device is only REPLACED through the replace endpoint", "lifecycle_state")
+		} else if err := s.transitionDeviceLifecycle(txCtx, existingDevice, *device.LifecycleState, device.LifecycleReason, derefString(device.UpdatedBy)); err != nil {
+			return err
+		}
+	}
+	// serial number is unique per manufacturer
+	if device.SerialNumber != nil && *device.SerialNumber != existingDevice.SerialNumber {
+		if err := s.checkSerialNumberUnique(txCtx, model.Manufacturer, *device.SerialNumber, id); err != nil {
+			return err
+		}
+	}
 	// Update device
 	if err := s.store.UpdateDevice(txCtx, id, device); err != nil {
 		logging.Errorf("failed to update device: %v", err)
 		return err
 	}
+	if err := s.emitDomainEvent(txCtx, models.AggregateDevice, existingDevice.Id, models.EventActionUpdated, derefString(device.UpdatedBy), device); err != nil {
+		return err
+	}
 
 	// Commit transaction if success
 	if err := s.store.CommitTx(txCtx); err != nil {
@@ -654,6 +680,10 @@ func (s *service) UpdateProfileWithParameterId(
 		}
 	}
 
+	if err := s.emitDomainEvent(txCtx, models.AggregateProfile, existingProfile.Id, models.EventActionUpdated, derefString(profileParameter.UpdatedBy), profileParameter); err != nil {
+		return err
+	}
+
 	// Commit transaction if success
 	if err := s.store.CommitTx(txCtx); err != nil {
 		logging.Errorf("failed to commit transaction: %v", err)
@@ -0,0 +1,525 @@
+package managementuc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+)
+
+func (s *service) CreateWebhookSubscription(
+	ctx context.Context,
+	subscription *models.WebhookSubscription,
+) (string, error) {
+	if err := validateWebhookSubscription(subscription.Url, subscription.EventTypes); err != nil {
+		return "", err
+	}
+	// Generate secret when the caller does not provide one, it is only returned on create
+	if subscription.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return "", apperrors.NewInternalError(err, "failed to generate webhook secret")
+		}
+		subscription.Secret = secret
+	}
+
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return "", err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+	//Find webhook name exists
+	existingSubscription, err := s.store.FindWebhookSubscription(txCtx, map[string]any{
+		models.WebhookSubscription{}.GetNameColumnName(): subscription.Name,
+	})
+	if err == nil && existingSubscription != nil {
+		logging.Errorf("webhook already exists with name: %s", subscription.Name)
+		return "", apperrors.NewInvalidRequestError(err, "webhook already exists with name: "+subscription.Name, "name")
+	}
+	// Insert webhook
+	if err := s.store.InsertWebhookSubscription(txCtx, subscription); err != nil {
+		logging.Errorf("failed to insert webhook: %v", err)
+		return "", err
+	}
+
+	// Commit transaction if success
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return "", err
+	}
+
+	success = true
+	logging.Infof("Webhook created successfully with ID: %s", subscription.Id)
+	return subscription.Id.String(), nil
+}
+
+func (s *service) UpdateWebhookSubscriptionWithId(
+	ctx context.Context,
+	id string,
+	subscription *models.WebhookSubscriptionUpdate,
+) error {
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+	// Find webhook with Id
+	existingSubscription, err := s.store.FindWebhookSubscription(txCtx, map[string]any{
+		models.WebhookSubscription{}.GetIdColumnName():     id,
+		models.WebhookSubscription{}.GetStatusColumnName(): []string{"ENABLE", "DISABLE"},
+	})
+	if err != nil {
+		logging.Errorf("webhook not found with id=%s: %v", id, err)
+		return apperrors.NewInvalidRequestError(err, "webhook not found with id="+id, "id")
+	}
+
+	targetUrl := existingSubscription.Url
+	if subscription.Url != nil {
+		targetUrl = *subscription.Url
+	}
+	eventTypes := existingSubscription.EventTypes
+	if subscription.EventTypes != nil {
+		eventTypes = subscription.EventTypes
+	}
+	if err := validateWebhookSubscription(targetUrl, eventTypes); err != nil {
+		return err
+	}
+	//Find webhook name exists
+	if subscription.Name != nil && *subscription.Name != "" {
+		existingName, err := s.store.FindWebhookSubscription(txCtx, map[string]any{
+			models.WebhookSubscription{}.GetNameColumnName(): *subscription.Name,
+		})
+		if err == nil && existingName != nil && existingName.Id.String() != id {
+			logging.Errorf("webhook already exists with name: %s", *subscription.Name)
+			return apperrors.NewInvalidRequestError(err, "webhook already exists with name: "+*subscription.Name, "name")
+		}
+	}
+	// Update webhook
+	if err := s.store.UpdateWebhookSubscription(txCtx, id, subscription); err != nil {
+		logging.Errorf("failed to update webhook: %v", err)
+		return err
+	}
+
+	// Commit transaction if success
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	success = true
+	logging.Infof("Webhook updated successfully with ID: %s", id)
+	return nil
+}
+
+func (s *service) DeleteWebhookSubscriptionWithId(
+	ctx context.Context,
+	id string,
+	updatedBy string,
+) error {
+	// Find webhook with Id
+	subscription, err := s.store.FindWebhookSubscription(ctx, map[string]any{
+		models.WebhookSubscription{}.GetIdColumnName(): id,
+	})
+	if err != nil {
+		logging.Errorf("webhook not found with id=%s: %v", id, err)
+		return err
+	}
+
+	// Change status of webhook to DELETE, pending deliveries are failed by the dispatcher
+	if err := s.store.ChangeStatusWebhookSubscriptionToDelete(ctx, subscription.Id.String(), updatedBy); err != nil {
+		logging.Errorf("failed to change status of webhook id=%s: %v", id, err)
+		return err
+	}
+
+	logging.Infof("Webhook disabled successfully with ID: %s", id)
+	return nil
+}
+
+func (s *service) GetWebhookSubscriptionWithId(
+	ctx context.Context,
+	id string,
+) (*models.WebhookSubscription, error) {
+	return s.store.FindWebhookSubscription(ctx, map[string]any{
+		models.WebhookSubscription{}.GetIdColumnName():     id,
+		models.WebhookSubscription{}.GetStatusColumnName(): []string{"ENABLE", "DISABLE"},
+	})
+}
+
+func (s *service) ListWebhookSubscriptions(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) ([]models.WebhookSubscription, error) {
+	qb := NewWebhookSubscriptionQueryBuilder()
+
+	// Set pagination
+	if err := qb.SetPagination(oppts.Limit, oppts.Offset); err != nil {
+		return nil, err
+	}
+	if err := qb.SetCursor(oppts.Cursor); err != nil {
+		return nil, err
+	}
+
+	// Add base conditions
+	for key, value := range condition {
+		qb.AddCondition(key, value)
+	}
+
+	// Set filter tree with validation
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return nil, err
+	}
+
+	// Add orders with validation
+	for _, order := range oppts.OrderExpr {
+		if err := qb.AddOrder(order.Field, order.Direction); err != nil {
+			return nil, err
+		}
+	}
+
+	finalCondition, finalOpts, err := qb.BuildWebhookSubscription()
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := s.store.ListWebhookSubscriptions(ctx, finalCondition, finalOpts)
+	if err != nil {
+		return nil, err
+	}
+	return finishKeysetPage(qb.QueryBuilder, subscriptions, oppts.Page), nil
+}
+
+func (s *service) ListWebhookDeliveries(
+	ctx context.Context,
+	subscriptionId string,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) ([]models.WebhookDelivery, error) {
+	// Validate webhook exists first
+	if _, err := s.store.FindWebhookSubscription(ctx, map[string]any{
+		models.WebhookSubscription{}.GetIdColumnName(): subscriptionId,
+	}); err != nil {
+		return nil, apperrors.NewInvalidRequestError(err, "webhook not found with id: "+subscriptionId, "webhook_id")
+	}
+
+	qb := NewWebhookDeliveryQueryBuilder()
+
+	// Set pagination
+	if err := qb.SetPagination(oppts.Limit, oppts.Offset); err != nil {
+		return nil, err
+	}
+	if err := qb.SetCursor(oppts.Cursor); err != nil {
+		return nil, err
+	}
+
+	// Add base conditions
+	for key, value := range condition {
+		qb.AddCondition(key, value)
+	}
+	qb.AddCondition(models.WebhookDelivery{}.GetSubscriptionIdColumnName(), subscriptionId)
+
+	// Set filter tree with validation
+	if err := qb.SetFilter(oppts.FilterExpr); err != nil {
+		return nil, err
+	}
+
+	// Add orders with validation
+	for _, order := range oppts.OrderExpr {
+		if err := qb.AddOrder(order.Field, order.Direction); err != nil {
+			return nil, err
+		}
+	}
+
+	finalCondition, finalOpts, err := qb.BuildWebhookDelivery()
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.store.ListWebhookDeliveries(ctx, finalCondition, finalOpts)
+	if err != nil {
+		return nil, err
+	}
+	return finishKeysetPage(qb.QueryBuilder, deliveries, oppts.Page), nil
+}
+
+type webhookSubscriptionsKey struct{}
+
+// withWebhookSubscriptions makes the events emitted with txCtx share one read of the subscriptions
+// per event type, for the bulk writes that emit one event per row. The transaction reads one
+// snapshot, so the cached subscriptions are the ones a new read would return.
+func withWebhookSubscriptions(txCtx context.Context) context.Context {
+	return context.WithValue(txCtx, webhookSubscriptionsKey{}, map[string][]models.WebhookSubscription{})
+}
+
+// webhookSubscriptionsForEvent returns the subscriptions of eventType, cached when txCtx
+// was made by withWebhookSubscriptions.
+func (s *service) webhookSubscriptionsForEvent(
+	txCtx context.Context,
+	eventType string,
+) ([]models.WebhookSubscription, error) {
+	cache, _ := txCtx.Value(webhookSubscriptionsKey{}).(map[string][]models.WebhookSubscription)
+	if subscriptions, ok := cache[eventType]; ok {
+		return subscriptions, nil
+	}
+	subscriptions, err := s.store.ListWebhookSubscriptionsForEvent(txCtx, eventType)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		cache[eventType] = subscriptions
+	}
+	return subscriptions, nil
+}
+
+// enqueueWebhookDeliveries queues the event for every matching subscription,
+// txCtx must hold the transaction of the change.
+func (s *service) enqueueWebhookDeliveries(
+	txCtx context.Context,
+	event *models.OutboxEvent,
+) error {
+	subscriptions, err := s.webhookSubscriptionsForEvent(txCtx, event.EventType)
+	if err != nil {
+		logging.Errorf("failed to list webhooks for %s: %v", event.EventType, err)
+		return err
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		delivery := &models.WebhookDelivery{
+			SubscriptionId: subscription.Id,
+			EventId:        event.Id,
+			EventType:      event.EventType,
+			Payload:        event.Payload,
+			Status:         models.WebhookDeliveryStatusPending,
+			NextAttemptAt:  &now,
+		}
+		if err := s.store.InsertWebhookDelivery(txCtx, delivery); err != nil {
+			logging.Errorf("failed to queue webhook delivery for webhook %s: %v", subscription.Id, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) DispatchWebhookDeliveries(
+	ctx context.Context,
+	batchSize int,
+	policy models.WebhookRetryPolicy,
+) (int, error) {
+	// microseconds, the precision next_attempt_at is stored and compared with
+	leasedUntil := time.Now().Add(policy.Lease(batchSize)).Truncate(time.Microsecond)
+	deliveries, err := s.claimWebhookDeliveries(ctx, batchSize, leasedUntil)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	// requests still running when the lease ends are cancelled, another dispatcher may send them
+	sendCtx, cancel := context.WithDeadline(ctx, leasedUntil)
+	defer cancel()
+
+	slots := make(chan struct{}, max(policy.Concurrency, 1))
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(delivery models.WebhookDelivery) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			s.sendWebhookDelivery(sendCtx, delivery, leasedUntil, policy)
+		}(delivery)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// claimWebhookDeliveries leases due deliveries so a concurrent dispatcher does not send them too.
+func (s *service) claimWebhookDeliveries(
+	ctx context.Context,
+	batchSize int,
+	leasedUntil time.Time,
+) ([]models.WebhookDelivery, error) {
+	txCtx, err := s.store.BeginTx(ctx)
+	if err != nil {
+		logging.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			rbErr := s.store.RollbackTx(txCtx)
+			if rbErr != nil {
+				logging.Errorf("failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	deliveries, err := s.store.ListDueWebhookDeliveries(txCtx, batchSize)
+	if err != nil {
+		logging.Errorf("failed to list due webhook deliveries: %v", err)
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.Id.String())
+	}
+	if err := s.store.PostponeWebhookDeliveries(txCtx, ids, leasedUntil); err != nil {
+		logging.Errorf("failed to lease webhook deliveries: %v", err)
+		return nil, err
+	}
+
+	if err := s.store.CommitTx(txCtx); err != nil {
+		logging.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	success = true
+	return deliveries, nil
+}
+
+func (s *service) sendWebhookDelivery(
+	ctx context.Context,
+	delivery models.WebhookDelivery,
+	leasedUntil time.Time,
+	policy models.WebhookRetryPolicy,
+) {
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	update := &models.WebhookDeliveryUpdate{
+		Attempts:  &attempts,
+		UpdatedAt: &now,
+	}
+
+	subscription := delivery.Subscription
+	if subscription == nil || subscription.Status != "ENABLE" {
+		status := models.WebhookDeliveryStatusFailed
+		reason := "webhook is disabled or deleted"
+		update.Attempts = &delivery.Attempts
+		update.Status = &status
+		update.LastError = &reason
+	} else {
+		timestamp := now.Unix()
+		headers := map[string]string{
+			models.WebhookHeaderEvent:     delivery.EventType,
+			models.WebhookHeaderDelivery:  delivery.Id.String(),
+			models.WebhookHeaderTimestamp: strconv.FormatInt(timestamp, 10),
+			models.WebhookHeaderSignature: models.SignWebhookPayload(subscription.Secret, timestamp, delivery.Payload),
+		}
+
+		code, err := s.webhookSender.Send(ctx, subscription.Url, headers, delivery.Payload)
+		update.ResponseCode = &code
+		switch {
+		case err == nil && code >= 200 && code < 300:
+			status := models.WebhookDeliveryStatusSuccess
+			update.Status = &status
+			update.DeliveredAt = &now
+		default:
+			reason := "unexpected response status " + strconv.Itoa(code)
+			if err != nil {
+				reason = err.Error()
+			}
+			update.LastError = &reason
+
+			if attempts >= policy.MaxAttempts {
+				status := models.WebhookDeliveryStatusFailed
+				update.Status = &status
+			} else {
+				nextAttemptAt := now.Add(policy.NextDelay(attempts))
+				update.NextAttemptAt = &nextAttemptAt
+			}
+			logging.Warnf("webhook delivery %s to %s failed, attempt %d/%d: %s",
+				delivery.Id, subscription.Url, attempts, policy.MaxAttempts, reason)
+		}
+	}
+
+	// recorded even when the request was cancelled at the end of the lease, the update checks the lease
+	recorded, err := s.store.UpdateWebhookDelivery(context.WithoutCancel(ctx), delivery.Id.String(), leasedUntil, update)
+	if err != nil {
+		logging.Errorf("failed to record webhook delivery %s: %v", delivery.Id, err)
+	} else if !recorded {
+		logging.Warnf("webhook delivery %s was leased by another dispatcher, attempt not recorded", delivery.Id)
+	}
+}
+
+func (s *service) RunWebhookDispatcher(
+	ctx context.Context,
+	interval time.Duration,
+	batchSize int,
+	policy models.WebhookRetryPolicy,
+) {
+	logging.Infof("Webhook dispatcher started, interval=%s batch_size=%d", interval, batchSize)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Infof("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+
+		// drain due deliveries before waiting for the next tick
+		for ctx.Err() == nil {
+			dispatched, err := s.DispatchWebhookDeliveries(ctx, batchSize, policy)
+			if err != nil || dispatched < batchSize {
+				break
+			}
+		}
+	}
+}
+
+func validateWebhookSubscription(targetUrl string, eventTypes []string) error {
+	parsed, err := url.ParseRequestURI(targetUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return apperrors.NewInvalidRequestError(err, "url must be an absolute http(s) url", "url")
+	}
+
+	if len(eventTypes) == 0 {
+		return apperrors.NewInvalidRequestError(nil, "event_types must not be empty", "event_types")
+	}
+	for _, eventType := range eventTypes {
+		if eventType != models.WebhookEventTypeAll && !contains(models.WebhookEventTypes, eventType) {
+			return apperrors.NewInvalidRequestError(nil, "unknown event type: "+eventType, "event_types")
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
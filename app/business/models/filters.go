@@ -2,6 +2,7 @@ package models
 
 import (
 	"math"
+	"strings"
 	"usp-management-device-api/common/validator"
 )
 
@@ -13,22 +14,93 @@ type Filters struct {
 }
 
 type QueryOptions struct {
-	Limit      int          `json:"limit"`
-	Offset     int          `json:"offset"`
-	FilterExpr []FilterExpr `json:"filter_expr"`
-	OrderExpr  []OrderExpr  `json:"order_expr"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	FilterExpr *FilterExpr `json:"filter_expr"`
+	OrderExpr  []OrderExpr `json:"order_expr"`
+
+	// Cursor switches the list to keyset pagination instead of Offset, "" asks for the first page.
+	// Keyset is the cursor resolved by the field registry, Page receives the cursors of the page read.
+	Cursor *string   `json:"cursor,omitempty"`
+	Keyset *Keyset   `json:"-"`
+	Page   *PageInfo `json:"-"`
+
+	// Expand names the relations to load with the rows, checked against the ExpandRegistry of the resource.
+	Expand []string `json:"expand,omitempty"`
+
+	// Fields names the response attributes asked by the client, nil asks for all of them.
+	// Select is the qualified columns the store reads for them, nil reads every column.
+	Fields []string `json:"fields,omitempty"`
+	Select []string `json:"-"`
+}
+
+// FilterOperators are the operators of a filter condition. in, nin and between read Values,
+// isnull and notnull take no value, contains tests membership in an array column.
+var FilterOperators = []string{
+	"eq", "ne", "lt", "gt", "lte", "gte",
+	"like", "nlike", "ilike", "startswith", "endswith",
+	"in", "nin", "between", "isnull", "notnull", "contains",
 }
 
+// MaxFilterValues bounds the list of an in or nin condition.
+const MaxFilterValues = 1000
+
+// FilterExpr is a node of a filter expression tree. A group joins its Children with Join,
+// a condition compares the column Filter with Value using Op.
 type FilterExpr struct {
-	Filter string `json:"filter"`
-	Op     string `json:"op"`
-	Value  string `json:"value"`
-	Join   string `json:"join"` // "AND" | "OR"
+	Join     string        `json:"join,omitempty"` // "AND" | "OR", groups only
+	Children []*FilterExpr `json:"children,omitempty"`
+
+	Filter string   `json:"filter,omitempty"`
+	Op     string   `json:"op,omitempty"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"` // in, nin and the two bounds of between
+
+	// Column and Args are set when the field registry resolves the condition,
+	// the qualified column and the values coerced to its type
+	Column string `json:"-"`
+	Args   []any  `json:"-"`
+}
+
+// IsGroup reports whether the node joins sub-expressions instead of holding a condition.
+func (f *FilterExpr) IsGroup() bool {
+	return f.Join != "" || len(f.Children) > 0
+}
+
+// SelectsValue reports whether the condition asks for rows equal to value with eq or in.
+func (f *FilterExpr) SelectsValue(value string) bool {
+	switch strings.ToLower(f.Op) {
+	case "eq":
+		return strings.EqualFold(f.Value, value)
+	case "in":
+		for _, v := range f.Values {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conditions returns the conditions of the tree from left to right, nil for a nil tree.
+func (f *FilterExpr) Conditions() []*FilterExpr {
+	if f == nil {
+		return nil
+	}
+	if !f.IsGroup() {
+		return []*FilterExpr{f}
+	}
+	var conditions []*FilterExpr
+	for _, child := range f.Children {
+		conditions = append(conditions, child.Conditions()...)
+	}
+	return conditions
 }
 
 type OrderExpr struct {
 	Field     string `json:"field"`     // name column
 	Direction string `json:"direction"` // "asc" | "desc"
+	Column    string `json:"-"`         // qualified column, set by the field registry
 }
 
 func (f *Filters) ValidateFilters(v *validator.Validator) {
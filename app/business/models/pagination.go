@@ -0,0 +1,134 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/schema"
+)
+
+// PageInfo holds the cursors of a page read with keyset pagination, empty when there is no such page.
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Cursor is the decoded form of the opaque cursor token of a list.
+type Cursor struct {
+	Order    string    `json:"o"`           // order the token was issued for, see OrderSignature
+	Values   []*string `json:"v"`           // sort keys of the boundary row then its id, nil for NULL
+	Backward bool      `json:"b,omitempty"` // the page before the boundary row
+}
+
+// Keyset is a cursor resolved by the field registry, ready for the store.
+type Keyset struct {
+	Columns  []KeysetColumn
+	Values   []any // boundary values in the order of Columns, nil for NULL, empty on the first page
+	Backward bool
+}
+
+// KeysetColumn is a qualified sort column of a keyset and its direction.
+type KeysetColumn struct {
+	Column string
+	Desc   bool
+}
+
+// EncodeCursor returns the opaque token of c.
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a token returned by EncodeCursor.
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Values) == 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
+
+// OrderSignature identifies an order so a cursor is only used with the order it was issued for.
+func OrderSignature(orders []OrderExpr) string {
+	parts := make([]string, 0, len(orders))
+	for _, o := range orders {
+		parts = append(parts, strings.ToLower(o.Field)+" "+strings.ToUpper(o.Direction))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ColumnValue returns the value of the field of row mapped to column by its gorm tag or,
+// without one, by the gorm naming strategy, row is a struct or a pointer to one.
+func ColumnValue(row any, column string) (any, bool) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := ""
+		for _, setting := range strings.Split(t.Field(i).Tag.Get("gorm"), ";") {
+			setting = strings.TrimSpace(setting)
+			if setting == "-" {
+				name = "-"
+			} else if strings.HasPrefix(setting, "column:") {
+				name = strings.TrimPrefix(setting, "column:")
+			}
+		}
+		if name == "" && t.Field(i).IsExported() {
+			name = schema.NamingStrategy{}.ColumnName("", t.Field(i).Name)
+		}
+		if name == column {
+			return v.Field(i).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// FormatCursorValue writes a sort key in a form QueryField.Coerce reads back, nil for NULL.
+func FormatCursorValue(value any) *string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		s = v.UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		s = v.UTC().Format(time.RFC3339Nano)
+	case *uuid.UUID:
+		if v == nil {
+			return nil
+		}
+		s = v.String()
+	case uuid.UUID:
+		s = v.String()
+	case bool:
+		s = strconv.FormatBool(v)
+	case string:
+		s = v
+	default:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return nil
+			}
+			return FormatCursorValue(rv.Elem().Interface())
+		}
+		s = fmt.Sprint(value)
+	}
+	return &s
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DriftStatusInSync  = "IN_SYNC"
+	DriftStatusDrifted = "DRIFTED"
+	DriftStatusMissing = "MISSING"
+)
+
+// ExpectedParameter is the value a device should report for a path and the profile it comes from.
+type ExpectedParameter struct {
+	ParameterId *uuid.UUID `json:"parameter_id"`
+	Path        string     `json:"path"`
+	Value       string     `json:"value"`
+	ProfileId   *uuid.UUID `json:"profile_id"`
+	ProfileName string     `json:"profile_name"`
+	Scope       string     `json:"scope"`
+}
+
+// ParameterDrift is one line of a drift report, Actual is nil when the device never reported the path.
+type ParameterDrift struct {
+	ParameterId *uuid.UUID `json:"parameter_id"`
+	Path        string     `json:"path"`
+	ProfileId   *uuid.UUID `json:"profile_id"`
+	ProfileName string     `json:"profile_name"`
+	Scope       string     `json:"scope"`
+	Expected    string     `json:"expected"`
+	Actual      *string    `json:"actual"`
+	ObservedAt  *time.Time `json:"observed_at"`
+	Status      string     `json:"status"`
+}
+
+type DeviceDriftReport struct {
+	DeviceId   *uuid.UUID       `json:"device_id"`
+	MacAddress string           `json:"mac_address"`
+	EndpointId string           `json:"endpoint_id"`
+	GroupId    *uuid.UUID       `json:"group_id"`
+	Checked    int              `json:"checked"`
+	Drifted    int              `json:"drifted"`
+	Missing    int              `json:"missing"`
+	Parameters []ParameterDrift `json:"parameters"`
+}
+
+// InSync reports whether every expected value was reported and matches.
+func (r DeviceDriftReport) InSync() bool {
+	return r.Drifted == 0 && r.Missing == 0
+}
+
+type ParameterDriftSummary struct {
+	Path           string     `json:"path"`
+	Devices        int        `json:"devices"`
+	Drifted        int        `json:"drifted"`
+	Missing        int        `json:"missing"`
+	LastObservedAt *time.Time `json:"last_observed_at"`
+}
+
+// EffectiveConfiguration resolves the expected value of every parameter path from the profiles
+// assigned to a device group and to the device itself. Device assignments win over group
+// assignments, inside a scope the latest assignment wins. Only ENABLE profiles and parameters
+// with a non-empty DefaultValue take part.
+func EffectiveConfiguration(groupAssignments, deviceAssignments []ProfileAssignment) map[string]ExpectedParameter {
+	expected := make(map[string]ExpectedParameter)
+	for _, assignments := range [][]ProfileAssignment{groupAssignments, deviceAssignments} {
+		ordered := make([]ProfileAssignment, len(assignments))
+		copy(ordered, assignments)
+		sort.SliceStable(ordered, func(i, j int) bool {
+			if ordered[i].CreatedAt == nil || ordered[j].CreatedAt == nil {
+				return ordered[j].CreatedAt != nil
+			}
+			return ordered[i].CreatedAt.Before(*ordered[j].CreatedAt)
+		})
+
+		for _, assignment := range ordered {
+			profile := assignment.Profile
+			if profile == nil || profile.Status != "ENABLE" {
+				continue
+			}
+			for _, profileParameter := range profile.ProfileParameters {
+				parameter := profileParameter.Parameter
+				if parameter == nil || parameter.Status != "ENABLE" || profileParameter.DefaultValue == "" {
+					continue
+				}
+				expected[parameter.Path] = ExpectedParameter{
+					ParameterId: parameter.Id,
+					Path:        parameter.Path,
+					Value:       profileParameter.DefaultValue,
+					ProfileId:   profile.Id,
+					ProfileName: profile.Name,
+					Scope:       assignment.Scope(),
+				}
+			}
+		}
+	}
+	return expected
+}
+
+// NewDeviceDriftReport compares the reported values of a device against its expected configuration.
+func NewDeviceDriftReport(
+	device Device,
+	expected map[string]ExpectedParameter,
+	reported []DeviceParameterValue,
+) DeviceDriftReport {
+	values := make(map[string]DeviceParameterValue, len(reported))
+	for _, value := range reported {
+		values[value.Path] = value
+	}
+
+	report := DeviceDriftReport{
+		DeviceId:   device.Id,
+		MacAddress: device.MacAddress,
+		EndpointId: device.EndpointId,
+		GroupId:    device.GroupId,
+		Parameters: make([]ParameterDrift, 0, len(expected)),
+	}
+	for path, parameter := range expected {
+		drift := ParameterDrift{
+			ParameterId: parameter.ParameterId,
+			Path:        parameter.Path,
+			ProfileId:   parameter.ProfileId,
+			ProfileName: parameter.ProfileName,
+			Scope:       parameter.Scope,
+			Expected:    parameter.Value,
+			Status:      DriftStatusMissing,
+		}
+		if value, ok := values[path]; ok {
+			actual := value.Value
+			drift.Actual = &actual
+			drift.ObservedAt = value.ObservedAt
+			drift.Status = DriftStatusInSync
+			if actual != parameter.Value {
+				drift.Status = DriftStatusDrifted
+			}
+		}
+
+		switch drift.Status {
+		case DriftStatusDrifted:
+			report.Drifted++
+		case DriftStatusMissing:
+			report.Missing++
+		}
+		report.Parameters = append(report.Parameters, drift)
+	}
+	report.Checked = len(report.Parameters)
+
+	sort.Slice(report.Parameters, func(i, j int) bool {
+		return report.Parameters[i].Path < report.Parameters[j].Path
+	})
+	return report
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 /*
@@ -27,22 +28,63 @@ CREATE TABLE public.devices (
 COMMENT ON COLUMN public.devices.mac_address IS 'WAN MAC Address of device, 12 chars, not contains `:`';
 COMMENT ON COLUMN public.devices.endpoint_id IS 'endpoint of device in TR369 System, example: `os::4485DA-4485DA68A1E7`';
 COMMENT ON COLUMN public.devices.model_id IS 'ref to models table, define device model.';
+
+ALTER TABLE public.devices ADD COLUMN software_version VARCHAR(64) NULL;
+ALTER TABLE public.devices ADD COLUMN last_boot_at TIMESTAMPTZ NULL;
+ALTER TABLE public.devices ADD COLUMN last_seen_at TIMESTAMPTZ NULL;
+COMMENT ON COLUMN public.devices.software_version IS 'last reported Device.DeviceInfo.SoftwareVersion';
+COMMENT ON COLUMN public.devices.last_boot_at IS 'time of the last Boot! event';
+COMMENT ON COLUMN public.devices.last_seen_at IS 'time of the last USP Notify received from the endpoint';
+
+ALTER TABLE public.devices ADD COLUMN serial_number VARCHAR(64) NULL;
+ALTER TABLE public.devices ADD COLUMN product_class VARCHAR(64) NULL;
+ALTER TABLE public.devices ADD COLUMN hardware_revision VARCHAR(64) NULL;
+CREATE INDEX devices_serial_number_idx ON public.devices (serial_number ASC) STORING (mac_address, endpoint_id, model_id, product_class);
+COMMENT ON COLUMN public.devices.serial_number IS 'Device.DeviceInfo.SerialNumber, unique per models.manufacturer';
+COMMENT ON COLUMN public.devices.product_class IS 'Device.DeviceInfo.ProductClass';
+COMMENT ON COLUMN public.devices.hardware_revision IS 'Device.DeviceInfo.HardwareVersion';
+
+-- stats: GROUP BY status, model_id (and the stored columns) is read from the index only
+CREATE INDEX devices_status_model_id_idx ON public.devices (status ASC, model_id ASC) STORING (group_id, lifecycle_state, software_version, product_class, hardware_revision);
+
+-- global search: ILIKE '%text%' is served by the trigram indexes
+CREATE INDEX devices_mac_address_trgm_idx ON public.devices USING GIN (mac_address gin_trgm_ops);
+CREATE INDEX devices_endpoint_id_trgm_idx ON public.devices USING GIN (endpoint_id gin_trgm_ops);
+CREATE INDEX devices_serial_number_trgm_idx ON public.devices USING GIN (serial_number gin_trgm_ops);
+
+ALTER TABLE public.devices ADD COLUMN labels STRING[] NULL;
+CREATE INVERTED INDEX devices_labels_idx ON public.devices (labels);
+COMMENT ON COLUMN public.devices.labels IS 'free labels of the site the device serves, moved to the successor on replace';
 */
 
 const USPDeviceTableName = "devices"
 const USPDeviceEntityName = "Device"
 
 type Device struct {
-	Id          *uuid.UUID `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"-"`
-	MacAddress  string     `gorm:"column:mac_address;type:varchar(12);not null" json:"mac_address"`
-	EndpointId  string     `gorm:"column:endpoint_id;type:varchar;not null" json:"endpoint_id"`
-	Status      string     `gorm:"column:status;type:varchar;default:'ENABLE'" json:"status"`
-	UpdatedBy   string     `gorm:"column:updated_by;type:varchar;not null" json:"updated_by"`
-	ModelId     *uuid.UUID `gorm:"column:model_id;type:uuid;not null" json:"-"`
-	GroupId     *uuid.UUID `gorm:"column:group_id;type:uuid;default:null" json:"-"`
-	CreatedAt   *time.Time `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt   *time.Time `json:"updated_at" gorm:"column:updated_at"`
-	Description string     `gorm:"column:description;type:varchar(255);default:null" json:"description,omitempty"`
+	Id          *uuid.UUID     `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"-"`
+	MacAddress  string         `gorm:"column:mac_address;type:varchar(12);not null" json:"mac_address"`
+	EndpointId  string         `gorm:"column:endpoint_id;type:varchar;not null" json:"endpoint_id"`
+	Status      string         `gorm:"column:status;type:varchar;default:'ENABLE'" json:"status"`
+	UpdatedBy   string         `gorm:"column:updated_by;type:varchar;not null" json:"updated_by"`
+	ModelId     *uuid.UUID     `gorm:"column:model_id;type:uuid;not null" json:"-"`
+	GroupId     *uuid.UUID     `gorm:"column:group_id;type:uuid;default:null" json:"-"`
+	CreatedAt   *time.Time     `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   *time.Time     `json:"updated_at" gorm:"column:updated_at"`
+	Description string         `gorm:"column:description;type:varchar(255);default:null" json:"description,omitempty"`
+	Labels      pq.StringArray `gorm:"column:labels;type:text[];default:null" json:"labels,omitempty"`
+
+	SoftwareVersion string     `gorm:"column:software_version;type:varchar(64);default:null" json:"software_version,omitempty"`
+	LastBootAt      *time.Time `gorm:"column:last_boot_at;default:null" json:"last_boot_at,omitempty"`
+	LastSeenAt      *time.Time `gorm:"column:last_seen_at;default:null" json:"last_seen_at,omitempty"`
+
+	SerialNumber     string `gorm:"column:serial_number;type:varchar(64);default:null" json:"serial_number,omitempty"`
+	ProductClass     string `gorm:"column:product_class;type:varchar(64);default:null" json:"product_class,omitempty"`
+	HardwareRevision string `gorm:"column:hardware_revision;type:varchar(64);default:null" json:"hardware_revision,omitempty"`
+
+	LifecycleState string `gorm:"column:lifecycle_state;type:varchar(32);default:'PRE_PROVISIONED'" json:"lifecycle_state"`
+
+	ReplacedById *uuid.UUID `gorm:"column:replaced_by_id;type:uuid;default:null" json:"replaced_by_id,omitempty"`
+	ReplacedAt   *time.Time `gorm:"column:replaced_at;default:null" json:"replaced_at,omitempty"`
 
 	Model *Model `gorm:"foreignKey:ModelId;references:Id;" json:"model,omitempty"`
 	Group *Group `gorm:"foreignKey:GroupId;references:Id;" json:"group,omitempty"`
@@ -61,6 +103,26 @@ func (Device) GetUpdatedAtColumnName() string   { return "updated_at" }
 func (Device) GetUpdatedByColumnName() string   { return "updated_by" }
 func (Device) GetStatusColumnName() string      { return "status" }
 func (Device) GetDescriptionColumnName() string { return "description" }
+func (Device) GetLabelsColumnName() string      { return "labels" }
+
+func (Device) GetSoftwareVersionColumnName() string { return "software_version" }
+func (Device) GetLastBootAtColumnName() string      { return "last_boot_at" }
+func (Device) GetLastSeenAtColumnName() string      { return "last_seen_at" }
+
+func (Device) GetSerialNumberColumnName() string     { return "serial_number" }
+func (Device) GetProductClassColumnName() string     { return "product_class" }
+func (Device) GetHardwareRevisionColumnName() string { return "hardware_revision" }
+func (Device) GetLifecycleStateColumnName() string   { return "lifecycle_state" }
+func (Device) GetReplacedByIdColumnName() string     { return "replaced_by_id" }
+
+// EndpointIdValues returns the identifiers the model endpoint id template is rendered from.
+func (d Device) EndpointIdValues() EndpointIdValues {
+	return EndpointIdValues{
+		MacAddress:   d.MacAddress,
+		SerialNumber: d.SerialNumber,
+		ProductClass: d.ProductClass,
+	}
+}
 
 type DeviceUpdate struct {
 	GroupId     *uuid.UUID `gorm:"column:group_id;type:uuid;default:null" json:"group_id,omitempty"`
@@ -68,6 +130,25 @@ type DeviceUpdate struct {
 	UpdatedBy   *string    `gorm:"column:updated_by;type:varchar;default:null" json:"updated_by,omitempty"`
 	UpdatedAt   *time.Time `json:"updated_at" gorm:"column:updated_at"`
 	Description *string    `gorm:"column:description;type:varchar(255);default:null" json:"description,omitempty"`
+	// Labels replaces the labels of the device, a non-nil empty array clears them
+	Labels pq.StringArray `gorm:"column:labels;type:text[];default:null" json:"labels,omitempty"`
+
+	SerialNumber     *string `gorm:"column:serial_number;type:varchar(64);default:null" json:"serial_number,omitempty"`
+	ProductClass     *string `gorm:"column:product_class;type:varchar(64);default:null" json:"product_class,omitempty"`
+	HardwareRevision *string `gorm:"column:hardware_revision;type:varchar(64);default:null" json:"hardware_revision,omitempty"`
+
+	// LifecycleState is only changed through an allowed transition, LifecycleReason is recorded with it
+	LifecycleState  *string `gorm:"column:lifecycle_state;type:varchar(32)" json:"lifecycle_state,omitempty"`
+	LifecycleReason string  `gorm:"-" json:"lifecycle_reason,omitempty"`
+
+	// Written by the replace flow only
+	ReplacedById *uuid.UUID `gorm:"column:replaced_by_id;type:uuid;default:null" json:"-"`
+	ReplacedAt   *time.Time `gorm:"column:replaced_at;default:null" json:"-"`
+
+	// Reported state, only written by the event ingest worker
+	SoftwareVersion *string    `gorm:"column:software_version;type:varchar(64);default:null" json:"-"`
+	LastBootAt      *time.Time `gorm:"column:last_boot_at;default:null" json:"-"`
+	LastSeenAt      *time.Time `gorm:"column:last_seen_at;default:null" json:"-"`
 }
 
 func (DeviceUpdate) TableName() string     { return USPDeviceTableName }
@@ -88,3 +169,17 @@ func NewDeviceUpdate(
 		UpdatedAt:   &now,
 	}
 }
+
+// DeviceLookup resolves devices from any identifier, empty fields are ignored.
+// Manufacturer and ProductClass only narrow a SerialNumber lookup.
+type DeviceLookup struct {
+	SerialNumber string
+	EndpointId   string
+	MacAddress   string
+	Manufacturer string
+	ProductClass string
+}
+
+func (l DeviceLookup) IsEmpty() bool {
+	return l.SerialNumber == "" && l.EndpointId == "" && l.MacAddress == ""
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+ALTER TABLE public.devices ADD COLUMN lifecycle_state VARCHAR(32) NOT NULL DEFAULT 'PRE_PROVISIONED';
+UPDATE public.devices SET lifecycle_state = 'IN_SERVICE' WHERE last_seen_at IS NOT NULL;
+CREATE INDEX devices_model_id_lifecycle_state_idx ON public.devices (model_id ASC, lifecycle_state ASC);
+COMMENT ON COLUMN public.devices.lifecycle_state IS 'PRE_PROVISIONED | ACTIVATED | IN_SERVICE | SUSPENDED | RMA | DECOMMISSIONED | REPLACED';
+
+CREATE TABLE public.device_lifecycle_transitions (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	device_id UUID NOT NULL,
+	from_state VARCHAR(32) NOT NULL,
+	to_state VARCHAR(32) NOT NULL,
+	reason VARCHAR(255) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT device_lifecycle_transitions_pkey PRIMARY KEY (id ASC),
+	CONSTRAINT device_lifecycle_transitions_device_id_fkey FOREIGN KEY (device_id) REFERENCES public.devices(id),
+	INDEX device_lifecycle_transitions_device_id_idx (device_id ASC, created_at DESC)
+);
+COMMENT ON TABLE public.device_lifecycle_transitions IS 'audit of every lifecycle_state change of a device';
+*/
+
+const (
+	DeviceStatePreProvisioned = "PRE_PROVISIONED"
+	DeviceStateActivated      = "ACTIVATED"
+	DeviceStateInService      = "IN_SERVICE"
+	DeviceStateSuspended      = "SUSPENDED"
+	DeviceStateRMA            = "RMA"
+	DeviceStateDecommissioned = "DECOMMISSIONED"
+	// DeviceStateReplaced is only entered through the replace flow, replaced_by_id links the successor
+	DeviceStateReplaced = "REPLACED"
+)
+
+// DeviceLifecycleStates in lifecycle order.
+var DeviceLifecycleStates = []string{
+	DeviceStatePreProvisioned,
+	DeviceStateActivated,
+	DeviceStateInService,
+	DeviceStateSuspended,
+	DeviceStateRMA,
+	DeviceStateDecommissioned,
+	DeviceStateReplaced,
+}
+
+// deviceLifecycleTransitions lists the states reachable from each state, terminal states have none.
+var deviceLifecycleTransitions = map[string][]string{
+	DeviceStatePreProvisioned: {DeviceStateActivated, DeviceStateDecommissioned, DeviceStateReplaced},
+	DeviceStateActivated:      {DeviceStateInService, DeviceStateSuspended, DeviceStateRMA, DeviceStateDecommissioned, DeviceStateReplaced},
+	DeviceStateInService:      {DeviceStateSuspended, DeviceStateRMA, DeviceStateDecommissioned, DeviceStateReplaced},
+	DeviceStateSuspended:      {DeviceStateInService, DeviceStateRMA, DeviceStateDecommissioned, DeviceStateReplaced},
+	DeviceStateRMA:            {DeviceStatePreProvisioned, DeviceStateDecommissioned, DeviceStateReplaced},
+	DeviceStateDecommissioned: {},
+	DeviceStateReplaced:       {},
+}
+
+func IsDeviceLifecycleState(state string) bool {
+	_, ok := deviceLifecycleTransitions[state]
+	return ok
+}
+
+// IsTerminalDeviceState reports whether no transition leaves state.
+func IsTerminalDeviceState(state string) bool {
+	next, ok := deviceLifecycleTransitions[state]
+	return ok && len(next) == 0
+}
+
+// NonTerminalDeviceStates are the states listed by default.
+func NonTerminalDeviceStates() []string {
+	states := make([]string, 0, len(DeviceLifecycleStates))
+	for _, state := range DeviceLifecycleStates {
+		if !IsTerminalDeviceState(state) {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// NextDeviceStates returns the states a device in state can move to.
+func NextDeviceStates(state string) []string {
+	return deviceLifecycleTransitions[state]
+}
+
+func CanTransitionDevice(from, to string) bool {
+	for _, next := range deviceLifecycleTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+const USPDeviceLifecycleTransitionTableName = "device_lifecycle_transitions"
+const USPDeviceLifecycleTransitionEntityName = "DeviceLifecycleTransition"
+
+type DeviceLifecycleTransition struct {
+	Id        *uuid.UUID `gorm:"column:id;type:uuid;default:gen_random_uuid()" json:"id"`
+	DeviceId  *uuid.UUID `gorm:"column:device_id;type:uuid;not null" json:"device_id"`
+	FromState string     `gorm:"column:from_state;type:varchar(32);not null" json:"from_state"`
+	ToState   string     `gorm:"column:to_state;type:varchar(32);not null" json:"to_state"`
+	Reason    string     `gorm:"column:reason;type:varchar(255);not null" json:"reason"`
+	Actor     string     `gorm:"column:actor;type:varchar(255);not null" json:"actor"`
+	CreatedAt *time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (DeviceLifecycleTransition) TableName() string { return USPDeviceLifecycleTransitionTableName }
+func (DeviceLifecycleTransition) GetEntityName() string {
+	return USPDeviceLifecycleTransitionEntityName
+}
+
+func (DeviceLifecycleTransition) GetDeviceIdColumnName() string  { return "device_id" }
+func (DeviceLifecycleTransition) GetCreatedAtColumnName() string { return "created_at" }
+
+// DeviceStateCount is the number of devices of a model in a lifecycle state.
+type DeviceStateCount struct {
+	LifecycleState string `gorm:"column:lifecycle_state" json:"lifecycle_state"`
+	Count          int64  `gorm:"column:count" json:"count"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+CREATE TABLE public.device_parameter_values (
+	device_id UUID NOT NULL,
+	path VARCHAR NOT NULL,
+	value STRING NULL,
+	observed_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT device_parameter_values_pkey PRIMARY KEY (device_id ASC, path ASC),
+	CONSTRAINT device_parameter_values_device_id_fkey FOREIGN KEY (device_id) REFERENCES public.devices(id)
+);
+COMMENT ON COLUMN public.device_parameter_values.path IS 'TR369 parameter path as reported by the device, example: Device.DeviceInfo.SoftwareVersion';
+COMMENT ON COLUMN public.device_parameter_values.observed_at IS 'time the device reported the value, an older report never overwrites a newer one';
+
+CREATE TABLE public.device_parameter_value_history (
+	device_id UUID NOT NULL,
+	path VARCHAR NOT NULL,
+	observed_at TIMESTAMPTZ NOT NULL,
+	value STRING NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT device_parameter_value_history_pkey PRIMARY KEY (device_id ASC, path ASC, observed_at DESC),
+	CONSTRAINT device_parameter_value_history_device_id_fkey FOREIGN KEY (device_id) REFERENCES public.devices(id),
+	INDEX device_parameter_value_history_observed_at_idx (observed_at ASC)
+);
+COMMENT ON TABLE public.device_parameter_value_history IS 'every reported value, only written when DEVICE_PARAMETER_HISTORY_ENABLE, pruned after DEVICE_PARAMETER_HISTORY_RETENTION';
+*/
+
+const USPDeviceParameterValueTableName = "device_parameter_values"
+const USPDeviceParameterValueEntityName = "DeviceParameterValue"
+const USPDeviceParameterValueHistoryTableName = "device_parameter_value_history"
+
+// DeviceParameterValue is the last value a device reported for a parameter path.
+type DeviceParameterValue struct {
+	DeviceId   *uuid.UUID `gorm:"column:device_id;type:uuid;primaryKey" json:"device_id"`
+	Path       string     `gorm:"column:path;type:varchar;primaryKey" json:"path"`
+	Value      string     `gorm:"column:value;type:string" json:"value"`
+	ObservedAt *time.Time `gorm:"column:observed_at;not null" json:"observed_at"`
+	UpdatedAt  *time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (DeviceParameterValue) TableName() string     { return USPDeviceParameterValueTableName }
+func (DeviceParameterValue) GetEntityName() string { return USPDeviceParameterValueEntityName }
+
+func (DeviceParameterValue) GetDeviceIdColumnName() string   { return "device_id" }
+func (DeviceParameterValue) GetPathColumnName() string       { return "path" }
+func (DeviceParameterValue) GetValueColumnName() string      { return "value" }
+func (DeviceParameterValue) GetObservedAtColumnName() string { return "observed_at" }
+func (DeviceParameterValue) GetUpdatedAtColumnName() string  { return "updated_at" }
+
+// DeviceParameterValueHistory is one reported value kept for as-of queries.
+type DeviceParameterValueHistory struct {
+	DeviceId   *uuid.UUID `gorm:"column:device_id;type:uuid;primaryKey" json:"device_id"`
+	Path       string     `gorm:"column:path;type:varchar;primaryKey" json:"path"`
+	ObservedAt *time.Time `gorm:"column:observed_at;primaryKey" json:"observed_at"`
+	Value      string     `gorm:"column:value;type:string" json:"value"`
+	CreatedAt  *time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (DeviceParameterValueHistory) TableName() string { return USPDeviceParameterValueHistoryTableName }
+func (DeviceParameterValueHistory) GetEntityName() string {
+	return USPDeviceParameterValueEntityName
+}
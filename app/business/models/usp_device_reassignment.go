@@ -0,0 +1,97 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ProfileChangeAdded   = "ADDED"
+	ProfileChangeRemoved = "REMOVED"
+	ProfileChangeKept    = "KEPT"
+)
+
+// DeviceReassignment moves a device to another model, TargetGroupId must belong to TargetModelId,
+// a nil TargetGroupId leaves the device without group.
+type DeviceReassignment struct {
+	TargetModelId *uuid.UUID
+	TargetGroupId *uuid.UUID
+	UpdatedBy     string
+	DryRun        bool
+}
+
+// DeviceModelUpdate is written by a reassignment, group_id is written even when nil.
+type DeviceModelUpdate struct {
+	ModelId    *uuid.UUID `gorm:"column:model_id;type:uuid;not null"`
+	GroupId    *uuid.UUID `gorm:"column:group_id;type:uuid;default:null"`
+	EndpointId string     `gorm:"column:endpoint_id;type:varchar;not null"`
+	UpdatedBy  string     `gorm:"column:updated_by;type:varchar;not null"`
+	UpdatedAt  *time.Time `gorm:"column:updated_at"`
+}
+
+func (DeviceModelUpdate) TableName() string     { return USPDeviceTableName }
+func (DeviceModelUpdate) GetEntityName() string { return USPDeviceEntityName }
+
+// FieldChange is a device field that changes with a reassignment.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// ProfileAssignmentChange tells whether a profile still applies to the device after a reassignment.
+type ProfileAssignmentChange struct {
+	ProfileId   *uuid.UUID `json:"profile_id"`
+	ProfileName string     `json:"profile_name"`
+	Scope       string     `json:"scope"`
+	Change      string     `json:"change"`
+}
+
+// EffectiveParameterChange is a parameter whose expected value changes, From or To is nil
+// when the parameter is not expected before or after.
+type EffectiveParameterChange struct {
+	Path        string  `json:"path"`
+	From        *string `json:"from"`
+	To          *string `json:"to"`
+	FromProfile string  `json:"from_profile,omitempty"`
+	ToProfile   string  `json:"to_profile,omitempty"`
+}
+
+// DeviceReassignmentPlan reports the effective changes of a reassignment, Applied is false for a dry run.
+type DeviceReassignmentPlan struct {
+	DeviceId                *uuid.UUID                 `json:"device_id"`
+	Applied                 bool                       `json:"applied"`
+	Changes                 []FieldChange              `json:"changes"`
+	Profiles                []ProfileAssignmentChange  `json:"profiles"`
+	Parameters              []EffectiveParameterChange `json:"parameters"`
+	FirmwareUpgradeRequired bool                       `json:"firmware_upgrade_required"`
+	Warnings                []string                   `json:"warnings"`
+}
+
+// DiffEffectiveConfiguration lists the parameters whose expected value differs between two
+// effective configurations, ordered by path.
+func DiffEffectiveConfiguration(before, after map[string]ExpectedParameter) []EffectiveParameterChange {
+	changes := make([]EffectiveParameterChange, 0)
+	for path, from := range before {
+		to, ok := after[path]
+		if ok && to.Value == from.Value {
+			continue
+		}
+		change := EffectiveParameterChange{Path: path, From: &from.Value, FromProfile: from.ProfileName}
+		if ok {
+			change.To = &to.Value
+			change.ToProfile = to.ProfileName
+		}
+		changes = append(changes, change)
+	}
+	for path, to := range after {
+		if _, ok := before[path]; ok {
+			continue
+		}
+		changes = append(changes, EffectiveParameterChange{Path: path, To: &to.Value, ToProfile: to.ProfileName})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
@@ -0,0 +1,30 @@
+package models
+
+/*
+ALTER TABLE public.devices ADD COLUMN replaced_by_id UUID NULL;
+ALTER TABLE public.devices ADD COLUMN replaced_at TIMESTAMPTZ NULL;
+ALTER TABLE public.devices ADD CONSTRAINT devices_replaced_by_id_fkey FOREIGN KEY (replaced_by_id) REFERENCES public.devices(id);
+CREATE UNIQUE INDEX devices_replaced_by_id_idx ON public.devices (replaced_by_id ASC) WHERE replaced_by_id IS NOT NULL;
+COMMENT ON COLUMN public.devices.replaced_by_id IS 'device that took over the site of this REPLACED device';
+COMMENT ON COLUMN public.devices.replaced_at IS 'time the device was replaced';
+*/
+
+// DeviceReplacement describes the hardware taking over the site of a device.
+// A device of the same model with MacAddress is reused if it has not been put in service yet,
+// otherwise a new device is created from the identifiers.
+type DeviceReplacement struct {
+	MacAddress       string
+	SerialNumber     string
+	ProductClass     string
+	HardwareRevision string
+	Reason           string
+	UpdatedBy        string
+}
+
+// DeviceReplacementResult is the outcome of a replacement.
+type DeviceReplacementResult struct {
+	Predecessor *Device `json:"predecessor"`
+	Successor   *Device `json:"successor"`
+	// TransferredProfileIds are the device profile assignments moved to the successor
+	TransferredProfileIds []string `json:"transferred_profile_ids"`
+}
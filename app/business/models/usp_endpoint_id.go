@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	validate "usp-management-device-api/common/validator"
+)
+
+// DefaultEndpointIdTemplate is used by models without an endpoint_id_template,
+// example: `os::4485DA-4485DA68A1E7`.
+const DefaultEndpointIdTemplate = "os::{oui}-{mac}"
+
+const (
+	EndpointIdPlaceholderOUI          = "{oui}"
+	EndpointIdPlaceholderMAC          = "{mac}"
+	EndpointIdPlaceholderSerial       = "{serial}"
+	EndpointIdPlaceholderProductClass = "{product_class}"
+)
+
+// endpointIdPlaceholderPatterns are used to read the values back from an endpoint id.
+var endpointIdPlaceholderPatterns = map[string]string{
+	EndpointIdPlaceholderOUI:          `(?P<oui>[0-9A-F]{6})`,
+	EndpointIdPlaceholderMAC:          `(?P<mac>[0-9A-F]{12})`,
+	EndpointIdPlaceholderSerial:       `(?P<serial>[A-Za-z0-9._~%]+)`,
+	EndpointIdPlaceholderProductClass: `(?P<product_class>[A-Za-z0-9._~%]+)`,
+}
+
+var endpointIdPlaceholderRegEx = regexp.MustCompile(`\{[a-z_]*\}`)
+
+// EndpointIdValues are the device identifiers an endpoint id template is rendered from.
+type EndpointIdValues struct {
+	MacAddress   string
+	SerialNumber string
+	ProductClass string
+}
+
+// OUI is the first 6 hex digits of the MAC address, uppercase.
+func (v EndpointIdValues) OUI() string {
+	mac := v.normalizedMac()
+	if len(mac) < 6 {
+		return ""
+	}
+	return mac[:6]
+}
+
+func (v EndpointIdValues) normalizedMac() string {
+	return strings.ToUpper(strings.ReplaceAll(v.MacAddress, ":", ""))
+}
+
+// ValidateEndpointIdTemplate checks the placeholders of a template and that a rendered
+// endpoint id follows the TR-369 endpoint id rules.
+func ValidateEndpointIdTemplate(template string) error {
+	placeholders := endpointIdPlaceholderRegEx.FindAllString(template, -1)
+	seen := map[string]bool{}
+	for _, placeholder := range placeholders {
+		if _, ok := endpointIdPlaceholderPatterns[placeholder]; !ok {
+			return fmt.Errorf("unknown placeholder %s, allowed: {oui}, {mac}, {serial}, {product_class}", placeholder)
+		}
+		if seen[placeholder] {
+			return fmt.Errorf("placeholder %s is used more than once", placeholder)
+		}
+		seen[placeholder] = true
+	}
+	if !seen[EndpointIdPlaceholderMAC] && !seen[EndpointIdPlaceholderSerial] {
+		return fmt.Errorf("template must contain {mac} or {serial} to be unique per device")
+	}
+	if strings.ContainsAny(endpointIdPlaceholderRegEx.ReplaceAllString(template, ""), "{}") {
+		return fmt.Errorf("template contains an unbalanced brace")
+	}
+
+	sample, err := RenderEndpointId(template, EndpointIdValues{
+		MacAddress:   "4485DA68A1E7",
+		SerialNumber: "SN0000000001",
+		ProductClass: "HGW",
+	})
+	if err != nil {
+		return err
+	}
+	return validate.ValidateEndpointID(sample)
+}
+
+// RenderEndpointId replaces the placeholders of template with the device identifiers,
+// a placeholder without a value is an error.
+func RenderEndpointId(template string, values EndpointIdValues) (string, error) {
+	replacements := map[string]string{
+		EndpointIdPlaceholderOUI:          values.OUI(),
+		EndpointIdPlaceholderMAC:          values.normalizedMac(),
+		EndpointIdPlaceholderSerial:       escapeEndpointIdValue(values.SerialNumber),
+		EndpointIdPlaceholderProductClass: escapeEndpointIdValue(values.ProductClass),
+	}
+
+	var missing string
+	endpointId := endpointIdPlaceholderRegEx.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value := replacements[placeholder]
+		if value == "" && missing == "" {
+			missing = placeholder
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("endpoint id template %s needs a value for %s", template, missing)
+	}
+	return endpointId, nil
+}
+
+// ParseEndpointId reads the device identifiers and the OUI back from an endpoint id built
+// with template, ok is false when the endpoint id does not match the template.
+func ParseEndpointId(template string, endpointId string) (values EndpointIdValues, oui string, ok bool) {
+	pattern := "^"
+	last := 0
+	for _, loc := range endpointIdPlaceholderRegEx.FindAllStringIndex(template, -1) {
+		pattern += regexp.QuoteMeta(template[last:loc[0]])
+		pattern += endpointIdPlaceholderPatterns[template[loc[0]:loc[1]]]
+		last = loc[1]
+	}
+	pattern += regexp.QuoteMeta(template[last:]) + "$"
+
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		return values, "", false
+	}
+	match := rx.FindStringSubmatch(endpointId)
+	if match == nil {
+		return values, "", false
+	}
+
+	for i, name := range rx.SubexpNames() {
+		switch name {
+		case "oui":
+			oui = match[i]
+		case "mac":
+			values.MacAddress = match[i]
+		case "serial":
+			values.SerialNumber, _ = url.PathUnescape(match[i])
+		case "product_class":
+			values.ProductClass, _ = url.PathUnescape(match[i])
+		}
+	}
+	if oui == "" {
+		oui = values.OUI()
+	}
+	if values.MacAddress != "" && !strings.HasPrefix(values.MacAddress, oui) {
+		return values, "", false
+	}
+	return values, oui, true
+}
+
+// escapeEndpointIdValue percent-encodes every character outside A-Z a-z 0-9 . _ ~,
+// so `-` and `:` in a serial number or product class cannot be confused with separators.
+func escapeEndpointIdValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	apperrors "usp-management-device-api/common/app_errors"
+)
+
+// USPNotifyEvent is the JSON form of a USP Notify message published by the controller,
+// the field names follow the Notify message in usp-msg.proto.
+// Exactly one of Event, ValueChange, ObjCreation or OperComplete must be set.
+type USPNotifyEvent struct {
+	EndpointId     string     `json:"endpoint_id"`
+	MsgId          string     `json:"msg_id,omitempty"`
+	SubscriptionId string     `json:"subscription_id,omitempty"`
+	SendResp       bool       `json:"send_resp,omitempty"`
+	Timestamp      *time.Time `json:"timestamp,omitempty"`
+
+	Event        *USPEvent             `json:"event,omitempty"`
+	ValueChange  *USPValueChange       `json:"value_change,omitempty"`
+	ObjCreation  *USPObjectCreation    `json:"obj_creation,omitempty"`
+	OperComplete *USPOperationComplete `json:"oper_complete,omitempty"`
+}
+
+type USPEvent struct {
+	ObjPath   string            `json:"obj_path"`
+	EventName string            `json:"event_name"`
+	Params    map[string]string `json:"params,omitempty"`
+}
+
+type USPValueChange struct {
+	ParamPath  string `json:"param_path"`
+	ParamValue string `json:"param_value"`
+}
+
+type USPObjectCreation struct {
+	ObjPath    string            `json:"obj_path"`
+	UniqueKeys map[string]string `json:"unique_keys,omitempty"`
+}
+
+type USPOperationComplete struct {
+	ObjPath       string             `json:"obj_path"`
+	CommandName   string             `json:"command_name"`
+	CommandKey    string             `json:"command_key,omitempty"`
+	ReqOutputArgs *USPOutputArgs     `json:"req_output_args,omitempty"`
+	CmdFailure    *USPCommandFailure `json:"cmd_failure,omitempty"`
+}
+
+type USPOutputArgs struct {
+	OutputArgs map[string]string `json:"output_args,omitempty"`
+}
+
+type USPCommandFailure struct {
+	ErrCode uint32 `json:"err_code"`
+	ErrMsg  string `json:"err_msg,omitempty"`
+}
+
+const (
+	USPEventTypeBoot              = "Boot!"
+	USPEventTypeValueChange       = "ValueChange"
+	USPEventTypeObjectCreation    = "ObjectCreation"
+	USPEventTypeOperationComplete = "OperationComplete"
+
+	USPBootParameterMap     = "ParameterMap"
+	USPBootFirmwareUpdated  = "FirmwareUpdated"
+	USPBootCause            = "Cause"
+	USPParamModelName       = "Device.DeviceInfo.ModelName"
+	USPParamSoftwareVersion = "Device.DeviceInfo.SoftwareVersion"
+	USPParamSerialNumber    = "Device.DeviceInfo.SerialNumber"
+	USPParamProductClass    = "Device.DeviceInfo.ProductClass"
+	USPParamHardwareVersion = "Device.DeviceInfo.HardwareVersion"
+	USPFirmwareImagePrefix  = "Device.DeviceInfo.FirmwareImage."
+	USPFirmwareDownloadCmd  = "Download()"
+	USPFirmwareActivateCmd  = "Activate()"
+)
+
+// Type returns the notification type carried by the message.
+func (e *USPNotifyEvent) Type() string {
+	switch {
+	case e.Event != nil:
+		return e.Event.EventName
+	case e.ValueChange != nil:
+		return USPEventTypeValueChange
+	case e.ObjCreation != nil:
+		return USPEventTypeObjectCreation
+	case e.OperComplete != nil:
+		return USPEventTypeOperationComplete
+	}
+	return ""
+}
+
+// Validate checks the payload shape before it is handed to the usecase.
+func (e *USPNotifyEvent) Validate() error {
+	if strings.TrimSpace(e.EndpointId) == "" {
+		return apperrors.NewErrInvalidEventPayload("endpoint_id is required")
+	}
+
+	set := 0
+	for _, ok := range []bool{e.Event != nil, e.ValueChange != nil, e.ObjCreation != nil, e.OperComplete != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return apperrors.NewErrInvalidEventPayload("exactly one of event, value_change, obj_creation, oper_complete must be set")
+	}
+
+	switch {
+	case e.Event != nil:
+		if e.Event.EventName == "" {
+			return apperrors.NewErrInvalidEventPayload("event.event_name is required")
+		}
+		if e.Event.EventName == USPEventTypeBoot {
+			if raw, ok := e.Event.Params[USPBootParameterMap]; ok && raw != "" {
+				if _, err := e.BootParameterMap(); err != nil {
+					return apperrors.NewErrInvalidEventPayload("event.params.ParameterMap is not a JSON object")
+				}
+			}
+			if raw, ok := e.Event.Params[USPBootFirmwareUpdated]; ok && raw != "" {
+				if _, err := strconv.ParseBool(raw); err != nil {
+					return apperrors.NewErrInvalidEventPayload("event.params.FirmwareUpdated must be a boolean")
+				}
+			}
+		}
+	case e.ValueChange != nil:
+		if e.ValueChange.ParamPath == "" {
+			return apperrors.NewErrInvalidEventPayload("value_change.param_path is required")
+		}
+	case e.ObjCreation != nil:
+		if e.ObjCreation.ObjPath == "" {
+			return apperrors.NewErrInvalidEventPayload("obj_creation.obj_path is required")
+		}
+	case e.OperComplete != nil:
+		if e.OperComplete.ObjPath == "" || e.OperComplete.CommandName == "" {
+			return apperrors.NewErrInvalidEventPayload("oper_complete.obj_path and oper_complete.command_name are required")
+		}
+	}
+	return nil
+}
+
+// BootParameterMap decodes the ParameterMap argument of a Boot! event.
+func (e *USPNotifyEvent) BootParameterMap() (map[string]string, error) {
+	out := map[string]string{}
+	if e.Event == nil {
+		return out, nil
+	}
+	raw := e.Event.Params[USPBootParameterMap]
+	if raw == "" {
+		return out, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FirmwareUpdated reports the FirmwareUpdated argument of a Boot! event.
+func (e *USPNotifyEvent) FirmwareUpdated() bool {
+	if e.Event == nil {
+		return false
+	}
+	updated, _ := strconv.ParseBool(e.Event.Params[USPBootFirmwareUpdated])
+	return updated
+}
+
+// ObservedAt returns the event timestamp, falling back to now.
+func (e *USPNotifyEvent) ObservedAt() time.Time {
+	if e.Timestamp != nil && !e.Timestamp.IsZero() {
+		return *e.Timestamp
+	}
+	return time.Now()
+}
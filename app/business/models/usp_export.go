@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// DefaultExportTimezone is the timezone of the times of an export when none is asked.
+const DefaultExportTimezone = "Asia/Ho_Chi_Minh"
+
+// ExportOptions controls an export.
+type ExportOptions struct {
+	// Format is one of the export writer formats: csv, ndjson or xlsx, empty means csv
+	Format string
+	// Timezone is the IANA name the times are written in, empty means DefaultExportTimezone
+	Timezone string
+}
+
+// ExportSchemaVersion is the version of the export columns below, an export sends it in the
+// X-Export-Schema-Version header. It changes when a column is renamed, moved or removed.
+const ExportSchemaVersion = 1
+
+// ExportSchema lists the columns of the export of a resource. Columns are read back verbatim by
+// the CSV import of the resource, ReadOnly columns follow them and are ignored by the import.
+type ExportSchema struct {
+	Columns  []string
+	ReadOnly []string
+}
+
+// Header returns the header row of an export
+func (s ExportSchema) Header() []string {
+	return append(append([]string{}, s.Columns...), s.ReadOnly...)
+}
+
+// exportAuditColumns end every export, they are set by the server on import
+var exportAuditColumns = []string{"Created At", "Updated At", "Updated By", "Status"}
+
+// ParameterExportSchema is read back by the parameter CSV import
+var ParameterExportSchema = ExportSchema{
+	Columns:  []string{"Path", "Data Type", "Description"},
+	ReadOnly: exportAuditColumns,
+}
+
+// ProfileExportSchema is read back by the profile CSV import, Parameters holds the
+// ProfileParameterMembers of the profile
+var ProfileExportSchema = ExportSchema{
+	Columns: []string{
+		"Name", "Msg Type", "Tags", "Max Depth", "Allow Partial",
+		"First Level Only", "Return Commands", "Return Events",
+		"Return Params", "Return Unique Key Sets", "Send Resp", "Description", "Parameters",
+	},
+	ReadOnly: exportAuditColumns,
+}
+
+// LegacyProfileImportColumns is the profile CSV header from before the export schema, parameters
+// are listed by id and get an empty default value.
+var LegacyProfileImportColumns = []string{
+	"Name", "Msg Type", "Tags", "Max Depth", "Allow Partial",
+	"First Level Only", "Return Commands", "Return Events",
+	"Return Params", "Return Unique Key Sets", "Send Resp", "Parameters IDs",
+}
+
+// DeviceExportSchema is read back by the device CSV import, the model and the group
+// of the imported devices are chosen by the import request
+var DeviceExportSchema = ExportSchema{
+	Columns: []string{"MAC Address", "Serial Number", "Product Class", "Hardware Revision"},
+	ReadOnly: append([]string{
+		"Endpoint ID", "Model ID", "Group ID", "Software Version", "Lifecycle State", "Description",
+	}, exportAuditColumns...),
+}
+
+// ModelExportSchema is read back by the model CSV import, a model is found by name
+var ModelExportSchema = ExportSchema{
+	Columns:  []string{"Name", "Vendor Name", "Manufacturer", "Description", "Endpoint ID Template"},
+	ReadOnly: exportAuditColumns,
+}
+
+// FirmwareExportSchema is read back by the firmware CSV import of a model, File Path is kept
+// as it is and the file is not uploaded again
+var FirmwareExportSchema = ExportSchema{
+	Columns:  []string{"Name", "File Path", "Description"},
+	ReadOnly: exportAuditColumns,
+}
+
+// GroupExportSchema is read back by the group CSV import of a model, Firmware ID is a
+// firmware of that model
+var GroupExportSchema = ExportSchema{
+	Columns:  []string{"Name", "Description", "Firmware ID", "Download Period"},
+	ReadOnly: exportAuditColumns,
+}
+
+// ProfileParameterMember is a parameter of a profile in the Parameters column, found by path
+// so a profile can be imported in another catalog
+type ProfileParameterMember struct {
+	Path         string `json:"path"`
+	DefaultValue string `json:"default_value"`
+	Required     bool   `json:"required"`
+}
+
+// ProfileParameterMembers is written as a JSON array, also in a CSV or XLSX cell
+type ProfileParameterMembers []ProfileParameterMember
+
+func (m ProfileParameterMembers) String() string {
+	if m == nil {
+		return "[]"
+	}
+	b, _ := json.Marshal([]ProfileParameterMember(m))
+	return string(b)
+}
+
+// ParseProfileParameterMembers reads the Parameters column of a profile, empty means no parameter
+func ParseProfileParameterMembers(text string) (ProfileParameterMembers, error) {
+	members := ProfileParameterMembers{}
+	if strings.TrimSpace(text) == "" {
+		return members, nil
+	}
+	if err := json.Unmarshal([]byte(text), &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
@@ -21,6 +21,9 @@ CREATE TABLE public.firmwares (
 	UNIQUE INDEX firmwares_name_idx (name ASC) STORING (model_id, file_path, status, description, created_at, updated_at),
 	INDEX firmwares_model_id_idx (model_id ASC) STORING (name, file_path, status, description, created_at, updated_at)
 );
+
+-- global search: ILIKE '%text%' is served by the trigram indexes
+CREATE INDEX firmwares_name_trgm_idx ON public.firmwares USING GIN (name gin_trgm_ops);
 */
 
 const USPFirmwareTableName = "firmwares"
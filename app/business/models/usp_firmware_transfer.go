@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+CREATE TABLE public.device_firmware_transfers (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	device_id UUID NOT NULL,
+	firmware_id UUID NULL,
+	obj_path VARCHAR NOT NULL,
+	command_name VARCHAR(64) NOT NULL,
+	command_key VARCHAR(255) NULL,
+	status VARCHAR(16) NOT NULL,
+	err_code INT8 NULL,
+	err_msg STRING NULL,
+	completed_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT device_firmware_transfers_pkey PRIMARY KEY (id ASC),
+	CONSTRAINT device_firmware_transfers_device_id_fkey FOREIGN KEY (device_id) REFERENCES public.devices(id),
+	CONSTRAINT device_firmware_transfers_firmware_id_fkey FOREIGN KEY (firmware_id) REFERENCES public.firmwares(id),
+	INDEX device_firmware_transfers_device_id_idx (device_id ASC, completed_at DESC) STORING (firmware_id, status)
+);
+COMMENT ON COLUMN public.device_firmware_transfers.obj_path IS 'firmware image object, example: `Device.DeviceInfo.FirmwareImage.2.`';
+COMMENT ON COLUMN public.device_firmware_transfers.command_key IS 'command key sent with Download(), resolved to a firmware by id or name';
+COMMENT ON COLUMN public.device_firmware_transfers.status IS 'SUCCESS | FAILED';
+*/
+
+const USPDeviceFirmwareTransferTableName = "device_firmware_transfers"
+const USPDeviceFirmwareTransferEntityName = "DeviceFirmwareTransfer"
+
+const (
+	FirmwareTransferStatusSuccess = "SUCCESS"
+	FirmwareTransferStatusFailed  = "FAILED"
+)
+
+type DeviceFirmwareTransfer struct {
+	Id          *uuid.UUID `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"id"`
+	DeviceId    *uuid.UUID `gorm:"column:device_id;type:uuid;not null" json:"device_id"`
+	FirmwareId  *uuid.UUID `gorm:"column:firmware_id;type:uuid;default:null" json:"firmware_id,omitempty"`
+	ObjPath     string     `gorm:"column:obj_path;type:varchar;not null" json:"obj_path"`
+	CommandName string     `gorm:"column:command_name;type:varchar(64);not null" json:"command_name"`
+	CommandKey  string     `gorm:"column:command_key;type:varchar(255);default:null" json:"command_key,omitempty"`
+	Status      string     `gorm:"column:status;type:varchar(16);not null" json:"status"`
+	ErrCode     uint32     `gorm:"column:err_code;default:null" json:"err_code,omitempty"`
+	ErrMsg      string     `gorm:"column:err_msg;type:string;default:null" json:"err_msg,omitempty"`
+	CompletedAt *time.Time `gorm:"column:completed_at;not null" json:"completed_at"`
+	CreatedAt   *time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (DeviceFirmwareTransfer) TableName() string     { return USPDeviceFirmwareTransferTableName }
+func (DeviceFirmwareTransfer) GetEntityName() string { return USPDeviceFirmwareTransferEntityName }
+
+func (DeviceFirmwareTransfer) GetIdColumnName() string          { return "id" }
+func (DeviceFirmwareTransfer) GetDeviceIdColumnName() string    { return "device_id" }
+func (DeviceFirmwareTransfer) GetFirmwareIdColumnName() string  { return "firmware_id" }
+func (DeviceFirmwareTransfer) GetStatusColumnName() string      { return "status" }
+func (DeviceFirmwareTransfer) GetCompletedAtColumnName() string { return "completed_at" }
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+	"time"
+	apperrors "usp-management-device-api/common/app_errors"
+)
+
+// USPGetResp is the JSON form of a USP GetResp message published by the controller,
+// the field names follow the GetResp message in usp-msg.proto.
+type USPGetResp struct {
+	EndpointId     string                   `json:"endpoint_id"`
+	MsgId          string                   `json:"msg_id,omitempty"`
+	Timestamp      *time.Time               `json:"timestamp,omitempty"`
+	ReqPathResults []USPRequestedPathResult `json:"req_path_results"`
+}
+
+type USPRequestedPathResult struct {
+	RequestedPath       string                  `json:"requested_path"`
+	ErrCode             uint32                  `json:"err_code,omitempty"`
+	ErrMsg              string                  `json:"err_msg,omitempty"`
+	ResolvedPathResults []USPResolvedPathResult `json:"resolved_path_results,omitempty"`
+}
+
+type USPResolvedPathResult struct {
+	// ResolvedPath is the object path the params belong to, example: `Device.WiFi.SSID.1.`
+	ResolvedPath string            `json:"resolved_path"`
+	ResultParams map[string]string `json:"result_params,omitempty"`
+}
+
+// Validate checks the payload shape before it is handed to the usecase,
+// endpointRequired is false when the device is already known from the request path.
+func (r *USPGetResp) Validate(endpointRequired bool) error {
+	if endpointRequired && strings.TrimSpace(r.EndpointId) == "" {
+		return apperrors.NewErrInvalidEventPayload("endpoint_id is required")
+	}
+	if len(r.ReqPathResults) == 0 {
+		return apperrors.NewErrInvalidEventPayload("req_path_results must not be empty")
+	}
+	for _, result := range r.ReqPathResults {
+		for _, resolved := range result.ResolvedPathResults {
+			if resolved.ResolvedPath == "" {
+				return apperrors.NewErrInvalidEventPayload("resolved_path is required")
+			}
+		}
+	}
+	return nil
+}
+
+// ParameterValues flattens the successful results into full parameter paths,
+// failed requested paths (err_code != 0) are skipped.
+func (r *USPGetResp) ParameterValues() map[string]string {
+	values := map[string]string{}
+	for _, result := range r.ReqPathResults {
+		if result.ErrCode != 0 {
+			continue
+		}
+		for _, resolved := range result.ResolvedPathResults {
+			prefix := resolved.ResolvedPath
+			if !strings.HasSuffix(prefix, ".") {
+				prefix += "."
+			}
+			for name, value := range resolved.ResultParams {
+				values[prefix+name] = value
+			}
+		}
+	}
+	return values
+}
+
+// ObservedAt returns the response timestamp, falling back to now.
+func (r *USPGetResp) ObservedAt() time.Time {
+	if r.Timestamp != nil && !r.Timestamp.IsZero() {
+		return *r.Timestamp
+	}
+	return time.Now()
+}
@@ -23,6 +23,9 @@ CREATE TABLE public.groups (
 	INDEX groups_model_id_idx (model_id ASC) STORING (firmware_id, name, status, description, created_at, updated_at),
 	INDEX groups_firmware_id_idx (firmware_id ASC) STORING (model_id, name, status, description, created_at, updated_at)
 );
+
+-- global search: ILIKE '%text%' is served by the trigram indexes
+CREATE INDEX groups_name_trgm_idx ON public.groups USING GIN (name gin_trgm_ops);
 */
 
 const USPGroupTableName = "groups"
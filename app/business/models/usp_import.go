@@ -0,0 +1,83 @@
+package models
+
+import "strconv"
+
+const (
+	// ImportModeInsert rejects rows whose key already exists
+	ImportModeInsert = "insert"
+	// ImportModeUpsert updates rows whose key exists and inserts the others
+	ImportModeUpsert = "upsert"
+	// ImportModeReplace upserts and soft-deletes the rows missing from the file
+	ImportModeReplace = "replace"
+)
+
+// ImportOptions controls a CSV import.
+type ImportOptions struct {
+	// Mode is one of the ImportMode constants, empty means insert
+	Mode string
+	// Strict rejects the whole file when a row is invalid, otherwise valid rows are committed
+	Strict bool
+	// DryRun runs the import in a transaction that is rolled back, the report tells what would change
+	DryRun bool
+}
+
+// Upserts reports whether rows whose key exists are updated.
+func (o ImportOptions) Upserts() bool {
+	return o.Mode == ImportModeUpsert || o.Mode == ImportModeReplace
+}
+
+// ImportRowError is a rejected row, Line is the line of the row in the file.
+type ImportRowError struct {
+	Line   int    `json:"line"`
+	Key    string `json:"key,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport is the outcome of a CSV import.
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Mode     string           `json:"mode"`
+	Total    int              `json:"total"`
+	Created  int              `json:"created"`
+	Updated  int              `json:"updated"`
+	Skipped  int              `json:"skipped"`
+	Deleted  int              `json:"deleted"`
+	Rejected []ImportRowError `json:"rejected"`
+	// Ids of the created rows, returned by each endpoint under its own field
+	Ids []string `json:"-"`
+}
+
+func NewImportReport(mode string) *ImportReport {
+	return &ImportReport{
+		Mode:     mode,
+		Rejected: []ImportRowError{},
+		Ids:      []string{},
+	}
+}
+
+func (r *ImportReport) Reject(line int, key string, reason string) {
+	r.Rejected = append(r.Rejected, ImportRowError{Line: line, Key: key, Reason: reason})
+}
+
+// Skip counts a row that is neither written nor rejected, such as a row with missing columns
+// or a row equal to the stored one.
+func (r *ImportReport) Skip() {
+	r.Skipped++
+}
+
+// MarkDryRun turns the report into the preview of the import, nothing was kept.
+func (r *ImportReport) MarkDryRun() *ImportReport {
+	r.DryRun = true
+	r.Ids = []string{}
+	return r
+}
+
+// RejectedCSV returns the rejected rows with a header line, ready for utils.ConvertToCSV.
+func (r *ImportReport) RejectedCSV() [][]string {
+	rows := make([][]string, 0, len(r.Rejected)+1)
+	rows = append(rows, []string{"Line", "Key", "Reason"})
+	for _, rejected := range r.Rejected {
+		rows = append(rows, []string{strconv.Itoa(rejected.Line), rejected.Key, rejected.Reason})
+	}
+	return rows
+}
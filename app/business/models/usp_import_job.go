@@ -0,0 +1,170 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+CREATE TABLE public.import_jobs (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	kind VARCHAR(16) NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'QUEUED',
+	mode VARCHAR(16) NOT NULL DEFAULT 'insert',
+	file_name VARCHAR(255) NOT NULL,
+	object_name VARCHAR NOT NULL,
+	model_id UUID NULL,
+	group_id UUID NULL,
+	total_rows INT8 NOT NULL DEFAULT 0,
+	processed_rows INT8 NOT NULL DEFAULT 0,
+	created INT8 NOT NULL DEFAULT 0,
+	updated INT8 NOT NULL DEFAULT 0,
+	skipped INT8 NOT NULL DEFAULT 0,
+	rejected INT8 NOT NULL DEFAULT 0,
+	attempts INT4 NOT NULL DEFAULT 0,
+	lease_until TIMESTAMPTZ NULL,
+	last_error STRING NULL,
+	updated_by VARCHAR(255) NOT NULL,
+	started_at TIMESTAMPTZ NULL,
+	finished_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT import_jobs_pkey PRIMARY KEY (id ASC),
+	INDEX import_jobs_claim_idx (status ASC, created_at ASC) STORING (lease_until)
+);
+COMMENT ON COLUMN public.import_jobs.kind IS 'DEVICE | PARAMETER';
+COMMENT ON COLUMN public.import_jobs.status IS 'QUEUED | RUNNING | SUCCEEDED | FAILED | CANCELLED';
+COMMENT ON COLUMN public.import_jobs.processed_rows IS 'checkpoint, rows of the file already committed, a reclaimed job resumes after them';
+COMMENT ON COLUMN public.import_jobs.lease_until IS 'a RUNNING job whose lease expired is claimed again by another worker';
+
+CREATE TABLE public.import_job_errors (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	job_id UUID NOT NULL,
+	line INT8 NOT NULL,
+	key VARCHAR(255) NULL,
+	reason STRING NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT import_job_errors_pkey PRIMARY KEY (id ASC),
+	CONSTRAINT import_job_errors_job_id_fkey FOREIGN KEY (job_id) REFERENCES public.import_jobs(id),
+	INDEX import_job_errors_job_id_idx (job_id ASC, line ASC)
+);
+*/
+
+const USPImportJobTableName = "import_jobs"
+const USPImportJobEntityName = "ImportJob"
+const USPImportJobErrorTableName = "import_job_errors"
+const USPImportJobErrorEntityName = "ImportJobError"
+
+const (
+	ImportJobKindDevice    = "DEVICE"
+	ImportJobKindParameter = "PARAMETER"
+
+	ImportJobStatusQueued    = "QUEUED"
+	ImportJobStatusRunning   = "RUNNING"
+	ImportJobStatusSucceeded = "SUCCEEDED"
+	ImportJobStatusFailed    = "FAILED"
+	ImportJobStatusCancelled = "CANCELLED"
+)
+
+// ImportJob is a CSV import processed in the background, chunk by chunk.
+type ImportJob struct {
+	Id            *uuid.UUID `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Kind          string     `gorm:"column:kind;type:varchar(16);not null" json:"kind"`
+	Status        string     `gorm:"column:status;type:varchar(16);default:'QUEUED'" json:"status"`
+	Mode          string     `gorm:"column:mode;type:varchar(16);not null" json:"mode"`
+	FileName      string     `gorm:"column:file_name;type:varchar(255);not null" json:"file_name"`
+	ObjectName    string     `gorm:"column:object_name;type:varchar;not null" json:"-"`
+	ModelId       *uuid.UUID `gorm:"column:model_id;type:uuid;default:null" json:"model_id,omitempty"`
+	GroupId       *uuid.UUID `gorm:"column:group_id;type:uuid;default:null" json:"group_id,omitempty"`
+	TotalRows     int        `gorm:"column:total_rows" json:"total_rows"`
+	ProcessedRows int        `gorm:"column:processed_rows" json:"processed_rows"`
+	Created       int        `gorm:"column:created" json:"created"`
+	Updated       int        `gorm:"column:updated" json:"updated"`
+	Skipped       int        `gorm:"column:skipped" json:"skipped"`
+	Rejected      int        `gorm:"column:rejected" json:"rejected"`
+	Attempts      int        `gorm:"column:attempts" json:"attempts"`
+	LeaseUntil    *time.Time `gorm:"column:lease_until" json:"-"`
+	LastError     string     `gorm:"column:last_error;type:string;default:null" json:"last_error,omitempty"`
+	UpdatedBy     string     `gorm:"column:updated_by;type:varchar(255);not null" json:"updated_by"`
+	StartedAt     *time.Time `gorm:"column:started_at" json:"started_at"`
+	FinishedAt    *time.Time `gorm:"column:finished_at" json:"finished_at"`
+	CreatedAt     *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     *time.Time `gorm:"column:updated_at" json:"updated_at"`
+
+	// Errors holds the first rejected rows, read separately from import_job_errors
+	Errors []ImportJobError `gorm:"-" json:"errors"`
+}
+
+func (ImportJob) TableName() string     { return USPImportJobTableName }
+func (ImportJob) GetEntityName() string { return USPImportJobEntityName }
+
+func (ImportJob) GetIdColumnName() string     { return "id" }
+func (ImportJob) GetStatusColumnName() string { return "status" }
+
+// Progress is the share of the file already processed, from 0 to 100.
+func (j ImportJob) Progress() float64 {
+	if j.TotalRows == 0 {
+		if j.Status == ImportJobStatusSucceeded {
+			return 100
+		}
+		return 0
+	}
+	return float64(j.ProcessedRows) * 100 / float64(j.TotalRows)
+}
+
+// IsFinished reports whether the job reached a terminal status.
+func (j ImportJob) IsFinished() bool {
+	return j.Status == ImportJobStatusSucceeded || j.Status == ImportJobStatusFailed || j.Status == ImportJobStatusCancelled
+}
+
+// ImportJobUpdate is written on claim, checkpoint and finish, nil fields are left unchanged.
+type ImportJobUpdate struct {
+	Status        *string    `gorm:"column:status;type:varchar(16)"`
+	TotalRows     *int       `gorm:"column:total_rows"`
+	ProcessedRows *int       `gorm:"column:processed_rows"`
+	Created       *int       `gorm:"column:created"`
+	Updated       *int       `gorm:"column:updated"`
+	Skipped       *int       `gorm:"column:skipped"`
+	Rejected      *int       `gorm:"column:rejected"`
+	Attempts      *int       `gorm:"column:attempts"`
+	LeaseUntil    *time.Time `gorm:"column:lease_until"`
+	LastError     *string    `gorm:"column:last_error;type:string"`
+	UpdatedBy     *string    `gorm:"column:updated_by;type:varchar(255)"`
+	StartedAt     *time.Time `gorm:"column:started_at"`
+	FinishedAt    *time.Time `gorm:"column:finished_at"`
+	UpdatedAt     *time.Time `gorm:"column:updated_at"`
+}
+
+func (ImportJobUpdate) TableName() string     { return USPImportJobTableName }
+func (ImportJobUpdate) GetEntityName() string { return USPImportJobEntityName }
+
+// NewImportJobCheckpoint returns the update that records the progress and counts of job.
+func NewImportJobCheckpoint(job *ImportJob, leaseUntil time.Time) *ImportJobUpdate {
+	now := time.Now()
+	return &ImportJobUpdate{
+		TotalRows:     &job.TotalRows,
+		ProcessedRows: &job.ProcessedRows,
+		Created:       &job.Created,
+		Updated:       &job.Updated,
+		Skipped:       &job.Skipped,
+		Rejected:      &job.Rejected,
+		LeaseUntil:    &leaseUntil,
+		UpdatedAt:     &now,
+	}
+}
+
+// ImportJobError is a rejected row of an import job, Line is the line of the row in the file.
+type ImportJobError struct {
+	Id        *uuid.UUID `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"-"`
+	JobId     *uuid.UUID `gorm:"column:job_id;type:uuid;not null" json:"-"`
+	Line      int        `gorm:"column:line" json:"line"`
+	Key       string     `gorm:"column:key;type:varchar(255);default:null" json:"key,omitempty"`
+	Reason    string     `gorm:"column:reason;type:string;not null" json:"reason"`
+	CreatedAt *time.Time `gorm:"column:created_at" json:"-"`
+}
+
+func (ImportJobError) TableName() string     { return USPImportJobErrorTableName }
+func (ImportJobError) GetEntityName() string { return USPImportJobErrorEntityName }
+
+func (ImportJobError) GetJobIdColumnName() string { return "job_id" }
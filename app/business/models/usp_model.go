@@ -21,6 +21,13 @@ import (
 // COMMENT ON COLUMN public.models.name IS 'factory model name: Model Wifi 6';
 // COMMENT ON COLUMN public.models.vendor_name IS 'factory model name: AX3000S';
 // COMMENT ON COLUMN public.models.manufacturer IS 'ODM name, such as: CIG, Skyworth';
+//
+// ALTER TABLE public.models ADD COLUMN endpoint_id_template VARCHAR NULL;
+// COMMENT ON COLUMN public.models.endpoint_id_template IS 'endpoint id scheme of the model devices, placeholders {oui} {mac} {serial} {product_class}, NULL means `os::{oui}-{mac}`';
+//
+// -- global search: ILIKE '%text%' is served by the trigram indexes
+// CREATE INDEX models_name_trgm_idx ON public.models USING GIN (name gin_trgm_ops);
+// CREATE INDEX models_vendor_name_trgm_idx ON public.models USING GIN (vendor_name gin_trgm_ops);
 
 const USPModelTableName = "models"
 const USPModedlEntityName = "Model"
@@ -38,6 +45,8 @@ type Model struct {
 	UpdatedBy    string `json:"updated_by,omitempty" gorm:"column:updated_by;type:varchar(255);default:null"`
 	Image        string `json:"image,omitempty" gorm:"column:image;type:text;default:null"`
 
+	EndpointIdTemplate string `json:"endpoint_id_template,omitempty" gorm:"column:endpoint_id_template;type:varchar;default:null"`
+
 	Groups            []Group            `gorm:"foreignKey:ModelId;references:Id;" json:"groups,omitempty"`
 	ModelCustomFields []ModelCustomField `gorm:"foreignKey:ModelId;references:Id;" json:"model_custom_fields,omitempty"`
 	Firmwares         []Firmware         `gorm:"foreignKey:ModelId;references:Id;" json:"firmwares,omitempty"`
@@ -55,6 +64,16 @@ func (Model) GetDescriptionColumnName() string  { return "description" }
 func (Model) GetCreatedAtColumnName() string    { return "created_at" }
 func (Model) GetUpdatedAtColumnName() string    { return "updated_at" }
 
+func (Model) GetEndpointIdTemplateColumnName() string { return "endpoint_id_template" }
+
+// GetEndpointIdTemplate returns the endpoint id template of the model or the default one.
+func (m Model) GetEndpointIdTemplate() string {
+	if m.EndpointIdTemplate == "" {
+		return DefaultEndpointIdTemplate
+	}
+	return m.EndpointIdTemplate
+}
+
 type ModelUpdate struct {
 	Name         *string    `gorm:"column:name;type:varchar;not null" json:"name"`
 	VendorName   *string    `gorm:"column:vendor_name;type:varchar;not null" json:"vendor_name"`
@@ -64,6 +83,8 @@ type ModelUpdate struct {
 	UpdatedBy    *string    `gorm:"column:updated_by;type:varchar(255);default:null" json:"updated_by,omitempty"`
 	UpdatedAt    *time.Time `json:"updated_at" gorm:"column:updated_at"`
 	Image        *string    `json:"image,omitempty" gorm:"column:image;type:text;default:null"`
+
+	EndpointIdTemplate *string `json:"endpoint_id_template,omitempty" gorm:"column:endpoint_id_template;type:varchar;default:null"`
 }
 
 func (ModelUpdate) TableName() string     { return USPModelTableName }
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+CREATE TABLE public.outbox_events (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	seq INT8 NOT NULL DEFAULT unique_rowid(),
+	aggregate_type VARCHAR(32) NOT NULL,
+	aggregate_id UUID NOT NULL,
+	aggregate_version INT8 NOT NULL,
+	event_type VARCHAR(64) NOT NULL,
+	schema_version INT4 NOT NULL,
+	payload JSONB NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'PENDING',
+	attempts INT4 NOT NULL DEFAULT 0,
+	last_error STRING NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	published_at TIMESTAMPTZ NULL,
+	CONSTRAINT outbox_events_pkey PRIMARY KEY (id ASC),
+	UNIQUE INDEX outbox_events_aggregate_version_idx (aggregate_id ASC, aggregate_version ASC) STORING (status),
+	INDEX outbox_events_status_idx (status ASC, seq ASC) STORING (aggregate_type, aggregate_id, aggregate_version, event_type, schema_version, payload, attempts)
+);
+COMMENT ON COLUMN public.outbox_events.seq IS 'relay order across aggregates, assigned by the database at insert time';
+COMMENT ON COLUMN public.outbox_events.aggregate_version IS '1, 2, 3... per aggregate_id in commit order, the relay publishes the events of an aggregate in this order';
+COMMENT ON COLUMN public.outbox_events.payload IS 'DomainEvent envelope published as the Kafka message value';
+COMMENT ON COLUMN public.outbox_events.status IS 'PENDING | PUBLISHED';
+*/
+
+const USPOutboxEventTableName = "outbox_events"
+const USPOutboxEventEntityName = "OutboxEvent"
+
+const (
+	OutboxStatusPending   = "PENDING"
+	OutboxStatusPublished = "PUBLISHED"
+)
+
+// DomainEventSchemaVersion is bumped on any breaking change of DomainEvent or its data.
+const DomainEventSchemaVersion = 1
+
+const (
+	AggregateProfile  = "profile"
+	AggregateGroup    = "group"
+	AggregateFirmware = "firmware"
+	AggregateDevice   = "device"
+
+	EventActionCreated = "created"
+	EventActionUpdated = "updated"
+	EventActionDeleted = "deleted"
+
+	EventActionFirmwareChanged  = "firmware_changed"
+	EventActionLifecycleChanged = "lifecycle_changed"
+	EventActionReplaced         = "replaced"
+	EventActionModelChanged     = "model_changed"
+)
+
+// DomainEvent is the versioned envelope published for every change of a managed entity.
+// Consumers must de-duplicate on Id, delivery is at-least-once. Events of one aggregate reach
+// its partition in AggregateVersion order, a re-sent batch can repeat versions already seen.
+type DomainEvent struct {
+	Id            uuid.UUID `json:"id"`
+	Type          string    `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateId   uuid.UUID `json:"aggregate_id"`
+	// AggregateVersion counts the events of the aggregate from 1, without gaps, in commit order
+	AggregateVersion int64           `json:"aggregate_version"`
+	OccurredAt       time.Time       `json:"occurred_at"`
+	Actor            string          `json:"actor,omitempty"`
+	Data             json.RawMessage `json:"data,omitempty"`
+}
+
+type OutboxEvent struct {
+	Id               *uuid.UUID `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Seq              int64      `gorm:"column:seq;->" json:"-"`
+	AggregateType    string     `gorm:"column:aggregate_type;type:varchar(32);not null" json:"aggregate_type"`
+	AggregateId      *uuid.UUID `gorm:"column:aggregate_id;type:uuid;not null" json:"aggregate_id"`
+	AggregateVersion int64      `gorm:"column:aggregate_version;not null" json:"aggregate_version"`
+	EventType        string     `gorm:"column:event_type;type:varchar(64);not null" json:"event_type"`
+	SchemaVersion    int        `gorm:"column:schema_version;not null" json:"schema_version"`
+	Payload          []byte     `gorm:"column:payload;type:jsonb;not null" json:"-"`
+	Status           string     `gorm:"column:status;type:varchar(16);default:'PENDING'" json:"status"`
+	Attempts         int        `gorm:"column:attempts;default:0" json:"attempts"`
+	LastError        string     `gorm:"column:last_error;type:string;default:null" json:"last_error,omitempty"`
+	CreatedAt        *time.Time `gorm:"column:created_at" json:"created_at"`
+	PublishedAt      *time.Time `gorm:"column:published_at;default:null" json:"published_at,omitempty"`
+}
+
+func (OutboxEvent) TableName() string     { return USPOutboxEventTableName }
+func (OutboxEvent) GetEntityName() string { return USPOutboxEventEntityName }
+
+func (OutboxEvent) GetIdColumnName() string               { return "id" }
+func (OutboxEvent) GetSeqColumnName() string              { return "seq" }
+func (OutboxEvent) GetAggregateIdColumnName() string      { return "aggregate_id" }
+func (OutboxEvent) GetAggregateVersionColumnName() string { return "aggregate_version" }
+func (OutboxEvent) GetStatusColumnName() string           { return "status" }
+func (OutboxEvent) GetAttemptsColumnName() string         { return "attempts" }
+func (OutboxEvent) GetLastErrorColumnName() string        { return "last_error" }
+func (OutboxEvent) GetCreatedAtColumnName() string        { return "created_at" }
+func (OutboxEvent) GetPublishedAtColumnName() string      { return "published_at" }
+
+// DomainEventType builds the event type, example: `device.created`.
+func DomainEventType(aggregateType, action string) string {
+	return aggregateType + "." + action
+}
+
+// NewOutboxEvent wraps data into a DomainEvent envelope ready to be stored in the outbox.
+func NewOutboxEvent(
+	aggregateType string,
+	aggregateId uuid.UUID,
+	aggregateVersion int64,
+	eventType string,
+	actor string,
+	data any,
+) (*OutboxEvent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	id := uuid.New()
+	payload, err := json.Marshal(DomainEvent{
+		Id:               id,
+		Type:             eventType,
+		SchemaVersion:    DomainEventSchemaVersion,
+		AggregateType:    aggregateType,
+		AggregateId:      aggregateId,
+		AggregateVersion: aggregateVersion,
+		OccurredAt:       now,
+		Actor:            actor,
+		Data:             raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		Id:               &id,
+		AggregateType:    aggregateType,
+		AggregateId:      &aggregateId,
+		AggregateVersion: aggregateVersion,
+		EventType:        eventType,
+		SchemaVersion:    DomainEventSchemaVersion,
+		Payload:          payload,
+		Status:           OutboxStatusPending,
+		CreatedAt:        &now,
+	}, nil
+}
@@ -23,6 +23,8 @@ COMMENT ON COLUMN public.parameters.path IS 'TR369 key, example: Device.DeviceIn
 COMMENT ON COLUMN public.parameters.data_type IS 'The datatype of TR369 key, example: `string`, ref: https://usp-data-models.broadband-forum.org/tr-181-2-19-1-usp.html';
 COMMENT ON COLUMN public.parameters.description IS 'just some description for human';
 
+-- global search: ILIKE '%text%' is served by the trigram indexes
+CREATE INDEX parameters_path_trgm_idx ON public.parameters USING GIN (path gin_trgm_ops);
 */
 
 const USPParameterTableName = "parameters"
@@ -43,6 +43,9 @@ CREATE TABLE public.profiles (
 );
 COMMENT ON COLUMN public.profiles.name IS 'profile name can be the route path with replace `/` to `_`, example: system_resources';
 COMMENT ON COLUMN public.profiles.tags IS 'some tags for categories api, can be: [<consumer_name>, <public or private>';
+
+-- global search: ILIKE '%text%' is served by the trigram indexes
+CREATE INDEX profiles_name_trgm_idx ON public.profiles USING GIN (name gin_trgm_ops);
 */
 
 const USPProfileTableName = "profiles"
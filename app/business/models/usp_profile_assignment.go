@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+CREATE TABLE public.profile_assignments (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	profile_id UUID NOT NULL,
+	group_id UUID NULL,
+	device_id UUID NULL,
+	updated_by VARCHAR(255) NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT profile_assignments_pkey PRIMARY KEY (id ASC),
+	CONSTRAINT profile_assignments_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES public.profiles(id),
+	CONSTRAINT profile_assignments_group_id_fkey FOREIGN KEY (group_id) REFERENCES public.groups(id),
+	CONSTRAINT profile_assignments_device_id_fkey FOREIGN KEY (device_id) REFERENCES public.devices(id),
+	CONSTRAINT profile_assignments_target_check CHECK ((group_id IS NULL) != (device_id IS NULL)),
+	UNIQUE INDEX profile_assignments_group_id_profile_id_idx (group_id ASC, profile_id ASC) WHERE group_id IS NOT NULL,
+	UNIQUE INDEX profile_assignments_device_id_profile_id_idx (device_id ASC, profile_id ASC) WHERE device_id IS NOT NULL,
+	INDEX profile_assignments_profile_id_idx (profile_id ASC)
+);
+COMMENT ON TABLE public.profile_assignments IS 'profiles applied to a group or to a single device, exactly one of group_id/device_id is set';
+*/
+
+const USPProfileAssignmentTableName = "profile_assignments"
+const USPProfileAssignmentEntityName = "ProfileAssignment"
+
+const (
+	ProfileAssignmentScopeGroup  = "GROUP"
+	ProfileAssignmentScopeDevice = "DEVICE"
+)
+
+type ProfileAssignment struct {
+	Id        *uuid.UUID `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"id"`
+	ProfileId *uuid.UUID `gorm:"column:profile_id;type:uuid;not null" json:"profile_id"`
+	GroupId   *uuid.UUID `gorm:"column:group_id;type:uuid;default:null" json:"group_id,omitempty"`
+	DeviceId  *uuid.UUID `gorm:"column:device_id;type:uuid;default:null" json:"device_id,omitempty"`
+	UpdatedBy string     `gorm:"column:updated_by;type:varchar(255);default:null" json:"updated_by,omitempty"`
+	CreatedAt *time.Time `gorm:"column:created_at" json:"created_at"`
+
+	Profile *Profile `gorm:"foreignKey:ProfileId;references:Id;" json:"profile,omitempty"`
+}
+
+func (ProfileAssignment) TableName() string     { return USPProfileAssignmentTableName }
+func (ProfileAssignment) GetEntityName() string { return USPProfileAssignmentEntityName }
+
+func (ProfileAssignment) GetIdColumnName() string        { return "id" }
+func (ProfileAssignment) GetProfileIdColumnName() string { return "profile_id" }
+func (ProfileAssignment) GetGroupIdColumnName() string   { return "group_id" }
+func (ProfileAssignment) GetDeviceIdColumnName() string  { return "device_id" }
+func (ProfileAssignment) GetCreatedAtColumnName() string { return "created_at" }
+
+// Preload helper
+func (ProfileAssignment) GetProfileParameterPreload() string {
+	return "Profile.ProfileParameters.Parameter"
+}
+
+// Scope returns GROUP or DEVICE depending on the assignment target.
+func (a ProfileAssignment) Scope() string {
+	if a.DeviceId != nil {
+		return ProfileAssignmentScopeDevice
+	}
+	return ProfileAssignmentScopeGroup
+}
@@ -0,0 +1,464 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of the fields a list can be filtered and ordered on.
+const (
+	FieldTypeString      = "string"
+	FieldTypeUUID        = "uuid"
+	FieldTypeInt         = "int"
+	FieldTypeBool        = "bool"
+	FieldTypeTime        = "time"
+	FieldTypeStringArray = "string[]"
+)
+
+// QueryField is a public field of a list resource, Column is the qualified column it reads.
+// Nullable is set for columns that can hold NULL.
+type QueryField struct {
+	Column   string
+	Type     string
+	Nullable bool
+}
+
+// FieldRegistry maps the public field names of a resource to their columns,
+// it is the only way a filter or order reaches SQL.
+type FieldRegistry map[string]QueryField
+
+func field(table string, column string, fieldType string) QueryField {
+	return QueryField{Column: table + "." + column, Type: fieldType}
+}
+
+func nullable(f QueryField) QueryField {
+	f.Nullable = true
+	return f
+}
+
+var ProfileFields = FieldRegistry{
+	"id":                     field(USPProfileTableName, "id", FieldTypeUUID),
+	"name":                   field(USPProfileTableName, "name", FieldTypeString),
+	"profile_name":           field(USPProfileTableName, "name", FieldTypeString),
+	"msg_type":               field(USPProfileTableName, "msg_type", FieldTypeInt),
+	"return_commands":        field(USPProfileTableName, "return_commands", FieldTypeBool),
+	"return_events":          field(USPProfileTableName, "return_events", FieldTypeBool),
+	"return_params":          field(USPProfileTableName, "return_params", FieldTypeBool),
+	"return_unique_key_sets": field(USPProfileTableName, "return_unique_key_sets", FieldTypeBool),
+	"allow_partial":          nullable(field(USPProfileTableName, "allow_partial", FieldTypeBool)),
+	"send_resp":              nullable(field(USPProfileTableName, "send_resp", FieldTypeBool)),
+	"first_level_only":       nullable(field(USPProfileTableName, "first_level_only", FieldTypeBool)),
+	"max_depth":              nullable(field(USPProfileTableName, "max_depth", FieldTypeInt)),
+	"tags":                   nullable(field(USPProfileTableName, "tags", FieldTypeStringArray)),
+	"description":            nullable(field(USPProfileTableName, "description", FieldTypeString)),
+	"created_at":             field(USPProfileTableName, "created_at", FieldTypeTime),
+	"updated_at":             field(USPProfileTableName, "updated_at", FieldTypeTime),
+	"updated_by":             nullable(field(USPProfileTableName, "updated_by", FieldTypeString)),
+	"status":                 field(USPProfileTableName, "status", FieldTypeString),
+}
+
+var ParameterFields = FieldRegistry{
+	"id":          field(USPParameterTableName, "id", FieldTypeUUID),
+	"path":        field(USPParameterTableName, "path", FieldTypeString),
+	"data_type":   field(USPParameterTableName, "data_type", FieldTypeString),
+	"description": nullable(field(USPParameterTableName, "description", FieldTypeString)),
+	"created_at":  field(USPParameterTableName, "created_at", FieldTypeTime),
+	"updated_at":  field(USPParameterTableName, "updated_at", FieldTypeTime),
+	"updated_by":  nullable(field(USPParameterTableName, "updated_by", FieldTypeString)),
+	"status":      field(USPParameterTableName, "status", FieldTypeString),
+}
+
+var ModelFields = FieldRegistry{
+	"id":           field(USPModelTableName, "id", FieldTypeUUID),
+	"name":         field(USPModelTableName, "name", FieldTypeString),
+	"vendor_name":  field(USPModelTableName, "vendor_name", FieldTypeString),
+	"manufacturer": field(USPModelTableName, "manufacturer", FieldTypeString),
+	"status":       field(USPModelTableName, "status", FieldTypeString),
+	"description":  nullable(field(USPModelTableName, "description", FieldTypeString)),
+	"created_at":   field(USPModelTableName, "created_at", FieldTypeTime),
+	"updated_at":   field(USPModelTableName, "updated_at", FieldTypeTime),
+	"updated_by":   nullable(field(USPModelTableName, "updated_by", FieldTypeString)),
+}
+
+var FirmwareFields = FieldRegistry{
+	"id":          field(USPFirmwareTableName, "id", FieldTypeUUID),
+	"model_id":    field(USPFirmwareTableName, "model_id", FieldTypeUUID),
+	"name":        field(USPFirmwareTableName, "name", FieldTypeString),
+	"file_path":   nullable(field(USPFirmwareTableName, "file_path", FieldTypeString)),
+	"status":      field(USPFirmwareTableName, "status", FieldTypeString),
+	"description": nullable(field(USPFirmwareTableName, "description", FieldTypeString)),
+	"created_at":  field(USPFirmwareTableName, "created_at", FieldTypeTime),
+	"updated_at":  field(USPFirmwareTableName, "updated_at", FieldTypeTime),
+	"updated_by":  nullable(field(USPFirmwareTableName, "updated_by", FieldTypeString)),
+}
+
+var GroupFields = FieldRegistry{
+	"id":          field(USPGroupTableName, "id", FieldTypeUUID),
+	"model_id":    field(USPGroupTableName, "model_id", FieldTypeUUID),
+	"firmware_id": nullable(field(USPGroupTableName, "firmware_id", FieldTypeUUID)),
+	"name":        field(USPGroupTableName, "name", FieldTypeString),
+	"description": nullable(field(USPGroupTableName, "description", FieldTypeString)),
+	"status":      field(USPGroupTableName, "status", FieldTypeString),
+	"created_at":  field(USPGroupTableName, "created_at", FieldTypeTime),
+	"updated_at":  field(USPGroupTableName, "updated_at", FieldTypeTime),
+	"updated_by":  nullable(field(USPGroupTableName, "updated_by", FieldTypeString)),
+}
+
+var DeviceFields = FieldRegistry{
+	"id":                field(USPDeviceTableName, "id", FieldTypeUUID),
+	"mac_address":       field(USPDeviceTableName, "mac_address", FieldTypeString),
+	"endpoint_id":       field(USPDeviceTableName, "endpoint_id", FieldTypeString),
+	"model_id":          field(USPDeviceTableName, "model_id", FieldTypeUUID),
+	"group_id":          nullable(field(USPDeviceTableName, "group_id", FieldTypeUUID)),
+	"created_at":        field(USPDeviceTableName, "created_at", FieldTypeTime),
+	"updated_at":        field(USPDeviceTableName, "updated_at", FieldTypeTime),
+	"updated_by":        field(USPDeviceTableName, "updated_by", FieldTypeString),
+	"status":            field(USPDeviceTableName, "status", FieldTypeString),
+	"description":       nullable(field(USPDeviceTableName, "description", FieldTypeString)),
+	"software_version":  nullable(field(USPDeviceTableName, "software_version", FieldTypeString)),
+	"last_boot_at":      nullable(field(USPDeviceTableName, "last_boot_at", FieldTypeTime)),
+	"last_seen_at":      nullable(field(USPDeviceTableName, "last_seen_at", FieldTypeTime)),
+	"serial_number":     nullable(field(USPDeviceTableName, "serial_number", FieldTypeString)),
+	"product_class":     nullable(field(USPDeviceTableName, "product_class", FieldTypeString)),
+	"hardware_revision": nullable(field(USPDeviceTableName, "hardware_revision", FieldTypeString)),
+	"lifecycle_state":   field(USPDeviceTableName, "lifecycle_state", FieldTypeString),
+	"labels":            nullable(field(USPDeviceTableName, "labels", FieldTypeStringArray)),
+}
+
+var WebhookSubscriptionFields = FieldRegistry{
+	"id":          field(USPWebhookSubscriptionTableName, "id", FieldTypeUUID),
+	"name":        field(USPWebhookSubscriptionTableName, "name", FieldTypeString),
+	"url":         field(USPWebhookSubscriptionTableName, "url", FieldTypeString),
+	"event_types": field(USPWebhookSubscriptionTableName, "event_types", FieldTypeStringArray),
+	"status":      field(USPWebhookSubscriptionTableName, "status", FieldTypeString),
+	"description": nullable(field(USPWebhookSubscriptionTableName, "description", FieldTypeString)),
+	"created_at":  field(USPWebhookSubscriptionTableName, "created_at", FieldTypeTime),
+	"updated_at":  field(USPWebhookSubscriptionTableName, "updated_at", FieldTypeTime),
+	"updated_by":  nullable(field(USPWebhookSubscriptionTableName, "updated_by", FieldTypeString)),
+}
+
+var WebhookDeliveryFields = FieldRegistry{
+	"id":              field(USPWebhookDeliveryTableName, "id", FieldTypeUUID),
+	"subscription_id": field(USPWebhookDeliveryTableName, "subscription_id", FieldTypeUUID),
+	"event_id":        field(USPWebhookDeliveryTableName, "event_id", FieldTypeUUID),
+	"event_type":      field(USPWebhookDeliveryTableName, "event_type", FieldTypeString),
+	"status":          field(USPWebhookDeliveryTableName, "status", FieldTypeString),
+	"attempts":        field(USPWebhookDeliveryTableName, "attempts", FieldTypeInt),
+	"response_code":   nullable(field(USPWebhookDeliveryTableName, "response_code", FieldTypeInt)),
+	"next_attempt_at": nullable(field(USPWebhookDeliveryTableName, "next_attempt_at", FieldTypeTime)),
+	"delivered_at":    nullable(field(USPWebhookDeliveryTableName, "delivered_at", FieldTypeTime)),
+	"created_at":      field(USPWebhookDeliveryTableName, "created_at", FieldTypeTime),
+	"updated_at":      field(USPWebhookDeliveryTableName, "updated_at", FieldTypeTime),
+}
+
+// ExpandRegistry maps the relations a resource can expand in its responses to their gorm
+// preload keys, a nested relation is written with a dot as in "group.firmware".
+type ExpandRegistry map[string]string
+
+var ModelExpands = ExpandRegistry{
+	"firmwares": "Firmwares",
+	"groups":    "Groups",
+}
+
+var FirmwareExpands = ExpandRegistry{
+	"model": "Model",
+}
+
+var GroupExpands = ExpandRegistry{
+	"model":    "Model",
+	"firmware": "Firmware",
+}
+
+var DeviceExpands = ExpandRegistry{
+	"model":          "Model",
+	"group":          "Group",
+	"group.firmware": "Group.Firmware",
+}
+
+// SelectRegistry maps the attributes of a resource response to the columns they are read from,
+// and its expandable relations to the foreign key they are loaded by.
+type SelectRegistry struct {
+	Attributes map[string][]string
+	Relations  map[string][]string
+}
+
+var ProfileSelects = SelectRegistry{
+	Attributes: map[string][]string{
+		"id":                     {"id"},
+		"name":                   {"name"},
+		"msg_type":               {"msg_type"},
+		"return_commands":        {"return_commands"},
+		"return_events":          {"return_events"},
+		"return_params":          {"return_params"},
+		"return_unique_key_sets": {"return_unique_key_sets"},
+		"allow_partial":          {"allow_partial"},
+		"send_resp":              {"send_resp"},
+		"first_level_only":       {"first_level_only"},
+		"max_depth":              {"max_depth"},
+		"tags":                   {"tags"},
+		"status":                 {"status"},
+		"created_at":             {"created_at"},
+		"updated_at":             {"updated_at"},
+		"updated_by":             {"updated_by"},
+		"description":            {"description"},
+		// the parameters are loaded by the profile id
+		"parameters": {},
+	},
+}
+
+var ParameterSelects = SelectRegistry{
+	Attributes: map[string][]string{
+		"id":          {"id"},
+		"path":        {"path"},
+		"data_type":   {"data_type"},
+		"description": {"description"},
+		"status":      {"status"},
+		"created_at":  {"created_at"},
+		"updated_at":  {"updated_at"},
+		"updated_by":  {"updated_by"},
+	},
+}
+
+var ModelSelects = SelectRegistry{
+	Attributes: map[string][]string{
+		"id":                   {"id"},
+		"name":                 {"name"},
+		"vendor_name":          {"vendor_name"},
+		"manufacturer":         {"manufacturer"},
+		"description":          {"description"},
+		"status":               {"status"},
+		"updated_by":           {"updated_by"},
+		"created_at":           {"created_at"},
+		"updated_at":           {"updated_at"},
+		"image":                {"image"},
+		"endpoint_id_template": {"endpoint_id_template"},
+	},
+	Relations: map[string][]string{
+		"firmwares": {"id"},
+		"groups":    {"id"},
+	},
+}
+
+var FirmwareSelects = SelectRegistry{
+	Attributes: map[string][]string{
+		"id":          {"id"},
+		"name":        {"name"},
+		"file_path":   {"file_path"},
+		"description": {"description"},
+		"status":      {"status"},
+		"updated_by":  {"updated_by"},
+		"created_at":  {"created_at"},
+		"updated_at":  {"updated_at"},
+	},
+	Relations: map[string][]string{
+		"model": {"model_id"},
+	},
+}
+
+var GroupSelects = SelectRegistry{
+	Attributes: map[string][]string{
+		"id":              {"id"},
+		"firmware":        {"firmware_id"},
+		"name":            {"name"},
+		"status":          {"status"},
+		"description":     {"description"},
+		"created_at":      {"created_at"},
+		"updated_at":      {"updated_at"},
+		"updated_by":      {"updated_by"},
+		"download_period": {"download_period"},
+	},
+	Relations: map[string][]string{
+		"firmware": {"firmware_id"},
+		"model":    {"model_id"},
+	},
+}
+
+var DeviceSelects = SelectRegistry{
+	Attributes: map[string][]string{
+		"id":                {"id"},
+		"mac_address":       {"mac_address"},
+		"endpoint_id":       {"endpoint_id"},
+		"status":            {"status"},
+		"model":             {"model_id"},
+		"group":             {"group_id"},
+		"created_at":        {"created_at"},
+		"updated_at":        {"updated_at"},
+		"updated_by":        {"updated_by"},
+		"description":       {"description"},
+		"serial_number":     {"serial_number"},
+		"product_class":     {"product_class"},
+		"hardware_revision": {"hardware_revision"},
+		"lifecycle_state":   {"lifecycle_state"},
+		"replaced_by_id":    {"replaced_by_id"},
+		"labels":            {"labels"},
+	},
+	Relations: map[string][]string{
+		"model": {"model_id"},
+		"group": {"group_id"},
+	},
+}
+
+// CheckAttributes returns an error naming the allowed attributes when one of fields is not an
+// attribute of the response, as the fields query of the lists is checked.
+func (r SelectRegistry) CheckAttributes(fields []string) error {
+	for _, field := range fields {
+		if _, ok := r.Attributes[field]; !ok {
+			allowed := make([]string, 0, len(r.Attributes))
+			for name := range r.Attributes {
+				allowed = append(allowed, name)
+			}
+			sort.Strings(allowed)
+			return fmt.Errorf("invalid field: %s, allowed fields: %s", field, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// Columns returns the columns of table to read for the asked attributes and expanded relations,
+// with id and the extra qualified columns (the keyset of a cursor page). It is nil when fields is
+// nil, every column is read then. Attributes are checked against the response before, an unknown
+// one reads nothing.
+func (r SelectRegistry) Columns(table string, fields []string, expand []string, extra ...string) []string {
+	if fields == nil {
+		return nil
+	}
+	columns := []string{table + ".id"}
+	seen := map[string]bool{columns[0]: true}
+	add := func(column string) {
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+	for _, name := range fields {
+		for _, column := range r.Attributes[name] {
+			add(table + "." + column)
+		}
+	}
+	for _, relation := range expand {
+		for _, column := range r.Relations[strings.SplitN(relation, ".", 2)[0]] {
+			add(table + "." + column)
+		}
+	}
+	for _, column := range extra {
+		add(column)
+	}
+	return columns
+}
+
+// Preloads returns the preload keys of the expanded relations, an unknown relation is an error.
+func (r ExpandRegistry) Preloads(names []string) ([]string, error) {
+	preloads := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		preload, ok := r[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("invalid expand: %s", name)
+		}
+		if !seen[preload] {
+			seen[preload] = true
+			preloads = append(preloads, preload)
+		}
+	}
+	return preloads, nil
+}
+
+// Lookup returns the field of a public name, names are case-insensitive.
+func (r FieldRegistry) Lookup(name string) (QueryField, bool) {
+	f, ok := r[strings.ToLower(strings.TrimSpace(name))]
+	return f, ok
+}
+
+// SupportsOperator reports whether op applies to the field type: the LIKE family needs text,
+// contains needs an array and an array only supports contains and the null checks.
+func (f QueryField) SupportsOperator(op string) bool {
+	switch strings.ToLower(op) {
+	case "isnull", "notnull":
+		return true
+	case "contains":
+		return f.Type == FieldTypeStringArray
+	}
+	if f.Type == FieldTypeStringArray {
+		return false
+	}
+	switch strings.ToLower(op) {
+	case "like", "nlike", "ilike", "startswith", "endswith":
+		return f.Type == FieldTypeString
+	case "lt", "gt", "lte", "gte", "between":
+		return f.Type != FieldTypeBool
+	}
+	return true
+}
+
+// KeysetExpression is the sort expression of the field in keyset pagination. A nullable text,
+// number or boolean column reaches Go as its zero value, it is compared the same way
+// so a boundary row read back from a cursor matches itself.
+func (f QueryField) KeysetExpression() string {
+	if !f.Nullable {
+		return f.Column
+	}
+	switch f.Type {
+	case FieldTypeString:
+		return "COALESCE(" + f.Column + ", '')"
+	case FieldTypeInt:
+		return "COALESCE(" + f.Column + ", 0)"
+	case FieldTypeBool:
+		return "COALESCE(" + f.Column + ", false)"
+	}
+	return f.Column
+}
+
+// filterTimeLayouts are the accepted time values, a value without zone is read in GMT+7 like the API output.
+var filterTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006",
+}
+
+// Coerce converts a filter value to the type of the field.
+func (f QueryField) Coerce(value string) (any, error) {
+	if f.Type == FieldTypeString || f.Type == FieldTypeStringArray {
+		return value, nil
+	}
+	value = strings.TrimSpace(value)
+	switch f.Type {
+	case FieldTypeUUID:
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uuid: %s", value)
+		}
+		return id.String(), nil
+	case FieldTypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer: %s", value)
+		}
+		return n, nil
+	case FieldTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean: %s", value)
+		}
+		return b, nil
+	case FieldTypeTime:
+		loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+		if err != nil {
+			loc = time.FixedZone("GMT+7", 7*60*60)
+		}
+		for _, layout := range filterTimeLayouts {
+			if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time: %s", value)
+	}
+	return value, nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+/*
+CREATE TABLE public.saved_views (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	name VARCHAR(255) NOT NULL,
+	resource VARCHAR(32) NOT NULL,
+	owner VARCHAR(255) NOT NULL,
+	shared BOOL NOT NULL DEFAULT false,
+	team VARCHAR(255) NULL,
+	filter STRING NULL,
+	order_by VARCHAR(255) NULL,
+	fields STRING[] NULL,
+	page_size INT4 NULL,
+	description VARCHAR(255) NULL,
+	status VARCHAR NOT NULL DEFAULT 'ENABLE',
+	updated_by VARCHAR(255) NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT saved_views_pkey PRIMARY KEY (id ASC),
+	UNIQUE INDEX saved_views_owner_resource_name_idx (owner ASC, resource ASC, name ASC) WHERE status != 'DELETE',
+	INDEX saved_views_resource_shared_idx (resource ASC, shared ASC) WHERE status != 'DELETE',
+	INDEX saved_views_resource_team_idx (resource ASC, team ASC) WHERE status != 'DELETE'
+);
+COMMENT ON COLUMN public.saved_views.resource IS 'list the view applies to: profiles | parameters | models | firmwares | groups | devices | webhooks';
+COMMENT ON COLUMN public.saved_views.owner IS 'User-Name of the creator, only the owner can change the view';
+COMMENT ON COLUMN public.saved_views.shared IS 'visible to every user when true';
+COMMENT ON COLUMN public.saved_views.team IS 'consumer group (X-Consumer-Groups) the view is shared with, one of the groups of the owner';
+*/
+
+const USPSavedViewTableName = "saved_views"
+const USPSavedViewEntityName = "SavedView"
+
+// MaxSavedViewPageSize is the largest page size of a view, the limit of the lists.
+const MaxSavedViewPageSize = 100
+
+// SavedViewResources are the lists a view can be saved for by their name in the API,
+// with the fields their filter and order are checked against.
+var SavedViewResources = map[string]FieldRegistry{
+	"profiles":   ProfileFields,
+	"parameters": ParameterFields,
+	"models":     ModelFields,
+	"firmwares":  FirmwareFields,
+	"groups":     GroupFields,
+	"devices":    DeviceFields,
+	"webhooks":   WebhookSubscriptionFields,
+}
+
+// SavedViewSelects are the responses the fields of a view are checked against,
+// the lists without fields selection have none.
+var SavedViewSelects = map[string]SelectRegistry{
+	"profiles":   ProfileSelects,
+	"parameters": ParameterSelects,
+	"models":     ModelSelects,
+	"firmwares":  FirmwareSelects,
+	"groups":     GroupSelects,
+	"devices":    DeviceSelects,
+}
+
+type SavedView struct {
+	Id          *uuid.UUID     `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Name        string         `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Resource    string         `gorm:"column:resource;type:varchar(32);not null" json:"resource"`
+	Owner       string         `gorm:"column:owner;type:varchar(255);not null" json:"owner"`
+	Shared      bool           `gorm:"column:shared;default:false" json:"shared"`
+	Team        string         `gorm:"column:team;type:varchar(255);default:null" json:"team,omitempty"`
+	Filter      string         `gorm:"column:filter;type:string;default:null" json:"filter,omitempty"`
+	OrderBy     string         `gorm:"column:order_by;type:varchar(255);default:null" json:"order_by,omitempty"`
+	Fields      pq.StringArray `gorm:"column:fields;type:string[];default:null" json:"fields,omitempty"`
+	PageSize    int            `gorm:"column:page_size;default:null" json:"page_size,omitempty"`
+	Description string         `gorm:"column:description;type:varchar(255);default:null" json:"description,omitempty"`
+	Status      string         `gorm:"column:status;type:varchar;default:'ENABLE'" json:"status"`
+	UpdatedBy   string         `gorm:"column:updated_by;type:varchar(255);default:null" json:"updated_by,omitempty"`
+	CreatedAt   *time.Time     `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   *time.Time     `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (SavedView) TableName() string     { return USPSavedViewTableName }
+func (SavedView) GetEntityName() string { return USPSavedViewEntityName }
+
+func (SavedView) GetIdColumnName() string       { return "id" }
+func (SavedView) GetNameColumnName() string     { return "name" }
+func (SavedView) GetResourceColumnName() string { return "resource" }
+func (SavedView) GetOwnerColumnName() string    { return "owner" }
+func (SavedView) GetSharedColumnName() string   { return "shared" }
+func (SavedView) GetTeamColumnName() string     { return "team" }
+func (SavedView) GetStatusColumnName() string   { return "status" }
+
+// VisibleTo reports whether user, a member of teams, can read and run the view.
+func (v SavedView) VisibleTo(user string, teams []string) bool {
+	return v.Shared || v.Owner == user || (v.Team != "" && slices.Contains(teams, v.Team))
+}
+
+type SavedViewUpdate struct {
+	Name        *string        `gorm:"column:name;type:varchar(255)" json:"name,omitempty"`
+	Shared      *bool          `gorm:"column:shared" json:"shared,omitempty"`
+	Team        *string        `gorm:"column:team;type:varchar(255)" json:"team,omitempty"`
+	Filter      *string        `gorm:"column:filter;type:string" json:"filter,omitempty"`
+	OrderBy     *string        `gorm:"column:order_by;type:varchar(255)" json:"order_by,omitempty"`
+	Fields      pq.StringArray `gorm:"column:fields;type:string[]" json:"fields,omitempty"`
+	PageSize    *int           `gorm:"column:page_size" json:"page_size,omitempty"`
+	Description *string        `gorm:"column:description;type:varchar(255)" json:"description,omitempty"`
+	UpdatedBy   *string        `gorm:"column:updated_by;type:varchar(255)" json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time     `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (SavedViewUpdate) TableName() string     { return USPSavedViewTableName }
+func (SavedViewUpdate) GetEntityName() string { return USPSavedViewEntityName }
+
+func NewSavedViewUpdate() *SavedViewUpdate {
+	now := time.Now()
+	return &SavedViewUpdate{
+		UpdatedAt: &now,
+	}
+}
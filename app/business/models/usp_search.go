@@ -0,0 +1,102 @@
+package models
+
+import "fmt"
+
+// Types of the hits of the global search.
+const (
+	SearchTypeDevice    = "device"
+	SearchTypeModel     = "model"
+	SearchTypeGroup     = "group"
+	SearchTypeFirmware  = "firmware"
+	SearchTypeProfile   = "profile"
+	SearchTypeParameter = "parameter"
+)
+
+// Length of the search text and number of hits of the global search.
+const (
+	MinSearchLength    = 2
+	MaxSearchLength    = 128
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// Scores of a match, the closest first.
+const (
+	SearchScoreContains = 1
+	SearchScorePrefix   = 2
+	SearchScoreExact    = 3
+)
+
+// SearchTarget is a column the global search looks into. Every column has a trigram
+// index (see the DDL of the tables) so the ILIKE match does not scan the table.
+type SearchTarget struct {
+	Type   string
+	Table  string
+	Column string
+	Title  string // column shown as the title of the hit
+	Parent string // column of the parent id in the route of the hit, "" when there is none
+	Term   string // text searched in the column, set per query
+}
+
+// SearchTargets are the searched columns, a resource with several columns is matched on the best one.
+var SearchTargets = []SearchTarget{
+	{Type: SearchTypeDevice, Table: USPDeviceTableName, Column: "mac_address", Title: "endpoint_id", Parent: "model_id"},
+	{Type: SearchTypeDevice, Table: USPDeviceTableName, Column: "endpoint_id", Title: "endpoint_id", Parent: "model_id"},
+	{Type: SearchTypeDevice, Table: USPDeviceTableName, Column: "serial_number", Title: "endpoint_id", Parent: "model_id"},
+	{Type: SearchTypeModel, Table: USPModelTableName, Column: "name", Title: "name"},
+	{Type: SearchTypeModel, Table: USPModelTableName, Column: "vendor_name", Title: "name"},
+	{Type: SearchTypeGroup, Table: USPGroupTableName, Column: "name", Title: "name", Parent: "model_id"},
+	{Type: SearchTypeFirmware, Table: USPFirmwareTableName, Column: "name", Title: "name", Parent: "model_id"},
+	{Type: SearchTypeProfile, Table: USPProfileTableName, Column: "name", Title: "name"},
+	{Type: SearchTypeParameter, Table: USPParameterTableName, Column: "path", Title: "path"},
+}
+
+// SearchTypes are the types of hit, in the order hits of the same score are listed.
+var SearchTypes = []string{
+	SearchTypeDevice,
+	SearchTypeModel,
+	SearchTypeGroup,
+	SearchTypeFirmware,
+	SearchTypeProfile,
+	SearchTypeParameter,
+}
+
+// SearchQuery is a resolved global search for the store, Limit applies to every target.
+type SearchQuery struct {
+	Targets []SearchTarget
+	Limit   int
+}
+
+// SearchHit is a row matched by the global search.
+type SearchHit struct {
+	Type     string  `gorm:"column:type" json:"type"`
+	Id       string  `gorm:"column:id" json:"id"`
+	ParentId *string `gorm:"column:parent_id" json:"-"`
+	Title    string  `gorm:"column:title" json:"title"`
+	Field    string  `gorm:"column:field" json:"field"`
+	Value    string  `gorm:"column:value" json:"value"`
+	Score    int     `gorm:"column:score" json:"score"`
+}
+
+// Link returns the canonical route of the hit.
+func (h SearchHit) Link() string {
+	parent := ""
+	if h.ParentId != nil {
+		parent = *h.ParentId
+	}
+	switch h.Type {
+	case SearchTypeDevice:
+		return fmt.Sprintf("/models/%s/devices/%s", parent, h.Id)
+	case SearchTypeModel:
+		return fmt.Sprintf("/models/%s", h.Id)
+	case SearchTypeGroup:
+		return fmt.Sprintf("/models/%s/groups/%s", parent, h.Id)
+	case SearchTypeFirmware:
+		return fmt.Sprintf("/models/%s/firmwares/%s", parent, h.Id)
+	case SearchTypeProfile:
+		return fmt.Sprintf("/profiles/%s", h.Id)
+	case SearchTypeParameter:
+		return fmt.Sprintf("/parameters/%s", h.Id)
+	}
+	return ""
+}
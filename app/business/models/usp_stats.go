@@ -0,0 +1,115 @@
+package models
+
+// MaxStatsDimensions is the number of dimensions a stats query can group by,
+// MaxStatsBuckets the number of buckets it returns, the largest counts first.
+const (
+	MaxStatsDimensions = 3
+	MaxStatsBuckets    = 1000
+)
+
+// StatsDimension is a column a resource can be counted by, Join is the join the column needs.
+type StatsDimension struct {
+	QueryField
+	Join string
+}
+
+// StatsResource describes how to count the rows of a resource: its table, the fields of its
+// filter and the dimensions it can be grouped by.
+type StatsResource struct {
+	Table      string
+	Fields     FieldRegistry
+	Dimensions map[string]StatsDimension
+}
+
+func dimension(f QueryField) StatsDimension {
+	return StatsDimension{QueryField: f}
+}
+
+// StatsResources are the resources of the stats API by their name in the URL.
+var StatsResources = map[string]StatsResource{
+	"profiles": {
+		Table:  USPProfileTableName,
+		Fields: ProfileFields,
+		Dimensions: map[string]StatsDimension{
+			"status":   dimension(ProfileFields["status"]),
+			"msg_type": dimension(ProfileFields["msg_type"]),
+		},
+	},
+	"parameters": {
+		Table:  USPParameterTableName,
+		Fields: ParameterFields,
+		Dimensions: map[string]StatsDimension{
+			"status":    dimension(ParameterFields["status"]),
+			"data_type": dimension(ParameterFields["data_type"]),
+		},
+	},
+	"models": {
+		Table:  USPModelTableName,
+		Fields: ModelFields,
+		Dimensions: map[string]StatsDimension{
+			"status":       dimension(ModelFields["status"]),
+			"manufacturer": dimension(ModelFields["manufacturer"]),
+			"vendor_name":  dimension(ModelFields["vendor_name"]),
+		},
+	},
+	"firmwares": {
+		Table:  USPFirmwareTableName,
+		Fields: FirmwareFields,
+		Dimensions: map[string]StatsDimension{
+			"status":   dimension(FirmwareFields["status"]),
+			"model_id": dimension(FirmwareFields["model_id"]),
+		},
+	},
+	"groups": {
+		Table:  USPGroupTableName,
+		Fields: GroupFields,
+		Dimensions: map[string]StatsDimension{
+			"status":      dimension(GroupFields["status"]),
+			"model_id":    dimension(GroupFields["model_id"]),
+			"firmware_id": dimension(GroupFields["firmware_id"]),
+		},
+	},
+	"devices": {
+		Table:  USPDeviceTableName,
+		Fields: DeviceFields,
+		Dimensions: map[string]StatsDimension{
+			"status":            dimension(DeviceFields["status"]),
+			"model_id":          dimension(DeviceFields["model_id"]),
+			"group_id":          dimension(DeviceFields["group_id"]),
+			"lifecycle_state":   dimension(DeviceFields["lifecycle_state"]),
+			"product_class":     dimension(DeviceFields["product_class"]),
+			"hardware_revision": dimension(DeviceFields["hardware_revision"]),
+			"software_version":  dimension(DeviceFields["software_version"]),
+			// firmware assigned to the group of the device, software_version is the one it reports
+			"firmware_id": {
+				QueryField: nullable(field(USPGroupTableName, "firmware_id", FieldTypeUUID)),
+				Join:       "LEFT JOIN " + USPGroupTableName + " ON " + USPGroupTableName + ".id = " + USPDeviceTableName + ".group_id",
+			},
+		},
+	},
+}
+
+// StatsQuery is a resolved stats query for the store.
+type StatsQuery struct {
+	Table      string
+	Joins      []string
+	Columns    []string // qualified columns of the dimensions
+	Condition  map[string]any
+	FilterExpr *FilterExpr
+	Limit      int
+}
+
+// StatsBucket is the count of the rows sharing Values, in the order of the dimensions, nil for NULL.
+type StatsBucket struct {
+	Values []any
+	Count  int64
+}
+
+// StatsResult holds the buckets of a stats query, Truncated is set when there were more
+// than MaxStatsBuckets buckets. Total counts every matching row, the cut buckets included.
+type StatsResult struct {
+	Dimensions []string
+	Buckets    []StatsBucket
+	Total      int64
+	Truncated  bool
+}
@@ -0,0 +1,223 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+/*
+CREATE TABLE public.webhook_subscriptions (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	name VARCHAR(255) NOT NULL,
+	url VARCHAR(2048) NOT NULL,
+	secret VARCHAR(255) NOT NULL,
+	event_types STRING[] NOT NULL,
+	status VARCHAR NOT NULL DEFAULT 'ENABLE',
+	description VARCHAR(255) NULL,
+	updated_by VARCHAR(255) NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT webhook_subscriptions_pkey PRIMARY KEY (id ASC),
+	UNIQUE INDEX webhook_subscriptions_name_idx (name ASC),
+	INVERTED INDEX webhook_subscriptions_event_types_idx (event_types)
+);
+COMMENT ON COLUMN public.webhook_subscriptions.secret IS 'HMAC-SHA256 key, never returned by the API';
+COMMENT ON COLUMN public.webhook_subscriptions.event_types IS 'event types to deliver, example: `{firmware.created,device.deleted}`, `*` matches every event';
+
+CREATE TABLE public.webhook_deliveries (
+	id UUID NOT NULL DEFAULT gen_random_uuid(),
+	subscription_id UUID NOT NULL,
+	event_id UUID NOT NULL,
+	event_type VARCHAR(64) NOT NULL,
+	payload JSONB NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'PENDING',
+	attempts INT4 NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	response_code INT4 NULL,
+	last_error STRING NULL,
+	delivered_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now():::TIMESTAMPTZ,
+	CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id ASC),
+	CONSTRAINT webhook_deliveries_subscription_id_fkey FOREIGN KEY (subscription_id) REFERENCES public.webhook_subscriptions(id),
+	INDEX webhook_deliveries_subscription_id_idx (subscription_id ASC, created_at DESC) STORING (event_id, event_type, status, attempts, response_code),
+	INDEX webhook_deliveries_due_idx (status ASC, next_attempt_at ASC)
+);
+COMMENT ON COLUMN public.webhook_deliveries.status IS 'PENDING | SUCCESS | FAILED';
+*/
+
+const USPWebhookSubscriptionTableName = "webhook_subscriptions"
+const USPWebhookSubscriptionEntityName = "WebhookSubscription"
+const USPWebhookDeliveryTableName = "webhook_deliveries"
+const USPWebhookDeliveryEntityName = "WebhookDelivery"
+
+const (
+	WebhookEventTypeAll = "*"
+
+	WebhookDeliveryStatusPending = "PENDING"
+	WebhookDeliveryStatusSuccess = "SUCCESS"
+	WebhookDeliveryStatusFailed  = "FAILED"
+
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// WebhookEventTypes lists the event types a subscription can filter on.
+var WebhookEventTypes = []string{
+	DomainEventType(AggregateProfile, EventActionCreated),
+	DomainEventType(AggregateProfile, EventActionUpdated),
+	DomainEventType(AggregateProfile, EventActionDeleted),
+	DomainEventType(AggregateGroup, EventActionCreated),
+	DomainEventType(AggregateGroup, EventActionUpdated),
+	DomainEventType(AggregateGroup, EventActionDeleted),
+	DomainEventType(AggregateGroup, EventActionFirmwareChanged),
+	DomainEventType(AggregateFirmware, EventActionCreated),
+	DomainEventType(AggregateFirmware, EventActionUpdated),
+	DomainEventType(AggregateFirmware, EventActionDeleted),
+	DomainEventType(AggregateDevice, EventActionCreated),
+	DomainEventType(AggregateDevice, EventActionUpdated),
+	DomainEventType(AggregateDevice, EventActionDeleted),
+	DomainEventType(AggregateDevice, EventActionLifecycleChanged),
+	DomainEventType(AggregateDevice, EventActionReplaced),
+	DomainEventType(AggregateDevice, EventActionModelChanged),
+}
+
+type WebhookSubscription struct {
+	Id          *uuid.UUID     `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Name        string         `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Url         string         `gorm:"column:url;type:varchar(2048);not null" json:"url"`
+	Secret      string         `gorm:"column:secret;type:varchar(255);not null" json:"-"`
+	EventTypes  pq.StringArray `gorm:"column:event_types;type:string[];not null" json:"event_types"`
+	Status      string         `gorm:"column:status;type:varchar;default:'ENABLE'" json:"status"`
+	Description string         `gorm:"column:description;type:varchar(255);default:null" json:"description,omitempty"`
+	UpdatedBy   string         `gorm:"column:updated_by;type:varchar(255);default:null" json:"updated_by,omitempty"`
+	CreatedAt   *time.Time     `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   *time.Time     `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (WebhookSubscription) TableName() string     { return USPWebhookSubscriptionTableName }
+func (WebhookSubscription) GetEntityName() string { return USPWebhookSubscriptionEntityName }
+
+func (WebhookSubscription) GetIdColumnName() string         { return "id" }
+func (WebhookSubscription) GetNameColumnName() string       { return "name" }
+func (WebhookSubscription) GetEventTypesColumnName() string { return "event_types" }
+func (WebhookSubscription) GetStatusColumnName() string     { return "status" }
+func (WebhookSubscription) GetUpdatedAtColumnName() string  { return "updated_at" }
+
+// Matches reports whether the subscription wants events of eventType.
+func (w WebhookSubscription) Matches(eventType string) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType || t == WebhookEventTypeAll {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookSubscriptionUpdate struct {
+	Name        *string        `gorm:"column:name;type:varchar(255)" json:"name,omitempty"`
+	Url         *string        `gorm:"column:url;type:varchar(2048)" json:"url,omitempty"`
+	Secret      *string        `gorm:"column:secret;type:varchar(255)" json:"-"`
+	EventTypes  pq.StringArray `gorm:"column:event_types;type:string[]" json:"event_types,omitempty"`
+	Status      *string        `gorm:"column:status;type:varchar" json:"status,omitempty"`
+	Description *string        `gorm:"column:description;type:varchar(255)" json:"description,omitempty"`
+	UpdatedBy   *string        `gorm:"column:updated_by;type:varchar(255)" json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time     `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (WebhookSubscriptionUpdate) TableName() string     { return USPWebhookSubscriptionTableName }
+func (WebhookSubscriptionUpdate) GetEntityName() string { return USPWebhookSubscriptionEntityName }
+
+func NewWebhookSubscriptionUpdate() *WebhookSubscriptionUpdate {
+	now := time.Now()
+	return &WebhookSubscriptionUpdate{
+		UpdatedAt: &now,
+	}
+}
+
+type WebhookDelivery struct {
+	Id             *uuid.UUID `gorm:"column:id;type:uuid;default:uuid_generate_v4()" json:"id"`
+	SubscriptionId *uuid.UUID `gorm:"column:subscription_id;type:uuid;not null" json:"subscription_id"`
+	EventId        *uuid.UUID `gorm:"column:event_id;type:uuid;not null" json:"event_id"`
+	EventType      string     `gorm:"column:event_type;type:varchar(64);not null" json:"event_type"`
+	Payload        []byte     `gorm:"column:payload;type:jsonb;not null" json:"-"`
+	Status         string     `gorm:"column:status;type:varchar(16);default:'PENDING'" json:"status"`
+	Attempts       int        `gorm:"column:attempts;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"column:next_attempt_at" json:"next_attempt_at,omitempty"`
+	ResponseCode   int        `gorm:"column:response_code;default:null" json:"response_code,omitempty"`
+	LastError      string     `gorm:"column:last_error;type:string;default:null" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at;default:null" json:"delivered_at,omitempty"`
+	CreatedAt      *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      *time.Time `gorm:"column:updated_at" json:"updated_at"`
+
+	Subscription *WebhookSubscription `gorm:"foreignKey:SubscriptionId;references:Id;" json:"-"`
+}
+
+func (WebhookDelivery) TableName() string     { return USPWebhookDeliveryTableName }
+func (WebhookDelivery) GetEntityName() string { return USPWebhookDeliveryEntityName }
+
+func (WebhookDelivery) GetIdColumnName() string             { return "id" }
+func (WebhookDelivery) GetSubscriptionIdColumnName() string { return "subscription_id" }
+func (WebhookDelivery) GetStatusColumnName() string         { return "status" }
+func (WebhookDelivery) GetNextAttemptAtColumnName() string  { return "next_attempt_at" }
+
+type WebhookDeliveryUpdate struct {
+	Status        *string    `gorm:"column:status;type:varchar(16)"`
+	Attempts      *int       `gorm:"column:attempts"`
+	NextAttemptAt *time.Time `gorm:"column:next_attempt_at"`
+	ResponseCode  *int       `gorm:"column:response_code"`
+	LastError     *string    `gorm:"column:last_error;type:string"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at"`
+	UpdatedAt     *time.Time `gorm:"column:updated_at"`
+}
+
+func (WebhookDeliveryUpdate) TableName() string     { return USPWebhookDeliveryTableName }
+func (WebhookDeliveryUpdate) GetEntityName() string { return USPWebhookDeliveryEntityName }
+
+// webhookLeaseMargin is added to the longest time a batch of deliveries can take to send
+const webhookLeaseMargin = 30 * time.Second
+
+// WebhookRetryPolicy controls how deliveries are sent and the exponential backoff of failed ones.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Timeout is the timeout of one request, Concurrency the requests of a batch sent at once
+	Timeout     time.Duration
+	Concurrency int
+}
+
+// Lease returns how long a batch of batchSize claimed deliveries is hidden from other
+// dispatchers, longer than sending the whole batch when every request times out.
+func (p WebhookRetryPolicy) Lease(batchSize int) time.Duration {
+	concurrency := max(p.Concurrency, 1)
+	rounds := (batchSize + concurrency - 1) / concurrency
+	return time.Duration(rounds)*p.Timeout + webhookLeaseMargin
+}
+
+// NextDelay returns the wait before the next attempt after `attempts` failed attempts.
+func (p WebhookRetryPolicy) NextDelay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value, `sha256=` followed by
+// the hex HMAC-SHA256 of `<timestamp>.<body>` keyed with the subscription secret.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -14,6 +14,7 @@ const (
 	ErrUnauthorized        = "ErrUnauthorized"
 	ErrDeviceNotOnline     = "ErrDeviceNotOnline"
 	ErrEntityAlreadyExists = "ErrEntityAlreadyExists"
+	ErrInvalidEventPayload = "ErrInvalidEventPayload"
 )
 
 type AppError struct {
@@ -121,7 +122,7 @@ func NewErrInvalidEventPayload(msg string) *AppError {
 		http.StatusBadRequest,
 		fmt.Errorf("invalid event payload"),
 		fmt.Sprintf("invalid event payload: %s", msg),
-		ErrInvalidRequest,
+		ErrInvalidEventPayload,
 		fmt.Sprintf("invalid event payload: %s", msg),
 	)
 }
@@ -0,0 +1,32 @@
+package exportwriter
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) WriteHeader(columns []string) error {
+	w.record = make([]string, len(columns))
+	return w.writer.Write(columns)
+}
+
+func (w *csvWriter) WriteRow(values []any) error {
+	for i, value := range values {
+		w.record[i] = formatText(value)
+	}
+	return w.writer.Write(w.record[:len(values)])
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,86 @@
+package exportwriter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats of an export file.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// Formats lists the export formats, the first one is the default.
+var Formats = []string{FormatCSV, FormatNDJSON, FormatXLSX}
+
+// Writer writes the rows of an export one at a time, nothing is kept in memory but the
+// current row. The values of a row are strings, integers, booleans, string slices or nil.
+type Writer interface {
+	// WriteHeader writes the column names, it is called once before the rows.
+	WriteHeader(columns []string) error
+	WriteRow(values []any) error
+	// Close flushes the rows, the XLSX archive is only complete once closed.
+	Close() error
+}
+
+// New returns the writer of format writing to w, sheet names the sheet of an XLSX file.
+func New(format string, w io.Writer, sheet string) (Writer, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	}
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+// IsFormat reports whether format is an export format.
+func IsFormat(format string) bool {
+	for _, f := range Formats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType returns the media type of a format.
+func ContentType(format string) string {
+	switch strings.ToLower(format) {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ListSeparator joins the items of a list value in the text formats, NDJSON keeps the array.
+const ListSeparator = ";"
+
+// formatText renders a value as the text of a CSV or XLSX cell
+func formatText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ListSeparator)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,51 @@
+package exportwriter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// ndjsonWriter writes one JSON object per row, its keys in the order of the columns
+type ndjsonWriter struct {
+	writer  *bufio.Writer
+	columns [][]byte
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{writer: bufio.NewWriter(w)}
+}
+
+func (w *ndjsonWriter) WriteHeader(columns []string) error {
+	w.columns = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		w.columns[i] = key
+	}
+	return nil
+}
+
+func (w *ndjsonWriter) WriteRow(values []any) error {
+	w.writer.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			w.writer.WriteByte(',')
+		}
+		w.writer.Write(w.columns[i])
+		w.writer.WriteByte(':')
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.writer.Write(encoded)
+	}
+	w.writer.WriteByte('}')
+	return w.writer.WriteByte('\n')
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.writer.Flush()
+}
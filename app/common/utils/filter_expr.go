@@ -0,0 +1,392 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"usp-management-device-api/business/models"
+)
+
+// maxFilterDepth bounds the nesting of groups in a filter expression.
+const maxFilterDepth = 10
+
+var filterFieldPattern = regexp.MustCompile(`^[a-zA-Z0-9_\.]+$`)
+
+// ParseFilterExpr parses a filter into an expression tree. The filter is either the string syntax,
+// `field op value` conditions joined by `and`/`or` with parentheses, where `and` binds tighter than `or`,
+// `field in (a, b)`, `field between a and b` and `field is [not] null` included,
+// or the same tree as JSON: {"join":"OR","children":[{"filter":"name","op":"eq","value":"x"}, ...]}.
+func ParseFilterExpr(raw string) (*models.FilterExpr, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(raw, "{") {
+		var expr models.FilterExpr
+		if err := json.Unmarshal([]byte(raw), &expr); err != nil {
+			return nil, fmt.Errorf("invalid filter json: %v", err)
+		}
+		if err := normalizeFilterTree(&expr, 1); err != nil {
+			return nil, err
+		}
+		return &expr, nil
+	}
+
+	tokens, err := tokenizeFilter(raw)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	expr, err := p.parseOr(1)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q in filter", p.peek().text)
+	}
+	return expr, nil
+}
+
+// normalizeFilterTree checks the shape of a JSON filter tree and upper-cases joins, lower-cases operators.
+func normalizeFilterTree(expr *models.FilterExpr, depth int) error {
+	if depth > maxFilterDepth {
+		return fmt.Errorf("filter is nested deeper than %d levels", maxFilterDepth)
+	}
+	if !expr.IsGroup() {
+		if expr.Filter == "" || expr.Op == "" {
+			return fmt.Errorf("filter condition needs filter and op")
+		}
+		expr.Op = strings.ToLower(expr.Op)
+		switch expr.Op {
+		case "in", "nin":
+			if len(expr.Values) == 0 || len(expr.Values) > models.MaxFilterValues {
+				return fmt.Errorf("%s needs 1 to %d values", expr.Op, models.MaxFilterValues)
+			}
+		case "between":
+			if len(expr.Values) != 2 {
+				return fmt.Errorf("between needs 2 values")
+			}
+		}
+		return nil
+	}
+
+	expr.Join = strings.ToUpper(expr.Join)
+	if expr.Join != "AND" && expr.Join != "OR" {
+		return fmt.Errorf("invalid filter join: %q", expr.Join)
+	}
+	if len(expr.Children) == 0 {
+		return fmt.Errorf("filter group has no children")
+	}
+	if expr.Filter != "" || expr.Op != "" || expr.Value != "" {
+		return fmt.Errorf("filter group cannot hold a condition")
+	}
+	for _, child := range expr.Children {
+		if child == nil {
+			return fmt.Errorf("filter group has an empty child")
+		}
+		if err := normalizeFilterTree(child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenWord filterTokenKind = iota
+	filterTokenQuoted
+	filterTokenOpen
+	filterTokenClose
+	filterTokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+// tokenizeFilter splits a filter into words, quoted values and parentheses.
+// A quote inside a quoted value is doubled, as in SQL string literals.
+func tokenizeFilter(raw string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(raw); {
+		ch := raw[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(':
+			tokens = append(tokens, filterToken{kind: filterTokenOpen, text: "("})
+			i++
+		case ch == ')':
+			tokens = append(tokens, filterToken{kind: filterTokenClose, text: ")"})
+			i++
+		case ch == ',':
+			tokens = append(tokens, filterToken{kind: filterTokenComma, text: ","})
+			i++
+		case ch == '\'' || ch == '"':
+			var b strings.Builder
+			closed := false
+			j := i + 1
+			for j < len(raw) {
+				if raw[j] == ch {
+					if j+1 < len(raw) && raw[j+1] == ch {
+						b.WriteByte(ch)
+						j += 2
+						continue
+					}
+					closed = true
+					j++
+					break
+				}
+				b.WriteByte(raw[j])
+				j++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quote in filter")
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenQuoted, text: b.String()})
+			i = j
+		default:
+			j := i
+			for j < len(raw) && !strings.ContainsRune(" \t\n\r(),'\"", rune(raw[j])) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenWord, text: raw[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser over the filter tokens:
+//
+//	or        = and { "or" and }
+//	and       = primary { "and" primary }
+//	primary   = "(" or ")" | condition
+//	condition = field op value | field ("in" | "nin") "(" value { "," value } ")"
+//	          | field "between" value "and" value | field "is" [ "not" ] "null"
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+// isKeyword reports whether the next token is the unquoted word keyword.
+func (p *filterParser) isKeyword(keyword string) bool {
+	return !p.done() && p.peek().kind == filterTokenWord && strings.EqualFold(p.peek().text, keyword)
+}
+
+func (p *filterParser) parseOr(depth int) (*models.FilterExpr, error) {
+	return p.parseJoin(depth, "or", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd(depth int) (*models.FilterExpr, error) {
+	return p.parseJoin(depth, "and", p.parsePrimary)
+}
+
+// parseJoin reads operands separated by keyword, a single operand is returned as is.
+func (p *filterParser) parseJoin(
+	depth int,
+	keyword string,
+	operand func(depth int) (*models.FilterExpr, error),
+) (*models.FilterExpr, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword(keyword) {
+		return first, nil
+	}
+
+	group := &models.FilterExpr{Join: strings.ToUpper(keyword), Children: []*models.FilterExpr{first}}
+	for p.isKeyword(keyword) {
+		p.pos++
+		next, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		group.Children = append(group.Children, next)
+	}
+	return group, nil
+}
+
+func (p *filterParser) parsePrimary(depth int) (*models.FilterExpr, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+	if p.peek().kind == filterTokenOpen {
+		if depth >= maxFilterDepth {
+			return nil, fmt.Errorf("filter is nested deeper than %d levels", maxFilterDepth)
+		}
+		p.pos++
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != filterTokenClose {
+			return nil, fmt.Errorf("missing closing parenthesis in filter")
+		}
+		p.pos++
+		return expr, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *filterParser) parseCondition() (*models.FilterExpr, error) {
+	field := p.peek()
+	if field.kind != filterTokenWord || !filterFieldPattern.MatchString(field.text) {
+		return nil, fmt.Errorf("invalid filter field: %q", field.text)
+	}
+	p.pos++
+	expr := &models.FilterExpr{Filter: field.text}
+
+	// field is null, field is not null
+	if p.isKeyword("is") {
+		p.pos++
+		expr.Op = "isnull"
+		if p.isKeyword("not") {
+			p.pos++
+			expr.Op = "notnull"
+		}
+		if !p.isKeyword("null") {
+			return nil, fmt.Errorf("expected null after is in %q", field.text)
+		}
+		p.pos++
+		return expr, nil
+	}
+
+	if p.done() || p.peek().kind != filterTokenWord || !ContainString(models.FilterOperators, strings.ToLower(p.peek().text)) {
+		return nil, fmt.Errorf("missing or invalid operator after %q", field.text)
+	}
+	expr.Op = strings.ToLower(p.peek().text)
+	p.pos++
+
+	switch expr.Op {
+	case "isnull", "notnull":
+		return expr, nil
+	case "in", "nin":
+		values, err := p.parseValueList()
+		if err != nil {
+			return nil, err
+		}
+		expr.Values = values
+	case "between":
+		low, err := p.parseSingleValue()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("and") {
+			return nil, fmt.Errorf("expected and in between of %q", field.text)
+		}
+		p.pos++
+		high, err := p.parseSingleValue()
+		if err != nil {
+			return nil, err
+		}
+		expr.Values = []string{low, high}
+	default:
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		expr.Value = value
+	}
+	return expr, nil
+}
+
+// parseValueList reads "(" value { "," value } ")".
+func (p *filterParser) parseValueList() ([]string, error) {
+	if p.done() || p.peek().kind != filterTokenOpen {
+		return nil, fmt.Errorf("expected ( before the list of values")
+	}
+	p.pos++
+
+	var values []string
+	for {
+		if p.done() {
+			return nil, fmt.Errorf("missing closing parenthesis in list of values")
+		}
+		if p.peek().kind == filterTokenQuoted {
+			values = append(values, p.peek().text)
+			p.pos++
+		} else {
+			var words []string
+			for !p.done() && p.peek().kind == filterTokenWord {
+				words = append(words, p.peek().text)
+				p.pos++
+			}
+			if len(words) == 0 {
+				return nil, fmt.Errorf("missing value in list of values")
+			}
+			values = append(values, strings.Join(words, " "))
+		}
+		if len(values) > models.MaxFilterValues {
+			return nil, fmt.Errorf("list of values is longer than %d", models.MaxFilterValues)
+		}
+
+		if p.done() {
+			return nil, fmt.Errorf("missing closing parenthesis in list of values")
+		}
+		switch p.peek().kind {
+		case filterTokenComma:
+			p.pos++
+		case filterTokenClose:
+			p.pos++
+			return values, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q in list of values", p.peek().text)
+		}
+	}
+}
+
+// parseSingleValue reads one quoted value or one word, the bounds of between.
+func (p *filterParser) parseSingleValue() (string, error) {
+	if p.done() || (p.peek().kind != filterTokenQuoted && p.peek().kind != filterTokenWord) {
+		return "", fmt.Errorf("missing filter value")
+	}
+	value := p.peek().text
+	p.pos++
+	return value, nil
+}
+
+// parseValue reads a quoted value, or the unquoted words up to the next and/or or closing parenthesis.
+func (p *filterParser) parseValue() (string, error) {
+	if p.done() {
+		return "", fmt.Errorf("missing filter value")
+	}
+	if p.peek().kind == filterTokenQuoted {
+		value := p.peek().text
+		p.pos++
+		return value, nil
+	}
+
+	var words []string
+	for !p.done() && !p.isKeyword("and") && !p.isKeyword("or") {
+		token := p.peek()
+		if token.kind == filterTokenComma && len(words) > 0 {
+			// "a, b" stays one value outside of a list
+			words[len(words)-1] += ","
+			p.pos++
+			continue
+		}
+		if token.kind != filterTokenWord {
+			break
+		}
+		words = append(words, token.text)
+		p.pos++
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("missing filter value")
+	}
+	return strings.Join(words, " "), nil
+}
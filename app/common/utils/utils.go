@@ -13,7 +13,6 @@ import (
 	"strings"
 	"time"
 	"usp-management-device-api/business/models"
-	apperrors "usp-management-device-api/common/app_errors"
 	"usp-management-device-api/common/logging"
 )
 
@@ -114,192 +113,6 @@ func RandomString(n int) string {
 	return s
 }
 
-func stripOuterParens(s string) string {
-	s = strings.TrimSpace(s)
-	if len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' {
-		depth := 0
-		for i := 0; i < len(s); i++ {
-			switch s[i] {
-			case '\'':
-			case '(':
-				depth++
-			case ')':
-				depth--
-				if depth == 0 && i != len(s)-1 {
-					return s
-				}
-			}
-		}
-		return s[1 : len(s)-1]
-	}
-	return s
-}
-
-func splitTopLevel(expr string) (segments []string, joins []string) {
-	expr = strings.TrimSpace(expr)
-	if expr == "" {
-		return nil, nil
-	}
-
-	var b strings.Builder
-	depth := 0
-	inQuote := false
-
-	lower := strings.ToLower(expr)
-	i := 0
-	for i < len(expr) {
-		ch := expr[i]
-
-		if ch == '\'' {
-			inQuote = !inQuote
-			b.WriteByte(ch)
-			i++
-			continue
-		}
-		if !inQuote {
-			if ch == '(' {
-				depth++
-				b.WriteByte(ch)
-				i++
-				continue
-			}
-			if ch == ')' {
-				if depth > 0 {
-					depth--
-				}
-				b.WriteByte(ch)
-				i++
-				continue
-			}
-			if depth == 0 {
-				if strings.HasPrefix(lower[i:], " and ") {
-					segments = append(segments, strings.TrimSpace(b.String()))
-					joins = append(joins, "AND")
-					b.Reset()
-					i += len(" and ")
-					continue
-				}
-				if strings.HasPrefix(lower[i:], " or ") {
-					segments = append(segments, strings.TrimSpace(b.String()))
-					joins = append(joins, "OR")
-					b.Reset()
-					i += len(" or ")
-					continue
-				}
-			}
-		}
-
-		b.WriteByte(ch)
-		i++
-	}
-	if b.Len() > 0 {
-		segments = append(segments, strings.TrimSpace(b.String()))
-	}
-	return
-}
-
-func splitInnerByAndOr(expr string) (parts []string, joins []string) {
-	return splitTopLevel(expr)
-}
-
-func cleanValue(v string) string {
-	v = strings.TrimSpace(v)
-	v = stripOuterParens(v)
-	if len(v) >= 2 && ((v[0] == '\'' && v[len(v)-1] == '\'') || (v[0] == '"' && v[len(v)-1] == '"')) {
-		v = v[1 : len(v)-1]
-	}
-	return strings.TrimSpace(v)
-}
-
-func parseCondition(token string) (field, op, value string, err error) {
-	re := regexp.MustCompile(`(?i)^\s*([a-zA-Z0-9_\.]+)\s+(eq|ne|lt|gt|lte|gte|like)\s+(.+?)\s*$`)
-	m := re.FindStringSubmatch(token)
-	if len(m) != 4 {
-		return "", "", "", fmt.Errorf("invalid condition: %s", token)
-	}
-	field = strings.TrimSpace(m[1])
-	op = strings.ToLower(strings.TrimSpace(m[2]))
-	value = cleanValue(m[3])
-	return
-}
-
-func ParseFilterExpr(raw string) ([]models.FilterExpr, error) {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return nil, nil
-	}
-
-	raw = stripOuterParens(raw)
-
-	segments, topJoins := splitTopLevel(raw)
-	if len(segments) == 0 {
-		return nil, apperrors.NewDBError(nil, "invalid filter expression")
-	}
-
-	var out []models.FilterExpr
-
-	for si, seg := range segments {
-		seg = stripOuterParens(seg) // "(a or b)" -> "a or b"
-
-		innerParts, innerJoins := splitInnerByAndOr(seg)
-		if len(innerParts) == 0 {
-			return nil, apperrors.NewDBError(nil, "invalid segment: "+seg)
-		}
-
-		for pi, part := range innerParts {
-			field, op, val, err := parseCondition(part)
-			if err != nil {
-				return nil, apperrors.NewDBError(err, "invalid filter condition")
-			}
-
-			join := "AND" //default is AND
-			if si == 0 && pi == 0 {
-				join = "AND"
-			} else if pi > 0 {
-				join = strings.ToUpper(innerJoins[pi-1])
-			} else {
-				join = strings.ToUpper(topJoins[si-1])
-			}
-
-			out = append(out, models.FilterExpr{
-				Filter: field,
-				Op:     op,
-				Value:  val,
-				Join:   join,
-			})
-			logging.Infof("Parsed filter: %+v", out[len(out)-1])
-		}
-	}
-
-	logging.Infof("Final parsed filters: %+v", out)
-	return out, nil
-}
-
-func sqlOp(op string) string {
-	switch strings.ToLower(op) {
-	case "eq":
-		return "="
-	case "ne":
-		return "!="
-	case "lt":
-		return "<"
-	case "gt":
-		return ">"
-	case "lte":
-		return "<="
-	case "gte":
-		return ">="
-	case "like":
-		return "LIKE"
-	default:
-		return "="
-	}
-}
-
-func BuildCond(f models.FilterExpr) (string, []any) {
-	return fmt.Sprintf("%s %s ?", f.Filter, sqlOp(f.Op)), []any{f.Value}
-}
-
 func ParseOrderExpr(raw string) ([]models.OrderExpr, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -64,18 +64,75 @@ func Unique(values []string) bool {
 	return len(values) == len(uniqueValues)
 }
 
-func ValidateEndpointID(endpointID, mac string) error {
-	mac = strings.ToUpper(strings.ReplaceAll(mac, ":", ""))
-	if len(mac) != 12 {
-		return fmt.Errorf("invalid MAC length: %s", mac)
+// endpointIdSchemes are the TR-369 authority schemes, the value tells whether the authority-id must be empty.
+var endpointIdSchemes = map[string]bool{
+	"oui":   false,
+	"cid":   false,
+	"pen":   false,
+	"self":  false,
+	"user":  false,
+	"os":    true,
+	"ops":   true,
+	"uuid":  true,
+	"imei":  true,
+	"proto": false,
+	"doc":   false,
+	"fqdn":  false,
+}
+
+var (
+	endpointInstanceIdRegEx = regexp.MustCompile(`^[A-Za-z0-9\-._~%:]+$`)
+	ouiRegEx                = regexp.MustCompile(`^[0-9A-F]{6}$`)
+	penRegEx                = regexp.MustCompile(`^[0-9]+$`)
+	uuidRegEx               = regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}$`)
+	imeiRegEx               = regexp.MustCompile(`^[0-9]{15}$`)
+)
+
+// ValidateEndpointID checks an endpoint id against the TR-369 rules:
+// `authority-scheme ":" [authority-id] ":" instance-id`, instance-id only uses
+// RFC 3986 unreserved characters, `%` and `:`.
+func ValidateEndpointID(endpointID string) error {
+	if endpointID == "" {
+		return fmt.Errorf("endpoint_id is empty")
+	}
+	if len(endpointID) > 255 {
+		return fmt.Errorf("endpoint_id longer than 255 characters: %s", endpointID)
 	}
 
-	prefix := mac[:6]
-	expected := fmt.Sprintf("os::%s-%s", prefix, mac)
+	parts := strings.SplitN(endpointID, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid endpoint_id %s, expected authority-scheme:[authority-id]:instance-id", endpointID)
+	}
+	scheme, authority, instance := parts[0], parts[1], parts[2]
 
-	if endpointID != expected {
-		return fmt.Errorf("invalid endpoint_id: got %s, expected %s", endpointID, expected)
+	emptyAuthority, ok := endpointIdSchemes[scheme]
+	if !ok {
+		return fmt.Errorf("invalid endpoint_id %s, unknown authority scheme %s", endpointID, scheme)
+	}
+	if emptyAuthority && authority != "" {
+		return fmt.Errorf("invalid endpoint_id %s, scheme %s does not take an authority-id", endpointID, scheme)
+	}
+	switch scheme {
+	case "oui", "cid":
+		if !ouiRegEx.MatchString(authority) {
+			return fmt.Errorf("invalid endpoint_id %s, authority-id of %s must be 6 uppercase hex digits", endpointID, scheme)
+		}
+	case "pen":
+		if !penRegEx.MatchString(authority) {
+			return fmt.Errorf("invalid endpoint_id %s, authority-id of pen must be a number", endpointID)
+		}
+	case "uuid":
+		if !uuidRegEx.MatchString(instance) {
+			return fmt.Errorf("invalid endpoint_id %s, instance-id of uuid must be a UUID", endpointID)
+		}
+	case "imei":
+		if !imeiRegEx.MatchString(instance) {
+			return fmt.Errorf("invalid endpoint_id %s, instance-id of imei must be 15 digits", endpointID)
+		}
 	}
 
+	if !endpointInstanceIdRegEx.MatchString(instance) {
+		return fmt.Errorf("invalid endpoint_id %s, instance-id contains characters outside A-Z a-z 0-9 - . _ ~ %% :", endpointID)
+	}
 	return nil
 }
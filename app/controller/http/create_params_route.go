@@ -13,6 +13,7 @@ import (
 	apperrors "usp-management-device-api/common/app_errors"
 	httphelper "usp-management-device-api/common/http_helper"
 	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -29,6 +30,16 @@ type createParametersResponse struct {
 	ParameterId []string `json:"parameter_id"`
 }
 
+type importParametersResponse struct {
+	ParameterId []string `json:"parameter_id"`
+	*models.ImportReport
+}
+
+type importProfilesResponse struct {
+	ProfileIds []string `json:"profile_ids"`
+	*models.ImportReport
+}
+
 type createProfileRequest struct {
 	Name                string                 `json:"name" validate:"required,max=255"`
 	MessageType         int                    `json:"msg_type" validate:"required,lte=23"`
@@ -59,6 +70,8 @@ type createModelRequest struct {
 	Manufacturer string `form:"manufacturer" validate:"required,max=255"`
 	Description  string `form:"description" validate:"omitempty,max=255"`
 	Image        string `form:"image"`
+
+	EndpointIdTemplate string `form:"endpoint_id_template" validate:"omitempty,max=255"`
 }
 
 type createModelResponse struct {
@@ -87,16 +100,41 @@ type createGroupResponse struct {
 }
 
 type createDeviceRequest struct {
-	MacAddress  string  `json:"mac_address" validate:"required"`
-	EndpointId  *string `json:"endpoint_id" validate:"omitempty"`
-	Status      string  `json:"status" validate:"omitempty,oneof=ENABLE DISABLE"`
-	Description string  `json:"description" validate:"omitempty,max=255"`
+	MacAddress  string   `json:"mac_address" validate:"required"`
+	EndpointId  *string  `json:"endpoint_id" validate:"omitempty"`
+	Status      string   `json:"status" validate:"omitempty,oneof=ENABLE DISABLE"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Labels      []string `json:"labels" validate:"omitempty,max=20,dive,required,max=64"`
+
+	SerialNumber     string `json:"serial_number" validate:"omitempty,max=64"`
+	ProductClass     string `json:"product_class" validate:"omitempty,max=64"`
+	HardwareRevision string `json:"hardware_revision" validate:"omitempty,max=64"`
 }
 
 type createDeviceResponse struct {
 	DeviceIDs []string `json:"device_ids"`
 }
 
+type importDevicesResponse struct {
+	DeviceIDs []string `json:"device_ids"`
+	*models.ImportReport
+}
+
+type createWebhookRequest struct {
+	Name        string   `json:"name" validate:"required,max=255"`
+	Url         string   `json:"url" validate:"required,url,max=2048"`
+	Secret      string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	EventTypes  []string `json:"event_types" validate:"required,min=1,dive,required"`
+	Status      string   `json:"status" validate:"omitempty,oneof=ENABLE DISABLE"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+}
+
+// createWebhookResponse the secret is only returned once, on create
+type createWebhookResponse struct {
+	WebhookID string `json:"webhook_id"`
+	Secret    string `json:"secret"`
+}
+
 func (h *httpController) createProfileWithParameterId() func(c *gin.Context) {
 	return func(c *gin.Context) {
 		var req createProfileRequest
@@ -307,6 +345,8 @@ func (h *httpController) createModels() func(c *gin.Context) {
 			VendorName:   c.PostForm("vendor_name"),
 			Manufacturer: c.PostForm("manufacturer"),
 			Description:  c.PostForm("description"),
+
+			EndpointIdTemplate: strings.TrimSpace(c.PostForm("endpoint_id_template")),
 		}
 		// Validate entity createModelRequest
 		if err := Validate.Struct(req); err != nil {
@@ -374,6 +414,8 @@ func (h *httpController) createModels() func(c *gin.Context) {
 			Description:  req.Description,
 			UpdatedBy:    updatedBy,
 			Image:        req.Image,
+
+			EndpointIdTemplate: req.EndpointIdTemplate,
 		}
 
 		modelId, err := h.usecase.CreateModels(
@@ -826,6 +868,11 @@ func (h *httpController) createDevice() func(c *gin.Context) {
 			Status:      "ENABLE",
 			UpdatedBy:   updatedBy,
 			Description: req.Description,
+			Labels:      req.Labels,
+
+			SerialNumber:     strings.TrimSpace(req.SerialNumber),
+			ProductClass:     strings.TrimSpace(req.ProductClass),
+			HardwareRevision: strings.TrimSpace(req.HardwareRevision),
 		}
 
 		// Handle EndpointId logic
@@ -842,11 +889,8 @@ func (h *httpController) createDevice() func(c *gin.Context) {
 				return
 			}
 			device.EndpointId = *req.EndpointId
-		} else {
-			// EndpointId not provided, auto-generate
-			macUpper := strings.ToUpper(macAddress)
-			device.EndpointId = "os::" + macUpper[:6] + "-" + macUpper
 		}
+		// EndpointId not provided, it is rendered from the model endpoint_id_template
 		deviceId, err := h.usecase.CreateDevice(c.Request.Context(), &device)
 		if err != nil {
 			logging.Errorf("failed to create device: %v", err)
@@ -931,30 +975,34 @@ func (h *httpController) createParametersFromCSVFile() func(c *gin.Context) {
 			return
 		}
 
-		ids, err := h.usecase.CreateParametersFromCSVFile(c.Request.Context(), f, updatedBy, 100)
+		opts, ok := catalogImportOptions(c)
+		if !ok {
+			return
+		}
+		if isAsyncImport(c) {
+			h.submitImportJob(c, &models.ImportJob{
+				Kind:      models.ImportJobKindParameter,
+				Mode:      opts.Mode,
+				FileName:  file.Filename,
+				UpdatedBy: updatedBy,
+			}, f, file.Size)
+			return
+		}
+
+		report, err := h.usecase.CreateParametersFromCSVFile(c.Request.Context(), f, updatedBy, 100, opts)
+		if report != nil && strings.EqualFold(c.Query("format"), "csv") {
+			writeImportReportCSV(c, report, err, "parameters-import-report.csv")
+			return
+		}
 		if err != nil {
 			logging.Errorf("failed to create parameters from CSV: %v", err)
-			if appErr, ok := err.(*apperrors.AppError); ok {
-				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
-					nil,
-					appErr.ErrorMessage(),
-					appErr.ErrorKey(),
-				))
-				return
-			}
-
-			c.JSON(http.StatusInternalServerError,
-				httphelper.NewErrorHTTPResponse(
-					nil,
-					"Failed to create parameters from CSV",
-					apperrors.ErrInternal,
-				),
-			)
+			writeImportError(c, report, err, "Failed to create parameters from CSV")
 			return
 		}
 
-		response := createParametersResponse{
-			ParameterId: ids,
+		response := importParametersResponse{
+			ParameterId:  report.Ids,
+			ImportReport: report,
 		}
 
 		c.JSON(http.StatusOK,
@@ -1066,32 +1114,34 @@ func (h *httpController) createDevicesWithBatch() func(c *gin.Context) {
 			return
 		}
 
+		if isAsyncImport(c) {
+			h.submitImportJob(c, &models.ImportJob{
+				Kind:      models.ImportJobKindDevice,
+				FileName:  file.Filename,
+				ModelId:   &modelId,
+				GroupId:   &groupIdUUID,
+				UpdatedBy: updatedBy,
+			}, f, file.Size)
+			return
+		}
+
 		batchSize := 100 // You can adjust the batch size as needed
+		opts := importOptions(c)
 
-		deviceIds, err := h.usecase.CreateDevicesWithBatch(c.Request.Context(), f, updatedBy, batchSize, modelId, groupIdUUID)
+		report, err := h.usecase.CreateDevicesWithBatch(c.Request.Context(), f, updatedBy, batchSize, modelId, groupIdUUID, opts)
+		if report != nil && strings.EqualFold(c.Query("format"), "csv") {
+			writeImportReportCSV(c, report, err, "devices-import-report.csv")
+			return
+		}
 		if err != nil {
 			logging.Errorf("failed to create devices from CSV: %v", err)
-			if appErr, ok := err.(*apperrors.AppError); ok {
-				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
-					nil,
-					appErr.ErrorMessage(),
-					appErr.ErrorKey(),
-				))
-				return
-			}
-
-			c.JSON(http.StatusInternalServerError,
-				httphelper.NewErrorHTTPResponse(
-					nil,
-					"Failed to create devices from CSV",
-					apperrors.ErrInternal,
-				),
-			)
+			writeImportError(c, report, err, "Failed to create devices from CSV")
 			return
 		}
 
-		response := createDeviceResponse{
-			DeviceIDs: deviceIds,
+		response := importDevicesResponse{
+			DeviceIDs:    report.Ids,
+			ImportReport: report,
 		}
 
 		c.JSON(http.StatusOK,
@@ -1104,6 +1154,69 @@ func (h *httpController) createDevicesWithBatch() func(c *gin.Context) {
 	}
 }
 
+// importOptions reads the `strict` and `dry_run` query parameters of an import.
+func importOptions(c *gin.Context) models.ImportOptions {
+	return models.ImportOptions{
+		Strict: strings.EqualFold(c.Query("strict"), "true"),
+		DryRun: strings.EqualFold(c.Query("dry_run"), "true"),
+	}
+}
+
+// catalogImportOptions reads the import options and the `mode` of a catalog import,
+// it answers 400 and returns false when the mode is unknown.
+func catalogImportOptions(c *gin.Context) (models.ImportOptions, bool) {
+	opts := importOptions(c)
+	opts.Mode = strings.ToLower(c.DefaultQuery("mode", models.ImportModeInsert))
+	switch opts.Mode {
+	case models.ImportModeInsert, models.ImportModeUpsert, models.ImportModeReplace:
+		return opts, true
+	}
+	c.JSON(http.StatusBadRequest,
+		httphelper.NewErrorHTTPResponse(
+			nil,
+			"mode must be one of insert, upsert, replace",
+			apperrors.ErrInvalidRequest,
+		),
+	)
+	return opts, false
+}
+
+// writeImportError answers a failed import, the report of a rejected strict import is sent as data.
+func writeImportError(c *gin.Context, report *models.ImportReport, err error, message string) {
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		var data any
+		if report != nil {
+			data = report
+		}
+		c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
+			data,
+			appErr.ErrorMessage(),
+			appErr.ErrorKey(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError,
+		httphelper.NewErrorHTTPResponse(
+			nil,
+			message,
+			apperrors.ErrInternal,
+		),
+	)
+}
+
+// writeImportReportCSV sends the rejected rows of an import as a CSV attachment,
+// a failed strict import is answered with the status of its error.
+func writeImportReportCSV(c *gin.Context, report *models.ImportReport, err error, filename string) {
+	status := http.StatusOK
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		status = appErr.HTTPCode()
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(status, "text/csv", utils.ConvertToCSV(report.RejectedCSV()))
+}
+
 func (h *httpController) createProfilesWithBatch() func(c *gin.Context) {
 	return func(c *gin.Context) {
 		file, err := c.FormFile("file")
@@ -1155,8 +1268,96 @@ func (h *httpController) createProfilesWithBatch() func(c *gin.Context) {
 			return
 		}
 
-		if err := h.usecase.CreateProfileWithBatch(c.Request.Context(), f, updatedBy); err != nil {
+		opts, ok := catalogImportOptions(c)
+		if !ok {
+			return
+		}
+
+		report, err := h.usecase.CreateProfileWithBatch(c.Request.Context(), f, updatedBy, opts)
+		if report != nil && strings.EqualFold(c.Query("format"), "csv") {
+			writeImportReportCSV(c, report, err, "profiles-import-report.csv")
+			return
+		}
+		if err != nil {
 			logging.Errorf("failed to create profiles from CSV: %v", err)
+			writeImportError(c, report, err, "Failed to create profiles from CSV")
+			return
+		}
+
+		response := importProfilesResponse{
+			ProfileIds:   report.Ids,
+			ImportReport: report,
+		}
+
+		c.JSON(http.StatusOK,
+			httphelper.NewSuccessResponse(
+				response,
+				nil,
+				nil,
+			),
+		)
+	}
+}
+
+func (h *httpController) createWebhook() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req createWebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					err.Error(),
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		// validate payload
+		if err := Validate.Struct(req); err != nil {
+			var invalidFields []invalidField
+			validationErrors := err.(validator.ValidationErrors)
+			for _, fieldError := range validationErrors {
+				invalidFields = append(invalidFields, generateInvalidFieldError(fieldError))
+			}
+
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					invalidFields,
+					"Invalid request fields",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		status := req.Status
+		if status == "" {
+			status = "ENABLE"
+		}
+		subscription := models.WebhookSubscription{
+			Name:        req.Name,
+			Url:         req.Url,
+			Secret:      req.Secret,
+			EventTypes:  req.EventTypes,
+			Status:      status,
+			Description: req.Description,
+			UpdatedBy:   updatedBy,
+		}
+		webhookId, err := h.usecase.CreateWebhookSubscription(c.Request.Context(), &subscription)
+		if err != nil {
+			logging.Errorf("failed to create webhook: %v", err)
 			if appErr, ok := err.(*apperrors.AppError); ok {
 				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
 					nil,
@@ -1165,20 +1366,21 @@ func (h *httpController) createProfilesWithBatch() func(c *gin.Context) {
 				))
 				return
 			}
-
 			c.JSON(http.StatusInternalServerError,
 				httphelper.NewErrorHTTPResponse(
 					nil,
-					"Failed to create profiles from CSV",
+					"Failed to create webhook",
 					apperrors.ErrInternal,
 				),
 			)
 			return
 		}
-
 		c.JSON(http.StatusOK,
 			httphelper.NewSuccessResponse(
-				nil,
+				createWebhookResponse{
+					WebhookID: webhookId,
+					Secret:    subscription.Secret,
+				},
 				nil,
 				nil,
 			),
@@ -478,3 +478,68 @@ func (h *httpController) deleteDeviceWithId() func(c *gin.Context) {
 
 	}
 }
+
+func (h *httpController) deleteWebhookWithId() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		webhookId := strings.TrimSpace(c.Param("webhook_id"))
+		if webhookId == "" || webhookId == ":webhook_id" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Webhook ID cannot be left blank.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if _, err := uuid.Parse(webhookId); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid Webhook ID format.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header cannot be left blank.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if err := h.usecase.DeleteWebhookSubscriptionWithId(c.Request.Context(), webhookId, updatedBy); err != nil {
+			logging.Errorf("failed to delete webhook with id=%s: %v", webhookId, err)
+			if appErr, ok := err.(*apperrors.AppError); ok {
+				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
+					nil,
+					appErr.ErrorMessage(),
+					appErr.ErrorKey(),
+				))
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Failed to delete webhook",
+					apperrors.ErrInternal,
+				),
+			)
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			httphelper.NewSuccessResponse(
+				nil,
+				nil,
+				nil,
+			),
+		)
+	}
+}
@@ -0,0 +1,298 @@
+package httpcontroller
+
+import (
+	"net/http"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+func (h *httpController) countDevicesByLifecycleState() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+
+		counts, err := h.usecase.CountDevicesByLifecycleState(c.Request.Context(), modelId)
+		if err != nil {
+			logging.Errorf("failed to count devices by lifecycle state: %v", err)
+			writeUsecaseError(c, err, "Failed to count devices by lifecycle state")
+			return
+		}
+
+		var total int64
+		states := make(map[string]int64, len(counts))
+		for _, count := range counts {
+			states[count.LifecycleState] = count.Count
+			total += count.Count
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(map[string]any{
+			"total_row": total,
+			"states":    states,
+		}, nil, nil))
+	}
+}
+
+func (h *httpController) listDeviceLifecycle() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		deviceId, ok := uuidParam(c, "device_id", "Device ID")
+		if !ok {
+			return
+		}
+
+		transitions, err := h.usecase.ListDeviceLifecycleTransitions(c.Request.Context(), modelId, deviceId)
+		if err != nil {
+			logging.Errorf("failed to list lifecycle of device %s: %v", deviceId, err)
+			writeUsecaseError(c, err, "Failed to list device lifecycle")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(transitions))
+		for _, transition := range transitions {
+			responseBody = append(responseBody, map[string]any{
+				"id":         transition.Id,
+				"from_state": transition.FromState,
+				"to_state":   transition.ToState,
+				"reason":     transition.Reason,
+				"actor":      transition.Actor,
+				"created_at": utils.FormatTimeGMT7(transition.CreatedAt, "02/01/2006 15:04:05"),
+			})
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+type replaceDeviceRequest struct {
+	MacAddress       string `json:"mac_address" validate:"required"`
+	SerialNumber     string `json:"serial_number" validate:"omitempty,max=64"`
+	ProductClass     string `json:"product_class" validate:"omitempty,max=64"`
+	HardwareRevision string `json:"hardware_revision" validate:"omitempty,max=64"`
+	Reason           string `json:"reason" validate:"required,max=255"`
+}
+
+// normalizeMacAddress strips separators and lowercases a MAC address, ok is false unless 12 hex characters remain.
+func normalizeMacAddress(raw string) (string, bool) {
+	macAddress := strings.ToLower(strings.NewReplacer(":", "", "-", "", " ", "").Replace(raw))
+	if len(macAddress) != 12 {
+		return "", false
+	}
+	for _, char := range macAddress {
+		if !((char >= '0' && char <= '9') || (char >= 'a' && char <= 'f')) {
+			return "", false
+		}
+	}
+	return macAddress, true
+}
+
+func lineageDeviceResponse(device *models.Device) map[string]any {
+	resp := map[string]any{
+		"id":                device.Id,
+		"mac_address":       device.MacAddress,
+		"endpoint_id":       device.EndpointId,
+		"serial_number":     device.SerialNumber,
+		"product_class":     device.ProductClass,
+		"hardware_revision": device.HardwareRevision,
+		"lifecycle_state":   device.LifecycleState,
+		"group_id":          device.GroupId,
+		"replaced_by_id":    device.ReplacedById,
+		"created_at":        utils.FormatTimeGMT7(device.CreatedAt, "02/01/2006 15:04:05"),
+	}
+	if device.ReplacedAt != nil {
+		resp["replaced_at"] = utils.FormatTimeGMT7(device.ReplacedAt, "02/01/2006 15:04:05")
+	}
+	return resp
+}
+
+func (h *httpController) replaceDevice() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		deviceId, ok := uuidParam(c, "device_id", "Device ID")
+		if !ok {
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		var req replaceDeviceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					err.Error(),
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if err := Validate.Struct(req); err != nil {
+			var invalidFields []invalidField
+			for _, fieldError := range err.(validator.ValidationErrors) {
+				invalidFields = append(invalidFields, generateInvalidFieldError(fieldError))
+			}
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					invalidFields,
+					"Invalid request fields",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		macAddress, ok := normalizeMacAddress(req.MacAddress)
+		if !ok {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"MAC address must contain exactly 12 hexadecimal characters",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		result, err := h.usecase.ReplaceDevice(c.Request.Context(), modelId, deviceId, &models.DeviceReplacement{
+			MacAddress:       macAddress,
+			SerialNumber:     strings.TrimSpace(req.SerialNumber),
+			ProductClass:     strings.TrimSpace(req.ProductClass),
+			HardwareRevision: strings.TrimSpace(req.HardwareRevision),
+			Reason:           req.Reason,
+			UpdatedBy:        updatedBy,
+		})
+		if err != nil {
+			logging.Errorf("failed to replace device %s: %v", deviceId, err)
+			writeUsecaseError(c, err, "Failed to replace device")
+			return
+		}
+
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(map[string]any{
+			"predecessor":             lineageDeviceResponse(result.Predecessor),
+			"successor":               lineageDeviceResponse(result.Successor),
+			"transferred_profile_ids": result.TransferredProfileIds,
+		}, nil, nil))
+	}
+}
+
+func (h *httpController) getDeviceLineage() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		deviceId, ok := uuidParam(c, "device_id", "Device ID")
+		if !ok {
+			return
+		}
+
+		lineage, err := h.usecase.GetDeviceLineage(c.Request.Context(), modelId, deviceId)
+		if err != nil {
+			logging.Errorf("failed to get lineage of device %s: %v", deviceId, err)
+			writeUsecaseError(c, err, "Failed to get device lineage")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(lineage))
+		for i := range lineage {
+			responseBody = append(responseBody, lineageDeviceResponse(&lineage[i]))
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+type reassignDeviceRequest struct {
+	TargetModelId string `json:"target_model_id" validate:"required,uuid"`
+	TargetGroupId string `json:"target_group_id" validate:"omitempty,uuid"`
+}
+
+func (h *httpController) reassignDevice() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		deviceId, ok := uuidParam(c, "device_id", "Device ID")
+		if !ok {
+			return
+		}
+		dryRun := strings.EqualFold(c.Query("dry_run"), "true")
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" && !dryRun {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		var req reassignDeviceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					err.Error(),
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if err := Validate.Struct(req); err != nil {
+			var invalidFields []invalidField
+			for _, fieldError := range err.(validator.ValidationErrors) {
+				invalidFields = append(invalidFields, generateInvalidFieldError(fieldError))
+			}
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					invalidFields,
+					"Invalid request fields",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		targetModelId := uuid.MustParse(req.TargetModelId)
+		reassignment := &models.DeviceReassignment{
+			TargetModelId: &targetModelId,
+			UpdatedBy:     updatedBy,
+			DryRun:        dryRun,
+		}
+		if req.TargetGroupId != "" {
+			targetGroupId := uuid.MustParse(req.TargetGroupId)
+			reassignment.TargetGroupId = &targetGroupId
+		}
+
+		plan, err := h.usecase.ReassignDeviceModel(c.Request.Context(), modelId, deviceId, reassignment)
+		if err != nil {
+			logging.Errorf("failed to reassign device %s: %v", deviceId, err)
+			writeUsecaseError(c, err, "Failed to reassign device")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(plan, nil, nil))
+	}
+}
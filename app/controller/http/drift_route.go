@@ -0,0 +1,393 @@
+package httpcontroller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type assignProfileRequest struct {
+	ProfileId string `json:"profile_id" validate:"required,uuid"`
+}
+
+type assignProfileResponse struct {
+	AssignmentID string `json:"assignment_id"`
+}
+
+// uuidParam reads a path param that must be a UUID, it writes the 400 response and returns false otherwise.
+func uuidParam(c *gin.Context, name string, label string) (string, bool) {
+	value := strings.TrimSpace(c.Param(name))
+	if value == "" || value == ":"+name {
+		c.JSON(http.StatusBadRequest,
+			httphelper.NewErrorHTTPResponse(
+				nil,
+				label+" cannot be left blank.",
+				apperrors.ErrInvalidRequest,
+			),
+		)
+		return "", false
+	}
+	if _, err := uuid.Parse(value); err != nil {
+		c.JSON(http.StatusBadRequest,
+			httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid "+label+" format.",
+				apperrors.ErrInvalidRequest,
+			),
+		)
+		return "", false
+	}
+	return value, true
+}
+
+// assignmentTarget returns the path param and assignment column of a GROUP or DEVICE scope.
+func assignmentTarget(scope string) (param string, label string, column string) {
+	if scope == models.ProfileAssignmentScopeDevice {
+		return "device_id", "Device ID", models.ProfileAssignment{}.GetDeviceIdColumnName()
+	}
+	return "group_id", "Group ID", models.ProfileAssignment{}.GetGroupIdColumnName()
+}
+
+func writeUsecaseError(c *gin.Context, err error, message string) {
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
+			nil,
+			appErr.ErrorMessage(),
+			appErr.ErrorKey(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError,
+		httphelper.NewErrorHTTPResponse(
+			nil,
+			message,
+			apperrors.ErrInternal,
+		),
+	)
+}
+
+func (h *httpController) listGroupProfiles() func(c *gin.Context) {
+	return h.listProfileAssignments(models.ProfileAssignmentScopeGroup)
+}
+
+func (h *httpController) listDeviceProfiles() func(c *gin.Context) {
+	return h.listProfileAssignments(models.ProfileAssignmentScopeDevice)
+}
+
+func (h *httpController) assignGroupProfile() func(c *gin.Context) {
+	return h.assignProfile(models.ProfileAssignmentScopeGroup)
+}
+
+func (h *httpController) assignDeviceProfile() func(c *gin.Context) {
+	return h.assignProfile(models.ProfileAssignmentScopeDevice)
+}
+
+func (h *httpController) unassignGroupProfile() func(c *gin.Context) {
+	return h.unassignProfile(models.ProfileAssignmentScopeGroup)
+}
+
+func (h *httpController) unassignDeviceProfile() func(c *gin.Context) {
+	return h.unassignProfile(models.ProfileAssignmentScopeDevice)
+}
+
+func (h *httpController) listProfileAssignments(scope string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		param, label, column := assignmentTarget(scope)
+		targetId, ok := uuidParam(c, param, label)
+		if !ok {
+			return
+		}
+
+		assignments, err := h.usecase.ListProfileAssignments(c.Request.Context(), modelId, map[string]any{
+			column: targetId,
+		})
+		if err != nil {
+			logging.Errorf("failed to list profile assignments: %v", err)
+			writeUsecaseError(c, err, "Failed to list profile assignments")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(assignments))
+		for _, assignment := range assignments {
+			resp := map[string]any{
+				"id":         assignment.Id,
+				"profile_id": assignment.ProfileId,
+				"scope":      assignment.Scope(),
+				"created_at": utils.FormatTimeGMT7(assignment.CreatedAt, "02/01/2006 15:04:05"),
+				"updated_by": assignment.UpdatedBy,
+			}
+			if assignment.Profile != nil {
+				resp["profile_name"] = assignment.Profile.Name
+				resp["profile_status"] = assignment.Profile.Status
+			}
+			responseBody = append(responseBody, resp)
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+func (h *httpController) assignProfile(scope string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		param, label, _ := assignmentTarget(scope)
+		targetId, ok := uuidParam(c, param, label)
+		if !ok {
+			return
+		}
+		var req assignProfileRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					err.Error(),
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		// validate payload
+		if err := Validate.Struct(req); err != nil {
+			var invalidFields []invalidField
+			validationErrors := err.(validator.ValidationErrors)
+			for _, fieldError := range validationErrors {
+				invalidFields = append(invalidFields, generateInvalidFieldError(fieldError))
+			}
+
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					invalidFields,
+					"Invalid request fields",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		profileUUID := uuid.MustParse(req.ProfileId)
+		targetUUID := uuid.MustParse(targetId)
+		assignment := models.ProfileAssignment{
+			ProfileId: &profileUUID,
+			UpdatedBy: updatedBy,
+		}
+		if scope == models.ProfileAssignmentScopeDevice {
+			assignment.DeviceId = &targetUUID
+		} else {
+			assignment.GroupId = &targetUUID
+		}
+
+		assignmentId, err := h.usecase.AssignProfile(c.Request.Context(), modelId, &assignment)
+		if err != nil {
+			logging.Errorf("failed to assign profile: %v", err)
+			writeUsecaseError(c, err, "Failed to assign profile")
+			return
+		}
+		c.JSON(http.StatusOK,
+			httphelper.NewSuccessResponse(
+				assignProfileResponse{AssignmentID: assignmentId},
+				nil,
+				nil,
+			),
+		)
+	}
+}
+
+func (h *httpController) unassignProfile(scope string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		param, label, column := assignmentTarget(scope)
+		targetId, ok := uuidParam(c, param, label)
+		if !ok {
+			return
+		}
+		profileId, ok := uuidParam(c, "profile_id", "Profile ID")
+		if !ok {
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header cannot be left blank.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		if err := h.usecase.UnassignProfile(c.Request.Context(), modelId, map[string]any{
+			column: targetId,
+			models.ProfileAssignment{}.GetProfileIdColumnName(): profileId,
+		}); err != nil {
+			logging.Errorf("failed to unassign profile %s by %s: %v", profileId, updatedBy, err)
+			writeUsecaseError(c, err, "Failed to unassign profile")
+			return
+		}
+		logging.Infof("Profile %s unassigned from %s %s by %s", profileId, param, targetId, updatedBy)
+
+		c.JSON(http.StatusOK,
+			httphelper.NewSuccessResponse(
+				nil,
+				nil,
+				nil,
+			),
+		)
+	}
+}
+
+func (h *httpController) getDeviceDrift() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		deviceId, ok := uuidParam(c, "device_id", "Device ID")
+		if !ok {
+			return
+		}
+
+		report, err := h.usecase.GetDeviceDriftReport(c.Request.Context(), modelId, deviceId)
+		if err != nil {
+			logging.Errorf("failed to build drift report of device %s: %v", deviceId, err)
+			writeUsecaseError(c, err, "Failed to build drift report")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(driftReportResponse(*report), nil, nil))
+	}
+}
+
+func (h *httpController) getGroupDrift() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		groupId, ok := uuidParam(c, "group_id", "Group ID")
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid limit value",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+		offset, err := strconv.Atoi(c.Query("offset"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid offset value",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+		driftedOnly := strings.EqualFold(c.Query("drifted_only"), "true")
+
+		reports, err := h.usecase.GetGroupDriftReport(c.Request.Context(), modelId, groupId, driftedOnly, models.QueryOptions{
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			logging.Errorf("failed to build drift report of group %s: %v", groupId, err)
+			writeUsecaseError(c, err, "Failed to build drift report")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(reports))
+		for _, report := range reports {
+			responseBody = append(responseBody, driftReportResponse(report))
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+func (h *httpController) getModelDriftSummary() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+
+		summaries, err := h.usecase.GetModelDriftSummary(c.Request.Context(), modelId)
+		if err != nil {
+			logging.Errorf("failed to build drift summary of model %s: %v", modelId, err)
+			writeUsecaseError(c, err, "Failed to build drift summary")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(summaries))
+		for _, summary := range summaries {
+			responseBody = append(responseBody, map[string]any{
+				"path":             summary.Path,
+				"devices":          summary.Devices,
+				"drifted":          summary.Drifted,
+				"missing":          summary.Missing,
+				"last_observed_at": utils.FormatTimeGMT7(summary.LastObservedAt, "02/01/2006 15:04:05"),
+			})
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+func driftReportResponse(report models.DeviceDriftReport) map[string]any {
+	parameters := make([]map[string]any, 0, len(report.Parameters))
+	for _, parameter := range report.Parameters {
+		parameters = append(parameters, map[string]any{
+			"parameter_id": parameter.ParameterId,
+			"path":         parameter.Path,
+			"profile_id":   parameter.ProfileId,
+			"profile_name": parameter.ProfileName,
+			"scope":        parameter.Scope,
+			"expected":     parameter.Expected,
+			"actual":       parameter.Actual,
+			"status":       parameter.Status,
+			"observed_at":  utils.FormatTimeGMT7(parameter.ObservedAt, "02/01/2006 15:04:05"),
+		})
+	}
+	return map[string]any{
+		"device_id":   report.DeviceId,
+		"mac_address": report.MacAddress,
+		"endpoint_id": report.EndpointId,
+		"group_id":    report.GroupId,
+		"in_sync":     report.InSync(),
+		"checked":     report.Checked,
+		"drifted":     report.Drifted,
+		"missing":     report.Missing,
+		"parameters":  parameters,
+	}
+}
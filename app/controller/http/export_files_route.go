@@ -0,0 +1,130 @@
+package httpcontroller
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	exportwriter "usp-management-device-api/common/export_writer"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+)
+
+// exportFunc streams the rows of a list to w, one of the Export methods of the usecase
+type exportFunc func(
+	ctx context.Context,
+	w io.Writer,
+	export models.ExportOptions,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) error
+
+// exportResponse sends the status and headers of the file with its first bytes, until then
+// an error can still be answered with JSON
+type exportResponse struct {
+	c        *gin.Context
+	filename string
+	format   string
+	started  bool
+}
+
+func (r *exportResponse) Write(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		r.c.Header("Content-Type", exportwriter.ContentType(r.format))
+		r.c.Header("Content-Disposition", "attachment; filename="+r.filename+"."+r.format)
+		r.c.Header("X-Export-Schema-Version", strconv.Itoa(models.ExportSchemaVersion))
+		r.c.Status(http.StatusOK)
+	}
+	return r.c.Writer.Write(p)
+}
+
+func (h *httpController) exportParameters() func(c *gin.Context) {
+	return h.exportList("parameters", h.usecase.ExportParameters)
+}
+
+func (h *httpController) exportProfiles() func(c *gin.Context) {
+	return h.exportList("profiles", h.usecase.ExportProfiles)
+}
+
+func (h *httpController) exportModels() func(c *gin.Context) {
+	return h.exportList("models", h.usecase.ExportModels)
+}
+
+func (h *httpController) exportFirmwares() func(c *gin.Context) {
+	return h.exportList("firmwares", h.usecase.ExportFirmwares)
+}
+
+func (h *httpController) exportGroups() func(c *gin.Context) {
+	return h.exportList("groups", h.usecase.ExportGroups)
+}
+
+func (h *httpController) exportDevices() func(c *gin.Context) {
+	return h.exportList("devices", h.usecase.ExportDevices)
+}
+
+// exportList streams the rows matching filter and orderBy as csv, ndjson or xlsx (format query,
+// csv by default) with the times in the timezone query; the lists of a model read model_id from the path
+func (h *httpController) exportList(resource string, export exportFunc) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		condition := make(map[string]any)
+		if c.Param("model_id") != "" {
+			modelId, ok := uuidParam(c, "model_id", "Model ID")
+			if !ok {
+				return
+			}
+			condition["model_id"] = modelId
+		}
+		// terminal lifecycle states are hidden unless asked for, as in the list
+		if resource == "devices" && strings.EqualFold(c.Query("include_terminal"), "true") {
+			condition["lifecycle_state"] = models.DeviceLifecycleStates
+		}
+
+		filterExpr, err := utils.ParseFilterExpr(c.Query("filter"))
+		if err != nil {
+			logging.Errorf("invalid filter expression: %v", err)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid filter expression",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+
+		// Parse order expression
+		orderExpr, err := utils.ParseOrderExpr(c.Query("orderBy"))
+		if err != nil {
+			logging.Errorf("invalid order expression: %v", err)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid order expression",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+		opts := models.QueryOptions{
+			FilterExpr: filterExpr,
+			OrderExpr:  orderExpr,
+		}
+		options := models.ExportOptions{
+			Format:   strings.ToLower(c.DefaultQuery("format", exportwriter.FormatCSV)),
+			Timezone: c.Query("timezone"),
+		}
+
+		response := &exportResponse{c: c, filename: resource, format: options.Format}
+		if err := export(c.Request.Context(), response, options, condition, opts); err != nil {
+			logging.Errorf("failed to export %s: %v", resource, err)
+			if response.started {
+				// the file is cut short, the client sees an incomplete download
+				c.Abort()
+				return
+			}
+			writeUsecaseError(c, err, "Failed to export file")
+		}
+	}
+}
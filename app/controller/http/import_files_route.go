@@ -0,0 +1,148 @@
+package httpcontroller
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+
+	"github.com/google/uuid"
+)
+
+type importModelsResponse struct {
+	ModelIds []string `json:"model_ids"`
+	*models.ImportReport
+}
+
+type importFirmwaresResponse struct {
+	FirmwareIds []string `json:"firmware_ids"`
+	*models.ImportReport
+}
+
+type importGroupsResponse struct {
+	GroupIds []string `json:"group_ids"`
+	*models.ImportReport
+}
+
+// importFunc imports an uploaded CSV, modelId is the model of the path, uuid.Nil for models
+type importFunc func(
+	ctx context.Context,
+	file io.Reader,
+	modelId uuid.UUID,
+	updatedBy string,
+	opts models.ImportOptions,
+) (*models.ImportReport, error)
+
+func (h *httpController) importModels() func(c *gin.Context) {
+	return h.importFile("models", func(ctx context.Context, file io.Reader, _ uuid.UUID, updatedBy string, opts models.ImportOptions) (*models.ImportReport, error) {
+		return h.usecase.ImportModelsFromCSVFile(ctx, file, updatedBy, opts)
+	}, func(report *models.ImportReport) any {
+		return importModelsResponse{ModelIds: report.Ids, ImportReport: report}
+	})
+}
+
+func (h *httpController) importFirmwares() func(c *gin.Context) {
+	return h.importFile("firmwares", h.usecase.ImportFirmwaresFromCSVFile, func(report *models.ImportReport) any {
+		return importFirmwaresResponse{FirmwareIds: report.Ids, ImportReport: report}
+	})
+}
+
+func (h *httpController) importGroups() func(c *gin.Context) {
+	return h.importFile("groups", h.usecase.ImportGroupsFromCSVFile, func(report *models.ImportReport) any {
+		return importGroupsResponse{GroupIds: report.Ids, ImportReport: report}
+	})
+}
+
+// importFile reads the CSV of the file form field and imports it with the mode (insert or
+// upsert), strict and dry_run queries; the lists of a model read model_id from the path
+func (h *httpController) importFile(resource string, importRows importFunc, response func(*models.ImportReport) any) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var modelId uuid.UUID
+		if c.Param("model_id") != "" {
+			id, ok := uuidParam(c, "model_id", "Model ID")
+			if !ok {
+				return
+			}
+			modelId = uuid.MustParse(id)
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"File is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if filepath.Ext(file.Filename) != ".csv" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Only .csv files are allowed",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		opts := importOptions(c)
+		opts.Mode = strings.ToLower(c.DefaultQuery("mode", models.ImportModeInsert))
+		if opts.Mode != models.ImportModeInsert && opts.Mode != models.ImportModeUpsert {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"mode must be one of insert, upsert",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			logging.Errorf("failed to open file: %v", err)
+			c.JSON(http.StatusInternalServerError,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Failed to process file",
+					apperrors.ErrInternal,
+				),
+			)
+			return
+		}
+		defer f.Close()
+
+		report, err := importRows(c.Request.Context(), f, modelId, updatedBy, opts)
+		if report != nil && strings.EqualFold(c.Query("format"), "csv") {
+			writeImportReportCSV(c, report, err, resource+"-import-report.csv")
+			return
+		}
+		if err != nil {
+			logging.Errorf("failed to import %s from CSV: %v", resource, err)
+			writeImportError(c, report, err, "Failed to import "+resource+" from CSV")
+			return
+		}
+
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(response(report), nil, nil))
+	}
+}
@@ -0,0 +1,114 @@
+package httpcontroller
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isAsyncImport reports whether an import request asks for a background job with `async=true`.
+func isAsyncImport(c *gin.Context) bool {
+	return strings.EqualFold(c.Query("async"), "true")
+}
+
+func importJobResponse(job *models.ImportJob) map[string]any {
+	return map[string]any{
+		"id":             job.Id,
+		"kind":           job.Kind,
+		"status":         job.Status,
+		"mode":           job.Mode,
+		"file_name":      job.FileName,
+		"model_id":       job.ModelId,
+		"group_id":       job.GroupId,
+		"progress":       job.Progress(),
+		"total_rows":     job.TotalRows,
+		"processed_rows": job.ProcessedRows,
+		"created":        job.Created,
+		"updated":        job.Updated,
+		"skipped":        job.Skipped,
+		"rejected":       job.Rejected,
+		"errors":         job.Errors,
+		"attempts":       job.Attempts,
+		"last_error":     job.LastError,
+		"updated_by":     job.UpdatedBy,
+		"started_at":     utils.FormatTimeGMT7(job.StartedAt, "02/01/2006 15:04:05"),
+		"finished_at":    utils.FormatTimeGMT7(job.FinishedAt, "02/01/2006 15:04:05"),
+		"created_at":     utils.FormatTimeGMT7(job.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":     utils.FormatTimeGMT7(job.UpdatedAt, "02/01/2006 15:04:05"),
+	}
+}
+
+// submitImportJob queues an uploaded CSV as an import job and answers 202 with the job.
+func (h *httpController) submitImportJob(c *gin.Context, job *models.ImportJob, file io.Reader, size int64) {
+	// a job commits chunk by chunk and reports through GET /jobs/:job_id
+	if c.Query("strict") != "" || c.Query("dry_run") != "" || c.Query("format") != "" {
+		c.JSON(http.StatusBadRequest,
+			httphelper.NewErrorHTTPResponse(
+				nil,
+				"strict, dry_run and format are not supported with async=true",
+				apperrors.ErrInvalidRequest,
+			),
+		)
+		return
+	}
+
+	submitted, err := h.usecase.SubmitImportJob(c.Request.Context(), job, file, size)
+	if err != nil {
+		logging.Errorf("failed to submit import job: %v", err)
+		writeUsecaseError(c, err, "Failed to submit import job")
+		return
+	}
+	c.JSON(http.StatusAccepted, httphelper.NewSuccessResponse(importJobResponse(submitted), nil, nil))
+}
+
+func (h *httpController) getImportJob() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		jobId, ok := uuidParam(c, "job_id", "Job ID")
+		if !ok {
+			return
+		}
+
+		job, err := h.usecase.GetImportJob(c.Request.Context(), jobId)
+		if err != nil {
+			logging.Errorf("failed to find import job %s: %v", jobId, err)
+			writeUsecaseError(c, err, "Failed to find import job")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(importJobResponse(job), nil, nil))
+	}
+}
+
+func (h *httpController) cancelImportJob() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		jobId, ok := uuidParam(c, "job_id", "Job ID")
+		if !ok {
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		job, err := h.usecase.CancelImportJob(c.Request.Context(), jobId, updatedBy)
+		if err != nil {
+			logging.Errorf("failed to cancel import job %s: %v", jobId, err)
+			writeUsecaseError(c, err, "Failed to cancel import job")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(importJobResponse(job), nil, nil))
+	}
+}
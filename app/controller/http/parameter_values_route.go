@@ -0,0 +1,149 @@
+package httpcontroller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultParameterValueLimit = 1000
+
+func (h *httpController) listDeviceParameterValues() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		deviceId, ok := uuidParam(c, "device_id", "Device ID")
+		if !ok {
+			return
+		}
+
+		limit := defaultParameterValueLimit
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > defaultParameterValueLimit {
+				c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid limit value",
+					apperrors.ErrInvalidRequest,
+				))
+				return
+			}
+			limit = parsed
+		}
+		offset := 0
+		if value := c.Query("offset"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid offset value",
+					apperrors.ErrInvalidRequest,
+				))
+				return
+			}
+			offset = parsed
+		}
+
+		// prefix may be repeated or comma separated, example: ?prefix=Device.WiFi.&prefix=Device.IP.
+		prefixes := []string{}
+		for _, value := range c.QueryArray("prefix") {
+			for _, prefix := range strings.Split(value, ",") {
+				if prefix = strings.TrimSpace(prefix); prefix != "" {
+					prefixes = append(prefixes, prefix)
+				}
+			}
+		}
+
+		var asOf *time.Time
+		if value := c.Query("as_of"); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid as_of value, RFC3339 expected",
+					apperrors.ErrInvalidRequest,
+				))
+				return
+			}
+			asOf = &parsed
+		}
+
+		values, err := h.usecase.GetDeviceParameterValues(c.Request.Context(), modelId, deviceId, prefixes, asOf, models.QueryOptions{
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			logging.Errorf("failed to list parameter values of device %s: %v", deviceId, err)
+			writeUsecaseError(c, err, "Failed to list parameter values")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(values))
+		for _, value := range values {
+			responseBody = append(responseBody, map[string]any{
+				"path":        value.Path,
+				"value":       value.Value,
+				"observed_at": utils.FormatTimeGMT7(value.ObservedAt, "02/01/2006 15:04:05"),
+				"updated_at":  utils.FormatTimeGMT7(value.UpdatedAt, "02/01/2006 15:04:05"),
+			})
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+func (h *httpController) ingestDeviceParameterValues() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		modelId, ok := uuidParam(c, "model_id", "Model ID")
+		if !ok {
+			return
+		}
+		deviceId, ok := uuidParam(c, "device_id", "Device ID")
+		if !ok {
+			return
+		}
+		var req models.USPGetResp
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					err.Error(),
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		stored, err := h.usecase.IngestDeviceParameterValues(c.Request.Context(), modelId, deviceId, &req)
+		if err != nil {
+			logging.Errorf("failed to ingest parameter values of device %s: %v", deviceId, err)
+			writeUsecaseError(c, err, "Failed to ingest parameter values")
+			return
+		}
+
+		logging.Infof("%d parameter values of device %s ingested by %s", stored, deviceId, updatedBy)
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(map[string]any{
+			"stored": stored,
+		}, nil, nil))
+	}
+}
@@ -26,13 +26,8 @@ func (h *httpController) listTotalProfiles() func(c *gin.Context) {
 			))
 			return
 		}
-		offset, err := strconv.Atoi(c.Query("offset"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
-				nil,
-				"Invalid offset value",
-				apperrors.ErrInvalidRequest,
-			))
+		offset, cursor, ok := readPagination(c)
+		if !ok {
 			return
 		}
 
@@ -59,11 +54,19 @@ func (h *httpController) listTotalProfiles() func(c *gin.Context) {
 			return
 		}
 
+		fields, ok := readFields(c, profileResponse(&models.Profile{}))
+		if !ok {
+			return
+		}
+
 		opts := models.QueryOptions{
 			Limit:      limit,
 			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
 			FilterExpr: filterExpr,
 			OrderExpr:  orderExpr,
+			Fields:     fields,
 		}
 		condition := make(map[string]any)
 		profiles, err := h.usecase.ListTotalProfiles(c.Request.Context(), condition, opts)
@@ -88,41 +91,10 @@ func (h *httpController) listTotalProfiles() func(c *gin.Context) {
 			return
 		}
 		responseBody := make([]map[string]any, 0, len(profiles))
-		for _, p := range profiles {
-			// Build parameters for each profile individually
-			var parameters []map[string]any
-			for _, pp := range p.ProfileParameters {
-				if pp.Parameter != nil {
-					parameters = append(parameters, map[string]any{
-						"id":   pp.Parameter.Id,
-						"path": pp.Parameter.Path,
-					})
-				}
-			}
-
-			resp := map[string]any{
-				"id":                     p.Id,
-				"name":                   p.Name,
-				"msg_type":               p.MsgType,
-				"return_commands":        p.ReturnCommands,
-				"return_events":          p.ReturnEvents,
-				"return_params":          p.ReturnParams,
-				"return_unique_key_sets": p.ReturnUniqueKeySets,
-				"allow_partial":          p.AllowPartial,
-				"send_resp":              p.SendResp,
-				"first_level_only":       p.FirstLevelOnly,
-				"max_depth":              p.MaxDepth,
-				"tags":                   p.Tags,
-				"status":                 p.Status,
-				"created_at":             utils.FormatTimeGMT7(p.CreatedAt, "02/01/2006 15:04:05"),
-				"updated_at":             utils.FormatTimeGMT7(p.UpdatedAt, "02/01/2006 15:04:05"),
-				"updated_by":             p.UpdatedBy,
-				"description":            p.Description,
-				"parameters":             parameters,
-			}
-			responseBody = append(responseBody, resp)
+		for i := range profiles {
+			responseBody = append(responseBody, selectFields(profileResponse(&profiles[i]), fields, nil))
 		}
-		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
 	}
 }
 
@@ -139,13 +111,8 @@ func (h *httpController) listTotalParameters() func(c *gin.Context) {
 			))
 			return
 		}
-		offset, err := strconv.Atoi(c.Query("offset"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
-				nil,
-				"Invalid offset value",
-				apperrors.ErrInvalidRequest,
-			))
+		offset, cursor, ok := readPagination(c)
+		if !ok {
 			return
 		}
 		filterExpr, err := utils.ParseFilterExpr(filterStr)
@@ -171,11 +138,19 @@ func (h *httpController) listTotalParameters() func(c *gin.Context) {
 			return
 		}
 
+		fields, ok := readFields(c, parameterResponse(&models.Parameter{}))
+		if !ok {
+			return
+		}
+
 		opts := models.QueryOptions{
 			Limit:      limit,
 			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
 			FilterExpr: filterExpr,
 			OrderExpr:  orderExpr,
+			Fields:     fields,
 		}
 		condition := make(map[string]any)
 		parameters, err := h.usecase.ListTotalParameters(
@@ -204,20 +179,10 @@ func (h *httpController) listTotalParameters() func(c *gin.Context) {
 			return
 		}
 		responseBody := make([]map[string]any, 0, len(parameters))
-		for _, p := range parameters {
-			resp := map[string]any{
-				"id":          p.Id,
-				"path":        p.Path,
-				"data_type":   p.DataType,
-				"description": p.Description,
-				"status":      p.Status,
-				"created_at":  utils.FormatTimeGMT7(p.CreatedAt, "02/01/2006 15:04:05"),
-				"updated_at":  utils.FormatTimeGMT7(p.UpdatedAt, "02/01/2006 15:04:05"),
-				"updated_by":  p.UpdatedBy,
-			}
-			responseBody = append(responseBody, resp)
+		for i := range parameters {
+			responseBody = append(responseBody, selectFields(parameterResponse(&parameters[i]), fields, nil))
 		}
-		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
 	}
 }
 
@@ -297,13 +262,8 @@ func (h *httpController) listTotalModels() func(c *gin.Context) {
 			))
 			return
 		}
-		offset, err := strconv.Atoi(c.Query("offset"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
-				nil,
-				"Invalid offset value",
-				apperrors.ErrInvalidRequest,
-			))
+		offset, cursor, ok := readPagination(c)
+		if !ok {
 			return
 		}
 		filterExpr, err := utils.ParseFilterExpr(filterStr)
@@ -329,11 +289,21 @@ func (h *httpController) listTotalModels() func(c *gin.Context) {
 			return
 		}
 
+		expand := readExpand(c)
+		fields, ok := readFields(c, modelResponse(&models.Model{}, nil))
+		if !ok {
+			return
+		}
+
 		opts := models.QueryOptions{
 			Limit:      limit,
 			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
 			FilterExpr: filterExpr,
 			OrderExpr:  orderExpr,
+			Expand:     expand,
+			Fields:     fields,
 		}
 		condition := make(map[string]any)
 		models, err := h.usecase.ListTotalModels(c.Request.Context(), condition, opts)
@@ -359,22 +329,10 @@ func (h *httpController) listTotalModels() func(c *gin.Context) {
 		}
 
 		responseBody := make([]map[string]any, 0, len(models))
-		for _, m := range models {
-			resp := map[string]any{
-				"id":           m.Id,
-				"name":         m.Name,
-				"vendor_name":  m.VendorName,
-				"manufacturer": m.Manufacturer,
-				"description":  m.Description,
-				"status":       m.Status,
-				"updated_by":   m.UpdatedBy,
-				"created_at":   utils.FormatTimeGMT7(m.CreatedAt, "02/01/2006 15:04:05"),
-				"updated_at":   utils.FormatTimeGMT7(m.UpdatedAt, "02/01/2006 15:04:05"),
-				"image":        m.Image,
-			}
-			responseBody = append(responseBody, resp)
+		for i := range models {
+			responseBody = append(responseBody, selectFields(modelResponse(&models[i], expand), fields, expand))
 		}
-		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
 	}
 }
 
@@ -444,13 +402,8 @@ func (h *httpController) listFirmwares() func(c *gin.Context) {
 			))
 			return
 		}
-		offset, err := strconv.Atoi(c.Query("offset"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
-				nil,
-				"Invalid offset value",
-				apperrors.ErrInvalidRequest,
-			))
+		offset, cursor, ok := readPagination(c)
+		if !ok {
 			return
 		}
 		filterExpr, err := utils.ParseFilterExpr(filterStr)
@@ -476,11 +429,21 @@ func (h *httpController) listFirmwares() func(c *gin.Context) {
 			return
 		}
 
+		expand := readExpand(c)
+		fields, ok := readFields(c, firmwareResponse(&models.Firmware{}, nil))
+		if !ok {
+			return
+		}
+
 		opts := models.QueryOptions{
 			Limit:      limit,
 			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
 			FilterExpr: filterExpr,
 			OrderExpr:  orderExpr,
+			Expand:     expand,
+			Fields:     fields,
 		}
 		condition := make(map[string]any)
 		condition["model_id"] = modelId
@@ -508,20 +471,10 @@ func (h *httpController) listFirmwares() func(c *gin.Context) {
 		}
 
 		responseBody := make([]map[string]any, 0, len(firmwares))
-		for _, fw := range firmwares {
-			resp := map[string]any{
-				"id":          fw.Id,
-				"name":        fw.Name,
-				"file_path":   fw.FilePath,
-				"description": fw.Description,
-				"status":      fw.Status,
-				"updated_by":  fw.UpdatedBy,
-				"created_at":  utils.FormatTimeGMT7(fw.CreatedAt, "02/01/2006 15:04:05"),
-				"updated_at":  utils.FormatTimeGMT7(fw.UpdatedAt, "02/01/2006 15:04:05"),
-			}
-			responseBody = append(responseBody, resp)
+		for i := range firmwares {
+			responseBody = append(responseBody, selectFields(firmwareResponse(&firmwares[i], expand), fields, expand))
 		}
-		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
 	}
 }
 
@@ -612,13 +565,8 @@ func (h *httpController) listTotalGroups() func(c *gin.Context) {
 			))
 			return
 		}
-		offset, err := strconv.Atoi(c.Query("offset"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
-				nil,
-				"Invalid offset value",
-				apperrors.ErrInvalidRequest,
-			))
+		offset, cursor, ok := readPagination(c)
+		if !ok {
 			return
 		}
 		filterExpr, err := utils.ParseFilterExpr(filterStr)
@@ -644,11 +592,21 @@ func (h *httpController) listTotalGroups() func(c *gin.Context) {
 			return
 		}
 
+		expand := readExpand(c, "firmware")
+		fields, ok := readFields(c, groupResponse(&models.Group{}, nil))
+		if !ok {
+			return
+		}
+
 		opts := models.QueryOptions{
 			Limit:      limit,
 			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
 			FilterExpr: filterExpr,
 			OrderExpr:  orderExpr,
+			Expand:     expand,
+			Fields:     fields,
 		}
 		condition := make(map[string]any)
 
@@ -675,29 +633,10 @@ func (h *httpController) listTotalGroups() func(c *gin.Context) {
 		}
 
 		responseBody := make([]map[string]any, 0, len(groups))
-		for _, group := range groups {
-			// Build firmware info
-			firmwareInfo := map[string]any{
-				"id": group.FirmwareId,
-			}
-			if group.Firmware != nil {
-				firmwareInfo["name"] = group.Firmware.Name
-			}
-
-			resp := map[string]any{
-				"id":              group.Id,
-				"firmware":        firmwareInfo,
-				"name":            group.Name,
-				"status":          group.Status,
-				"description":     group.Description,
-				"created_at":      utils.FormatTimeGMT7(group.CreatedAt, "02/01/2006 15:04:05"),
-				"updated_at":      utils.FormatTimeGMT7(group.UpdatedAt, "02/01/2006 15:04:05"),
-				"updated_by":      group.UpdatedBy,
-				"download_period": group.DownloadPeriod,
-			}
-			responseBody = append(responseBody, resp)
+		for i := range groups {
+			responseBody = append(responseBody, selectFields(groupResponse(&groups[i], expand), fields, expand))
 		}
-		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
 	}
 }
 
@@ -788,13 +727,8 @@ func (h *httpController) listTotalDevices() func(c *gin.Context) {
 			))
 			return
 		}
-		offset, err := strconv.Atoi(c.Query("offset"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
-				nil,
-				"Invalid offset value",
-				apperrors.ErrInvalidRequest,
-			))
+		offset, cursor, ok := readPagination(c)
+		if !ok {
 			return
 		}
 		filterExpr, err := utils.ParseFilterExpr(filterStr)
@@ -820,14 +754,28 @@ func (h *httpController) listTotalDevices() func(c *gin.Context) {
 			return
 		}
 
+		expand := readExpand(c, "group")
+		fields, ok := readFields(c, deviceResponse(&models.Device{}, nil))
+		if !ok {
+			return
+		}
+
 		opts := models.QueryOptions{
 			Limit:      limit,
 			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
 			FilterExpr: filterExpr,
 			OrderExpr:  orderExpr,
+			Expand:     expand,
+			Fields:     fields,
 		}
 		condition := make(map[string]any)
 		condition["model_id"] = modelId
+		// terminal lifecycle states are hidden unless asked for
+		if strings.EqualFold(c.Query("include_terminal"), "true") {
+			condition["lifecycle_state"] = models.DeviceLifecycleStates
+		}
 		devices, err := h.usecase.ListTotalDevices(c.Request.Context(), condition, opts)
 		if err != nil {
 			logging.Errorf("failed to list devices: %v", err)
@@ -851,35 +799,10 @@ func (h *httpController) listTotalDevices() func(c *gin.Context) {
 		}
 
 		responseBody := make([]map[string]any, 0, len(devices))
-		for _, device := range devices {
-			// Build group info
-			groupInfo := map[string]any{
-				"id": device.GroupId,
-			}
-			if device.Group != nil {
-				groupInfo["name"] = device.Group.Name
-			}
-			modelInfo := map[string]any{
-				"id": device.ModelId,
-			}
-			if device.Model != nil {
-				modelInfo["name"] = device.Model.Name
-			}
-			resp := map[string]any{
-				"id":          device.Id,
-				"mac_address": device.MacAddress,
-				"endpoint_id": device.EndpointId,
-				"status":      device.Status,
-				"model":       modelInfo,
-				"group":       groupInfo,
-				"created_at":  utils.FormatTimeGMT7(device.CreatedAt, "02/01/2006 15:04:05"),
-				"updated_at":  utils.FormatTimeGMT7(device.UpdatedAt, "02/01/2006 15:04:05"),
-				"updated_by":  device.UpdatedBy,
-				"description": device.Description,
-			}
-			responseBody = append(responseBody, resp)
+		for i := range devices {
+			responseBody = append(responseBody, selectFields(deviceResponse(&devices[i], expand), fields, expand))
 		}
-		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
 	}
 }
 
@@ -959,7 +882,12 @@ func (h *httpController) getModelWithModelId() func(c *gin.Context) {
 			)
 			return
 		}
-		model, err := h.usecase.FindModelWithModelId(c.Request.Context(), modelId)
+		expand := readExpand(c)
+		fields, ok := readFields(c, modelResponse(&models.Model{}, nil))
+		if !ok {
+			return
+		}
+		model, err := h.usecase.FindModelWithModelId(c.Request.Context(), modelId, expand...)
 		if err != nil {
 			logging.Errorf("failed to find model with model ID: %v", err)
 			if appErr, ok := err.(*apperrors.AppError); ok {
@@ -990,18 +918,7 @@ func (h *httpController) getModelWithModelId() func(c *gin.Context) {
 			)
 			return
 		}
-		responseBody := map[string]any{
-			"id":           model.Id,
-			"name":         model.Name,
-			"vendor_name":  model.VendorName,
-			"manufacturer": model.Manufacturer,
-			"description":  model.Description,
-			"status":       model.Status,
-			"updated_by":   model.UpdatedBy,
-			"created_at":   utils.FormatTimeGMT7(model.CreatedAt, "02/01/2006 15:04:05"),
-			"updated_at":   utils.FormatTimeGMT7(model.UpdatedAt, "02/01/2006 15:04:05"),
-			"image":        model.Image,
-		}
+		responseBody := selectFields(modelResponse(model, expand), fields, expand)
 		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
 	}
 }
@@ -1050,10 +967,15 @@ func (h *httpController) getFirmwareWithId() func(c *gin.Context) {
 			)
 			return
 		}
+		expand := readExpand(c)
+		fields, ok := readFields(c, firmwareResponse(&models.Firmware{}, nil))
+		if !ok {
+			return
+		}
 		condition := make(map[string]any)
 		condition["model_id"] = modelId
 		condition["id"] = firmwareId
-		firmware, err := h.usecase.GetFirmwareWithId(c.Request.Context(), condition)
+		firmware, err := h.usecase.GetFirmwareWithId(c.Request.Context(), condition, expand...)
 		if err != nil {
 			logging.Errorf("failed to find firmware with ID: %v", err)
 			if appErr, ok := err.(*apperrors.AppError); ok {
@@ -1074,16 +996,7 @@ func (h *httpController) getFirmwareWithId() func(c *gin.Context) {
 			)
 			return
 		}
-		responseBody := map[string]any{
-			"id":          firmware.Id,
-			"name":        firmware.Name,
-			"file_path":   firmware.FilePath,
-			"description": firmware.Description,
-			"status":      firmware.Status,
-			"updated_by":  firmware.UpdatedBy,
-			"created_at":  utils.FormatTimeGMT7(firmware.CreatedAt, "02/01/2006 15:04:05"),
-			"updated_at":  utils.FormatTimeGMT7(firmware.UpdatedAt, "02/01/2006 15:04:05"),
-		}
+		responseBody := selectFields(firmwareResponse(firmware, expand), fields, expand)
 		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
 	}
 }
@@ -1132,10 +1045,15 @@ func (h *httpController) getGroupWithGroupId() func(c *gin.Context) {
 			)
 			return
 		}
+		expand := readExpand(c, "firmware")
+		fields, ok := readFields(c, groupResponse(&models.Group{}, nil))
+		if !ok {
+			return
+		}
 		condition := make(map[string]any)
 		condition["model_id"] = modelId
 		condition["id"] = groupId
-		group, err := h.usecase.GetGroupWithId(c.Request.Context(), condition)
+		group, err := h.usecase.GetGroupWithId(c.Request.Context(), condition, expand...)
 		if err != nil {
 			logging.Errorf("failed to find group with group ID: %v", err)
 			if appErr, ok := err.(*apperrors.AppError); ok {
@@ -1156,23 +1074,7 @@ func (h *httpController) getGroupWithGroupId() func(c *gin.Context) {
 			)
 			return
 		}
-		firmwareInfo := map[string]any{
-			"id": group.FirmwareId,
-		}
-		if group.Firmware != nil {
-			firmwareInfo["name"] = group.Firmware.Name
-		}
-		responseBody := map[string]any{
-			"id":              group.Id,
-			"firmware":        firmwareInfo,
-			"name":            group.Name,
-			"status":          group.Status,
-			"description":     group.Description,
-			"created_at":      utils.FormatTimeGMT7(group.CreatedAt, "02/01/2006 15:04:05"),
-			"updated_at":      utils.FormatTimeGMT7(group.UpdatedAt, "02/01/2006 15:04:05"),
-			"updated_by":      group.UpdatedBy,
-			"download_period": group.DownloadPeriod,
-		}
+		responseBody := selectFields(groupResponse(group, expand), fields, expand)
 		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
 	}
 }
@@ -1221,10 +1123,15 @@ func (h *httpController) getDeviceWithId() func(c *gin.Context) {
 			)
 			return
 		}
+		expand := readExpand(c, "group", "model")
+		fields, ok := readFields(c, deviceResponse(&models.Device{}, nil))
+		if !ok {
+			return
+		}
 		condition := make(map[string]any)
 		condition["model_id"] = modelId
 		condition["id"] = deviceId
-		device, err := h.usecase.GetDeviceWithId(c.Request.Context(), condition)
+		device, err := h.usecase.GetDeviceWithId(c.Request.Context(), condition, expand...)
 		if err != nil {
 			logging.Errorf("failed to find device with ID: %v", err)
 			if appErr, ok := err.(*apperrors.AppError); ok {
@@ -1245,30 +1152,7 @@ func (h *httpController) getDeviceWithId() func(c *gin.Context) {
 			)
 			return
 		}
-		groupInfo := map[string]any{
-			"id": device.GroupId,
-		}
-		if device.Group != nil {
-			groupInfo["name"] = device.Group.Name
-		}
-		modelInfo := map[string]any{
-			"id": device.ModelId,
-		}
-		if device.Model != nil {
-			modelInfo["name"] = device.Model.Name
-		}
-		responseBody := map[string]any{
-			"id":          device.Id,
-			"mac_address": device.MacAddress,
-			"endpoint_id": device.EndpointId,
-			"status":      device.Status,
-			"model":       modelInfo,
-			"group":       groupInfo,
-			"created_at":  utils.FormatTimeGMT7(device.CreatedAt, "02/01/2006 15:04:05"),
-			"updated_at":  utils.FormatTimeGMT7(device.UpdatedAt, "02/01/2006 15:04:05"),
-			"updated_by":  device.UpdatedBy,
-			"description": device.Description,
-		}
+		responseBody := selectFields(deviceResponse(device, expand), fields, expand)
 		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
 	}
 }
@@ -1497,3 +1381,364 @@ func (h *httpController) listGroups() func(c *gin.Context) {
 		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
 	}
 }
+
+func webhookResponse(subscription models.WebhookSubscription) map[string]any {
+	return map[string]any{
+		"id":          subscription.Id,
+		"name":        subscription.Name,
+		"url":         subscription.Url,
+		"event_types": subscription.EventTypes,
+		"status":      subscription.Status,
+		"description": subscription.Description,
+		"created_at":  utils.FormatTimeGMT7(subscription.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":  utils.FormatTimeGMT7(subscription.UpdatedAt, "02/01/2006 15:04:05"),
+		"updated_by":  subscription.UpdatedBy,
+	}
+}
+
+func (h *httpController) listWebhookEventTypes() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(models.WebhookEventTypes, nil, nil))
+	}
+}
+
+func (h *httpController) listWebhooks() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		filterStr := c.Query("filter")
+		orderStr := c.Query("orderBy")
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid limit value",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+		offset, cursor, ok := readPagination(c)
+		if !ok {
+			return
+		}
+		filterExpr, err := utils.ParseFilterExpr(filterStr)
+		if err != nil {
+			logging.Errorf("invalid filter expression: %v", err)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid filter expression",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+
+		// Parse order expression
+		orderExpr, err := utils.ParseOrderExpr(orderStr)
+		if err != nil {
+			logging.Errorf("invalid order expression: %v", err)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid order expression",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+
+		opts := models.QueryOptions{
+			Limit:      limit,
+			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
+			FilterExpr: filterExpr,
+			OrderExpr:  orderExpr,
+		}
+		condition := make(map[string]any)
+
+		subscriptions, err := h.usecase.ListWebhookSubscriptions(c.Request.Context(), condition, opts)
+		if err != nil {
+			logging.Errorf("failed to list webhooks: %v", err)
+			if appErr, ok := err.(*apperrors.AppError); ok {
+				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
+					nil,
+					appErr.ErrorMessage(),
+					appErr.ErrorKey(),
+				))
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Failed to list webhooks",
+					apperrors.ErrInternal,
+				),
+			)
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			responseBody = append(responseBody, webhookResponse(subscription))
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
+	}
+}
+
+func (h *httpController) getWebhookWithId() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		webhookId := strings.TrimSpace(c.Param("webhook_id"))
+		if webhookId == "" || webhookId == ":webhook_id" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Webhook ID cannot be left blank.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if _, err := uuid.Parse(webhookId); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid Webhook ID format.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		subscription, err := h.usecase.GetWebhookSubscriptionWithId(c.Request.Context(), webhookId)
+		if err != nil {
+			logging.Errorf("failed to find webhook with webhook ID: %v", err)
+			if appErr, ok := err.(*apperrors.AppError); ok {
+				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
+					nil,
+					appErr.ErrorMessage(),
+					appErr.ErrorKey(),
+				))
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Failed to find webhook with webhook ID",
+					apperrors.ErrInternal,
+				),
+			)
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(webhookResponse(*subscription), nil, nil))
+	}
+}
+
+func (h *httpController) listWebhookDeliveries() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		filterStr := c.Query("filter")
+		orderStr := c.Query("orderBy")
+		webhookId := strings.TrimSpace(c.Param("webhook_id"))
+		if webhookId == "" || webhookId == ":webhook_id" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Webhook ID cannot be left blank.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if _, err := uuid.Parse(webhookId); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid Webhook ID format.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid limit value",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+		offset, cursor, ok := readPagination(c)
+		if !ok {
+			return
+		}
+		filterExpr, err := utils.ParseFilterExpr(filterStr)
+		if err != nil {
+			logging.Errorf("invalid filter expression: %v", err)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid filter expression",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+
+		// Parse order expression
+		orderExpr, err := utils.ParseOrderExpr(orderStr)
+		if err != nil {
+			logging.Errorf("invalid order expression: %v", err)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid order expression",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+
+		opts := models.QueryOptions{
+			Limit:      limit,
+			Offset:     offset,
+			Cursor:     cursor,
+			Page:       newPageInfo(cursor),
+			FilterExpr: filterExpr,
+			OrderExpr:  orderExpr,
+		}
+		condition := make(map[string]any)
+		if status := strings.TrimSpace(c.Query("status")); status != "" {
+			condition["status"] = strings.ToUpper(status)
+		}
+		if eventType := strings.TrimSpace(c.Query("event_type")); eventType != "" {
+			condition["event_type"] = eventType
+		}
+
+		deliveries, err := h.usecase.ListWebhookDeliveries(c.Request.Context(), webhookId, condition, opts)
+		if err != nil {
+			logging.Errorf("failed to list webhook deliveries: %v", err)
+			if appErr, ok := err.(*apperrors.AppError); ok {
+				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
+					nil,
+					appErr.ErrorMessage(),
+					appErr.ErrorKey(),
+				))
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Failed to list webhook deliveries",
+					apperrors.ErrInternal,
+				),
+			)
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			resp := map[string]any{
+				"id":              delivery.Id,
+				"event_id":        delivery.EventId,
+				"event_type":      delivery.EventType,
+				"status":          delivery.Status,
+				"attempts":        delivery.Attempts,
+				"response_code":   delivery.ResponseCode,
+				"last_error":      delivery.LastError,
+				"next_attempt_at": utils.FormatTimeGMT7(delivery.NextAttemptAt, "02/01/2006 15:04:05"),
+				"delivered_at":    utils.FormatTimeGMT7(delivery.DeliveredAt, "02/01/2006 15:04:05"),
+				"created_at":      utils.FormatTimeGMT7(delivery.CreatedAt, "02/01/2006 15:04:05"),
+				"updated_at":      utils.FormatTimeGMT7(delivery.UpdatedAt, "02/01/2006 15:04:05"),
+			}
+			responseBody = append(responseBody, resp)
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, pagingResponse(opts.Page), nil))
+	}
+}
+
+func (h *httpController) lookupDevices() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		lookup := models.DeviceLookup{
+			SerialNumber: strings.TrimSpace(c.Query("serial_number")),
+			EndpointId:   strings.TrimSpace(c.Query("endpoint_id")),
+			MacAddress:   strings.TrimSpace(c.Query("mac_address")),
+			Manufacturer: strings.TrimSpace(c.Query("manufacturer")),
+			ProductClass: strings.TrimSpace(c.Query("product_class")),
+		}
+		devices, err := h.usecase.LookupDevices(c.Request.Context(), lookup)
+		if err != nil {
+			logging.Errorf("failed to lookup devices: %v", err)
+			writeUsecaseError(c, err, "Failed to lookup devices")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(devices))
+		for _, device := range devices {
+			groupInfo := map[string]any{
+				"id": device.GroupId,
+			}
+			if device.Group != nil {
+				groupInfo["name"] = device.Group.Name
+			}
+			modelInfo := map[string]any{
+				"id": device.ModelId,
+			}
+			if device.Model != nil {
+				modelInfo["name"] = device.Model.Name
+				modelInfo["manufacturer"] = device.Model.Manufacturer
+			}
+			responseBody = append(responseBody, map[string]any{
+				"id":          device.Id,
+				"mac_address": device.MacAddress,
+				"endpoint_id": device.EndpointId,
+				"status":      device.Status,
+				"model":       modelInfo,
+				"group":       groupInfo,
+				"created_at":  utils.FormatTimeGMT7(device.CreatedAt, "02/01/2006 15:04:05"),
+				"updated_at":  utils.FormatTimeGMT7(device.UpdatedAt, "02/01/2006 15:04:05"),
+				"updated_by":  device.UpdatedBy,
+				"description": device.Description,
+
+				"serial_number":     device.SerialNumber,
+				"product_class":     device.ProductClass,
+				"hardware_revision": device.HardwareRevision,
+				"lifecycle_state":   device.LifecycleState,
+			})
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+// readPagination reads offset and cursor of a list request, the presence of cursor switches
+// the list to keyset pagination and an empty cursor reads the first page
+func readPagination(c *gin.Context) (int, *string, bool) {
+	cursor, hasCursor := c.GetQuery("cursor")
+	offsetStr, hasOffset := c.GetQuery("offset")
+	offset := 0
+	if hasOffset || !hasCursor {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid offset value",
+				apperrors.ErrInvalidRequest,
+			))
+			return 0, nil, false
+		}
+	}
+	if !hasCursor {
+		return offset, nil, true
+	}
+	return offset, &cursor, true
+}
+
+// newPageInfo holds the cursors returned by a keyset list, nil in offset mode
+func newPageInfo(cursor *string) *models.PageInfo {
+	if cursor == nil {
+		return nil
+	}
+	return &models.PageInfo{}
+}
+
+// pagingResponse keeps paging out of the response in offset mode
+func pagingResponse(page *models.PageInfo) any {
+	if page == nil {
+		return nil
+	}
+	return page
+}
@@ -0,0 +1,267 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	utils "usp-management-device-api/common/utils"
+)
+
+// expandSet holds the relations asked by the expand query, "group.firmware" expands group then its firmware.
+type expandSet []string
+
+// has reports whether the relation or one of its nested relations is expanded
+func (e expandSet) has(name string) bool {
+	for _, relation := range e {
+		if relation == name || strings.HasPrefix(relation, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// sub returns the relations expanded under name
+func (e expandSet) sub(name string) expandSet {
+	var nested expandSet
+	for _, relation := range e {
+		if strings.HasPrefix(relation, name+".") {
+			nested = append(nested, strings.TrimPrefix(relation, name+"."))
+		}
+	}
+	return nested
+}
+
+// top returns the relations of the resource itself, without the nested ones
+func (e expandSet) top() []string {
+	var relations []string
+	for _, relation := range e {
+		relations = append(relations, strings.SplitN(relation, ".", 2)[0])
+	}
+	return relations
+}
+
+// readExpand reads the comma separated expand query, defaults apply when the query is absent
+// so the responses keep their relations, an empty expand loads none
+func readExpand(c *gin.Context, defaults ...string) expandSet {
+	raw, ok := c.GetQuery("expand")
+	if !ok {
+		return defaults
+	}
+	var expand expandSet
+	for _, relation := range strings.Split(raw, ",") {
+		if relation = strings.ToLower(strings.TrimSpace(relation)); relation != "" {
+			expand = append(expand, relation)
+		}
+	}
+	return expand
+}
+
+// readFields reads the comma separated fields query and checks it against the attributes of
+// sample, the response of the resource; nil means every attribute
+func readFields(c *gin.Context, sample map[string]any) ([]string, bool) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, true
+	}
+	return checkFields(c, strings.Split(raw, ","), sample)
+}
+
+// checkFields normalizes the asked attributes and writes a 400 listing the allowed ones
+// when one of them is not an attribute of sample
+func checkFields(c *gin.Context, names []string, sample map[string]any) ([]string, bool) {
+	var fields []string
+	for _, field := range names {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if _, ok := sample[field]; !ok {
+			allowed := make([]string, 0, len(sample))
+			for name := range sample {
+				allowed = append(allowed, name)
+			}
+			sort.Strings(allowed)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid field: "+field+", allowed fields: "+strings.Join(allowed, ", "),
+				apperrors.ErrInvalidRequest,
+			))
+			return nil, false
+		}
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// selectFields keeps the asked attributes of resp, id and the expanded relations are always kept
+func selectFields(resp map[string]any, fields []string, expand expandSet) map[string]any {
+	if fields == nil {
+		return resp
+	}
+	selected := map[string]any{"id": resp["id"]}
+	for _, field := range append(fields, expand.top()...) {
+		if value, ok := resp[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
+
+func profileResponse(p *models.Profile) map[string]any {
+	var parameters []map[string]any
+	for _, pp := range p.ProfileParameters {
+		if pp.Parameter != nil {
+			parameters = append(parameters, map[string]any{
+				"id":   pp.Parameter.Id,
+				"path": pp.Parameter.Path,
+			})
+		}
+	}
+	return map[string]any{
+		"id":                     p.Id,
+		"name":                   p.Name,
+		"msg_type":               p.MsgType,
+		"return_commands":        p.ReturnCommands,
+		"return_events":          p.ReturnEvents,
+		"return_params":          p.ReturnParams,
+		"return_unique_key_sets": p.ReturnUniqueKeySets,
+		"allow_partial":          p.AllowPartial,
+		"send_resp":              p.SendResp,
+		"first_level_only":       p.FirstLevelOnly,
+		"max_depth":              p.MaxDepth,
+		"tags":                   p.Tags,
+		"status":                 p.Status,
+		"created_at":             utils.FormatTimeGMT7(p.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":             utils.FormatTimeGMT7(p.UpdatedAt, "02/01/2006 15:04:05"),
+		"updated_by":             p.UpdatedBy,
+		"description":            p.Description,
+		"parameters":             parameters,
+	}
+}
+
+func parameterResponse(p *models.Parameter) map[string]any {
+	return map[string]any{
+		"id":          p.Id,
+		"path":        p.Path,
+		"data_type":   p.DataType,
+		"description": p.Description,
+		"status":      p.Status,
+		"created_at":  utils.FormatTimeGMT7(p.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":  utils.FormatTimeGMT7(p.UpdatedAt, "02/01/2006 15:04:05"),
+		"updated_by":  p.UpdatedBy,
+	}
+}
+
+func modelResponse(model *models.Model, expand expandSet) map[string]any {
+	resp := map[string]any{
+		"id":           model.Id,
+		"name":         model.Name,
+		"vendor_name":  model.VendorName,
+		"manufacturer": model.Manufacturer,
+		"description":  model.Description,
+		"status":       model.Status,
+		"updated_by":   model.UpdatedBy,
+		"created_at":   utils.FormatTimeGMT7(model.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":   utils.FormatTimeGMT7(model.UpdatedAt, "02/01/2006 15:04:05"),
+		"image":        model.Image,
+
+		"endpoint_id_template": model.GetEndpointIdTemplate(),
+	}
+	// deleted firmwares and groups stay hidden as in their lists
+	if expand.has("firmwares") {
+		firmwares := make([]map[string]any, 0, len(model.Firmwares))
+		for i := range model.Firmwares {
+			if model.Firmwares[i].Status != "DELETE" {
+				firmwares = append(firmwares, firmwareResponse(&model.Firmwares[i], expand.sub("firmwares")))
+			}
+		}
+		resp["firmwares"] = firmwares
+	}
+	if expand.has("groups") {
+		groups := make([]map[string]any, 0, len(model.Groups))
+		for i := range model.Groups {
+			if model.Groups[i].Status != "DELETE" {
+				groups = append(groups, groupResponse(&model.Groups[i], expand.sub("groups")))
+			}
+		}
+		resp["groups"] = groups
+	}
+	return resp
+}
+
+func firmwareResponse(firmware *models.Firmware, expand expandSet) map[string]any {
+	resp := map[string]any{
+		"id":          firmware.Id,
+		"name":        firmware.Name,
+		"file_path":   firmware.FilePath,
+		"description": firmware.Description,
+		"status":      firmware.Status,
+		"updated_by":  firmware.UpdatedBy,
+		"created_at":  utils.FormatTimeGMT7(firmware.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":  utils.FormatTimeGMT7(firmware.UpdatedAt, "02/01/2006 15:04:05"),
+	}
+	if expand.has("model") {
+		resp["model"] = map[string]any{"id": firmware.ModelId}
+		if firmware.Model != nil {
+			resp["model"] = modelResponse(firmware.Model, expand.sub("model"))
+		}
+	}
+	return resp
+}
+
+func groupResponse(group *models.Group, expand expandSet) map[string]any {
+	resp := map[string]any{
+		"id":              group.Id,
+		"firmware":        map[string]any{"id": group.FirmwareId},
+		"name":            group.Name,
+		"status":          group.Status,
+		"description":     group.Description,
+		"created_at":      utils.FormatTimeGMT7(group.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":      utils.FormatTimeGMT7(group.UpdatedAt, "02/01/2006 15:04:05"),
+		"updated_by":      group.UpdatedBy,
+		"download_period": group.DownloadPeriod,
+	}
+	if expand.has("firmware") && group.Firmware != nil {
+		resp["firmware"] = firmwareResponse(group.Firmware, expand.sub("firmware"))
+	}
+	if expand.has("model") {
+		resp["model"] = map[string]any{"id": group.ModelId}
+		if group.Model != nil {
+			resp["model"] = modelResponse(group.Model, expand.sub("model"))
+		}
+	}
+	return resp
+}
+
+func deviceResponse(device *models.Device, expand expandSet) map[string]any {
+	resp := map[string]any{
+		"id":          device.Id,
+		"mac_address": device.MacAddress,
+		"endpoint_id": device.EndpointId,
+		"status":      device.Status,
+		"model":       map[string]any{"id": device.ModelId},
+		"group":       map[string]any{"id": device.GroupId},
+		"created_at":  utils.FormatTimeGMT7(device.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":  utils.FormatTimeGMT7(device.UpdatedAt, "02/01/2006 15:04:05"),
+		"updated_by":  device.UpdatedBy,
+		"description": device.Description,
+		"labels":      device.Labels,
+
+		"serial_number":     device.SerialNumber,
+		"product_class":     device.ProductClass,
+		"hardware_revision": device.HardwareRevision,
+		"lifecycle_state":   device.LifecycleState,
+		"replaced_by_id":    device.ReplacedById,
+	}
+	if expand.has("model") && device.Model != nil {
+		resp["model"] = modelResponse(device.Model, expand.sub("model"))
+	}
+	if expand.has("group") && device.Group != nil {
+		resp["group"] = groupResponse(device.Group, expand.sub("group"))
+	}
+	return resp
+}
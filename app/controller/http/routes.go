@@ -71,21 +71,23 @@ func (h *httpController) SetupRoute(
 
 	profiles := router.Group("/profiles")
 	{
-		profiles.GET("", h.listTotalProfiles())
+		profiles.GET("", h.withView("profiles"), h.listTotalProfiles())
 		profiles.GET("/count", h.countProfilesByStatus())
 		profiles.POST("/", h.createProfileWithParameterId())
 		profiles.POST("/import-csv", h.createProfilesWithBatch())
-		profiles.GET("/export-csv", h.exportProfilesCSV())
+		profiles.GET("/export", h.exportProfiles())
+		profiles.GET("/export-csv", h.exportProfiles())
 		profiles.PUT("/:profile_id", h.updateProfileWithParametersId())
 		profiles.DELETE("/:profile_id", h.deleteProfileWithId())
 	}
 
 	parameters := router.Group("/parameters")
 	{
-		parameters.GET("", h.listTotalParameters())
+		parameters.GET("", h.withView("parameters"), h.listTotalParameters())
 		parameters.GET("/combobox", h.listParameters())
 		parameters.GET("/count", h.countParametersByStatus())
-		parameters.GET("/export-csv", h.exportParameterCSV())
+		parameters.GET("/export", h.exportParameters())
+		parameters.GET("/export-csv", h.exportParameters())
 		parameters.POST("/", h.createNewParameter())
 		parameters.POST("/import-csv", h.createParametersFromCSVFile())
 		parameters.PUT("/:parameter_id", h.updateParameterWithId())
@@ -95,39 +97,108 @@ func (h *httpController) SetupRoute(
 	models := router.Group("/models")
 	{
 		// ----- Models -----
-		models.GET("", h.listTotalModels())
+		models.GET("", h.withView("models"), h.listTotalModels())
 		models.GET("/:model_id", h.getModelWithModelId())
 		models.POST("", h.createModels())
 		models.GET("/count", h.countModelsByStatus())
+		models.GET("/export", h.exportModels())
+		models.POST("/import-csv", h.importModels())
 		models.PUT("/:model_id", h.updateModelWithModelId())
 		models.DELETE("/:model_id", h.deleteModelWithId())
 
 		// ----- Groups -----
-		models.GET("/:model_id/groups", h.listTotalGroups())
+		models.GET("/:model_id/groups", h.withView("groups"), h.listTotalGroups())
 		models.GET("/:model_id/groups/combobox", h.listGroups())
 		models.GET("/:model_id/groups/:group_id", h.getGroupWithGroupId())
 		models.POST("/:model_id/groups", h.createGroup())
 		models.PUT("/:model_id/groups/:group_id", h.updateGroupWithId())
 		models.DELETE("/:model_id/groups/:group_id", h.deleteGroupWithId())
 		models.GET("/:model_id/groups/count", h.countGroupsByStatus())
+		models.GET("/:model_id/groups/export", h.exportGroups())
+		models.POST("/:model_id/groups/import-csv", h.importGroups())
 
 		// ----- Firmwares -----
-		models.GET("/:model_id/firmwares", h.listFirmwares())
+		models.GET("/:model_id/firmwares", h.withView("firmwares"), h.listFirmwares())
 		models.GET("/:model_id/firmwares/combobox", h.listTotalFirmwares())
 		models.GET("/:model_id/firmwares/:firmware_id", h.getFirmwareWithId())
 		models.POST("/:model_id/firmwares", h.createFirmware())
 		models.PUT("/:model_id/firmwares/:firmware_id", h.updateFirmwareWithId())
 		models.DELETE("/:model_id/firmwares/:firmware_id", h.deleteFirmwareWithId())
 		models.GET("/:model_id/firmwares/count", h.countFirmwaresByStatus())
+		models.GET("/:model_id/firmwares/export", h.exportFirmwares())
+		models.POST("/:model_id/firmwares/import-csv", h.importFirmwares())
 
 		// ----- Devices -----
-		models.GET("/:model_id/devices", h.listTotalDevices())
+		models.GET("/:model_id/devices", h.withView("devices"), h.listTotalDevices())
 		models.GET("/:model_id/groups/:group_id/devices/count", h.totalDevicesWithGroupId())
 		models.GET("/:model_id/devices/:device_id", h.getDeviceWithId())
 		models.GET("/:model_id/devices/count", h.countDevicesByStatus())
+		models.GET("/:model_id/devices/count/lifecycle", h.countDevicesByLifecycleState())
+		models.GET("/:model_id/devices/export", h.exportDevices())
+		models.GET("/:model_id/devices/:device_id/lifecycle", h.listDeviceLifecycle())
+		models.POST("/:model_id/devices/:device_id/replace", h.replaceDevice())
+		models.GET("/:model_id/devices/:device_id/lineage", h.getDeviceLineage())
+		models.POST("/:model_id/devices/:device_id/reassign", h.reassignDevice())
 		models.POST("/:model_id/groups/:group_id/devices", h.createDevice())
 		models.POST("/:model_id/groups/:group_id/devices/import-csv", h.createDevicesWithBatch())
 		models.PUT("/:model_id/devices/:device_id", h.updateDeviceWithId())
 		models.DELETE("/:model_id/devices/:device_id", h.deleteDeviceWithId())
+
+		// ----- Profile assignments -----
+		models.GET("/:model_id/groups/:group_id/profiles", h.listGroupProfiles())
+		models.POST("/:model_id/groups/:group_id/profiles", h.assignGroupProfile())
+		models.DELETE("/:model_id/groups/:group_id/profiles/:profile_id", h.unassignGroupProfile())
+		models.GET("/:model_id/devices/:device_id/profiles", h.listDeviceProfiles())
+		models.POST("/:model_id/devices/:device_id/profiles", h.assignDeviceProfile())
+		models.DELETE("/:model_id/devices/:device_id/profiles/:profile_id", h.unassignDeviceProfile())
+
+		// ----- Configuration drift -----
+		models.GET("/:model_id/devices/:device_id/drift", h.getDeviceDrift())
+		models.GET("/:model_id/groups/:group_id/drift", h.getGroupDrift())
+		models.GET("/:model_id/drift/parameters", h.getModelDriftSummary())
+
+		// ----- Reported parameter values -----
+		models.GET("/:model_id/devices/:device_id/parameters", h.listDeviceParameterValues())
+		models.POST("/:model_id/devices/:device_id/parameters", h.ingestDeviceParameterValues())
+	}
+
+	devices := router.Group("/devices")
+	{
+		// resolve devices across models by serial_number, endpoint_id or mac_address
+		devices.GET("/lookup", h.lookupDevices())
+	}
+
+	webhooks := router.Group("/webhooks")
+	{
+		webhooks.GET("", h.withView("webhooks"), h.listWebhooks())
+		webhooks.GET("/event-types", h.listWebhookEventTypes())
+		webhooks.POST("", h.createWebhook())
+		webhooks.GET("/:webhook_id", h.getWebhookWithId())
+		webhooks.PUT("/:webhook_id", h.updateWebhookWithId())
+		webhooks.DELETE("/:webhook_id", h.deleteWebhookWithId())
+		webhooks.GET("/:webhook_id/deliveries", h.listWebhookDeliveries())
+	}
+
+	// search every resource by MAC, endpoint id, serial, name or parameter path
+	router.GET("/search", h.search())
+
+	// counts grouped by dimensions for dashboards: /stats/devices?groupBy=status,model_id
+	router.GET("/stats/:resource", h.getStats())
+
+	// saved filter, orderBy, fields and page size of a list, run with ?view=<id> on the list
+	views := router.Group("/views")
+	{
+		views.GET("", h.listSavedViews())
+		views.POST("", h.createSavedView())
+		views.GET("/:view_id", h.getSavedViewWithId())
+		views.PUT("/:view_id", h.updateSavedViewWithId())
+		views.DELETE("/:view_id", h.deleteSavedViewWithId())
+	}
+
+	jobs := router.Group("/jobs")
+	{
+		// CSV imports submitted with async=true
+		jobs.GET("/:job_id", h.getImportJob())
+		jobs.POST("/:job_id/cancel", h.cancelImportJob())
 	}
 }
@@ -0,0 +1,316 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"strings"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+)
+
+type createSavedViewRequest struct {
+	Name        string   `json:"name" validate:"required,max=255"`
+	Resource    string   `json:"resource" validate:"required"`
+	Shared      bool     `json:"shared"`
+	Team        string   `json:"team" validate:"omitempty,max=255"`
+	Filter      string   `json:"filter" validate:"omitempty,max=4096"`
+	OrderBy     string   `json:"order_by" validate:"omitempty,max=255"`
+	Fields      []string `json:"fields" validate:"omitempty,dive,required"`
+	PageSize    int      `json:"page_size" validate:"omitempty,min=1,max=100"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+}
+
+type updateSavedViewRequest struct {
+	Name        *string  `json:"name" validate:"omitempty,min=1,max=255"`
+	Shared      *bool    `json:"shared"`
+	Team        *string  `json:"team" validate:"omitempty,max=255"`
+	Filter      *string  `json:"filter" validate:"omitempty,max=4096"`
+	OrderBy     *string  `json:"order_by" validate:"omitempty,max=255"`
+	Fields      []string `json:"fields" validate:"omitempty,dive,required"`
+	PageSize    *int     `json:"page_size" validate:"omitempty,min=1,max=100"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+}
+
+func savedViewResponse(view *models.SavedView) map[string]any {
+	return map[string]any{
+		"id":          view.Id,
+		"name":        view.Name,
+		"resource":    view.Resource,
+		"owner":       view.Owner,
+		"shared":      view.Shared,
+		"team":        view.Team,
+		"filter":      view.Filter,
+		"order_by":    view.OrderBy,
+		"fields":      view.Fields,
+		"page_size":   view.PageSize,
+		"description": view.Description,
+		"created_at":  utils.FormatTimeGMT7(view.CreatedAt, "02/01/2006 15:04:05"),
+		"updated_at":  utils.FormatTimeGMT7(view.UpdatedAt, "02/01/2006 15:04:05"),
+		"updated_by":  view.UpdatedBy,
+	}
+}
+
+// bindViewRequest decodes and validates the body of a view request
+func bindViewRequest(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest,
+			httphelper.NewErrorHTTPResponse(
+				nil,
+				err.Error(),
+				apperrors.ErrInvalidRequest,
+			),
+		)
+		return false
+	}
+
+	// validate payload
+	if err := Validate.Struct(req); err != nil {
+		var invalidFields []invalidField
+		validationErrors := err.(validator.ValidationErrors)
+		for _, fieldError := range validationErrors {
+			invalidFields = append(invalidFields, generateInvalidFieldError(fieldError))
+		}
+
+		c.JSON(http.StatusBadRequest,
+			httphelper.NewErrorHTTPResponse(
+				invalidFields,
+				"Invalid request fields",
+				apperrors.ErrInvalidRequest,
+			),
+		)
+		return false
+	}
+	return true
+}
+
+// viewUser returns the User-Name of the request, views belong to it
+func viewUser(c *gin.Context) (string, bool) {
+	user := c.GetHeader("User-Name")
+	if user == "" {
+		c.JSON(http.StatusBadRequest,
+			httphelper.NewErrorHTTPResponse(
+				nil,
+				"User-Name header is required",
+				apperrors.ErrInvalidRequest,
+			),
+		)
+		return "", false
+	}
+	return user, true
+}
+
+// viewTeams returns the consumer groups the gateway sets for the caller, views shared with one
+// of them are visible to the caller
+func viewTeams(c *gin.Context) []string {
+	var teams []string
+	for _, team := range strings.Split(c.GetHeader("X-Consumer-Groups"), ",") {
+		if team = strings.TrimSpace(team); team != "" {
+			teams = append(teams, team)
+		}
+	}
+	return teams
+}
+
+// viewFields normalizes the fields of a view as the fields query, they are checked against the
+// response of its resource when the view is saved
+func viewFields(fields []string) []string {
+	normalized := []string{}
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			normalized = append(normalized, field)
+		}
+	}
+	return normalized
+}
+
+func (h *httpController) createSavedView() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req createSavedViewRequest
+		if !bindViewRequest(c, &req) {
+			return
+		}
+		user, ok := viewUser(c)
+		if !ok {
+			return
+		}
+
+		view := models.SavedView{
+			Name:        req.Name,
+			Resource:    strings.ToLower(strings.TrimSpace(req.Resource)),
+			Owner:       user,
+			Shared:      req.Shared,
+			Team:        strings.TrimSpace(req.Team),
+			Filter:      strings.TrimSpace(req.Filter),
+			OrderBy:     strings.TrimSpace(req.OrderBy),
+			Fields:      viewFields(req.Fields),
+			PageSize:    req.PageSize,
+			Description: req.Description,
+			Status:      "ENABLE",
+			UpdatedBy:   user,
+		}
+		viewId, err := h.usecase.CreateSavedView(c.Request.Context(), &view, viewTeams(c))
+		if err != nil {
+			logging.Errorf("failed to create view: %v", err)
+			writeUsecaseError(c, err, "Failed to create view")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(map[string]string{"id": viewId}, nil, nil))
+	}
+}
+
+func (h *httpController) updateSavedViewWithId() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		viewId, ok := uuidParam(c, "view_id", "View ID")
+		if !ok {
+			return
+		}
+		var req updateSavedViewRequest
+		if !bindViewRequest(c, &req) {
+			return
+		}
+		user, ok := viewUser(c)
+		if !ok {
+			return
+		}
+
+		view := models.NewSavedViewUpdate()
+		view.Name = req.Name
+		view.Shared = req.Shared
+		if req.Team != nil {
+			team := strings.TrimSpace(*req.Team)
+			view.Team = &team
+		}
+		view.OrderBy = req.OrderBy
+		view.PageSize = req.PageSize
+		view.Description = req.Description
+		view.UpdatedBy = &user
+		if req.Filter != nil {
+			filter := strings.TrimSpace(*req.Filter)
+			view.Filter = &filter
+		}
+		// fields: [] clears the selection
+		if req.Fields != nil {
+			view.Fields = viewFields(req.Fields)
+		}
+		if err := h.usecase.UpdateSavedViewWithId(c.Request.Context(), viewId, user, viewTeams(c), view); err != nil {
+			logging.Errorf("failed to update view: %v", err)
+			writeUsecaseError(c, err, "Failed to update view")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(map[string]string{"id": viewId}, nil, nil))
+	}
+}
+
+func (h *httpController) deleteSavedViewWithId() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		viewId, ok := uuidParam(c, "view_id", "View ID")
+		if !ok {
+			return
+		}
+		user, ok := viewUser(c)
+		if !ok {
+			return
+		}
+		if err := h.usecase.DeleteSavedViewWithId(c.Request.Context(), viewId, user, viewTeams(c)); err != nil {
+			logging.Errorf("failed to delete view: %v", err)
+			writeUsecaseError(c, err, "Failed to delete view")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(map[string]string{"id": viewId}, nil, nil))
+	}
+}
+
+func (h *httpController) getSavedViewWithId() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		viewId, ok := uuidParam(c, "view_id", "View ID")
+		if !ok {
+			return
+		}
+		view, err := h.usecase.GetSavedViewWithId(c.Request.Context(), viewId, c.GetHeader("User-Name"), viewTeams(c))
+		if err != nil {
+			logging.Errorf("failed to find view with id=%s: %v", viewId, err)
+			writeUsecaseError(c, err, "Failed to find view")
+			return
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(savedViewResponse(view), nil, nil))
+	}
+}
+
+// listSavedViews lists the views of ?resource= owned by the user, shared with their teams or with everyone
+func (h *httpController) listSavedViews() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		resource := strings.ToLower(strings.TrimSpace(c.Query("resource")))
+		if resource == "" {
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"resource is required",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+		views, err := h.usecase.ListSavedViews(c.Request.Context(), resource, c.GetHeader("User-Name"), viewTeams(c))
+		if err != nil {
+			logging.Errorf("failed to list views: %v", err)
+			writeUsecaseError(c, err, "Failed to list views")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(views))
+		for i := range views {
+			responseBody = append(responseBody, savedViewResponse(&views[i]))
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
+
+// withView runs the list with the saved view of ?view=<id>: its filter, orderBy, limit and
+// fields fill the query parameters the request does not give, an explicit parameter wins.
+// The query is rewritten before the handler reads it so the handler checks the values as usual.
+func (h *httpController) withView(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// read the raw query, c.Query would cache it before the rewrite
+		query := c.Request.URL.Query()
+		viewId := strings.TrimSpace(query.Get("view"))
+		if viewId == "" {
+			return
+		}
+		if _, err := uuid.Parse(viewId); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid View ID format.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		view, err := h.usecase.LoadSavedView(c.Request.Context(), viewId, c.GetHeader("User-Name"), viewTeams(c), resource)
+		if err != nil {
+			logging.Errorf("failed to load view with id=%s: %v", viewId, err)
+			writeUsecaseError(c, err, "Failed to load view")
+			c.Abort()
+			return
+		}
+
+		setDefault := func(key string, value string) {
+			if value != "" && !query.Has(key) {
+				query.Set(key, value)
+			}
+		}
+		setDefault("filter", view.Filter)
+		setDefault("orderBy", view.OrderBy)
+		setDefault("fields", strings.Join(view.Fields, ","))
+		if view.PageSize > 0 {
+			setDefault("limit", strconv.Itoa(view.PageSize))
+		}
+		query.Del("view")
+		c.Request.URL.RawQuery = query.Encode()
+	}
+}
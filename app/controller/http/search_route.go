@@ -0,0 +1,57 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+)
+
+// search looks for q in every resource, types=device,model restricts the kinds of hit
+func (h *httpController) search() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		limit := 0
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			var err error
+			if limit, err = strconv.Atoi(raw); err != nil {
+				c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid limit value",
+					apperrors.ErrInvalidRequest,
+				))
+				return
+			}
+		}
+
+		var types []string
+		for _, t := range strings.Split(c.Query("types"), ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+
+		hits, err := h.usecase.Search(c.Request.Context(), c.Query("q"), types, limit)
+		if err != nil {
+			logging.Errorf("failed to search: %v", err)
+			writeUsecaseError(c, err, "Failed to search")
+			return
+		}
+
+		responseBody := make([]map[string]any, 0, len(hits))
+		for _, hit := range hits {
+			responseBody = append(responseBody, map[string]any{
+				"type":  hit.Type,
+				"id":    hit.Id,
+				"title": hit.Title,
+				"field": hit.Field,
+				"value": hit.Value,
+				"score": hit.Score,
+				"link":  hit.Link(),
+			})
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(responseBody, nil, nil))
+	}
+}
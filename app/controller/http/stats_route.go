@@ -0,0 +1,60 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	apperrors "usp-management-device-api/common/app_errors"
+	httphelper "usp-management-device-api/common/http_helper"
+	"usp-management-device-api/common/logging"
+	utils "usp-management-device-api/common/utils"
+)
+
+// getStats counts the rows of a resource grouped by the comma separated groupBy dimensions,
+// filter has the syntax of the lists.
+func (h *httpController) getStats() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		resource := strings.TrimSpace(c.Param("resource"))
+
+		var groupBy []string
+		for _, dimension := range strings.Split(c.Query("groupBy"), ",") {
+			if dimension = strings.TrimSpace(dimension); dimension != "" {
+				groupBy = append(groupBy, dimension)
+			}
+		}
+
+		filterExpr, err := utils.ParseFilterExpr(c.Query("filter"))
+		if err != nil {
+			logging.Errorf("invalid filter expression: %v", err)
+			c.JSON(http.StatusBadRequest, httphelper.NewErrorHTTPResponse(
+				nil,
+				"Invalid filter expression",
+				apperrors.ErrInvalidRequest,
+			))
+			return
+		}
+
+		result, err := h.usecase.GetStats(c.Request.Context(), resource, groupBy, filterExpr)
+		if err != nil {
+			logging.Errorf("failed to get %s stats: %v", resource, err)
+			writeUsecaseError(c, err, "Failed to get stats")
+			return
+		}
+
+		buckets := make([]map[string]any, 0, len(result.Buckets))
+		for _, bucket := range result.Buckets {
+			resp := make(map[string]any, len(result.Dimensions)+1)
+			for i, dimension := range result.Dimensions {
+				resp[dimension] = bucket.Values[i]
+			}
+			resp["count"] = bucket.Count
+			buckets = append(buckets, resp)
+		}
+		c.JSON(http.StatusOK, httphelper.NewSuccessResponse(map[string]any{
+			"dimensions": result.Dimensions,
+			"buckets":    buckets,
+			"total_row":  result.Total,
+			"truncated":  result.Truncated,
+		}, nil, nil))
+	}
+}
@@ -36,10 +36,27 @@ type updateGroupRequest struct {
 }
 
 type updateDeviceRequest struct {
-	GroupId     *string `json:"group_id" validate:"omitempty,uuid"`
-	Status      *string `json:"status" validate:"omitempty,oneof=ENABLE DISABLE"`
-	UpdatedBy   *string `json:"updated_by" validate:"omitempty,max=255"`
-	Description *string `json:"description" validate:"omitempty,max=255"`
+	GroupId     *string  `json:"group_id" validate:"omitempty,uuid"`
+	Status      *string  `json:"status" validate:"omitempty,oneof=ENABLE DISABLE"`
+	UpdatedBy   *string  `json:"updated_by" validate:"omitempty,max=255"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	Labels      []string `json:"labels" validate:"omitempty,max=20,dive,required,max=64"`
+
+	SerialNumber     *string `json:"serial_number" validate:"omitempty,max=64"`
+	ProductClass     *string `json:"product_class" validate:"omitempty,max=64"`
+	HardwareRevision *string `json:"hardware_revision" validate:"omitempty,max=64"`
+
+	LifecycleState  *string `json:"lifecycle_state" validate:"omitempty,oneof=PRE_PROVISIONED ACTIVATED IN_SERVICE SUSPENDED RMA DECOMMISSIONED"`
+	LifecycleReason string  `json:"lifecycle_reason" validate:"omitempty,max=255"`
+}
+
+type updateWebhookRequest struct {
+	Name        *string  `json:"name" validate:"omitempty,min=1,max=255"`
+	Url         *string  `json:"url" validate:"omitempty,url,max=2048"`
+	Secret      *string  `json:"secret" validate:"omitempty,min=16,max=255"`
+	EventTypes  []string `json:"event_types" validate:"omitempty,min=1,dive,required"`
+	Status      *string  `json:"status" validate:"omitempty,oneof=ENABLE DISABLE"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
 }
 
 type updateFirmwareRequest struct {
@@ -55,6 +72,8 @@ type updateModelRequest struct {
 	Status       *string `form:"status" validate:"omitempty,oneof=ENABLE DISABLE"`
 	Description  *string `form:"description" validate:"omitempty,max=255"`
 	Image        *string `form:"image"`
+
+	EndpointIdTemplate *string `form:"endpoint_id_template" validate:"omitempty,max=255"`
 }
 
 type profileRequestBody struct {
@@ -404,6 +423,10 @@ func (h *httpController) updateModelWithModelId() func(c *gin.Context) {
 		if val, ok := c.GetPostForm("description"); ok {
 			req.Description = &val
 		}
+		if val, ok := c.GetPostForm("endpoint_id_template"); ok {
+			val = strings.TrimSpace(val)
+			req.EndpointIdTemplate = &val
+		}
 
 		// Validate entity updateModelRequest
 		if err := Validate.Struct(req); err != nil {
@@ -487,6 +510,9 @@ func (h *httpController) updateModelWithModelId() func(c *gin.Context) {
 		if req.Image != nil {
 			Modelinfo.Image = req.Image
 		}
+		if req.EndpointIdTemplate != nil {
+			Modelinfo.EndpointIdTemplate = req.EndpointIdTemplate
+		}
 
 		if err := h.usecase.UpdateModelWithModelId(
 			c.Request.Context(),
@@ -790,6 +816,22 @@ func (h *httpController) updateDeviceWithId() func(c *gin.Context) {
 		if req.Description != nil {
 			deviceIdInfo.Description = req.Description
 		}
+		if req.Labels != nil {
+			deviceIdInfo.Labels = req.Labels
+		}
+		if req.SerialNumber != nil {
+			deviceIdInfo.SerialNumber = req.SerialNumber
+		}
+		if req.ProductClass != nil {
+			deviceIdInfo.ProductClass = req.ProductClass
+		}
+		if req.HardwareRevision != nil {
+			deviceIdInfo.HardwareRevision = req.HardwareRevision
+		}
+		if req.LifecycleState != nil {
+			deviceIdInfo.LifecycleState = req.LifecycleState
+			deviceIdInfo.LifecycleReason = req.LifecycleReason
+		}
 		if err := h.usecase.UpdateDeviceWithId(
 			c.Request.Context(),
 			modelId,
@@ -976,3 +1018,110 @@ func (h *httpController) updateProfileWithParametersId() func(c *gin.Context) {
 		)
 	}
 }
+
+func (h *httpController) updateWebhookWithId() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		webhookId := strings.TrimSpace(c.Param("webhook_id"))
+		if webhookId == "" || webhookId == ":webhook_id" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Webhook ID must not be empty.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		if _, err := uuid.Parse(webhookId); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Invalid Webhook ID format.",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		// bind json
+		var req updateWebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					err.Error(),
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+
+		// validate payload
+		if err := Validate.Struct(req); err != nil {
+			var invalidFields []invalidField
+			validationErrors := err.(validator.ValidationErrors)
+			for _, fieldError := range validationErrors {
+				invalidFields = append(invalidFields, generateInvalidFieldError(fieldError))
+			}
+
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					invalidFields,
+					"Invalid request fields",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		updatedBy := c.GetHeader("User-Name")
+		if updatedBy == "" {
+			c.JSON(http.StatusBadRequest,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"User-Name header is required",
+					apperrors.ErrInvalidRequest,
+				),
+			)
+			return
+		}
+		webhookInfo := models.NewWebhookSubscriptionUpdate()
+		webhookInfo.UpdatedBy = &updatedBy
+		webhookInfo.Name = req.Name
+		webhookInfo.Url = req.Url
+		webhookInfo.Secret = req.Secret
+		webhookInfo.EventTypes = req.EventTypes
+		webhookInfo.Status = req.Status
+		webhookInfo.Description = req.Description
+		if err := h.usecase.UpdateWebhookSubscriptionWithId(
+			c.Request.Context(),
+			webhookId,
+			webhookInfo,
+		); err != nil {
+			logging.Errorf("failed to update webhook: %v", err)
+			if appErr, ok := err.(*apperrors.AppError); ok {
+				c.JSON(appErr.HTTPCode(), httphelper.NewErrorHTTPResponse(
+					nil,
+					appErr.ErrorMessage(),
+					appErr.ErrorKey(),
+				))
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError,
+				httphelper.NewErrorHTTPResponse(
+					nil,
+					"Failed to update webhook",
+					apperrors.ErrInternal,
+				),
+			)
+			return
+		}
+
+		c.JSON(http.StatusOK,
+			httphelper.NewSuccessResponse(
+				nil,
+				nil,
+				nil,
+			),
+		)
+	}
+}
@@ -0,0 +1,161 @@
+package kafkacontroller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	managementuc "usp-management-device-api/business/management_uc"
+	"usp-management-device-api/business/models"
+	apperrors "usp-management-device-api/common/app_errors"
+	"usp-management-device-api/common/logging"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	retryInitialBackoff = time.Second
+	retryMaxBackoff     = 30 * time.Second
+)
+
+type uspEventConsumer struct {
+	usecase         managementuc.IEventUsecase
+	reader          *kafka.Reader
+	deadLetter      *kafka.Writer
+	deadLetterTopic string
+
+	// getRespTopics carry USP GetResp messages, every other topic carries Notify messages
+	getRespTopics map[string]bool
+}
+
+func NewUSPEventConsumer(
+	usecase managementuc.IEventUsecase,
+	reader *kafka.Reader,
+	deadLetter *kafka.Writer,
+	deadLetterTopic string,
+	getRespTopics []string,
+) *uspEventConsumer {
+	topics := make(map[string]bool, len(getRespTopics))
+	for _, topic := range getRespTopics {
+		topics[topic] = true
+	}
+	return &uspEventConsumer{
+		usecase:         usecase,
+		reader:          reader,
+		deadLetter:      deadLetter,
+		deadLetterTopic: deadLetterTopic,
+		getRespTopics:   topics,
+	}
+}
+
+// Run consumes USP Notify and GetResp messages until ctx is cancelled.
+// A message is committed once it is applied, dead-lettered or rejected,
+// transient failures are retried so the offset never moves past an unapplied event.
+func (c *uspEventConsumer) Run(ctx context.Context) {
+	logging.Infof("USP event consumer started")
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				logging.Infof("USP event consumer stopped")
+				return
+			}
+			logging.Errorf("failed to fetch USP event: %v", err)
+			continue
+		}
+
+		if !c.handle(ctx, msg) {
+			logging.Infof("USP event consumer stopped")
+			return
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			logging.Errorf("failed to commit USP event topic=%s partition=%d offset=%d: %v",
+				msg.Topic, msg.Partition, msg.Offset, err)
+		}
+	}
+}
+
+// handle returns false when ctx was cancelled before the message was settled.
+func (c *uspEventConsumer) handle(ctx context.Context, msg kafka.Message) bool {
+	var endpointId string
+	var apply func() error
+	if c.getRespTopics[msg.Topic] {
+		var resp models.USPGetResp
+		if err := json.Unmarshal(msg.Value, &resp); err != nil {
+			return c.sendDeadLetter(ctx, msg, apperrors.NewErrInvalidEventPayload(err.Error()))
+		}
+		endpointId = resp.EndpointId
+		apply = func() error { return c.usecase.HandleUSPGetResp(ctx, &resp) }
+	} else {
+		var event models.USPNotifyEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return c.sendDeadLetter(ctx, msg, apperrors.NewErrInvalidEventPayload(err.Error()))
+		}
+		endpointId = event.EndpointId
+		apply = func() error { return c.usecase.HandleUSPNotify(ctx, &event) }
+	}
+
+	backoff := retryInitialBackoff
+	for {
+		err := apply()
+		if err == nil {
+			return true
+		}
+
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			if appErr.IsErrorKey(apperrors.ErrInvalidEventPayload) {
+				return c.sendDeadLetter(ctx, msg, appErr)
+			}
+			if appErr.HTTPCode() < http.StatusInternalServerError {
+				logging.Warnf("USP event from endpoint %s rejected: %v", endpointId, appErr)
+				return true
+			}
+		}
+
+		logging.Errorf("failed to apply USP event from endpoint %s, retry in %s: %v", endpointId, backoff, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, retryMaxBackoff)
+	}
+}
+
+// sendDeadLetter publishes the original message with the failure reason in its headers.
+func (c *uspEventConsumer) sendDeadLetter(ctx context.Context, msg kafka.Message, reason *apperrors.AppError) bool {
+	logging.Warnf("USP event topic=%s partition=%d offset=%d sent to %s: %s",
+		msg.Topic, msg.Partition, msg.Offset, c.deadLetterTopic, reason.ErrorMessage())
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "x-error-key", Value: []byte(reason.ErrorKey())},
+		kafka.Header{Key: "x-error-message", Value: []byte(reason.ErrorMessage())},
+		kafka.Header{Key: "x-source-topic", Value: []byte(msg.Topic)},
+		kafka.Header{Key: "x-source-partition", Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: "x-source-offset", Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+
+	backoff := retryInitialBackoff
+	for {
+		err := c.deadLetter.WriteMessages(ctx, kafka.Message{
+			Topic:   c.deadLetterTopic,
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
+		})
+		if err == nil {
+			return true
+		}
+
+		logging.Errorf("failed to write dead letter to %s, retry in %s: %v", c.deadLetterTopic, backoff, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, retryMaxBackoff)
+	}
+}
@@ -2,7 +2,9 @@ package enviroments
 
 import (
 	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/caarlos0/env"
 )
@@ -38,6 +40,68 @@ type config struct {
 	MinIOAccessKey string `env:"MINIO_ACCESS_KEY,notEmpty,required" json:"minio_access_key"`
 	// MinIO Secret Key
 	MinIOSecretKey string `env:"MINIO_SECRET_KEY,notEmpty,required" json:"minio_secret_key"`
+
+	// Kafka brokers, comma separated
+	KafkaBrokers []string `env:"KAFKA_BROKERS" envSeparator:"," json:"kafka_brokers"`
+	// Kafka SASL user
+	KafkaUser string `env:"KAFKA_USER" json:"kafka_user"`
+	// Kafka SASL password
+	KafkaPass string `env:"KAFKA_PASS" json:"kafka_pass"`
+
+	// Enable USP event ingest worker
+	USPEventIngestEnable bool `env:"USP_EVENT_INGEST_ENABLE" envDefault:"false" json:"usp_event_ingest_enable"`
+	// USP Notify topics, comma separated
+	USPEventTopics []string `env:"USP_EVENT_TOPICS" envDefault:"usp.notify" envSeparator:"," json:"usp_event_topics"`
+	// USP Notify consumer group
+	USPEventGroupID string `env:"USP_EVENT_GROUP_ID" envDefault:"usp-management-event-ingest" json:"usp_event_group_id"`
+	// USP Notify dead-letter topic
+	USPEventDeadLetterTopic string `env:"USP_EVENT_DEAD_LETTER_TOPIC" envDefault:"usp.notify.dlq" json:"usp_event_dead_letter_topic"`
+	// USP GetResp topics, comma separated, consumed by the same worker and group
+	USPGetRespTopics []string `env:"USP_GETRESP_TOPICS" envDefault:"usp.getresp" envSeparator:"," json:"usp_getresp_topics"`
+	// Model name used when auto-registering an endpoint that does not report its model
+	USPAutoRegisterDefaultModel string `env:"USP_AUTO_REGISTER_DEFAULT_MODEL" json:"usp_auto_register_default_model"`
+
+	// Enable outbox relay to Kafka
+	OutboxRelayEnable bool `env:"OUTBOX_RELAY_ENABLE" envDefault:"false" json:"outbox_relay_enable"`
+	// Domain event topic
+	OutboxTopic string `env:"OUTBOX_TOPIC" envDefault:"usp.management.events" json:"outbox_topic"`
+	// Outbox poll interval
+	OutboxRelayInterval time.Duration `env:"OUTBOX_RELAY_INTERVAL" envDefault:"1s" json:"outbox_relay_interval"`
+	// Outbox events published per poll
+	OutboxRelayBatchSize int `env:"OUTBOX_RELAY_BATCH_SIZE" envDefault:"100" json:"outbox_relay_batch_size"`
+
+	// Enable webhook dispatcher
+	WebhookDispatchEnable bool `env:"WEBHOOK_DISPATCH_ENABLE" envDefault:"true" json:"webhook_dispatch_enable"`
+	// Webhook delivery poll interval
+	WebhookDispatchInterval time.Duration `env:"WEBHOOK_DISPATCH_INTERVAL" envDefault:"2s" json:"webhook_dispatch_interval"`
+	// Webhook deliveries sent per poll
+	WebhookDispatchBatchSize int `env:"WEBHOOK_DISPATCH_BATCH_SIZE" envDefault:"50" json:"webhook_dispatch_batch_size"`
+	// Webhook deliveries of a batch sent at the same time
+	WebhookDispatchConcurrency int `env:"WEBHOOK_DISPATCH_CONCURRENCY" envDefault:"10" json:"webhook_dispatch_concurrency"`
+	// Attempts before a webhook delivery is marked FAILED
+	WebhookMaxAttempts int `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8" json:"webhook_max_attempts"`
+	// First retry delay, doubled on every attempt
+	WebhookRetryBaseDelay time.Duration `env:"WEBHOOK_RETRY_BASE_DELAY" envDefault:"10s" json:"webhook_retry_base_delay"`
+	// Upper bound of the retry delay
+	WebhookRetryMaxDelay time.Duration `env:"WEBHOOK_RETRY_MAX_DELAY" envDefault:"1h" json:"webhook_retry_max_delay"`
+	// Webhook HTTP request timeout
+	WebhookTimeout time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s" json:"webhook_timeout"`
+
+	// Keep every reported parameter value for as-of queries
+	DeviceParameterHistoryEnable bool `env:"DEVICE_PARAMETER_HISTORY_ENABLE" envDefault:"false" json:"device_parameter_history_enable"`
+	// How long parameter history is kept
+	DeviceParameterHistoryRetention time.Duration `env:"DEVICE_PARAMETER_HISTORY_RETENTION" envDefault:"720h" json:"device_parameter_history_retention"`
+	// Parameter history prune interval
+	DeviceParameterHistoryPruneInterval time.Duration `env:"DEVICE_PARAMETER_HISTORY_PRUNE_INTERVAL" envDefault:"1h" json:"device_parameter_history_prune_interval"`
+
+	// Enable import job workers
+	ImportJobEnable bool `env:"IMPORT_JOB_ENABLE" envDefault:"true" json:"import_job_enable"`
+	// Import jobs processed concurrently
+	ImportJobWorkers int `env:"IMPORT_JOB_WORKERS" envDefault:"2" json:"import_job_workers"`
+	// Import job poll interval
+	ImportJobInterval time.Duration `env:"IMPORT_JOB_INTERVAL" envDefault:"2s" json:"import_job_interval"`
+	// Rows committed per import job checkpoint
+	ImportJobChunkSize int `env:"IMPORT_JOB_CHUNK_SIZE" envDefault:"1000" json:"import_job_chunk_size"`
 }
 
 type store struct {
@@ -76,6 +140,33 @@ func (s *store) Print() {
 	println("MinIO Endpoint:", s.GetMinIOEndpoint())
 	println("MinIO Access Key:", s.GetMinIOAccessKey())
 	println("MinIO Secret Key:", s.GetMinIOSecretKey())
+	println("Kafka Brokers:", strings.Join(s.GetKafkaBrokers(), ","))
+	println("Kafka User:", s.GetKafkaUser())
+	println("USP Event Ingest Enable:", s.GetUSPEventIngestEnable())
+	println("USP Event Topics:", strings.Join(s.GetUSPEventTopics(), ","))
+	println("USP Event Group ID:", s.GetUSPEventGroupID())
+	println("USP Event Dead Letter Topic:", s.GetUSPEventDeadLetterTopic())
+	println("USP GetResp Topics:", strings.Join(s.GetUSPGetRespTopics(), ","))
+	println("USP Auto Register Default Model:", s.GetUSPAutoRegisterDefaultModel())
+	println("Outbox Relay Enable:", s.GetOutboxRelayEnable())
+	println("Outbox Topic:", s.GetOutboxTopic())
+	println("Outbox Relay Interval:", s.GetOutboxRelayInterval().String())
+	println("Outbox Relay Batch Size:", s.GetOutboxRelayBatchSize())
+	println("Webhook Dispatch Enable:", s.GetWebhookDispatchEnable())
+	println("Webhook Dispatch Interval:", s.GetWebhookDispatchInterval().String())
+	println("Webhook Dispatch Batch Size:", s.GetWebhookDispatchBatchSize())
+	println("Webhook Dispatch Concurrency:", s.GetWebhookDispatchConcurrency())
+	println("Webhook Max Attempts:", s.GetWebhookMaxAttempts())
+	println("Webhook Retry Base Delay:", s.GetWebhookRetryBaseDelay().String())
+	println("Webhook Retry Max Delay:", s.GetWebhookRetryMaxDelay().String())
+	println("Webhook Timeout:", s.GetWebhookTimeout().String())
+	println("Device Parameter History Enable:", s.GetDeviceParameterHistoryEnable())
+	println("Device Parameter History Retention:", s.GetDeviceParameterHistoryRetention().String())
+	println("Device Parameter History Prune Interval:", s.GetDeviceParameterHistoryPruneInterval().String())
+	println("Import Job Enable:", s.GetImportJobEnable())
+	println("Import Job Workers:", s.GetImportJobWorkers())
+	println("Import Job Interval:", s.GetImportJobInterval().String())
+	println("Import Job Chunk Size:", s.GetImportJobChunkSize())
 }
 
 func (s *store) GetAppName() string        { return s.config.AppName }
@@ -90,3 +181,40 @@ func (s *store) GetSQLUSPDB() string       { return s.config.SQLUSPDB }
 func (s *store) GetMinIOEndpoint() string  { return s.config.MinIOEndpoint }
 func (s *store) GetMinIOAccessKey() string { return s.config.MinIOAccessKey }
 func (s *store) GetMinIOSecretKey() string { return s.config.MinIOSecretKey }
+
+func (s *store) GetKafkaBrokers() []string              { return s.config.KafkaBrokers }
+func (s *store) GetKafkaUser() string                   { return s.config.KafkaUser }
+func (s *store) GetKafkaPass() string                   { return s.config.KafkaPass }
+func (s *store) GetUSPEventIngestEnable() bool          { return s.config.USPEventIngestEnable }
+func (s *store) GetUSPEventTopics() []string            { return s.config.USPEventTopics }
+func (s *store) GetUSPEventGroupID() string             { return s.config.USPEventGroupID }
+func (s *store) GetUSPEventDeadLetterTopic() string     { return s.config.USPEventDeadLetterTopic }
+func (s *store) GetUSPAutoRegisterDefaultModel() string { return s.config.USPAutoRegisterDefaultModel }
+func (s *store) GetUSPGetRespTopics() []string          { return s.config.USPGetRespTopics }
+
+func (s *store) GetOutboxRelayEnable() bool            { return s.config.OutboxRelayEnable }
+func (s *store) GetOutboxTopic() string                { return s.config.OutboxTopic }
+func (s *store) GetOutboxRelayInterval() time.Duration { return s.config.OutboxRelayInterval }
+func (s *store) GetOutboxRelayBatchSize() int          { return s.config.OutboxRelayBatchSize }
+
+func (s *store) GetWebhookDispatchEnable() bool            { return s.config.WebhookDispatchEnable }
+func (s *store) GetWebhookDispatchInterval() time.Duration { return s.config.WebhookDispatchInterval }
+func (s *store) GetWebhookDispatchBatchSize() int          { return s.config.WebhookDispatchBatchSize }
+func (s *store) GetWebhookDispatchConcurrency() int        { return s.config.WebhookDispatchConcurrency }
+func (s *store) GetWebhookMaxAttempts() int                { return s.config.WebhookMaxAttempts }
+func (s *store) GetWebhookRetryBaseDelay() time.Duration   { return s.config.WebhookRetryBaseDelay }
+func (s *store) GetWebhookRetryMaxDelay() time.Duration    { return s.config.WebhookRetryMaxDelay }
+func (s *store) GetWebhookTimeout() time.Duration          { return s.config.WebhookTimeout }
+
+func (s *store) GetDeviceParameterHistoryEnable() bool { return s.config.DeviceParameterHistoryEnable }
+func (s *store) GetDeviceParameterHistoryRetention() time.Duration {
+	return s.config.DeviceParameterHistoryRetention
+}
+func (s *store) GetDeviceParameterHistoryPruneInterval() time.Duration {
+	return s.config.DeviceParameterHistoryPruneInterval
+}
+
+func (s *store) GetImportJobEnable() bool            { return s.config.ImportJobEnable }
+func (s *store) GetImportJobWorkers() int            { return s.config.ImportJobWorkers }
+func (s *store) GetImportJobInterval() time.Duration { return s.config.ImportJobInterval }
+func (s *store) GetImportJobChunkSize() int          { return s.config.ImportJobChunkSize }
@@ -0,0 +1,41 @@
+package kafkapublisher
+
+import (
+	"context"
+	"strconv"
+	"usp-management-device-api/business/models"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaPublisher struct {
+	Writer *kafka.Writer
+	Topic  string
+}
+
+func NewKafkaPublisher(writer *kafka.Writer, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		Writer: writer,
+		Topic:  topic,
+	}
+}
+
+// PublishOutboxEvents writes the events in order, keyed by aggregate id
+// so every event of one aggregate lands on the same partition.
+func (p *KafkaPublisher) PublishOutboxEvents(ctx context.Context, events []models.OutboxEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		messages = append(messages, kafka.Message{
+			Topic: p.Topic,
+			Key:   []byte(event.AggregateId.String()),
+			Value: event.Payload,
+			Headers: []kafka.Header{
+				{Key: "event-id", Value: []byte(event.Id.String())},
+				{Key: "event-type", Value: []byte(event.EventType)},
+				{Key: "schema-version", Value: []byte(strconv.Itoa(event.SchemaVersion))},
+				{Key: "aggregate-version", Value: []byte(strconv.FormatInt(event.AggregateVersion, 10))},
+			},
+		})
+	}
+	return p.Writer.WriteMessages(ctx, messages...)
+}
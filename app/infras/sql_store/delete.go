@@ -2,6 +2,7 @@ package uspstore
 
 import (
 	"context"
+	"time"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
 )
@@ -246,3 +247,78 @@ func (s *store) ChangeStatusDeviceToDelete(
 	}
 	return nil
 }
+
+func (s *store) ChangeStatusWebhookSubscriptionToDelete(
+	ctx context.Context,
+	id string,
+	updatedBy string,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.WebhookSubscription{}.TableName()).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     "DELETE",
+			"updated_by": updatedBy,
+		}).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+func (s *store) ChangeStatusSavedViewToDelete(
+	ctx context.Context,
+	id string,
+	updatedBy string,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.SavedView{}.TableName()).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     "DELETE",
+			"updated_by": updatedBy,
+		}).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+func (s *store) DeleteProfileAssignment(
+	ctx context.Context,
+	id string,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).Table(models.ProfileAssignment{}.TableName()).Where("id = ?", id).Delete(&models.ProfileAssignment{}).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+// DeleteDeviceParameterHistoryBefore prunes history rows observed before the given time.
+func (s *store) DeleteDeviceParameterHistoryBefore(
+	ctx context.Context,
+	before time.Time,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	result := db.WithContext(ctx).
+		Table(models.DeviceParameterValueHistory{}.TableName()).
+		Where("observed_at < ?", before).
+		Delete(&models.DeviceParameterValueHistory{})
+	if result.Error != nil {
+		return 0, apperrors.NewDBError(result.Error, s.GetDBName())
+	}
+	return result.RowsAffected, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
+
+	"gorm.io/gorm/clause"
 )
 
 // InsertDevice inserts a new device into the database.
@@ -207,3 +209,223 @@ func (s *store) InsertProfileWithBatch(
 
 	return nil
 }
+
+// InsertDeviceFirmwareTransfer records a firmware transfer reported by a device.
+func (s *store) InsertDeviceFirmwareTransfer(
+	ctx context.Context,
+	transfer *models.DeviceFirmwareTransfer,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(transfer.TableName()).Create(transfer).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertOutboxEvent stores a domain event, it must run in the transaction of the change it describes.
+func (s *store) InsertOutboxEvent(
+	ctx context.Context,
+	event *models.OutboxEvent,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(event.TableName()).Create(event).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertWebhookSubscription inserts a new webhook subscription into the database.
+func (s *store) InsertWebhookSubscription(
+	ctx context.Context,
+	subscription *models.WebhookSubscription,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(subscription.TableName()).Create(subscription).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertWebhookDelivery queues a webhook delivery, it must run in the transaction of the change it describes.
+func (s *store) InsertWebhookDelivery(
+	ctx context.Context,
+	delivery *models.WebhookDelivery,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(delivery.TableName()).Omit("Subscription").Create(delivery).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertSavedView inserts a new saved view into the database.
+func (s *store) InsertSavedView(
+	ctx context.Context,
+	view *models.SavedView,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(view.TableName()).Create(view).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// UpsertDeviceParameterValues stores the last reported value per device and path,
+// a report older than the stored one is ignored.
+func (s *store) UpsertDeviceParameterValues(
+	ctx context.Context,
+	values []models.DeviceParameterValue,
+) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).
+		Table(models.DeviceParameterValue{}.TableName()).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{
+				{Name: models.DeviceParameterValue{}.GetDeviceIdColumnName()},
+				{Name: models.DeviceParameterValue{}.GetPathColumnName()},
+			},
+			DoUpdates: clause.AssignmentColumns([]string{
+				models.DeviceParameterValue{}.GetValueColumnName(),
+				models.DeviceParameterValue{}.GetObservedAtColumnName(),
+				models.DeviceParameterValue{}.GetUpdatedAtColumnName(),
+			}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "excluded.observed_at >= device_parameter_values.observed_at"},
+			}},
+		}).
+		Create(&values).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertProfileAssignment assigns a profile to a group or a device.
+func (s *store) InsertProfileAssignment(
+	ctx context.Context,
+	assignment *models.ProfileAssignment,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(assignment.TableName()).Omit("Profile").Create(assignment).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertDeviceParameterHistory appends reported values to the history,
+// a value already recorded for the same observed_at is skipped.
+func (s *store) InsertDeviceParameterHistory(
+	ctx context.Context,
+	values []models.DeviceParameterValueHistory,
+) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).
+		Table(models.DeviceParameterValueHistory{}.TableName()).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&values).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertDeviceLifecycleTransition records a lifecycle_state change of a device.
+func (s *store) InsertDeviceLifecycleTransition(
+	ctx context.Context,
+	transition *models.DeviceLifecycleTransition,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(transition.TableName()).Create(transition).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertImportJob queues an import job.
+func (s *store) InsertImportJob(
+	ctx context.Context,
+	job *models.ImportJob,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(job.TableName()).Create(job).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
+
+// InsertImportJobErrors stores the rejected rows of an import job.
+func (s *store) InsertImportJobErrors(
+	ctx context.Context,
+	jobErrors []models.ImportJobError,
+) error {
+	if len(jobErrors) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+
+	if err := db.WithContext(ctx).Table(models.ImportJobError{}.TableName()).Create(&jobErrors).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return nil
+}
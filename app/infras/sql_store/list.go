@@ -3,10 +3,13 @@ package uspstore
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (s *store) ListProfileParameter(
@@ -49,19 +52,25 @@ func (s *store) ListProfiles(
 	query := db.WithContext(ctx).
 		Table(models.Profile{}.TableName())
 
+	// fields= reads only the columns of the asked attributes
+	if len(oppts.Select) > 0 {
+		query = query.Select(oppts.Select)
+	}
 	query = queryConditionBuilder(query, condition)
 
 	// Use Specification Pattern instead of applyFilterExprs directly
 	var specs []Specification
 
 	// Add filter specification
-	if len(oppts.FilterExpr) > 0 {
+	if oppts.FilterExpr != nil {
 		filterSpec := NewFilterSpecification(oppts.FilterExpr)
 		specs = append(specs, filterSpec)
 	}
 
-	// Add order specification
-	if len(oppts.OrderExpr) > 0 {
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
 		orderSpec := NewOrderSpecification(oppts.OrderExpr)
 		specs = append(specs, orderSpec)
 	}
@@ -101,18 +110,24 @@ func (s *store) ListParameters(
 		Table(models.Parameter{}.TableName()).
 		WithContext(ctx)
 
+	// fields= reads only the columns of the asked attributes
+	if len(oppts.Select) > 0 {
+		query = query.Select(oppts.Select)
+	}
 	query = queryConditionBuilder(query, condition)
 
 	var specs []Specification
 
 	// Add filter specification
-	if len(oppts.FilterExpr) > 0 {
+	if oppts.FilterExpr != nil {
 		filterSpec := NewFilterSpecification(oppts.FilterExpr)
 		specs = append(specs, filterSpec)
 	}
 
-	// Add order specification
-	if len(oppts.OrderExpr) > 0 {
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
 		orderSpec := NewOrderSpecification(oppts.OrderExpr)
 		specs = append(specs, orderSpec)
 	}
@@ -143,6 +158,7 @@ func (s *store) ListModels(
 	ctx context.Context,
 	condition map[string]any,
 	oppts models.QueryOptions,
+	moreKeys ...string,
 ) ([]models.Model, error) {
 	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
 	defer cancel()
@@ -153,19 +169,29 @@ func (s *store) ListModels(
 	query := db.WithContext(ctx).
 		Table(models.Model{}.TableName())
 
+	for _, key := range moreKeys {
+		query = query.Preload(key)
+	}
+
+	// fields= reads only the columns of the asked attributes
+	if len(oppts.Select) > 0 {
+		query = query.Select(oppts.Select)
+	}
 	query = queryConditionBuilder(query, condition)
 
 	// Sử dụng Specification Pattern thay vì applyFilterExprs trực tiếp
 	var specs []Specification
 
 	// Add filter specification
-	if len(oppts.FilterExpr) > 0 {
+	if oppts.FilterExpr != nil {
 		filterSpec := NewFilterSpecification(oppts.FilterExpr)
 		specs = append(specs, filterSpec)
 	}
 
-	// Add order specification
-	if len(oppts.OrderExpr) > 0 {
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
 		orderSpec := NewOrderSpecification(oppts.OrderExpr)
 		specs = append(specs, orderSpec)
 	}
@@ -196,6 +222,7 @@ func (s *store) ListFirmware(
 	ctx context.Context,
 	condition map[string]any,
 	oppts models.QueryOptions,
+	moreKeys ...string,
 ) ([]models.Firmware, error) {
 	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
 	defer cancel()
@@ -206,19 +233,29 @@ func (s *store) ListFirmware(
 	query := db.WithContext(ctx).Debug().
 		Table(models.Firmware{}.TableName())
 
+	for _, key := range moreKeys {
+		query = query.Preload(key)
+	}
+
+	// fields= reads only the columns of the asked attributes
+	if len(oppts.Select) > 0 {
+		query = query.Select(oppts.Select)
+	}
 	query = queryConditionBuilder(query, condition)
 
 	// Sử dụng Specification Pattern thay vì applyFilterExprs trực tiếp
 	var specs []Specification
 
 	// Add filter specification
-	if len(oppts.FilterExpr) > 0 {
+	if oppts.FilterExpr != nil {
 		filterSpec := NewFilterSpecification(oppts.FilterExpr)
 		specs = append(specs, filterSpec)
 	}
 
-	// Add order specification
-	if len(oppts.OrderExpr) > 0 {
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
 		orderSpec := NewOrderSpecification(oppts.OrderExpr)
 		specs = append(specs, orderSpec)
 	}
@@ -249,6 +286,7 @@ func (s *store) ListGroups(
 	ctx context.Context,
 	condition map[string]any,
 	oppts models.QueryOptions,
+	moreKeys ...string,
 ) ([]models.Group, error) {
 	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
 	defer cancel()
@@ -256,22 +294,31 @@ func (s *store) ListGroups(
 	var groups []models.Group
 	query := s.getDBFromContext(ctx).
 		Table(models.Group{}.TableName()).
-		Preload("Firmware").
 		WithContext(ctx)
 
+	for _, key := range moreKeys {
+		query = query.Preload(key)
+	}
+
+	// fields= reads only the columns of the asked attributes
+	if len(oppts.Select) > 0 {
+		query = query.Select(oppts.Select)
+	}
 	query = queryConditionBuilder(query, condition)
 
 	// Sử dụng Specification Pattern thay vì applyFilterExprs trực tiếp
 	var specs []Specification
 
 	// Add filter specification
-	if len(oppts.FilterExpr) > 0 {
+	if oppts.FilterExpr != nil {
 		filterSpec := NewFilterSpecification(oppts.FilterExpr)
 		specs = append(specs, filterSpec)
 	}
 
-	// Add order specification
-	if len(oppts.OrderExpr) > 0 {
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
 		orderSpec := NewOrderSpecification(oppts.OrderExpr)
 		specs = append(specs, orderSpec)
 	}
@@ -302,6 +349,7 @@ func (s *store) ListDevices(
 	ctx context.Context,
 	condition map[string]any,
 	oppts models.QueryOptions,
+	moreKeys ...string,
 ) ([]models.Device, error) {
 	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
 	defer cancel()
@@ -309,22 +357,31 @@ func (s *store) ListDevices(
 	var devices []models.Device
 	query := s.getDBFromContext(ctx).
 		Table(models.Device{}.TableName()).
-		Preload("Group").
 		WithContext(ctx)
 
+	for _, key := range moreKeys {
+		query = query.Preload(key)
+	}
+
+	// fields= reads only the columns of the asked attributes
+	if len(oppts.Select) > 0 {
+		query = query.Select(oppts.Select)
+	}
 	query = queryConditionBuilder(query, condition)
 
 	// Use Specification Pattern thay vì applyFilterExprs trực tiếp
 	var specs []Specification
 
 	// Add filter specification
-	if len(oppts.FilterExpr) > 0 {
+	if oppts.FilterExpr != nil {
 		filterSpec := NewFilterSpecification(oppts.FilterExpr)
 		specs = append(specs, filterSpec)
 	}
 
-	// Add order specification
-	if len(oppts.OrderExpr) > 0 {
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
 		orderSpec := NewOrderSpecification(oppts.OrderExpr)
 		specs = append(specs, orderSpec)
 	}
@@ -429,3 +486,599 @@ func (s *store) ListTotalGroups(
 
 	return groups, nil
 }
+
+// ListPendingOutboxEvents returns the oldest pending outbox events in seq order.
+// Rows are locked until the surrounding transaction ends so concurrent relays do not interleave.
+// seq is assigned at insert time, a transaction committing late can still be relayed after later
+// events, consumers order the events of an aggregate by aggregate_version.
+func (s *store) ListPendingOutboxEvents(
+	ctx context.Context,
+	limit int,
+) ([]models.OutboxEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var events []models.OutboxEvent
+
+	// an event waits while a lower version of its aggregate is pending, seq is given at insert
+	// time and a later version can have the lower seq
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.OutboxEvent{}.TableName()+" AS e").
+		Where("e.status = ?", models.OutboxStatusPending).
+		Where(`NOT EXISTS (SELECT 1 FROM `+models.OutboxEvent{}.TableName()+` AS earlier
+			WHERE earlier.aggregate_id = e.aggregate_id
+			AND earlier.aggregate_version < e.aggregate_version
+			AND earlier.status = ?)`, models.OutboxStatusPending).
+		Order("e.seq ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&events).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return events, nil
+}
+
+func (s *store) ListWebhookSubscriptions(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) ([]models.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var subscriptions []models.WebhookSubscription
+	query := s.getDBFromContext(ctx).
+		Table(models.WebhookSubscription{}.TableName()).
+		WithContext(ctx)
+
+	query = queryConditionBuilder(query, condition)
+
+	// Use Specification Pattern instead of applyFilterExprs directly
+	var specs []Specification
+
+	// Add filter specification
+	if oppts.FilterExpr != nil {
+		filterSpec := NewFilterSpecification(oppts.FilterExpr)
+		specs = append(specs, filterSpec)
+	}
+
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
+		orderSpec := NewOrderSpecification(oppts.OrderExpr)
+		specs = append(specs, orderSpec)
+	}
+
+	// Add pagination specification
+	if oppts.Limit > 0 {
+		paginationSpec := NewPaginationSpecification(oppts.Limit, oppts.Offset)
+		specs = append(specs, paginationSpec)
+	}
+
+	// Apply all specifications
+	if len(specs) > 0 {
+		compositeSpec := NewCompositeSpecification(specs...)
+		query = compositeSpec.Apply(query)
+	}
+
+	if err := query.Find(&subscriptions).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return subscriptions, nil
+}
+
+// ListWebhookSubscriptionsForEvent returns the enabled subscriptions whose filter matches eventType.
+func (s *store) ListWebhookSubscriptionsForEvent(
+	ctx context.Context,
+	eventType string,
+) ([]models.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var subscriptions []models.WebhookSubscription
+	if err := s.getDBFromContext(ctx).
+		WithContext(ctx).
+		Table(models.WebhookSubscription{}.TableName()).
+		Where("status = ?", "ENABLE").
+		Where("(? = ANY(event_types) OR ? = ANY(event_types))", eventType, models.WebhookEventTypeAll).
+		Find(&subscriptions).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return subscriptions, nil
+}
+
+// ListSavedViews returns the views of a resource owned by user, shared with one of teams or
+// with everyone, by name.
+func (s *store) ListSavedViews(
+	ctx context.Context,
+	resource string,
+	user string,
+	teams []string,
+) ([]models.SavedView, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var views []models.SavedView
+	if err := s.getDBFromContext(ctx).
+		WithContext(ctx).
+		Table(models.SavedView{}.TableName()).
+		Where("resource = ? AND status = ?", resource, "ENABLE").
+		Where("(owner = ? OR shared OR team IN ?)", user, teams).
+		Order("name ASC").
+		Find(&views).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return views, nil
+}
+
+func (s *store) ListWebhookDeliveries(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+) ([]models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var deliveries []models.WebhookDelivery
+	query := s.getDBFromContext(ctx).
+		Table(models.WebhookDelivery{}.TableName()).
+		WithContext(ctx)
+
+	query = queryConditionBuilder(query, condition)
+
+	// Use Specification Pattern instead of applyFilterExprs directly
+	var specs []Specification
+
+	// Add filter specification
+	if oppts.FilterExpr != nil {
+		filterSpec := NewFilterSpecification(oppts.FilterExpr)
+		specs = append(specs, filterSpec)
+	}
+
+	// Add order specification, keyset pagination orders by its own columns
+	if oppts.Keyset != nil {
+		specs = append(specs, NewKeysetSpecification(oppts.Keyset))
+	} else if len(oppts.OrderExpr) > 0 {
+		orderSpec := NewOrderSpecification(oppts.OrderExpr)
+		specs = append(specs, orderSpec)
+	}
+
+	// Add pagination specification
+	if oppts.Limit > 0 {
+		paginationSpec := NewPaginationSpecification(oppts.Limit, oppts.Offset)
+		specs = append(specs, paginationSpec)
+	}
+
+	// Apply all specifications
+	if len(specs) > 0 {
+		compositeSpec := NewCompositeSpecification(specs...)
+		query = compositeSpec.Apply(query)
+	}
+
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return deliveries, nil
+}
+
+// ListDueWebhookDeliveries returns pending deliveries whose next attempt is due, locked for update.
+func (s *store) ListDueWebhookDeliveries(
+	ctx context.Context,
+	limit int,
+) ([]models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var deliveries []models.WebhookDelivery
+	if err := s.getDBFromContext(ctx).
+		WithContext(ctx).
+		Table(models.WebhookDelivery{}.TableName()).
+		Preload("Subscription").
+		Where("status = ?", models.WebhookDeliveryStatusPending).
+		Where("next_attempt_at <= ?", time.Now()).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&deliveries).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return deliveries, nil
+}
+
+// ListProfileAssignments returns assignments with their profile, profile parameters and parameters preloaded.
+func (s *store) ListProfileAssignments(
+	ctx context.Context,
+	condition map[string]any,
+) ([]models.ProfileAssignment, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var assignments []models.ProfileAssignment
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.ProfileAssignment{}.TableName()).
+		Preload(models.ProfileAssignment{}.GetProfileParameterPreload())
+
+	query = queryConditionBuilder(query, condition)
+	query = query.Order("created_at ASC")
+
+	if err := query.Find(&assignments).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return assignments, nil
+}
+
+func (s *store) ListDeviceParameterValues(
+	ctx context.Context,
+	condition map[string]any,
+) ([]models.DeviceParameterValue, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var values []models.DeviceParameterValue
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.DeviceParameterValue{}.TableName())
+
+	query = queryConditionBuilder(query, condition)
+	query = query.Order("path ASC")
+
+	if err := query.Find(&values).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return values, nil
+}
+
+// ListDeviceParameterSnapshot returns the values of a device whose path starts with one of the prefixes.
+// Without asOf the latest values are read, with asOf the last value observed at or before asOf
+// is read from the history.
+func (s *store) ListDeviceParameterSnapshot(
+	ctx context.Context,
+	deviceId string,
+	prefixes []string,
+	asOf *time.Time,
+	opts models.QueryOptions,
+) ([]models.DeviceParameterValue, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var values []models.DeviceParameterValue
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx)
+	if asOf == nil {
+		query = query.Table(models.DeviceParameterValue{}.TableName()).
+			Select("device_id, path, value, observed_at, updated_at")
+	} else {
+		query = query.Table(models.DeviceParameterValueHistory{}.TableName()).
+			Select("DISTINCT ON (path) device_id, path, value, observed_at, created_at AS updated_at").
+			Where("observed_at <= ?", *asOf)
+	}
+	query = query.Where("device_id = ?", deviceId)
+
+	if len(prefixes) > 0 {
+		exprs := make([]string, 0, len(prefixes))
+		args := make([]any, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			exprs = append(exprs, "path LIKE ?")
+			args = append(args, escapeLike(prefix)+"%")
+		}
+		query = query.Where("("+strings.Join(exprs, " OR ")+")", args...)
+	}
+
+	if asOf == nil {
+		query = query.Order("path ASC")
+	} else {
+		query = query.Order("path ASC, observed_at DESC")
+	}
+	if opts.Limit > 0 {
+		query = NewPaginationSpecification(opts.Limit, opts.Offset).Apply(query)
+	}
+
+	if err := query.Find(&values).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return values, nil
+}
+
+// escapeLike escapes the LIKE wildcards of a literal, backslash is the default escape character.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func (s *store) ListDeviceLifecycleTransitions(
+	ctx context.Context,
+	condition map[string]any,
+) ([]models.DeviceLifecycleTransition, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var transitions []models.DeviceLifecycleTransition
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.DeviceLifecycleTransition{}.TableName())
+
+	query = queryConditionBuilder(query, condition)
+	query = query.Order("created_at DESC")
+
+	if err := query.Find(&transitions).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return transitions, nil
+}
+
+// ListClaimableImportJobs returns queued jobs and running jobs whose lease expired, locked for update.
+func (s *store) ListClaimableImportJobs(
+	ctx context.Context,
+	limit int,
+) ([]models.ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var jobs []models.ImportJob
+	if err := s.getDBFromContext(ctx).
+		WithContext(ctx).
+		Table(models.ImportJob{}.TableName()).
+		Where("status IN ?", []string{models.ImportJobStatusQueued, models.ImportJobStatusRunning}).
+		Where("lease_until IS NULL OR lease_until <= ?", time.Now()).
+		Order("created_at ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&jobs).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return jobs, nil
+}
+
+// ListImportJobErrors returns the first rejected rows of an import job ordered by line.
+func (s *store) ListImportJobErrors(
+	ctx context.Context,
+	jobId string,
+	limit int,
+) ([]models.ImportJobError, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	jobErrors := []models.ImportJobError{}
+	if err := s.getDBFromContext(ctx).
+		WithContext(ctx).
+		Table(models.ImportJobError{}.TableName()).
+		Where("job_id = ?", jobId).
+		Order("line ASC").
+		Limit(limit).
+		Find(&jobErrors).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return jobErrors, nil
+}
+
+// ListDeviceMacAddresses returns the mac addresses already used by a device, deleted ones included.
+func (s *store) ListDeviceMacAddresses(
+	ctx context.Context,
+	macAddresses []string,
+) ([]string, error) {
+	if len(macAddresses) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var existing []string
+	if err := s.getDBFromContext(ctx).
+		WithContext(ctx).
+		Table(models.USPDeviceTableName).
+		Where("mac_address IN ?", macAddresses).
+		Pluck("mac_address", &existing).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return existing, nil
+}
+
+// ListDeviceSerialNumbers returns the serial numbers already used by a non-deleted device of the manufacturer.
+func (s *store) ListDeviceSerialNumbers(
+	ctx context.Context,
+	manufacturer string,
+	serialNumbers []string,
+) ([]string, error) {
+	if len(serialNumbers) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var existing []string
+	if err := s.getDBFromContext(ctx).
+		WithContext(ctx).
+		Table(models.USPDeviceTableName).
+		Joins("JOIN models ON models.id = devices.model_id").
+		Where("devices.status != ?", "DELETE").
+		Where("models.manufacturer = ?", manufacturer).
+		Where("devices.serial_number IN ?", serialNumbers).
+		Distinct().
+		Pluck("devices.serial_number", &existing).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return existing, nil
+}
+
+// exportBatchSize is the number of rows read from the cursor of an export before they are handed over.
+const exportBatchSize = 500
+
+// exportQuery applies the filter and order of an export, exports are not paginated
+func exportQuery(query *gorm.DB, condition map[string]any, oppts models.QueryOptions) *gorm.DB {
+	query = queryConditionBuilder(query, condition)
+
+	var specs []Specification
+	if oppts.FilterExpr != nil {
+		specs = append(specs, NewFilterSpecification(oppts.FilterExpr))
+	}
+	if len(oppts.OrderExpr) > 0 {
+		specs = append(specs, NewOrderSpecification(oppts.OrderExpr))
+	}
+	if len(specs) > 0 {
+		query = NewCompositeSpecification(specs...).Apply(query)
+	}
+	return query
+}
+
+// streamRows reads the rows of query from a database cursor and hands them to fn by batches of
+// exportBatchSize, only one batch is held in memory. An error of fn stops the read and is returned as is.
+func streamRows[T any](s *store, query *gorm.DB, fn func([]T) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	defer rows.Close()
+
+	batch := make([]T, 0, exportBatchSize)
+	for rows.Next() {
+		var row T
+		if err := query.ScanRows(rows, &row); err != nil {
+			return apperrors.NewDBError(err, s.GetDBName())
+		}
+		batch = append(batch, row)
+		if len(batch) == exportBatchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// StreamProfiles reads the profiles of an export from a cursor, the parameters of every batch
+// are loaded with one query.
+func (s *store) StreamProfiles(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+	fn func([]models.Profile) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, maxQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	query := exportQuery(db.WithContext(ctx).Table(models.Profile{}.TableName()), condition, oppts)
+
+	return streamRows(s, query, func(profiles []models.Profile) error {
+		ids := make([]string, 0, len(profiles))
+		for _, profile := range profiles {
+			ids = append(ids, profile.Id.String())
+		}
+		var profileParameters []*models.ProfileParameter
+		if err := db.WithContext(ctx).
+			Table(models.ProfileParameter{}.TableName()).
+			Preload(models.ProfileParameter{}.GetParameterPreload()).
+			Where("profile_id IN ?", ids).
+			Order("created_at ASC").
+			Find(&profileParameters).Error; err != nil {
+			return apperrors.NewDBError(err, s.GetDBName())
+		}
+		byProfile := make(map[string][]*models.ProfileParameter, len(profiles))
+		for _, pp := range profileParameters {
+			byProfile[pp.ProfileId.String()] = append(byProfile[pp.ProfileId.String()], pp)
+		}
+		for i := range profiles {
+			profiles[i].ProfileParameters = byProfile[profiles[i].Id.String()]
+		}
+		return fn(profiles)
+	})
+}
+
+// StreamParameters reads the parameters of an export from a cursor.
+func (s *store) StreamParameters(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+	fn func([]models.Parameter) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, maxQueryTimeout)
+	defer cancel()
+
+	query := exportQuery(s.getDBFromContext(ctx).WithContext(ctx).Table(models.Parameter{}.TableName()), condition, oppts)
+	return streamRows(s, query, fn)
+}
+
+// StreamModels reads the models of an export from a cursor.
+func (s *store) StreamModels(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+	fn func([]models.Model) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, maxQueryTimeout)
+	defer cancel()
+
+	query := exportQuery(s.getDBFromContext(ctx).WithContext(ctx).Table(models.Model{}.TableName()), condition, oppts)
+	return streamRows(s, query, fn)
+}
+
+// StreamFirmwares reads the firmwares of an export from a cursor.
+func (s *store) StreamFirmwares(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+	fn func([]models.Firmware) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, maxQueryTimeout)
+	defer cancel()
+
+	query := exportQuery(s.getDBFromContext(ctx).WithContext(ctx).Table(models.Firmware{}.TableName()), condition, oppts)
+	return streamRows(s, query, fn)
+}
+
+// StreamGroups reads the groups of an export from a cursor.
+func (s *store) StreamGroups(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+	fn func([]models.Group) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, maxQueryTimeout)
+	defer cancel()
+
+	query := exportQuery(s.getDBFromContext(ctx).WithContext(ctx).Table(models.Group{}.TableName()), condition, oppts)
+	return streamRows(s, query, fn)
+}
+
+// StreamDevices reads the devices of an export from a cursor.
+func (s *store) StreamDevices(
+	ctx context.Context,
+	condition map[string]any,
+	oppts models.QueryOptions,
+	fn func([]models.Device) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, maxQueryTimeout)
+	defer cancel()
+
+	query := exportQuery(s.getDBFromContext(ctx).WithContext(ctx).Table(models.Device{}.TableName()), condition, oppts)
+	return streamRows(s, query, fn)
+}
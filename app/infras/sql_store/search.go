@@ -2,11 +2,15 @@ package uspstore
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -426,3 +430,318 @@ func (s *store) CountDeviceByGroupId(
 
 	return count, nil
 }
+
+// FindWebhookSubscription retrieves a webhook subscription by condition.
+// If record not found, returns an error indicating the entity does not exist.
+func (s *store) FindWebhookSubscription(
+	ctx context.Context,
+	condition map[string]any,
+) (*models.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var subscription = models.WebhookSubscription{}
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.USPWebhookSubscriptionTableName)
+
+	query = queryConditionBuilder(query, condition)
+
+	if err := query.First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewErrEntityNotExist(subscription.GetEntityName())
+		}
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return &subscription, nil
+}
+
+// FindSavedView retrieves a saved view by condition.
+// If record not found, returns an error indicating the entity does not exist.
+func (s *store) FindSavedView(
+	ctx context.Context,
+	condition map[string]any,
+) (*models.SavedView, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var view = models.SavedView{}
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.USPSavedViewTableName)
+
+	query = queryConditionBuilder(query, condition)
+
+	if err := query.First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewErrEntityNotExist(view.GetEntityName())
+		}
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return &view, nil
+}
+
+// FindProfileAssignment retrieves a profile assignment by condition.
+// If record not found, returns an error indicating the entity does not exist.
+func (s *store) FindProfileAssignment(
+	ctx context.Context,
+	condition map[string]any,
+) (*models.ProfileAssignment, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var assignment = models.ProfileAssignment{}
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.USPProfileAssignmentTableName)
+
+	query = queryConditionBuilder(query, condition)
+
+	if err := query.First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewErrEntityNotExist(assignment.GetEntityName())
+		}
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return &assignment, nil
+}
+
+// LookupDevices resolves devices by serial number, endpoint id or MAC address,
+// the model is preloaded so callers can tell devices of different manufacturers apart.
+func (s *store) LookupDevices(
+	ctx context.Context,
+	lookup models.DeviceLookup,
+) ([]models.Device, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var devices []models.Device
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.USPDeviceTableName).
+		Select("devices.*").
+		Joins("JOIN models ON models.id = devices.model_id").
+		Where("devices.status != ?", "DELETE").
+		Preload("Model").
+		Preload("Group")
+
+	if lookup.SerialNumber != "" {
+		query = query.Where("devices.serial_number = ?", lookup.SerialNumber)
+	}
+	if lookup.EndpointId != "" {
+		query = query.Where("devices.endpoint_id = ?", lookup.EndpointId)
+	}
+	if lookup.MacAddress != "" {
+		query = query.Where("devices.mac_address = ?", lookup.MacAddress)
+	}
+	if lookup.Manufacturer != "" {
+		query = query.Where("models.manufacturer = ?", lookup.Manufacturer)
+	}
+	if lookup.ProductClass != "" {
+		query = query.Where("devices.product_class = ?", lookup.ProductClass)
+	}
+
+	if err := query.Order("devices.created_at ASC").Find(&devices).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return devices, nil
+}
+
+// CountDeviceByLifecycleState counts the non-deleted devices of a model per lifecycle_state.
+func (s *store) CountDeviceByLifecycleState(
+	ctx context.Context,
+	modelId string,
+) ([]models.DeviceStateCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var counts []models.DeviceStateCount
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.USPDeviceTableName).
+		Select("lifecycle_state, COUNT(*) AS count").
+		Where("model_id = ?", modelId).
+		Where("status IN ?", []string{"ENABLE", "DISABLE"}).
+		Group("lifecycle_state").
+		Scan(&counts).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return counts, nil
+}
+
+// AggregateStats counts the rows of the query grouped by its columns, the values are read as text,
+// the largest counts come first. The window sum is computed before the limit, so total counts
+// the rows of every bucket.
+func (s *store) AggregateStats(
+	ctx context.Context,
+	query models.StatsQuery,
+) ([]models.StatsBucket, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	selects := make([]string, 0, len(query.Columns)+1)
+	groups := make([]string, 0, len(query.Columns))
+	for i, column := range query.Columns {
+		selects = append(selects, fmt.Sprintf("CAST(%s AS STRING) AS key%d", column, i))
+		groups = append(groups, fmt.Sprintf("key%d", i))
+	}
+	selects = append(selects, "COUNT(*) AS count", "CAST(SUM(COUNT(*)) OVER () AS INT8) AS total")
+
+	db := s.getDBFromContext(ctx)
+	q := db.WithContext(ctx).
+		Table(query.Table).
+		Select(strings.Join(selects, ", "))
+	for _, join := range query.Joins {
+		q = q.Joins(join)
+	}
+	q = queryConditionBuilder(q, query.Condition)
+	if query.FilterExpr != nil {
+		q = NewFilterSpecification(query.FilterExpr).Apply(q)
+	}
+	q = q.Group(strings.Join(groups, ", ")).
+		Order("count DESC").
+		Order(strings.Join(groups, ", "))
+	if query.Limit > 0 {
+		q = q.Limit(query.Limit)
+	}
+
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, 0, apperrors.NewDBError(err, s.GetDBName())
+	}
+	defer rows.Close()
+
+	var buckets []models.StatsBucket
+	var total int64
+	for rows.Next() {
+		keys := make([]sql.NullString, len(query.Columns))
+		dest := make([]any, 0, len(keys)+2)
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		var bucket models.StatsBucket
+		dest = append(dest, &bucket.Count, &total)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, apperrors.NewDBError(err, s.GetDBName())
+		}
+		bucket.Values = make([]any, len(keys))
+		for i, key := range keys {
+			if key.Valid {
+				bucket.Values[i] = key.String
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return buckets, total, nil
+}
+
+// SearchEntities matches the text of every target with ILIKE and scores the match: exact,
+// prefix then substring, case-insensitive. Deleted rows are left out. Each target returns
+// at most Limit rows, the best first.
+func (s *store) SearchEntities(
+	ctx context.Context,
+	query models.SearchQuery,
+) ([]models.SearchHit, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	if len(query.Targets) == 0 {
+		return nil, nil
+	}
+
+	parts := make([]string, 0, len(query.Targets))
+	var args []any
+	for _, target := range query.Targets {
+		column := target.Table + "." + target.Column
+		parent := "NULL"
+		if target.Parent != "" {
+			parent = fmt.Sprintf("CAST(%s.%s AS STRING)", target.Table, target.Parent)
+		}
+		score := fmt.Sprintf(
+			"CASE WHEN lower(%s) = lower(?) THEN %d WHEN %s ILIKE ? THEN %d ELSE %d END",
+			column, models.SearchScoreExact, column, models.SearchScorePrefix, models.SearchScoreContains,
+		)
+		parts = append(parts, fmt.Sprintf(
+			"(SELECT '%s' AS type, CAST(%s.id AS STRING) AS id, %s AS parent_id, %s.%s AS title, '%s' AS field, %s AS value, %s AS score"+
+				" FROM %s WHERE %s.status <> 'DELETE' AND %s ILIKE ?"+
+				" ORDER BY score DESC, length(%s), %s LIMIT ?)",
+			target.Type, target.Table, parent, target.Table, target.Title, target.Column, column, score,
+			target.Table, target.Table, column,
+			column, column,
+		))
+		term := escapeLike(target.Term)
+		args = append(args, target.Term, term+"%", "%"+term+"%", query.Limit)
+	}
+
+	var hits []models.SearchHit
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Raw("SELECT * FROM ("+strings.Join(parts, " UNION ALL ")+") AS hits ORDER BY score DESC, length(value), value", args...).
+		Scan(&hits).Error; err != nil {
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return hits, nil
+}
+
+// FindImportJob retrieves an import job by condition.
+// If record not found, returns an error indicating the entity does not exist.
+func (s *store) FindImportJob(
+	ctx context.Context,
+	condition map[string]any,
+) (*models.ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var job = models.ImportJob{}
+
+	db := s.getDBFromContext(ctx)
+	query := db.WithContext(ctx).
+		Table(models.USPImportJobTableName)
+
+	query = queryConditionBuilder(query, condition)
+
+	if err := query.First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewErrEntityNotExist(job.GetEntityName())
+		}
+		return nil, apperrors.NewDBError(err, s.GetDBName())
+	}
+
+	return &job, nil
+}
+
+// NextOutboxAggregateVersion returns the version of the next event of an aggregate.
+// It must run in the transaction inserting the event, two transactions reading the same
+// version conflict on the unique index and one of them is retried by the client.
+func (s *store) NextOutboxAggregateVersion(
+	ctx context.Context,
+	aggregateId uuid.UUID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	var version int64
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.OutboxEvent{}.TableName()).
+		Select("COALESCE(MAX(aggregate_version), 0) + 1").
+		Where("aggregate_id = ?", aggregateId).
+		Scan(&version).Error; err != nil {
+		return 0, apperrors.NewDBError(err, s.GetDBName())
+	}
+	return version, nil
+}
@@ -2,6 +2,7 @@ package uspstore
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"usp-management-device-api/business/models"
 
@@ -13,19 +14,30 @@ type Specification interface {
 	Apply(db *gorm.DB) *gorm.DB
 }
 
-// FilterSpecification implementation for FilterExpr
+// FilterSpecification implementation for a FilterExpr tree
 type FilterSpecification struct {
-	filters []models.FilterExpr
+	filter *models.FilterExpr
 }
 
 // NewFilterSpecification create FilterSpecification
-func NewFilterSpecification(filters []models.FilterExpr) *FilterSpecification {
-	return &FilterSpecification{filters: filters}
+func NewFilterSpecification(filter *models.FilterExpr) *FilterSpecification {
+	return &FilterSpecification{filter: filter}
 }
 
-// Apply applies filters to GORM query
+// Apply applies the filter tree to GORM query as one parenthesized condition,
+// a condition the field registry did not resolve fails the query
 func (fs *FilterSpecification) Apply(db *gorm.DB) *gorm.DB {
-	return applyFilterExprsV2(db, fs.filters)
+	if fs.filter == nil {
+		return db
+	}
+	for _, condition := range fs.filter.Conditions() {
+		if condition.Column == "" {
+			_ = db.AddError(fmt.Errorf("filter field %s is not resolved", condition.Filter))
+			return db
+		}
+	}
+	condition, args := buildFilterExpr(fs.filter)
+	return db.Where(condition, args...)
 }
 
 // OrderSpecification implementation for OrderExpr
@@ -38,18 +50,104 @@ func NewOrderSpecification(orders []models.OrderExpr) *OrderSpecification {
 	return &OrderSpecification{orders: orders}
 }
 
-// Apply applies orders to GORM query
+// Apply applies orders to GORM query, an order the field registry did not resolve
+// is only accepted for a plain column name set by the service itself
 func (os *OrderSpecification) Apply(db *gorm.DB) *gorm.DB {
 	for _, o := range os.orders {
 		direction := "ASC"
 		if strings.ToUpper(o.Direction) == "DESC" {
 			direction = "DESC"
 		}
-		db = db.Order(fmt.Sprintf("%s %s", o.Field, direction))
+		column := o.Column
+		if column == "" {
+			if !plainColumnPattern.MatchString(o.Field) {
+				_ = db.AddError(fmt.Errorf("order field %s is not resolved", o.Field))
+				return db
+			}
+			column = o.Field
+		}
+		db = db.Order(fmt.Sprintf("%s %s", column, direction))
 	}
 	return db
 }
 
+var plainColumnPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// KeysetSpecification implementation for keyset (cursor) pagination
+type KeysetSpecification struct {
+	keyset *models.Keyset
+}
+
+// NewKeysetSpecification create KeysetSpecification
+func NewKeysetSpecification(keyset *models.Keyset) *KeysetSpecification {
+	return &KeysetSpecification{keyset: keyset}
+}
+
+// Apply keeps the rows after the boundary row and orders by the keyset columns,
+// a backward page reads the rows before the boundary in reverse order.
+// NULL sorts as the smallest value, as CockroachDB orders it.
+func (ks *KeysetSpecification) Apply(db *gorm.DB) *gorm.DB {
+	columns := make([]models.KeysetColumn, len(ks.keyset.Columns))
+	for i, column := range ks.keyset.Columns {
+		columns[i] = models.KeysetColumn{Column: column.Column, Desc: column.Desc != ks.keyset.Backward}
+	}
+
+	if len(ks.keyset.Values) > 0 {
+		condition, args := buildKeysetCondition(columns, ks.keyset.Values)
+		db = db.Where(condition, args...)
+	}
+	for _, column := range columns {
+		direction := "ASC"
+		if column.Desc {
+			direction = "DESC"
+		}
+		db = db.Order(fmt.Sprintf("%s %s", column.Column, direction))
+	}
+	return db
+}
+
+// buildKeysetCondition matches the rows after values: equal on the first columns, after on the next one
+func buildKeysetCondition(columns []models.KeysetColumn, values []any) (string, []any) {
+	var disjuncts []string
+	var args []any
+	for i, column := range columns {
+		after, afterArgs, ok := keysetAfter(column, values[i])
+		if !ok {
+			continue
+		}
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			if values[j] == nil {
+				parts = append(parts, fmt.Sprintf("%s IS NULL", columns[j].Column))
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s = ?", columns[j].Column))
+			args = append(args, values[j])
+		}
+		parts = append(parts, after)
+		args = append(args, afterArgs...)
+		disjuncts = append(disjuncts, "("+strings.Join(parts, " AND ")+")")
+	}
+	if len(disjuncts) == 0 {
+		return "FALSE", nil
+	}
+	return "(" + strings.Join(disjuncts, " OR ") + ")", args
+}
+
+// keysetAfter is the condition of the values after value in the column order, ok is false when there is none
+func keysetAfter(column models.KeysetColumn, value any) (string, []any, bool) {
+	if value == nil {
+		if column.Desc {
+			return "", nil, false
+		}
+		return fmt.Sprintf("%s IS NOT NULL", column.Column), nil, true
+	}
+	if column.Desc {
+		return fmt.Sprintf("(%s < ? OR %s IS NULL)", column.Column, column.Column), []any{value}, true
+	}
+	return fmt.Sprintf("%s > ?", column.Column), []any{value}, true
+}
+
 // PaginationSpecification implementation for pagination
 type PaginationSpecification struct {
 	limit  int
@@ -84,122 +182,54 @@ func (cs *CompositeSpecification) Apply(db *gorm.DB) *gorm.DB {
 	return db
 }
 
-func applyFilterExprsV2(db *gorm.DB, filters []models.FilterExpr) *gorm.DB {
-	if len(filters) == 0 {
-		return db
-	}
-
-	// Group filters by join type
-	groups := groupFiltersByJoin(filters)
-
-	for i, group := range groups {
-		query := buildGroupQuery(group.filters)
-
-		if i == 0 {
-			db = db.Where(query.condition, query.args...)
-		} else {
-			if strings.ToUpper(group.joinType) == "OR" {
-				db = db.Or(query.condition, query.args...)
-			} else {
-				db = db.Where(query.condition, query.args...)
-			}
-		}
-	}
-
-	return db
-}
-
-// FilterGroup groups filters theo join type
-type FilterGroup struct {
-	filters  []models.FilterExpr
-	joinType string
-}
-
-// QueryResult result of build query
-type QueryResult struct {
-	condition string
-	args      []any
-}
-
-// groupFiltersByJoin groups filters by join type
-func groupFiltersByJoin(filters []models.FilterExpr) []FilterGroup {
-	if len(filters) == 0 {
-		return nil
+// buildFilterExpr compiles a filter tree, every group is wrapped in parentheses
+// so nesting and precedence are kept as parsed
+func buildFilterExpr(filter *models.FilterExpr) (string, []any) {
+	if !filter.IsGroup() {
+		return buildCondition(filter)
 	}
 
-	var groups []FilterGroup
-	var currentGroup FilterGroup
-
-	for i, filter := range filters {
-		if i == 0 {
-			// First filter starts new group
-			currentGroup = FilterGroup{
-				filters:  []models.FilterExpr{filter},
-				joinType: "AND", // Default join type
-			}
-		} else {
-			if strings.ToUpper(filter.Join) == "OR" {
-				// Continue current group if OR
-				currentGroup.filters = append(currentGroup.filters, filter)
-			} else {
-				// Start new group if AND
-				groups = append(groups, currentGroup)
-				currentGroup = FilterGroup{
-					filters:  []models.FilterExpr{filter},
-					joinType: filter.Join,
-				}
-			}
-		}
-	}
-
-	// Add last group
-	if len(currentGroup.filters) > 0 {
-		groups = append(groups, currentGroup)
+	join := " AND "
+	if strings.ToUpper(filter.Join) == "OR" {
+		join = " OR "
 	}
-
-	return groups
-}
-
-// buildGroupQuery builds query for a group of filters
-func buildGroupQuery(filters []models.FilterExpr) QueryResult {
-	if len(filters) == 0 {
-		return QueryResult{}
-	}
-
-	var conditions []string
+	conditions := make([]string, 0, len(filter.Children))
 	var args []any
-
-	for i, filter := range filters {
-		condition, filterArgs := buildCondition(filter)
+	for _, child := range filter.Children {
+		condition, childArgs := buildFilterExpr(child)
 		conditions = append(conditions, condition)
-		args = append(args, filterArgs...)
-
-		// Add join operator if not the last filter
-		if i < len(filters)-1 {
-			// All filters in a group should be joined by OR within parentheses
-		}
-	}
-
-	// Join conditions with OR and wrap in parentheses
-	finalCondition := "(" + strings.Join(conditions, " OR ") + ")"
-
-	return QueryResult{
-		condition: finalCondition,
-		args:      args,
+		args = append(args, childArgs...)
 	}
+	return "(" + strings.Join(conditions, join) + ")", args
 }
 
-// buildCondition xây dựng điều kiện cho một filter
-func buildCondition(filter models.FilterExpr) (string, []any) {
-	op := getSQLOperator(filter.Op)
-	condition := fmt.Sprintf("%s %s ?", filter.Filter, op)
-
-	value := filter.Value
-	if strings.ToLower(filter.Op) == "like" {
-		value = "%" + filter.Value + "%"
+// buildCondition xây dựng điều kiện cho một filter đã resolve, Args giữ giá trị đã ép kiểu
+func buildCondition(filter *models.FilterExpr) (string, []any) {
+	column := filter.Column
+	switch strings.ToLower(filter.Op) {
+	case "isnull":
+		return fmt.Sprintf("%s IS NULL", column), nil
+	case "notnull":
+		return fmt.Sprintf("%s IS NOT NULL", column), nil
+	case "in":
+		return fmt.Sprintf("%s IN ?", column), []any{filter.Args}
+	case "nin":
+		return fmt.Sprintf("%s NOT IN ?", column), []any{filter.Args}
+	case "between":
+		return fmt.Sprintf("%s BETWEEN ? AND ?", column), []any{filter.Args[0], filter.Args[1]}
+	case "contains":
+		return fmt.Sprintf("? = ANY(%s)", column), []any{filter.Args[0]}
+	// LIKE wildcards in the value are matched literally
+	case "like", "nlike", "ilike":
+		return fmt.Sprintf("%s %s ?", column, getSQLOperator(filter.Op)), []any{"%" + escapeLike(filter.Value) + "%"}
+	case "startswith":
+		return fmt.Sprintf("%s LIKE ?", column), []any{escapeLike(filter.Value) + "%"}
+	case "endswith":
+		return fmt.Sprintf("%s LIKE ?", column), []any{"%" + escapeLike(filter.Value)}
 	}
 
-	return condition, []any{value}
+	op := getSQLOperator(filter.Op)
+	return fmt.Sprintf("%s %s ?", column, op), filter.Args
 }
 
 // getSQLOperator chuyển đổi operator thành SQL operator
@@ -219,6 +249,10 @@ func getSQLOperator(op string) string {
 		return ">="
 	case "like":
 		return "LIKE"
+	case "nlike":
+		return "NOT LIKE"
+	case "ilike":
+		return "ILIKE"
 	default:
 		return "="
 	}
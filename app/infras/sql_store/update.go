@@ -2,8 +2,11 @@ package uspstore
 
 import (
 	"context"
+	"time"
 	"usp-management-device-api/business/models"
 	apperrors "usp-management-device-api/common/app_errors"
+
+	"gorm.io/gorm"
 )
 
 // UpdateDevice updates an existing device in the database.
@@ -25,6 +28,26 @@ func (s *store) UpdateDevice(
 	return nil
 }
 
+// UpdateDeviceModel moves a device to another model, group_id is written even when nil.
+func (s *store) UpdateDeviceModel(
+	ctx context.Context,
+	id string,
+	device *models.DeviceModelUpdate,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(device.TableName()).
+		Where("id = ?", id).
+		Select("model_id", "group_id", "endpoint_id", "updated_by", "updated_at").
+		Updates(device).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
 // UpdateGroup updates an existing group in the database.
 func (s *store) UpdateGroup(
 	ctx context.Context,
@@ -136,3 +159,195 @@ func (s *store) UpdateProfileParameter(
 	}
 	return nil
 }
+
+// MarkOutboxEventsPublished marks outbox events as delivered to the broker.
+func (s *store) MarkOutboxEventsPublished(
+	ctx context.Context,
+	ids []string,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.OutboxEvent{}.TableName()).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":       models.OutboxStatusPublished,
+			"published_at": time.Now(),
+			"last_error":   nil,
+		}).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+// MarkOutboxEventsFailed records a failed publish attempt, the events stay pending.
+func (s *store) MarkOutboxEventsFailed(
+	ctx context.Context,
+	ids []string,
+	reason string,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.OutboxEvent{}.TableName()).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": reason,
+		}).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+// UpdateWebhookSubscription updates an existing webhook subscription in the database.
+func (s *store) UpdateWebhookSubscription(
+	ctx context.Context,
+	id string,
+	subscription *models.WebhookSubscriptionUpdate,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(subscription.TableName()).
+		Where("id = ?", id).
+		Updates(subscription).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+// UpdateSavedView updates an existing saved view in the database.
+func (s *store) UpdateSavedView(
+	ctx context.Context,
+	id string,
+	view *models.SavedViewUpdate,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(view.TableName()).
+		Where("id = ?", id).
+		Updates(view).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt.
+func (s *store) UpdateWebhookDelivery(
+	ctx context.Context,
+	id string,
+	leasedUntil time.Time,
+	delivery *models.WebhookDeliveryUpdate,
+) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	result := db.WithContext(ctx).
+		Table(delivery.TableName()).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", id, models.WebhookDeliveryStatusPending, leasedUntil).
+		Updates(delivery)
+	if result.Error != nil {
+		return false, apperrors.NewDBError(result.Error, s.GetDBName())
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// PostponeWebhookDeliveries moves next_attempt_at of the deliveries, used to lease them to one dispatcher.
+func (s *store) PostponeWebhookDeliveries(
+	ctx context.Context,
+	ids []string,
+	nextAttemptAt time.Time,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.WebhookDelivery{}.TableName()).
+		Where("id IN ?", ids).
+		Update("next_attempt_at", nextAttemptAt).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+// MoveProfileAssignmentsToDevice retargets device profile assignments to another device.
+func (s *store) MoveProfileAssignmentsToDevice(
+	ctx context.Context,
+	ids []string,
+	deviceId string,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	if err := db.WithContext(ctx).
+		Table(models.ProfileAssignment{}.TableName()).
+		Where("id IN ?", ids).
+		Update(models.ProfileAssignment{}.GetDeviceIdColumnName(), deviceId).Error; err != nil {
+		return apperrors.NewDBError(err, s.GetDBName())
+	}
+	return nil
+}
+
+// UpdateImportJobInStatus updates an import job only while its status is one of statuses,
+// it reports whether the job was updated.
+func (s *store) UpdateImportJobInStatus(
+	ctx context.Context,
+	id string,
+	statuses []string,
+	job *models.ImportJobUpdate,
+) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	result := db.WithContext(ctx).
+		Table(job.TableName()).
+		Where("id = ?", id).
+		Where("status IN ?", statuses).
+		Updates(job)
+	if result.Error != nil {
+		return false, apperrors.NewDBError(result.Error, s.GetDBName())
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateRunningImportJob updates a RUNNING import job only while it is held by the claim that set attempts,
+// it reports whether the job was updated.
+func (s *store) UpdateRunningImportJob(
+	ctx context.Context,
+	id string,
+	attempts int,
+	job *models.ImportJobUpdate,
+) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	db := s.getDBFromContext(ctx)
+	result := db.WithContext(ctx).
+		Table(job.TableName()).
+		Where("id = ?", id).
+		Where("status = ?", models.ImportJobStatusRunning).
+		Where("attempts = ?", attempts).
+		Updates(job)
+	if result.Error != nil {
+		return false, apperrors.NewDBError(result.Error, s.GetDBName())
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,46 @@
+package webhookclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+type WebhookClient struct {
+	Client *http.Client
+}
+
+func NewWebhookClient(timeout time.Duration) *WebhookClient {
+	return &WebhookClient{
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts body as JSON to url and returns the response status code.
+func (w *WebhookClient) Send(
+	ctx context.Context,
+	url string,
+	headers map[string]string,
+	body []byte,
+) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	return resp.StatusCode, nil
+}